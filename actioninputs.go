@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Environment variable holding the action inputs as JSON (toJSON(inputs)).
+// Inputs are passed as data rather than interpolated into the shell command,
+// and each input maps to the runner flag of the same name.
+const actionInputsEnv = "TERRAGRUNT_RUNNER_INPUTS"
+
+// Inputs consumed by the action steps rather than the runner
+var actionOnlyInputs = map[string]string{
+	"terragrunt-version": "Terragrunt version to install (e.g., 'v0.88.1'; must match a release tag with 'v' prefix; leave empty to use pre-installed version)",
+	"opentofu-version":   "OpenTofu version to install (e.g., '1.8.3'; leave empty to skip installation)",
+	"terraform-version":  "Terraform version to install (e.g., '1.9.7'; only used if opentofu-version is empty)",
+	"working-directory":  "Working directory for the action",
+}
+
+// Runner flags that are not action inputs: set by the action itself, or
+// derived from the workflow environment
+var cliOnlyFlags = map[string]bool{
+	"help":           true,
+	"owner":          true,
+	"crash-dump-dir": true,
+	"triage-dir":     true,
+}
+
+// Action input and the runner flag it maps to
+type ActionInput struct {
+	Name        string `json:"name"`           // Input name in action.yaml
+	Flag        string `json:"flag,omitempty"` // Runner flag receiving the input (empty for action-only inputs)
+	Type        string `json:"type"`           // Flag value type (string, bool, int, stringSlice)
+	Default     string `json:"default"`        // Flag default, used when the input is empty
+	Description string `json:"description"`
+}
+
+// Build the action inputs from the runner flags, followed by the action-only inputs
+func actionInputsFromFlags(flags *pflag.FlagSet) []ActionInput {
+	var inputs []ActionInput
+	flags.VisitAll(func(f *pflag.Flag) {
+		if cliOnlyFlags[f.Name] || f.Hidden {
+			return
+		}
+		inputs = append(inputs, ActionInput{Name: f.Name, Flag: f.Name, Type: f.Value.Type(), Default: f.DefValue, Description: f.Usage})
+	})
+	var actionOnly []string
+	for name := range actionOnlyInputs {
+		actionOnly = append(actionOnly, name)
+	}
+	sort.Strings(actionOnly)
+	for _, name := range actionOnly {
+		inputs = append(inputs, ActionInput{Name: name, Type: "string", Description: actionOnlyInputs[name]})
+	}
+	return inputs
+}
+
+// Set the runner flags from the action inputs JSON. Empty inputs keep the flag
+// default and flags given on the command line win. Unknown inputs and invalid
+// values are rejected, so action.yaml and the flags cannot silently drift.
+// The action passes every input, defaults included, so inputs equal to the flag
+// default leave the flag unset for the config files.
+func applyActionInputs(flags *pflag.FlagSet, inputsJSON string) error {
+	if strings.TrimSpace(inputsJSON) == "" {
+		return nil
+	}
+	var inputs map[string]any
+	if err := json.Unmarshal([]byte(inputsJSON), &inputs); err != nil {
+		return fmt.Errorf("invalid %s: %w", actionInputsEnv, err)
+	}
+
+	var unset []*pflag.Flag
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			unset = append(unset, f)
+		}
+	})
+	if err := applyInputs(flags, inputs); err != nil {
+		return err
+	}
+	for _, f := range unset {
+		if f.Changed && f.Value.String() == f.DefValue {
+			f.Changed = false
+		}
+	}
+	return nil
+}
+
+// Set the runner flags not set yet from inputs by name, skipping empty inputs
+// and the inputs of the action steps
+func applyInputs(flags *pflag.FlagSet, inputs map[string]any) error {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := actionOnlyInputs[name]; ok {
+			continue
+		}
+		f := flags.Lookup(name)
+		if f == nil || cliOnlyFlags[name] {
+			return fmt.Errorf("unknown action input: %s (no matching runner flag)", name)
+		}
+		var value string
+		switch v := inputs[name].(type) {
+		case nil:
+			continue
+		case string:
+			value = v
+		default:
+			value = fmt.Sprint(v)
+		}
+		if value == "" || f.Changed {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value for input %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Create the inputs-schema subcommand
+func newInputsSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inputs-schema",
+		Short: "Print the action inputs and the flags they map to as JSON",
+		Long: `Print the action inputs as JSON: each runner flag is an input of the same name,
+with its type, default and description, followed by the inputs used only by the
+action steps. Use it to generate or check the inputs of action.yaml.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(actionInputsFromFlags(cmd.Root().LocalFlags()))
+		},
+	}
+}
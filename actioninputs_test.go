@@ -0,0 +1,116 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newTestFlagSet() (*pflag.FlagSet, *Config) {
+	config := &Config{}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&config.Command, "command", "plan", "")
+	flags.BoolVar(&config.ParallelExec, "parallel", true, "")
+	flags.IntVar(&config.MaxRuns, "max-runs", 20, "")
+	flags.StringSliceVar(&config.SkipStages, "skip-stages", nil, "")
+	flags.StringVar(&config.Owner, "owner", "", "")
+	flags.StringVar(&config.DetailLevel, "detail-level", DetailStandard, "")
+	return flags, config
+}
+
+func TestApplyActionInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  string
+		cli     []string
+		want    Config
+		wantErr string
+	}{
+		{
+			name:   "inputs set flags",
+			inputs: `{"command": "apply", "parallel": "false", "max-runs": "5", "skip-stages": "fmt,policy"}`,
+			want:   Config{Command: "apply", ParallelExec: false, MaxRuns: 5, SkipStages: []string{"fmt", "policy"}},
+		},
+		{
+			name:   "empty inputs keep defaults",
+			inputs: `{"command": "", "max-runs": "", "terragrunt-version": "v0.88.1"}`,
+			want:   Config{Command: "plan", ParallelExec: true, MaxRuns: 20},
+		},
+		{
+			name:   "command line wins",
+			inputs: `{"command": "apply"}`,
+			cli:    []string{"--command", "validate"},
+			want:   Config{Command: "validate", ParallelExec: true, MaxRuns: 20},
+		},
+		{
+			name:   "no inputs",
+			inputs: "",
+			want:   Config{Command: "plan", ParallelExec: true, MaxRuns: 20},
+		},
+		{name: "unknown input", inputs: `{"max-run": "5"}`, wantErr: "unknown action input: max-run"},
+		{name: "cli-only flag", inputs: `{"owner": "boogy"}`, wantErr: "unknown action input: owner"},
+		{name: "invalid bool", inputs: `{"parallel": "yes"}`, wantErr: "invalid value for input parallel"},
+		{name: "invalid JSON", inputs: `{"command":`, wantErr: "invalid " + actionInputsEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, config := newTestFlagSet()
+			if err := flags.Parse(tt.cli); err != nil {
+				t.Fatal(err)
+			}
+			err := applyActionInputs(flags, tt.inputs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyActionInputs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyActionInputs() error = %v", err)
+			}
+			if config.Command != tt.want.Command || config.ParallelExec != tt.want.ParallelExec || config.MaxRuns != tt.want.MaxRuns || !slices.Equal(config.SkipStages, tt.want.SkipStages) {
+				t.Errorf("config = %+v, want %+v", *config, tt.want)
+			}
+		})
+	}
+}
+
+// Every action.yaml input must map to a runner flag or be an action-only
+// input, and every runner flag must be an input
+func TestActionYAMLMatchesFlags(t *testing.T) {
+	content, err := os.ReadFile("action.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputsSection, _, _ := strings.Cut(string(content), "\noutputs:")
+	declared := regexp.MustCompile(`(?m)^  ([a-z0-9-]+):$`).FindAllStringSubmatch(inputsSection, -1)
+	var actionInputs []string
+	for _, m := range declared {
+		actionInputs = append(actionInputs, m[1])
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	var schemaInputs []string
+	for _, input := range actionInputsFromFlags(newRootCmd(logger).LocalFlags()) {
+		schemaInputs = append(schemaInputs, input.Name)
+	}
+
+	for _, name := range actionInputs {
+		if !slices.Contains(schemaInputs, name) {
+			t.Errorf("action.yaml input %s has no runner flag", name)
+		}
+	}
+	for _, name := range schemaInputs {
+		if !slices.Contains(actionInputs, name) {
+			t.Errorf("runner flag %s is not an action.yaml input", name)
+		}
+	}
+	if !strings.Contains(string(content), actionInputsEnv+": ${{ toJSON(inputs) }}") {
+		t.Errorf("action.yaml does not pass the inputs in %s", actionInputsEnv)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attempts of an idempotent GitHub API request answered with a transient error
+const apiMaxAttempts = 3
+
+// Delay before the first retry of a GitHub API request without Retry-After,
+// doubled for every further attempt (var for tests)
+var apiRetryDelay = time.Second
+
+// Longest Retry-After honored before giving up on a request
+const apiMaxRetryAfter = 60 * time.Second
+
+var reAPIPathID = regexp.MustCompile(`^(\d+|[0-9a-f]{7,40})$`)
+
+// GitHub API consumption of a run, for sizing tokens and finding which feature
+// uses the rate limit budget
+type APIUsage struct {
+	Calls           int            `json:"calls"`
+	Retries         int            `json:"retries"`
+	RateLimit       *int           `json:"rate_limit,omitempty"`                  // Requests per hour of the token
+	RemainingBefore *int           `json:"rate_limit_remaining_before,omitempty"` // Remaining requests at the first call
+	RemainingAfter  *int           `json:"rate_limit_remaining_after,omitempty"`  // Remaining requests at the last call
+	ByEndpoint      map[string]int `json:"calls_by_endpoint"`                     // Calls per method and path template
+}
+
+// Transport counting the GitHub API calls of a run and retrying idempotent
+// requests on transient errors and secondary rate limits
+type apiMetricsTransport struct {
+	base  http.RoundTripper
+	mu    sync.Mutex
+	usage APIUsage
+}
+
+func newAPIMetricsTransport(base http.RoundTripper) *apiMetricsTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &apiMetricsTransport{base: base, usage: APIUsage{ByEndpoint: map[string]int{}}}
+}
+
+func (t *apiMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := apiEndpoint(req.Method, req.URL.Path)
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	delay := apiRetryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		t.record(endpoint, resp, attempt > 1)
+		wait, retryable := apiRetryWait(resp, err, delay)
+		if !idempotent || !retryable || attempt == apiMaxAttempts {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// Record a call and the rate limit reported by its response
+func (t *apiMetricsTransport) record(endpoint string, resp *http.Response, retry bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.Calls++
+	t.usage.ByEndpoint[endpoint]++
+	if retry {
+		t.usage.Retries++
+	}
+	if resp == nil {
+		return
+	}
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		t.usage.RateLimit = &limit
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		if t.usage.RemainingBefore == nil {
+			t.usage.RemainingBefore = &remaining
+		}
+		t.usage.RemainingAfter = &remaining
+	}
+}
+
+// Get a copy of the usage recorded so far
+func (t *apiMetricsTransport) snapshot() APIUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.usage
+	usage.ByEndpoint = make(map[string]int, len(t.usage.ByEndpoint))
+	for endpoint, calls := range t.usage.ByEndpoint {
+		usage.ByEndpoint[endpoint] = calls
+	}
+	return usage
+}
+
+// Decide whether a response is worth retrying and how long to wait first:
+// network errors, 502-504, and rate limiting with a short enough Retry-After.
+// The primary rate limit (no remaining requests) is not retried.
+func apiRetryWait(resp *http.Response, err error, delay time.Duration) (time.Duration, bool) {
+	if err != nil {
+		return delay, true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return delay, true
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return 0, false
+		}
+		seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil {
+			return delay, resp.StatusCode == http.StatusTooManyRequests
+		}
+		wait := time.Duration(seconds) * time.Second
+		return wait, wait <= apiMaxRetryAfter
+	}
+	return 0, false
+}
+
+// Get the endpoint template of a request, e.g. "DELETE /repos/{owner}/{repo}/issues/comments/{id}"
+func apiEndpoint(method, path string) string {
+	// GitHub Enterprise Server serves the API under /api/v3
+	path = strings.TrimPrefix(path, "/api/v3")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case segments[0] == "repos" && i == 1:
+			segments[i] = "{owner}"
+		case segments[0] == "repos" && i == 2:
+			segments[i] = "{repo}"
+		case reAPIPathID.MatchString(segment):
+			segments[i] = "{id}"
+		}
+	}
+	return method + " /" + strings.Join(segments, "/")
+}
+
+// Log the GitHub API consumption of the run
+func (r *Runner) logAPIUsage() {
+	if r.apiMetrics == nil {
+		return
+	}
+	usage := r.apiMetrics.snapshot()
+	args := []any{"calls", usage.Calls, "retries", usage.Retries, "by_endpoint", usage.ByEndpoint}
+	if usage.RemainingBefore != nil {
+		args = append(args, "rate_limit_remaining_before", *usage.RemainingBefore, "rate_limit_remaining_after", *usage.RemainingAfter)
+	}
+	if usage.RateLimit != nil {
+		args = append(args, "rate_limit", *usage.RateLimit)
+	}
+	r.logger.Debug("GitHub API usage", args...)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"DELETE", "/repos/acme/infra/issues/comments/123456", "DELETE /repos/{owner}/{repo}/issues/comments/{id}"},
+		{"GET", "/api/v3/repos/acme/infra/pulls/42/files", "GET /repos/{owner}/{repo}/pulls/{id}/files"},
+		{"POST", "/repos/acme/infra/commits/0123abcd4567/comments", "POST /repos/{owner}/{repo}/commits/{id}/comments"},
+		{"GET", "/rate_limit", "GET /rate_limit"},
+	}
+	for _, tt := range tests {
+		if got := apiEndpoint(tt.method, tt.path); got != tt.want {
+			t.Errorf("apiEndpoint(%s, %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAPIMetricsTransport(t *testing.T) {
+	old := apiRetryDelay
+	t.Cleanup(func() { apiRetryDelay = old })
+	apiRetryDelay = time.Millisecond
+
+	remaining, filesCalls := 5000, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		remaining--
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		switch {
+		case req.URL.Path == "/repos/owner/repo/pulls/1/files":
+			filesCalls++
+			if filesCalls == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`[]`))
+		case req.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	transport := newAPIMetricsTransport(nil)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL + "/repos/owner/repo/pulls/1/files")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET = %v, %v, want the bad gateway retried", resp, err)
+	}
+	resp.Body.Close()
+	// Only idempotent requests are retried
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/repos/owner/repo/issues/comments/7", nil)
+	if resp, err = client.Do(req); err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("DELETE = %v, %v, want the error returned", resp, err)
+	}
+	resp.Body.Close()
+
+	usage := transport.snapshot()
+	if usage.Calls != 3 || usage.Retries != 1 {
+		t.Errorf("calls = %d, retries = %d, want 3 and 1", usage.Calls, usage.Retries)
+	}
+	if usage.ByEndpoint["GET /repos/{owner}/{repo}/pulls/{id}/files"] != 2 || usage.ByEndpoint["DELETE /repos/{owner}/{repo}/issues/comments/{id}"] != 1 {
+		t.Errorf("calls by endpoint = %v", usage.ByEndpoint)
+	}
+	if usage.RateLimit == nil || *usage.RateLimit != 5000 || *usage.RemainingBefore != 4999 || *usage.RemainingAfter != 4997 {
+		t.Errorf("rate limit = %v, remaining %v -> %v", usage.RateLimit, usage.RemainingBefore, usage.RemainingAfter)
+	}
+}
+
+func TestAPIRetryWait(t *testing.T) {
+	response := func(status int, headers ...string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		for i := 0; i+1 < len(headers); i += 2 {
+			resp.Header.Set(headers[i], headers[i+1])
+		}
+		return resp
+	}
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		wantWait  time.Duration
+		wantRetry bool
+	}{
+		{name: "success", resp: response(http.StatusOK)},
+		{name: "not found", resp: response(http.StatusNotFound)},
+		{name: "gateway timeout", resp: response(http.StatusGatewayTimeout), wantWait: time.Second, wantRetry: true},
+		{name: "secondary rate limit", resp: response(http.StatusForbidden, "Retry-After", "5"), wantWait: 5 * time.Second, wantRetry: true},
+		{name: "retry after too long", resp: response(http.StatusTooManyRequests, "Retry-After", "600"), wantWait: 600 * time.Second},
+		{name: "primary rate limit", resp: response(http.StatusForbidden, "X-RateLimit-Remaining", "0", "Retry-After", "5")},
+		{name: "permission denied", resp: response(http.StatusForbidden)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, retry := apiRetryWait(tt.resp, nil, time.Second)
+			if retry != tt.wantRetry || (retry && wait != tt.wantWait) {
+				t.Errorf("apiRetryWait() = %v, %v, want %v, %v", wait, retry, tt.wantWait, tt.wantRetry)
+			}
+		})
+	}
+}
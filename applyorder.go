@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const applyProgressMarkerFolder = "_apply-progress" // Marker folder key of the apply progress comment
+
+// Apply group statuses
+const (
+	ApplyWaiting    = "waiting"
+	ApplyGating     = "gating"
+	ApplyRunning    = "applying"
+	ApplyDone       = "applied"
+	ApplyFailed     = "failed"
+	ApplyGateFailed = "gate failed"
+	ApplyBlocked    = "blocked"
+)
+
+// Name of the group collecting folders that match no --apply-order group
+const applyOtherGroup = "other"
+
+// Folders applied together, after the previous group succeeded
+type ApplyGroup struct {
+	Name    string   // Group name (e.g. staging)
+	Folders []string // Folders of the group, applied with the usual parallelism
+	Status  string   // Progress of the group
+	Detail  string   // Why the group failed or was blocked
+}
+
+// Parse "group=glob" entries into the group names in order and their globs;
+// entries repeating a group name add globs to it
+func parseApplyOrder(entries []string) ([]string, map[string][]string, error) {
+	var names []string
+	globs := map[string][]string{}
+	for _, entry := range entries {
+		name, glob, ok := strings.Cut(entry, "=")
+		name, glob = strings.TrimSpace(name), strings.TrimSpace(glob)
+		if !ok || name == "" || glob == "" {
+			return nil, nil, fmt.Errorf("invalid apply-order entry %q (expected group=folder-glob)", entry)
+		}
+		if _, seen := globs[name]; !seen {
+			names = append(names, name)
+		}
+		globs[name] = append(globs[name], glob)
+	}
+	return names, globs, nil
+}
+
+// Validate the apply order and its gates
+func (r *Runner) validateApplyOrder() error {
+	if len(r.config.ApplyOrder) == 0 {
+		if len(r.config.ApplyGates) > 0 {
+			return fmt.Errorf("apply-gates requires apply-order")
+		}
+		return nil
+	}
+	if strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all") {
+		return fmt.Errorf("apply-order requires per-folder execution, not run --all")
+	}
+	names, globs, err := parseApplyOrder(r.config.ApplyOrder)
+	if err != nil {
+		return err
+	}
+	for _, entry := range r.config.ApplyGates {
+		name, command, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(command) == "" {
+			return fmt.Errorf("invalid apply-gates entry %q (expected group=command)", entry)
+		}
+		if _, known := globs[name]; !known && name != applyOtherGroup {
+			return fmt.Errorf("invalid apply-gates entry %q: unknown group %s (expected one of %s)", entry, name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// Split folders into the ordered apply groups. A folder belongs to the first
+// group with a matching glob; unmatched folders are applied last, in "other".
+func (r *Runner) applyGroups(folders []string) []ApplyGroup {
+	names, globs, _ := parseApplyOrder(r.config.ApplyOrder)
+	byGroup := map[string][]string{}
+	for _, folder := range folders {
+		group := applyOtherGroup
+	match:
+		for _, name := range names {
+			for _, glob := range globs[name] {
+				if folderGlobMatches(glob, folder) {
+					group = name
+					break match
+				}
+			}
+		}
+		byGroup[group] = append(byGroup[group], folder)
+	}
+
+	var groups []ApplyGroup
+	for _, name := range append(names, applyOtherGroup) {
+		if len(byGroup[name]) > 0 {
+			groups = append(groups, ApplyGroup{Name: name, Folders: byGroup[name], Status: ApplyWaiting})
+		}
+	}
+	return groups
+}
+
+// Get the gate command of a group (empty if the group has no gate)
+func (r *Runner) applyGate(group string) string {
+	for _, entry := range r.config.ApplyGates {
+		if name, command, _ := strings.Cut(entry, "="); name == group {
+			return command
+		}
+	}
+	return ""
+}
+
+// Run a group's gate command; a non-zero exit blocks the group. The group name
+// and folders are passed as APPLY_GROUP and APPLY_FOLDERS.
+func (r *Runner) runApplyGate(command string, group ApplyGroup) error {
+	r.logger.Info("Running apply gate", "group", group.Name, "command", command)
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = r.subprocessEnv("APPLY_GROUP="+group.Name, "APPLY_FOLDERS="+strings.Join(group.Folders, ","))
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := r.runCommand(cmd); err != nil {
+		if line, _, _ := strings.Cut(strings.TrimSpace(stripAnsiCodes(out.String())), "\n"); line != "" {
+			return fmt.Errorf("%w: %s", err, line)
+		}
+		return err
+	}
+	return nil
+}
+
+// Record the folders of a group that were not applied
+func notAppliedResults(group ApplyGroup, reason string) []ExecutionResult {
+	var results []ExecutionResult
+	for _, folder := range group.Folders {
+		results = append(results, ExecutionResult{Folder: folder, Success: false, Error: fmt.Errorf("not applied: %s", reason)})
+	}
+	return results
+}
+
+// Apply the folders group by group: each group runs its gate, then applies, and
+// only once it fully succeeded does the next group start. Progress is kept in a
+// single PR comment updated as the groups advance.
+func (r *Runner) executeApplyOrder(ctx context.Context, client *github.Client) []ExecutionResult {
+	groups := r.applyGroups(r.config.Folders)
+	allFolders := r.config.Folders
+	defer func() { r.config.Folders = allFolders }()
+
+	var results []ExecutionResult
+	stopped := ""
+	for i := range groups {
+		group := &groups[i]
+		if stopped != "" {
+			group.Status, group.Detail = ApplyBlocked, "group "+stopped+" did not complete"
+			results = append(results, notAppliedResults(*group, "apply group "+stopped+" did not complete")...)
+			continue
+		}
+
+		if gate := r.applyGate(group.Name); gate != "" {
+			group.Status = ApplyGating
+			r.postApplyProgress(ctx, client, groups)
+			if err := r.runApplyGate(gate, *group); err != nil {
+				r.logger.Warn("Apply gate failed", "group", group.Name, "error", err)
+				group.Status, group.Detail = ApplyGateFailed, err.Error()
+				results = append(results, notAppliedResults(*group, "gate of apply group "+group.Name+" failed")...)
+				stopped = group.Name
+				continue
+			}
+		}
+
+		group.Status = ApplyRunning
+		r.postApplyProgress(ctx, client, groups)
+		r.logger.Info("Applying group", "group", group.Name, "folders", group.Folders)
+		r.config.Folders = group.Folders
+		groupResults := r.executeTerragrunt()
+		results = append(results, groupResults...)
+
+		group.Status = ApplyDone
+		for _, result := range groupResults {
+			if !result.Success {
+				group.Status, group.Detail = ApplyFailed, "failed in "+result.Folder
+				stopped = group.Name
+				break
+			}
+		}
+	}
+	r.postApplyProgress(ctx, client, groups)
+	return results
+}
+
+// Format the apply progress comment
+func formatApplyProgress(groups []ApplyGroup) string {
+	icons := map[string]string{
+		ApplyWaiting:    "⏸️",
+		ApplyGating:     "🚧",
+		ApplyRunning:    "⏳",
+		ApplyDone:       "✅",
+		ApplyFailed:     "❌",
+		ApplyGateFailed: "⛔",
+		ApplyBlocked:    "⏭️",
+	}
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+
+	var b strings.Builder
+	b.WriteString("## 🚦 Apply Progress\n\n")
+	b.WriteString(fmt.Sprintf("**Order:** %s\n\n", strings.Join(names, " → ")))
+	b.WriteString("| Group | Folders | Status |\n|-------|---------|--------|\n")
+	for _, group := range groups {
+		status := icons[group.Status] + " " + group.Status
+		if group.Detail != "" {
+			status += ": " + strings.ReplaceAll(group.Detail, "|", "\\|")
+		}
+		b.WriteString(fmt.Sprintf("| %s | `%s` | %s |\n", group.Name, strings.Join(group.Folders, "`, `"), status))
+	}
+	return b.String()
+}
+
+// Create the apply progress comment, or update it once created. Progress is
+// best effort: failures are logged and the run goes on.
+func (r *Runner) postApplyProgress(ctx context.Context, client *github.Client, groups []ApplyGroup) {
+	if client == nil || !r.caps.commentsAllowed() {
+		return
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	body := commentMarker(applyProgressMarkerFolder, r.config.RunLabel) + "\n" + formatApplyProgress(groups) + commentFooter(r.config.RunLabel)
+
+	if r.applyComment != 0 {
+		if err := r.editTargetComment(ctx, client, owner, repo, r.applyComment, body); err != nil {
+			r.logger.Warn("Failed to update apply progress comment", "error", err)
+		}
+		return
+	}
+	id, err := r.postTargetComment(ctx, client, owner, repo, body)
+	if err != nil {
+		r.logger.Warn("Failed to post apply progress comment", "error", err)
+		return
+	}
+	r.applyComment = id
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateApplyOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "disabled", config: Config{Command: "apply"}},
+		{name: "valid", config: Config{Command: "apply", ApplyOrder: []string{"dev=live/dev/**", "prod=live/prod/**"}, ApplyGates: []string{"prod=./check-window.sh"}}},
+		{name: "gate on other", config: Config{Command: "apply", ApplyOrder: []string{"dev=live/dev/**"}, ApplyGates: []string{"other=true"}}},
+		{name: "missing glob", config: Config{Command: "apply", ApplyOrder: []string{"dev"}}, wantErr: "expected group=folder-glob"},
+		{name: "unknown gate group", config: Config{Command: "apply", ApplyOrder: []string{"dev=live/dev/**"}, ApplyGates: []string{"prod=true"}}, wantErr: "unknown group prod"},
+		{name: "gates without order", config: Config{Command: "apply", ApplyGates: []string{"prod=true"}}, wantErr: "requires apply-order"},
+		{name: "run all", config: Config{Command: "run --all apply", ApplyOrder: []string{"dev=live/dev/**"}}, wantErr: "not run --all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestRunner(&tt.config).validateApplyOrder()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validateApplyOrder() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("validateApplyOrder() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyGroups(t *testing.T) {
+	r := newTestRunner(&Config{Command: "apply", ApplyOrder: []string{"dev=live/dev/**", "staging=live/staging/**", "prod=live/prod/**", "dev=live/sandbox/**"}})
+	groups := r.applyGroups([]string{"live/prod/app", "live/dev/app", "global/iam", "live/sandbox/app", "live/prod/db"})
+
+	want := []struct {
+		name    string
+		folders string
+	}{
+		{"dev", "live/dev/app,live/sandbox/app"},
+		{"prod", "live/prod/app,live/prod/db"},
+		{"other", "global/iam"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("applyGroups() = %+v, want %d groups", groups, len(want))
+	}
+	for i, w := range want {
+		if groups[i].Name != w.name || strings.Join(groups[i].Folders, ",") != w.folders || groups[i].Status != ApplyWaiting {
+			t.Errorf("applyGroups()[%d] = %+v, want %s with %s", i, groups[i], w.name, w.folders)
+		}
+	}
+}
+
+func TestRunApplyGate(t *testing.T) {
+	r := newTestRunner(&Config{})
+	group := ApplyGroup{Name: "prod", Folders: []string{"live/prod/app", "live/prod/db"}}
+
+	if err := r.runApplyGate(`test "$APPLY_GROUP" = prod && test "$APPLY_FOLDERS" = live/prod/app,live/prod/db`, group); err != nil {
+		t.Errorf("runApplyGate() error = %v, want gate to see the group", err)
+	}
+	err := r.runApplyGate("echo outside change window; exit 3", group)
+	if err == nil || !strings.Contains(err.Error(), "outside change window") {
+		t.Errorf("runApplyGate() error = %v, want gate output", err)
+	}
+}
+
+func TestExecuteApplyOrderStopsOnFailedGate(t *testing.T) {
+	r := newTestRunner(&Config{
+		Command:    "apply",
+		Folders:    []string{"live/staging/app", "live/prod/app"},
+		ApplyOrder: []string{"staging=live/staging/**", "prod=live/prod/**"},
+		ApplyGates: []string{"staging=exit 1"},
+	})
+	results := r.executeApplyOrder(context.Background(), nil)
+
+	if len(results) != 2 {
+		t.Fatalf("executeApplyOrder() = %+v, want 2 results", results)
+	}
+	for _, result := range results {
+		if result.Success || result.Error == nil || !strings.HasPrefix(result.Error.Error(), "not applied") {
+			t.Errorf("executeApplyOrder() result = %+v, want not applied", result)
+		}
+	}
+	if !strings.Contains(results[1].Error.Error(), "apply group staging did not complete") {
+		t.Errorf("executeApplyOrder() prod error = %v", results[1].Error)
+	}
+	if strings.Join(r.config.Folders, ",") != "live/staging/app,live/prod/app" {
+		t.Errorf("executeApplyOrder() left folders = %v", r.config.Folders)
+	}
+}
+
+func TestFormatApplyProgress(t *testing.T) {
+	body := formatApplyProgress([]ApplyGroup{
+		{Name: "dev", Folders: []string{"live/dev/app"}, Status: ApplyDone},
+		{Name: "staging", Folders: []string{"live/staging/app", "live/staging/db"}, Status: ApplyGateFailed, Detail: "exit status 1: a|b"},
+		{Name: "prod", Folders: []string{"live/prod/app"}, Status: ApplyBlocked, Detail: "group staging did not complete"},
+	})
+	for _, want := range []string{
+		"**Order:** dev → staging → prod",
+		"| dev | `live/dev/app` | ✅ applied |",
+		"| staging | `live/staging/app`, `live/staging/db` | ⛔ gate failed: exit status 1: a\\|b |",
+		"| prod | `live/prod/app` | ⏭️ blocked: group staging did not complete |",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatApplyProgress() missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestPostApplyProgressUpdatesOneComment(t *testing.T) {
+	var created, edited int
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/issues/1/comments":
+			created++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{"id": 99})
+		case req.Method == http.MethodPatch && req.URL.Path == "/repos/owner/repo/issues/comments/99":
+			edited++
+			json.NewEncoder(w).Encode(map[string]any{"id": 99})
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1})
+	groups := []ApplyGroup{{Name: "dev", Folders: []string{"live/dev/app"}, Status: ApplyRunning}}
+	for range 3 {
+		r.postApplyProgress(context.Background(), client, groups)
+	}
+	if created != 1 || edited != 2 {
+		t.Errorf("postApplyProgress() created %d and edited %d comments, want 1 and 2", created, edited)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Shell syntax never accepted in arguments, whatever the policy
+var forbiddenArgPatterns = []string{";", "&&", "||", "|", ">", "<", "`", "$(", "${"}
+
+// Policy deciding which Terraform/Terragrunt flags may be passed through --args or
+// ChatOps comments. Entries are flag names with or without dashes; denylist entries
+// may carry a value ("-refresh=false") to only deny that value.
+type ArgPolicy struct {
+	Allowed []string // Flags permitted (empty = any flag not denied)
+	Denied  []string // Flags rejected, checked before the allowlist
+}
+
+// Get the argument policy of the run
+func (r *Runner) argPolicy() ArgPolicy {
+	return ArgPolicy{Allowed: r.config.AllowedArgs, Denied: r.config.DeniedArgs}
+}
+
+// Split a flag into its dash-less lowercase name and value ("" if none); non-flag
+// fields (values of a preceding flag) return ok false
+func parseArgFlag(field string) (name, value string, ok bool) {
+	if !strings.HasPrefix(field, "-") || field == "-" || field == "--" {
+		return "", "", false
+	}
+	name, value, _ = strings.Cut(strings.TrimLeft(field, "-"), "=")
+	return strings.ToLower(name), value, name != ""
+}
+
+// Check every field of the arguments against the policy
+func (p ArgPolicy) check(fields []string) error {
+	for _, field := range fields {
+		for _, pat := range forbiddenArgPatterns {
+			if strings.Contains(field, pat) {
+				return fmt.Errorf("forbidden pattern in arg: %s", field)
+			}
+		}
+		name, value, ok := parseArgFlag(field)
+		if !ok {
+			continue
+		}
+		for _, entry := range p.Denied {
+			deniedName, deniedValue, _ := parseArgFlag("-" + strings.TrimLeft(entry, "-"))
+			if deniedName == name && (deniedValue == "" || strings.EqualFold(deniedValue, value)) {
+				return fmt.Errorf("arg %s is denied by policy (%s)", field, entry)
+			}
+		}
+		if len(p.Allowed) > 0 && !slices.ContainsFunc(p.Allowed, func(entry string) bool {
+			allowedName, _, _ := parseArgFlag("-" + strings.TrimLeft(entry, "-"))
+			return allowedName == name
+		}) {
+			return fmt.Errorf("arg %s is not in the allowed args", field)
+		}
+	}
+	return nil
+}
+
+// Split the arguments and check them against the policy
+func sanitizeArgs(args string, policy ArgPolicy) ([]string, error) {
+	sanitized := strings.Fields(args)
+	if err := policy.check(sanitized); err != nil {
+		return nil, err
+	}
+	return sanitized, nil
+}
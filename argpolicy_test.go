@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArgPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ArgPolicy
+		args    string
+		wantErr string
+	}{
+		{name: "no policy", args: "-target=aws_s3_bucket.logs -refresh=false -var foo=bar"},
+		{name: "shell syntax", args: "-var=x;id", wantErr: "forbidden pattern"},
+		{name: "denied flag", policy: ArgPolicy{Denied: []string{"-auto-approve"}}, args: "-lock=false -auto-approve", wantErr: "-auto-approve is denied"},
+		{name: "denied flag with any value", policy: ArgPolicy{Denied: []string{"target"}}, args: "--target=module.vpc", wantErr: "denied"},
+		{name: "denied value", policy: ArgPolicy{Denied: []string{"-refresh=false"}}, args: "-refresh=FALSE", wantErr: "denied"},
+		{name: "other value of denied flag", policy: ArgPolicy{Denied: []string{"-refresh=false"}}, args: "-refresh=true"},
+		{name: "allowed flags", policy: ArgPolicy{Allowed: []string{"-var", "lock-timeout"}}, args: "-var foo=bar --lock-timeout=5m"},
+		{name: "flag outside allowlist", policy: ArgPolicy{Allowed: []string{"-var"}}, args: "-var foo=bar -parallelism=50", wantErr: "not in the allowed args"},
+		{name: "denylist wins over allowlist", policy: ArgPolicy{Allowed: []string{"-target"}, Denied: []string{"-target"}}, args: "-target=x", wantErr: "denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.check(strings.Fields(tt.args))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("check() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseArgFlag(t *testing.T) {
+	tests := []struct {
+		field, name, value string
+		ok                 bool
+	}{
+		{"-auto-approve", "auto-approve", "", true},
+		{"--Target=module.vpc", "target", "module.vpc", true},
+		{"-var=a=b", "var", "a=b", true},
+		{"foo=bar", "", "", false},
+		{"--", "", "", false},
+	}
+	for _, tt := range tests {
+		name, value, ok := parseArgFlag(tt.field)
+		if name != tt.name || value != tt.value || ok != tt.ok {
+			t.Errorf("parseArgFlag(%q) = %q, %q, %v, want %q, %q, %v", tt.field, name, value, ok, tt.name, tt.value, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Serializes appends to the audit log across the folders run in parallel
+var auditMu sync.Mutex
+
+// Audit log entry of an executed command
+type AuditEntry struct {
+	Argv        []string  `json:"argv"`       // Exact arguments, the program first
+	Dir         string    `json:"dir"`        // Working directory
+	EnvSHA256   string    `json:"env_sha256"` // Fingerprint of the environment (names and values)
+	EnvNames    []string  `json:"env_names"`  // Names of the environment variables, sorted
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	ExitCode    int       `json:"exit_code"`       // -1 if the command did not start or was killed
+	Error       string    `json:"error,omitempty"` // Why the command failed to start or run
+	RunID       string    `json:"run_id"`          // Run of the runner (see the run-id output)
+	Repository  string    `json:"repository,omitempty"`
+	PullRequest int       `json:"pull_request,omitempty"` // Pull request of the run (0 = none)
+	Commit      string    `json:"commit,omitempty"`       // Commit the workflow runs on (GITHUB_SHA)
+	Actor       string    `json:"actor,omitempty"`        // User who initiated the workflow run
+	Triggered   string    `json:"triggered_by,omitempty"` // User who triggered a re-run, if different
+	Workflow    string    `json:"workflow,omitempty"`     // Workflow file and ref (GITHUB_WORKFLOW_REF)
+}
+
+// Run a command and record it in the audit log when enabled
+func (r *Runner) runCommand(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	r.recordAudit(cmd, start, time.Now(), err)
+	return err
+}
+
+// Run a command returning its standard output, like exec.Cmd.Output, and
+// record it in the audit log when enabled
+func (r *Runner) commandOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	r.recordAudit(cmd, start, time.Now(), err)
+	return out, err
+}
+
+// Run a command returning its combined output, like exec.Cmd.CombinedOutput,
+// and record it in the audit log when enabled
+func (r *Runner) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	r.recordAudit(cmd, start, time.Now(), err)
+	return out, err
+}
+
+// Fingerprint an environment, independently of the order of its variables
+func envFingerprint(env []string) (string, []string) {
+	sorted := slices.Sorted(slices.Values(env))
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\x00")))
+	names := make([]string, 0, len(sorted))
+	for _, entry := range sorted {
+		name, _, _ := strings.Cut(entry, "=")
+		names = append(names, name)
+	}
+	return hex.EncodeToString(sum[:]), slices.Compact(names)
+}
+
+// Build the audit entry of a finished command
+func (r *Runner) auditEntry(cmd *exec.Cmd, start, end time.Time, runErr error) AuditEntry {
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	dir := cmd.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	entry := AuditEntry{
+		Argv:        cmd.Args,
+		Dir:         dir,
+		Start:       start.UTC(),
+		End:         end.UTC(),
+		ExitCode:    -1,
+		RunID:       getRunID(),
+		Repository:  r.config.Repository,
+		PullRequest: r.config.PullRequest,
+		Commit:      os.Getenv("GITHUB_SHA"),
+		Actor:       os.Getenv("GITHUB_ACTOR"),
+		Workflow:    os.Getenv("GITHUB_WORKFLOW_REF"),
+	}
+	entry.EnvSHA256, entry.EnvNames = envFingerprint(env)
+	if triggered := os.Getenv("GITHUB_TRIGGERING_ACTOR"); triggered != entry.Actor {
+		entry.Triggered = triggered
+	}
+	if cmd.ProcessState != nil {
+		entry.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		entry.Error = runErr.Error()
+	}
+	return entry
+}
+
+// Append a command to the audit log. The log is only ever appended to, one
+// JSON object per line; failures to write it are logged, not fatal.
+func (r *Runner) recordAudit(cmd *exec.Cmd, start, end time.Time, runErr error) {
+	if r.config.AuditLog == "" {
+		return
+	}
+	line, err := json.Marshal(r.auditEntry(cmd, start, end, runErr))
+	if err != nil {
+		r.logger.Warn("Failed to encode audit log entry", "error", err)
+		return
+	}
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	f, err := os.OpenFile(r.config.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		r.logger.Warn("Failed to open audit log", "file", r.config.AuditLog, "error", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		r.logger.Warn("Failed to write audit log", "file", r.config.AuditLog, "error", err)
+	}
+}
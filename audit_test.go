@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEnvFingerprint(t *testing.T) {
+	sum, names := envFingerprint([]string{"B=2", "A=1", "A=1"})
+	reordered, _ := envFingerprint([]string{"A=1", "B=2", "A=1"})
+	changed, _ := envFingerprint([]string{"A=1", "B=3", "A=1"})
+	if sum != reordered {
+		t.Error("envFingerprint() depends on the order of the variables")
+	}
+	if sum == changed {
+		t.Error("envFingerprint() ignores the values of the variables")
+	}
+	if !slices.Equal(names, []string{"A", "B"}) {
+		t.Errorf("envFingerprint() names = %v, want [A B]", names)
+	}
+}
+
+func TestRunCommandAudit(t *testing.T) {
+	t.Setenv("GITHUB_ACTOR", "alice")
+	t.Setenv("GITHUB_TRIGGERING_ACTOR", "bob")
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "audit.jsonl")
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 7, AuditLog: logPath})
+
+	ok := exec.Command("sh", "-c", "exit 0")
+	ok.Dir = dir
+	ok.Env = []string{"TF_IN_AUTOMATION=true"}
+	if err := r.runCommand(ok); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.runCommand(exec.Command("sh", "-c", "exit 3")); err == nil {
+		t.Fatal("runCommand() of a failing command should fail")
+	}
+	if err := r.runCommand(exec.Command(filepath.Join(dir, "missing"))); err == nil {
+		t.Fatal("runCommand() of a missing program should fail")
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("audit log has %d entries, want 3", len(entries))
+	}
+
+	first := entries[0]
+	if !slices.Equal(first.Argv, []string{"sh", "-c", "exit 0"}) || first.Dir != dir || first.ExitCode != 0 {
+		t.Errorf("entry = %+v, want the argv, dir and exit code of the command", first)
+	}
+	if !slices.Equal(first.EnvNames, []string{"TF_IN_AUTOMATION"}) || first.EnvSHA256 == "" {
+		t.Errorf("entry env = %v %q, want the fingerprint of the command environment", first.EnvNames, first.EnvSHA256)
+	}
+	if first.Repository != "owner/repo" || first.PullRequest != 7 || first.Actor != "alice" || first.Triggered != "bob" {
+		t.Errorf("entry = %+v, want the repository, PR and actors", first)
+	}
+	if first.End.Before(first.Start) {
+		t.Errorf("entry ends at %v before its start %v", first.End, first.Start)
+	}
+	if entries[1].ExitCode != 3 || entries[1].Error != "" {
+		t.Errorf("failing entry exit code = %d, error = %q, want 3 and no error", entries[1].ExitCode, entries[1].Error)
+	}
+	if entries[2].ExitCode != -1 || entries[2].Error == "" {
+		t.Errorf("missing program entry exit code = %d, error = %q, want -1 and the start error", entries[2].ExitCode, entries[2].Error)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Formatting fixes applied to the PR folders
+type FmtAutofix struct {
+	Files     []string // Reformatted files relative to the repo root
+	CommitSHA string   // Commit pushed to the PR branch (empty if not pushed)
+	Error     string   // Why the fixes could not be pushed (empty on success)
+}
+
+// Get the PR head commit SHA from the event payload (empty if not a PR event)
+func getPRHeadSHA() string {
+	payload, err := readEventPayload()
+	if err != nil {
+		return ""
+	}
+	pr, _ := payload["pull_request"].(map[string]any)
+	head, _ := pr["head"].(map[string]any)
+	sha, _ := head["sha"].(string)
+	return sha
+}
+
+// Get the Terraform/OpenTofu binary formatting .tf files (tofu when installed)
+func tfBinary() string {
+	if _, err := exec.LookPath("tofu"); err == nil {
+		return "tofu"
+	}
+	return "terraform"
+}
+
+// Format the HCL and Terraform files of the folders in place and list the files changed
+func (r *Runner) formatFolders(repoRoot string, folders []string) ([]string, error) {
+	var paths []string
+	for _, folder := range folders {
+		absFolder := folder
+		if !filepath.IsAbs(folder) {
+			absFolder = filepath.Join(repoRoot, folder)
+		}
+		for _, args := range [][]string{{"terragrunt", "hcl", "fmt"}, {tfBinary(), "fmt"}} {
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Dir = absFolder
+			cmd.Env = r.subprocessEnv()
+			var out bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &out, &out
+			if err := r.runCommand(cmd); err != nil {
+				// Unparseable files are reported by the plan itself
+				r.logger.Warn("Formatter failed", "folder", folder, "command", strings.Join(args, " "), "error", err, "output", strings.TrimSpace(stripAnsiCodes(out.String())))
+			}
+		}
+		paths = append(paths, absFolder)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	out, err := r.commandOutput(exec.Command("git", append([]string{"-C", repoRoot, "diff", "--name-only", "--"}, paths...)...))
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	var files []string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// Format the folders and push the changes to the PR branch as a single commit
+func (r *Runner) autofixFormatting(ctx context.Context, client *github.Client) *FmtAutofix {
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		r.logger.Warn("Failed to determine repo root, skipping format autofix", "error", err)
+		return nil
+	}
+	files, err := r.formatFolders(repoRoot, r.config.Folders)
+	if err != nil {
+		r.logger.Warn("Failed to format folders", "error", err)
+		return nil
+	}
+	if len(files) == 0 {
+		r.logger.Info("No formatting changes needed")
+		return nil
+	}
+
+	fix := &FmtAutofix{Files: files}
+	sha, err := r.commitFormatting(ctx, client, repoRoot, files)
+	if err != nil {
+		r.logger.Warn("Failed to push formatting fixes", "error", err)
+		fix.Error = err.Error()
+		return fix
+	}
+	r.logger.Info("Pushed formatting fixes", "files", files, "commit", sha)
+	fix.CommitSHA = sha
+	return fix
+}
+
+// Commit the reformatted files on top of the PR branch through the Git data API
+func (r *Runner) commitFormatting(ctx context.Context, client *github.Client, repoRoot string, files []string) (string, error) {
+	branch := os.Getenv("GITHUB_HEAD_REF")
+	if branch == "" {
+		return "", fmt.Errorf("PR head branch unknown (GITHUB_HEAD_REF is not set)")
+	}
+	if isForkPullRequest() {
+		return "", fmt.Errorf("cannot push to a pull request from a fork")
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+
+	ref, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	parent := ref.GetObject().GetSHA()
+	// The files were formatted from the checked out commit; newer pushes would be overwritten
+	if head := getPRHeadSHA(); head != "" && head != parent {
+		return "", fmt.Errorf("branch %s moved since this run started (%s != %s)", branch, shortSHA(parent), shortSHA(head))
+	}
+
+	var entries []*github.TreeEntry
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(repoRoot, file))
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.Ptr(filepath.ToSlash(file)),
+			Mode:    github.Ptr("100644"),
+			Type:    github.Ptr("blob"),
+			Content: github.Ptr(string(content)),
+		})
+	}
+	tree, _, err := client.Git.CreateTree(ctx, owner, repo, parent, entries)
+	if err != nil {
+		return "", err
+	}
+
+	message := "Apply terragrunt hcl fmt and " + tfBinary() + " fmt"
+	commit, _, err := client.Git.CreateCommit(ctx, owner, repo, github.Commit{
+		Message: &message,
+		Tree:    tree,
+		Parents: []*github.Commit{{SHA: &parent}},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := client.Git.UpdateRef(ctx, owner, repo, "heads/"+branch, github.UpdateRef{SHA: commit.GetSHA()}); err != nil {
+		return "", err
+	}
+	return commit.GetSHA(), nil
+}
+
+// Shorten a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// Format the formatting autofix note of the summary comment
+func formatFmtAutofix(fix *FmtAutofix) string {
+	if fix == nil {
+		return ""
+	}
+	files := "`" + strings.Join(fix.Files, "`, `") + "`"
+	if fix.Error != "" {
+		return fmt.Sprintf("\n🧹 **Formatting:** %d files need formatting (%s) but the fixes could not be pushed: %s\n", len(fix.Files), files, fix.Error)
+	}
+	return fmt.Sprintf("\n🧹 **Formatting:** fixed %d files in %s: %s\n", len(fix.Files), shortSHA(fix.CommitSHA), files)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetPRHeadSHA(t *testing.T) {
+	eventFile := filepath.Join(t.TempDir(), "event.json")
+	payload := `{"number": 42, "pull_request": {"base": {"sha": "abc123"}, "head": {"sha": "def456"}}}`
+	if err := os.WriteFile(eventFile, []byte(payload), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", eventFile)
+	if got := getPRHeadSHA(); got != "def456" {
+		t.Errorf("getPRHeadSHA() = %q, want %q", got, "def456")
+	}
+
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	if got := getPRHeadSHA(); got != "" {
+		t.Errorf("getPRHeadSHA() = %q, want empty without payload", got)
+	}
+}
+
+func TestFormatFmtAutofix(t *testing.T) {
+	if got := formatFmtAutofix(nil); got != "" {
+		t.Errorf("formatFmtAutofix(nil) = %q, want empty", got)
+	}
+
+	files := []string{"live/app/terragrunt.hcl", "modules/app/main.tf"}
+	got := formatFmtAutofix(&FmtAutofix{Files: files, CommitSHA: "0123456789abcdef"})
+	want := "fixed 2 files in 0123456: `live/app/terragrunt.hcl`, `modules/app/main.tf`"
+	if !strings.Contains(got, want) {
+		t.Errorf("formatFmtAutofix() = %q, want it to contain %q", got, want)
+	}
+
+	got = formatFmtAutofix(&FmtAutofix{Files: files[:1], Error: "cannot push to a pull request from a fork"})
+	if !strings.Contains(got, "1 files need formatting") || !strings.Contains(got, "cannot push to a pull request from a fork") {
+		t.Errorf("formatFmtAutofix() = %q, want the push failure", got)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Comparison of a folder's plan at the PR head with its plan at the base ref
+type BaseComparison struct {
+	Folder     string   // Folder compared
+	Introduced []string // Changes only planned at the head, i.e. introduced by the PR
+	Drift      []string // Changes planned at both refs, i.e. pre-existing drift
+	Resolved   []string // Changes only planned at the base, i.e. drift the PR resolves
+	NewFolder  bool     // Whether the folder does not exist at the base ref
+	BaseError  string   // Why the base plan could not be compared (empty on success)
+}
+
+// Parse the planned resource actions of a plan output keyed by resource address
+func parsePlannedActions(output string) map[string]string {
+	actions := make(map[string]string)
+	for line := range strings.SplitSeq(stripAnsiCodes(output), "\n") {
+		if m := rePlannedResourceChange.FindStringSubmatch(line); m != nil {
+			actions[m[1]] = m[2]
+		}
+	}
+	return actions
+}
+
+// Compare the planned actions of the head and base plans of a folder
+func compareWithBasePlan(folder, headOutput, baseOutput string) BaseComparison {
+	head, base := parsePlannedActions(headOutput), parsePlannedActions(baseOutput)
+	c := BaseComparison{Folder: folder}
+	for address, action := range head {
+		change := address + " " + action
+		if base[address] == action {
+			c.Drift = append(c.Drift, change)
+		} else {
+			c.Introduced = append(c.Introduced, change)
+		}
+	}
+	for address, action := range base {
+		if head[address] != action {
+			c.Resolved = append(c.Resolved, address+" "+action)
+		}
+	}
+	sort.Strings(c.Introduced)
+	sort.Strings(c.Drift)
+	sort.Strings(c.Resolved)
+	return c
+}
+
+// Plan the folders at the base ref in a temporary worktree and compare the plans
+// with the head results
+func (r *Runner) compareWithBase(results []ExecutionResult) []BaseComparison {
+	if r.config.DiffBase == "" {
+		r.logger.Warn("No base ref available, skipping base comparison")
+		return nil
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		r.logger.Warn("Failed to determine repo root, skipping base comparison", "error", err)
+		return nil
+	}
+
+	worktree, err := os.MkdirTemp("", "terragrunt-runner-base-*")
+	if err != nil {
+		r.logger.Warn("Failed to create base worktree directory", "error", err)
+		return nil
+	}
+	defer os.RemoveAll(worktree)
+	if out, err := r.combinedOutput(exec.Command("git", "-C", repoRoot, "worktree", "add", "--detach", worktree, r.config.DiffBase)); err != nil {
+		r.logger.Warn("Failed to check out base ref, skipping base comparison", "base", r.config.DiffBase, "error", err, "output", strings.TrimSpace(string(out)))
+		return nil
+	}
+	defer r.runCommand(exec.Command("git", "-C", repoRoot, "worktree", "remove", "--force", worktree))
+
+	// The base is always planned per folder, whatever the head command was
+	baseConfig := *r.config
+	baseConfig.Command = "plan"
+	base := NewRunner(&baseConfig, r.logger)
+	base.workRoot = worktree
+
+	var comparisons []BaseComparison
+	for _, result := range r.folderResults(results) {
+		if !result.Success {
+			continue
+		}
+		folder := result.Folder
+		if filepath.IsAbs(folder) {
+			if rel, err := filepath.Rel(repoRoot, folder); err == nil {
+				folder = rel
+			}
+		}
+		baseFolder := filepath.Join(worktree, folder)
+		if _, err := os.Stat(baseFolder); err != nil {
+			comparisons = append(comparisons, BaseComparison{Folder: result.Folder, NewFolder: true})
+			continue
+		}
+
+		r.logger.Info("Planning folder at base ref", "folder", folder, "base", r.config.DiffBase)
+		baseResult := base.executeTerragruntInFolder(baseFolder)
+		if !baseResult.Success {
+			comparisons = append(comparisons, BaseComparison{Folder: result.Folder, BaseError: firstErrorLine(baseResult)})
+			continue
+		}
+		headOutput := result.FullOutput
+		if headOutput == "" {
+			headOutput = result.Output
+		}
+		comparisons = append(comparisons, compareWithBasePlan(result.Folder, headOutput, baseResult.FullOutput))
+	}
+	return comparisons
+}
+
+// Format the base comparison as a section of the summary comment
+func formatBaseComparison(comparisons []BaseComparison, baseRef string) string {
+	if len(comparisons) == 0 {
+		return ""
+	}
+	if len(baseRef) > 12 {
+		baseRef = baseRef[:12]
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n### Comparison with base (`%s`)\n\n", baseRef))
+	b.WriteString("| Folder | Introduced by PR | Pre-existing drift | Resolved by PR |\n|--------|------------------|--------------------|----------------|\n")
+	var details []string
+	for _, c := range comparisons {
+		switch {
+		case c.NewFolder:
+			b.WriteString(fmt.Sprintf("| %s | all (new folder) | - | - |\n", c.Folder))
+			continue
+		case c.BaseError != "":
+			b.WriteString(fmt.Sprintf("| %s | ⚠️ base plan failed | - | - |\n", c.Folder))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", c.Folder, len(c.Introduced), len(c.Drift), len(c.Resolved)))
+		if len(c.Drift) > 0 {
+			details = append(details, fmt.Sprintf("%s:\n  %s", c.Folder, strings.Join(c.Drift, "\n  ")))
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString("\n<details><summary><b>Pre-existing drift (not caused by this PR)</b></summary>\n\n```\n" +
+			strings.Join(details, "\n") + "\n```\n</details>\n")
+	}
+	return b.String()
+}
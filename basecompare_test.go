@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareWithBasePlan(t *testing.T) {
+	base := `  # aws_s3_bucket.logs will be updated in-place
+  # aws_iam_role.old will be destroyed
+Plan: 0 to add, 1 to change, 1 to destroy.`
+	head := `  # aws_s3_bucket.logs will be updated in-place
+  # aws_sqs_queue.jobs will be created
+  # aws_iam_role.old will be destroyed
+Plan: 1 to add, 1 to change, 1 to destroy.`
+
+	got := compareWithBasePlan("live/app", head, base)
+	expected := BaseComparison{
+		Folder:     "live/app",
+		Introduced: []string{"aws_sqs_queue.jobs will be created"},
+		Drift:      []string{"aws_iam_role.old will be destroyed", "aws_s3_bucket.logs will be updated in-place"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("compareWithBasePlan() = %+v, want %+v", got, expected)
+	}
+
+	got = compareWithBasePlan("live/app", "No changes.", base)
+	if len(got.Resolved) != 2 || len(got.Introduced) != 0 {
+		t.Errorf("compareWithBasePlan() = %+v, want 2 resolved changes", got)
+	}
+}
+
+func TestFormatBaseComparison(t *testing.T) {
+	comparisons := []BaseComparison{
+		{Folder: "live/app", Introduced: []string{"aws_sqs_queue.jobs will be created"}, Drift: []string{"aws_s3_bucket.logs will be updated in-place"}},
+		{Folder: "live/new", NewFolder: true},
+	}
+	got := formatBaseComparison(comparisons, "0123456789abcdef")
+
+	for _, want := range []string{
+		"### Comparison with base (`0123456789ab`)",
+		"| live/app | 1 | 1 | 0 |",
+		"| live/new | all (new folder) | - | - |",
+		"aws_s3_bucket.logs will be updated in-place",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatBaseComparison() missing %q:\n%s", want, got)
+		}
+	}
+	if formatBaseComparison(nil, "main") != "" {
+		t.Error("formatBaseComparison() without comparisons should be empty")
+	}
+}
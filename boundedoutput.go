@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Output writer keeping at most limit bytes in memory (the head and a ring buffer
+// of the tail) while spilling the complete stream to a temporary file
+type boundedOutput struct {
+	mu      sync.Mutex
+	limit   int      // Maximum bytes kept in memory (0 = unlimited)
+	head    []byte   // First bytes of the output
+	tail    []byte   // Ring buffer of the last bytes of the output
+	tailPos int      // Next write position in the ring buffer
+	wrapped bool     // Whether the ring buffer has wrapped around
+	total   int64    // Total bytes written
+	spill   *os.File // Complete output on disk (nil if unavailable)
+}
+
+// Create a bounded output writer; the caller must Close it
+func newBoundedOutput(limit int) *boundedOutput {
+	b := &boundedOutput{limit: limit}
+	if limit > 0 {
+		// The tail is usually more relevant (plan summary, errors), so it gets most of the budget
+		b.tail = make([]byte, limit-limit/4)
+		// Without a spill file the console output is truncated like the in-memory output
+		if f, err := os.CreateTemp("", "terragrunt-runner-output-*"); err == nil {
+			b.spill = f
+		}
+	}
+	return b
+}
+
+func (b *boundedOutput) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written := len(p)
+	b.total += int64(written)
+	if b.spill != nil {
+		if _, err := b.spill.Write(p); err != nil {
+			b.spill.Close()
+			os.Remove(b.spill.Name())
+			b.spill = nil
+		}
+	}
+
+	if b.limit <= 0 {
+		b.head = append(b.head, p...)
+		return written, nil
+	}
+
+	if headRoom := b.limit/4 - len(b.head); headRoom > 0 {
+		n := min(headRoom, len(p))
+		b.head = append(b.head, p[:n]...)
+		p = p[n:]
+	}
+	for len(p) > 0 {
+		n := copy(b.tail[b.tailPos:], p)
+		p = p[n:]
+		b.tailPos += n
+		if b.tailPos == len(b.tail) {
+			b.tailPos = 0
+			b.wrapped = true
+		}
+	}
+	return written, nil
+}
+
+// Get the in-memory output, marking where bytes were dropped
+func (b *boundedOutput) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit <= 0 {
+		return string(b.head)
+	}
+	var tail []byte
+	if b.wrapped {
+		tail = append(append([]byte{}, b.tail[b.tailPos:]...), b.tail[:b.tailPos]...)
+	} else {
+		tail = b.tail[:b.tailPos]
+	}
+	kept := int64(len(b.head) + len(tail))
+	if kept == b.total {
+		return string(b.head) + string(tail)
+	}
+	return fmt.Sprintf("%s\n\n... [%d bytes truncated, output exceeded %d bytes] ...\n\n%s", b.head, b.total-kept, b.limit, tail)
+}
+
+// Check whether output was dropped from memory
+func (b *boundedOutput) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit > 0 && b.total > int64(b.limit)
+}
+
+// Copy the complete output to w, from the spill file when available
+func (b *boundedOutput) WriteTo(w io.Writer) (int64, error) {
+	b.mu.Lock()
+	spill := b.spill
+	b.mu.Unlock()
+
+	if spill == nil {
+		n, err := io.WriteString(w, b.String())
+		return int64(n), err
+	}
+	if _, err := spill.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, spill)
+}
+
+// Remove the spill file
+func (b *boundedOutput) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spill == nil {
+		return nil
+	}
+	b.spill.Close()
+	err := os.Remove(b.spill.Name())
+	b.spill = nil
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBoundedOutput(t *testing.T) {
+	b := newBoundedOutput(40)
+	defer b.Close()
+
+	input := strings.Repeat("a", 50) + strings.Repeat("z", 50)
+	for i := 0; i < len(input); i += 7 {
+		end := min(i+7, len(input))
+		if n, err := b.Write([]byte(input[i:end])); err != nil || n != end-i {
+			t.Fatalf("Write() = %d, %v, want %d", n, err, end-i)
+		}
+	}
+
+	if !b.Truncated() {
+		t.Error("Truncated() = false, want true")
+	}
+	got := b.String()
+	if !strings.HasPrefix(got, strings.Repeat("a", 10)+"\n\n... [60 bytes truncated") {
+		t.Errorf("String() head = %q", got)
+	}
+	if !strings.HasSuffix(got, strings.Repeat("z", 30)) {
+		t.Errorf("String() tail = %q", got)
+	}
+
+	var full bytes.Buffer
+	if _, err := b.WriteTo(&full); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if full.String() != input {
+		t.Errorf("WriteTo() = %q, want complete output", full.String())
+	}
+}
+
+func TestBoundedOutputUnlimited(t *testing.T) {
+	b := newBoundedOutput(0)
+	defer b.Close()
+	b.Write([]byte("hello "))
+	b.Write([]byte("world"))
+	if b.String() != "hello world" || b.Truncated() {
+		t.Errorf("String() = %q, Truncated() = %v", b.String(), b.Truncated())
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Check whether the command applies or destroys infrastructure
+func isApplyCommand(command string) bool {
+	for _, field := range strings.Fields(command) {
+		if field == "apply" || field == "destroy" {
+			return true
+		}
+	}
+	return false
+}
+
+// Format a changelog entry for the applied results
+func (r *Runner) formatChangelogEntry(results []ExecutionResult, now time.Time) string {
+	var b strings.Builder
+	title := now.UTC().Format("2006-01-02 15:04 UTC")
+	if r.config.PullRequest > 0 {
+		title += fmt.Sprintf(" — PR #%d", r.config.PullRequest)
+	}
+	b.WriteString("## " + title + "\n\n")
+	b.WriteString(fmt.Sprintf("**Command:** `%s`\n\n", r.config.Command))
+	b.WriteString("| Folder | Status | Added | Changed | Destroyed | Duration |\n|--------|--------|-------|---------|-----------|----------|\n")
+
+	var total time.Duration
+	for _, r := range results {
+		status := "✅"
+		if !r.Success {
+			status = "❌"
+		}
+		add, change, destroy := 0, 0, 0
+		if r.ResourceChanges != nil {
+			add, change, destroy = r.ResourceChanges.ToAdd, r.ResourceChanges.ToChange, r.ResourceChanges.ToDestroy
+		}
+		total += r.Duration
+		b.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %s |\n", r.Folder, status, add, change, destroy, r.Duration.Round(time.Second)))
+	}
+	b.WriteString(fmt.Sprintf("\n_Total duration: %s_\n", total.Round(time.Second)))
+	return b.String()
+}
+
+// Record a changelog entry locally or through the GitHub API
+func (r *Runner) recordChangelog(ctx context.Context, client *github.Client, results []ExecutionResult) error {
+	entry := r.formatChangelogEntry(r.folderResults(results), time.Now())
+	if r.config.ChangelogCommit {
+		return r.commitChangelog(ctx, client, r.config.ChangelogFile, r.config.ChangelogBranch, entry)
+	}
+
+	f, err := os.OpenFile(r.config.ChangelogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "\n%s", entry)
+	return err
+}
+
+// Append the entry to the changelog file in the repository with a commit
+func (r *Runner) commitChangelog(ctx context.Context, client *github.Client, path, branch, entry string) error {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+
+	var getOpts *github.RepositoryContentGetOptions
+	if branch != "" {
+		getOpts = &github.RepositoryContentGetOptions{Ref: branch}
+	}
+
+	content := "# Infrastructure Changelog\n"
+	var sha *string
+	file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, getOpts)
+	switch {
+	case err == nil && file != nil:
+		existing, err := file.GetContent()
+		if err != nil {
+			return err
+		}
+		content = existing
+		sha = file.SHA
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		// New changelog file
+	case err == nil:
+		return fmt.Errorf("changelog path %s is not a file", path)
+	default:
+		return err
+	}
+
+	message := fmt.Sprintf("Update infrastructure changelog for %s", r.config.Command)
+	if r.config.PullRequest > 0 {
+		message += fmt.Sprintf(" (#%d)", r.config.PullRequest)
+	}
+	opts := &github.RepositoryContentFileOptions{
+		Message: &message,
+		Content: []byte(strings.TrimRight(content, "\n") + "\n\n" + entry),
+		SHA:     sha,
+	}
+	if branch != "" {
+		opts.Branch = &branch
+	}
+	_, _, err = client.Repositories.UpdateFile(ctx, owner, repo, path, opts)
+	return err
+}
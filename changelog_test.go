@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsApplyCommand(t *testing.T) {
+	tests := map[string]bool{
+		"apply":                    true,
+		"run --all apply":          true,
+		"run --all -- destroy":     true,
+		"plan":                     false,
+		"run --all plan -no-color": false,
+	}
+	for command, expected := range tests {
+		if got := isApplyCommand(command); got != expected {
+			t.Errorf("isApplyCommand(%q) = %v, want %v", command, got, expected)
+		}
+	}
+}
+
+func TestFormatChangelogEntry(t *testing.T) {
+	r := newTestRunner(&Config{Command: "apply", PullRequest: 42})
+
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2, ToDestroy: 1}, Duration: 90 * time.Second},
+		{Folder: "live/db", Success: false, Duration: 30 * time.Second},
+	}
+	got := r.formatChangelogEntry(results, time.Date(2025, 3, 1, 14, 5, 0, 0, time.UTC))
+
+	for _, want := range []string{
+		"## 2025-03-01 14:05 UTC — PR #42",
+		"**Command:** `apply`",
+		"| live/app | ✅ | 2 | 0 | 1 | 1m30s |",
+		"| live/db | ❌ | 0 | 0 | 0 | 30s |",
+		"_Total duration: 2m0s_",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatChangelogEntry() missing %q:\n%s", want, got)
+		}
+	}
+}
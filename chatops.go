@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Actions a comment can request
+const (
+	ChatOpsPlan    = "plan"
+	ChatOpsApply   = "apply"
+	ChatOpsRefresh = "refresh" // Plan a single folder again, replacing only its comment
+)
+
+// Repository permission levels, from least to most privileged
+var permissionLevels = []string{"none", "read", "triage", "write", "maintain", "admin"}
+
+// Role map deciding who may run which action on which folders
+type RoleMap struct {
+	Rules []RoleRule `json:"rules"`
+}
+
+// A role granting actions on folders to users, or to anyone with a minimum
+// repository permission level
+type RoleRule struct {
+	Name       string   `json:"name,omitempty"`       // Name shown in the decision (optional)
+	Users      []string `json:"users,omitempty"`      // GitHub logins granted the role
+	Permission string   `json:"permission,omitempty"` // Minimum repository permission granting the role
+	Actions    []string `json:"actions"`              // Allowed actions (plan, apply)
+	Folders    []string `json:"folders"`              // Folder globs the actions are allowed on
+}
+
+// Outcome of an authorization check, recorded in the response comment
+type AuthzDecision struct {
+	User       string   // Commenter
+	Permission string   // Commenter's repository permission level
+	Action     string   // Requested action
+	Folders    []string // Requested folders (empty = auto-detected folders)
+	Args       []string // Extra arguments requested after "--"
+	Allowed    bool     // Whether the run may proceed
+	Reason     string   // Matching rule, or why the request was denied
+}
+
+// Role map used without --role-map: writers may plan, maintainers may apply
+var defaultRoleMap = RoleMap{Rules: []RoleRule{
+	{Name: "writers", Permission: "write", Actions: []string{ChatOpsPlan}, Folders: []string{"**"}},
+	{Name: "maintainers", Permission: "maintain", Actions: []string{ChatOpsPlan, ChatOpsApply}, Folders: []string{"**"}},
+}}
+
+// Load a role map from a JSON file (the default role map if path is empty)
+func loadRoleMap(path string) (*RoleMap, error) {
+	if path == "" {
+		return &defaultRoleMap, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var roleMap RoleMap
+	if err := json.Unmarshal(data, &roleMap); err != nil {
+		return nil, fmt.Errorf("invalid role map %s: %w", path, err)
+	}
+	for i, rule := range roleMap.Rules {
+		if len(rule.Users) == 0 && rule.Permission == "" {
+			return nil, fmt.Errorf("invalid role map %s: rule %d grants nobody (set users or permission)", path, i)
+		}
+		if rule.Permission != "" && !slices.Contains(permissionLevels, rule.Permission) {
+			return nil, fmt.Errorf("invalid role map %s: unknown permission %q", path, rule.Permission)
+		}
+	}
+	return &roleMap, nil
+}
+
+// Check whether a permission level is at least the required level
+func permissionAtLeast(level, required string) bool {
+	return slices.Index(permissionLevels, level) >= slices.Index(permissionLevels, required)
+}
+
+// Parse the requested action, folders and extra arguments from a trigger comment
+// ("<trigger> [plan|apply|refresh] [folder...] [-- arg...]"); other text after the
+// trigger is ignored. Only the first line of the comment is parsed.
+func parseChatOpsCommand(body, trigger string) (action string, folders, args []string) {
+	line, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(body), trigger), "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !slices.Contains([]string{ChatOpsPlan, ChatOpsApply, ChatOpsRefresh}, fields[0]) {
+		return "", nil, nil
+	}
+	folders = fields[1:]
+	if i := slices.Index(folders, "--"); i >= 0 {
+		folders, args = folders[:i], folders[i+1:]
+	}
+	return fields[0], folders, args
+}
+
+// Get the value of a runner flag from its arguments (def if absent)
+func runnerFlag(args []string, flag, def string) string {
+	value := def
+	for i, arg := range args {
+		if v, ok := strings.CutPrefix(arg, flag+"="); ok {
+			value = v
+		} else if arg == flag && i+1 < len(args) {
+			value = args[i+1]
+		}
+	}
+	return value
+}
+
+// Get the command passed to the runner through --command (plan if absent)
+func runnerCommand(args []string) string {
+	return runnerFlag(args, "--command", "plan")
+}
+
+// Get the argument policy passed to the runner through --allowed-args and --denied-args
+func runnerArgPolicy(args []string) ArgPolicy {
+	return ArgPolicy{
+		Allowed: parseFolders(runnerFlag(args, "--allowed-args", "")),
+		Denied:  parseFolders(runnerFlag(args, "--denied-args", "")),
+	}
+}
+
+// Decide whether a user may run the action on the folders. Without folders the
+// run covers auto-detected folders, so a rule must allow every folder ("**").
+func (m *RoleMap) authorize(user, permission, action string, folders []string) AuthzDecision {
+	decision := AuthzDecision{User: user, Permission: permission, Action: action, Folders: folders}
+	targets := folders
+	if len(targets) == 0 {
+		targets = []string{"**"}
+	}
+
+	var granted []string
+	for _, folder := range targets {
+		// Authorize the folder the run will resolve, so live/../prod cannot pass a live/ grant
+		folder = path.Clean(folder)
+		if path.IsAbs(folder) || slices.Contains(strings.Split(folder, "/"), "..") {
+			decision.Reason = fmt.Sprintf("folder %s is outside the repository", folder)
+			return decision
+		}
+		rule, ok := m.matchingRule(user, permission, action, folder)
+		if !ok {
+			decision.Reason = fmt.Sprintf("no rule allows %s on %s", action, folder)
+			return decision
+		}
+		granted = append(granted, rule)
+	}
+	decision.Allowed = true
+	decision.Reason = "granted by " + strings.Join(uniqueStrings(granted), ", ")
+	return decision
+}
+
+// Find the first rule allowing the user to run the action on the folder
+func (m *RoleMap) matchingRule(user, permission, action, folder string) (string, bool) {
+	for i, rule := range m.Rules {
+		member := slices.ContainsFunc(rule.Users, func(u string) bool { return strings.EqualFold(strings.TrimPrefix(u, "@"), user) })
+		if !member && (rule.Permission == "" || !permissionAtLeast(permission, rule.Permission)) {
+			continue
+		}
+		if !slices.Contains(rule.Actions, action) {
+			continue
+		}
+		for _, glob := range rule.Folders {
+			if folderGlobMatches(glob, folder) {
+				name := rule.Name
+				if name == "" {
+					name = fmt.Sprintf("rule %d", i+1)
+				}
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Check whether a folder glob matches a folder. Globs follow CODEOWNERS semantics:
+// a directory pattern also matches everything below it. The "**" folder stands for
+// all folders and is only matched by catch-all globs.
+func folderGlobMatches(glob, folder string) bool {
+	if folder == "**" {
+		glob = strings.Trim(glob, "/")
+		return glob == "*" || glob == "**"
+	}
+	return codeownersPatternMatches(glob, strings.Trim(folder, "/"), "")
+}
+
+// Get the repository permission level of a user
+func getPermissionLevel(ctx context.Context, client *github.Client, owner, repo, user string) (string, error) {
+	level, _, err := client.Repositories.GetPermissionLevel(ctx, owner, repo, user)
+	if err != nil {
+		return "", err
+	}
+	// The role name distinguishes triage and maintain, which permission folds into read and write
+	if role := level.GetRoleName(); slices.Contains(permissionLevels, role) {
+		return role, nil
+	}
+	return level.GetPermission(), nil
+}
+
+// Format the authorization decision for the response comment
+func formatAuthzDecision(d AuthzDecision) string {
+	folders := "auto-detected folders"
+	if len(d.Folders) > 0 {
+		folders = "`" + strings.Join(d.Folders, "`, `") + "`"
+	}
+	status := "✅ Authorized"
+	if !d.Allowed {
+		status = "⛔ Denied"
+	}
+	args := ""
+	if len(d.Args) > 0 {
+		args = fmt.Sprintf("**Arguments:** `%s`\n", strings.Join(d.Args, " "))
+	}
+	return fmt.Sprintf("## %s: `%s` requested by @%s\n**Folders:** %s\n%s**Repository permission:** %s\n**Decision:** %s\n",
+		status, d.Action, d.User, folders, args, d.Permission, d.Reason)
+}
+
+// Authorize a comment-triggered job, including its extra arguments against the
+// argument policy, and record the decision on the pull request. Comment arguments
+// are denied unless the runner restricts them with an allowlist.
+func authorizeJob(ctx context.Context, client *github.Client, roleMap *RoleMap, policy ArgPolicy, job RunJob, action string) (AuthzDecision, error) {
+	owner, repo, _ := strings.Cut(job.Repository, "/")
+	permission, err := getPermissionLevel(ctx, client, owner, repo, job.Commenter)
+	if err != nil {
+		return AuthzDecision{}, fmt.Errorf("failed to get permission level of %s: %w", job.Commenter, err)
+	}
+	decision := roleMap.authorize(job.Commenter, permission, action, strings.Fields(job.Folders))
+	decision.Args = strings.Fields(job.Args)
+	if err := policy.check(decision.Args); decision.Allowed && err != nil {
+		decision.Allowed = false
+		decision.Reason = err.Error()
+	}
+	if decision.Allowed && len(decision.Args) > 0 && len(policy.Allowed) == 0 {
+		decision.Allowed = false
+		decision.Reason = "comment arguments are disabled (no --allowed-args given to the runner)"
+	}
+
+	// No marker: the decision is an audit record that must survive the run's comment cleanup
+	body := formatAuthzDecision(decision)
+	if _, _, err := client.Issues.CreateComment(ctx, owner, repo, job.PullRequest, &github.IssueComment{Body: &body}); err != nil {
+		return decision, fmt.Errorf("failed to record authorization decision: %w", err)
+	}
+	return decision, nil
+}
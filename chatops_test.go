@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseChatOpsCommand(t *testing.T) {
+	tests := []struct {
+		body        string
+		wantAction  string
+		wantFolders []string
+		wantArgs    []string
+	}{
+		{"terragrunt-runner run", "", nil, nil},
+		{"terragrunt-runner run please", "", nil, nil},
+		{"terragrunt-runner run plan", "plan", []string{}, nil},
+		{"terragrunt-runner run apply live/dev/app live/dev/db", "apply", []string{"live/dev/app", "live/dev/db"}, nil},
+		{"terragrunt-runner run plan live/dev/app -- -target=aws_s3_bucket.logs -lock=false", "plan", []string{"live/dev/app"}, []string{"-target=aws_s3_bucket.logs", "-lock=false"}},
+		{"terragrunt-runner run plan\nThanks! -- -auto-approve", "plan", []string{}, nil},
+		{"terragrunt-runner run refresh live/dev/app", "refresh", []string{"live/dev/app"}, nil},
+	}
+
+	for _, tt := range tests {
+		action, folders, args := parseChatOpsCommand(tt.body, "terragrunt-runner run")
+		if action != tt.wantAction || strings.Join(folders, " ") != strings.Join(tt.wantFolders, " ") || strings.Join(args, " ") != strings.Join(tt.wantArgs, " ") {
+			t.Errorf("parseChatOpsCommand(%q) = %q, %v, %v, want %q, %v, %v", tt.body, action, folders, args, tt.wantAction, tt.wantFolders, tt.wantArgs)
+		}
+	}
+}
+
+func TestRoleMapAuthorize(t *testing.T) {
+	roleMap := &RoleMap{Rules: []RoleRule{
+		{Name: "dev-appliers", Users: []string{"@alice"}, Actions: []string{"plan", "apply"}, Folders: []string{"live/dev"}},
+		{Name: "writers", Permission: "write", Actions: []string{"plan"}, Folders: []string{"**"}},
+		{Name: "admins", Permission: "admin", Actions: []string{"plan", "apply"}, Folders: []string{"**"}},
+	}}
+
+	tests := []struct {
+		name       string
+		user       string
+		permission string
+		action     string
+		folders    []string
+		want       bool
+	}{
+		{"writer plans anything", "bob", "write", "plan", nil, true},
+		{"writer cannot apply", "bob", "write", "apply", []string{"live/dev/app"}, false},
+		{"reader cannot plan", "eve", "read", "plan", []string{"live/dev/app"}, false},
+		{"user rule applies in dev", "alice", "read", "apply", []string{"live/dev/app"}, true},
+		{"user rule does not cover prod", "alice", "read", "apply", []string{"live/dev/app", "live/prod/app"}, false},
+		{"user rule does not cover auto-detected folders", "alice", "read", "apply", nil, false},
+		{"admin applies anything", "carol", "admin", "apply", nil, true},
+		{"parent segments are cleaned", "alice", "read", "apply", []string{"live/dev/../prod/app"}, false},
+		{"cleaned folder stays granted", "alice", "read", "apply", []string{"live/dev/./app/"}, true},
+		{"escaping folder is denied", "carol", "admin", "apply", []string{"../other"}, false},
+		{"absolute folder is denied", "carol", "admin", "apply", []string{"/live/dev/app"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := roleMap.authorize(tt.user, tt.permission, tt.action, tt.folders)
+			if decision.Allowed != tt.want {
+				t.Errorf("authorize() = %+v, want allowed=%v", decision, tt.want)
+			}
+			if !strings.Contains(formatAuthzDecision(decision), "@"+tt.user) {
+				t.Errorf("formatAuthzDecision() does not mention %s", tt.user)
+			}
+		})
+	}
+}
+
+func TestAuthorizeJobCommentArgs(t *testing.T) {
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/permission") {
+			w.Write([]byte(`{"permission": "admin", "role_name": "admin"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	job := RunJob{Repository: "owner/repo", PullRequest: 1, Commenter: "carol"}
+
+	tests := []struct {
+		name   string
+		policy ArgPolicy
+		args   string
+		want   bool
+	}{
+		{"no arguments without policy", ArgPolicy{}, "", true},
+		{"arguments without allowlist", ArgPolicy{}, "-lock=false", false},
+		{"arguments with only a denylist", ArgPolicy{Denied: []string{"-target"}}, "-lock=false", false},
+		{"allowed arguments", ArgPolicy{Allowed: []string{"-lock"}}, "-lock=false", true},
+		{"arguments outside allowlist", ArgPolicy{Allowed: []string{"-lock"}}, "-tf-path=/tmp/tf", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job.Args = tt.args
+			decision, err := authorizeJob(context.Background(), client, &defaultRoleMap, tt.policy, job, "plan")
+			if err != nil {
+				t.Fatalf("authorizeJob() error = %v", err)
+			}
+			if decision.Allowed != tt.want {
+				t.Errorf("authorizeJob() = %+v, want allowed=%v", decision, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRoleMap(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "roles.json")
+	os.WriteFile(valid, []byte(`{"rules": [{"users": ["alice"], "actions": ["apply"], "folders": ["live/dev/**"]}]}`), 0644)
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"rules": [{"actions": ["apply"], "folders": ["**"]}]}`), 0644)
+
+	if roleMap, err := loadRoleMap(valid); err != nil || len(roleMap.Rules) != 1 {
+		t.Errorf("loadRoleMap() = %+v, %v", roleMap, err)
+	}
+	if _, err := loadRoleMap(invalid); err == nil {
+		t.Error("loadRoleMap() expected error for a rule granting nobody")
+	}
+	if roleMap, err := loadRoleMap(""); err != nil || roleMap != &defaultRoleMap {
+		t.Errorf("loadRoleMap(\"\") = %+v, %v, want default role map", roleMap, err)
+	}
+}
+
+func TestRunnerCommand(t *testing.T) {
+	if got := runnerCommand([]string{"--auto-detect", "--command", "run --all apply"}); got != "run --all apply" {
+		t.Errorf("runnerCommand() = %q", got)
+	}
+	if got := runnerCommand([]string{"--command=apply"}); got != "apply" {
+		t.Errorf("runnerCommand() = %q", got)
+	}
+	if got := runnerCommand(nil); got != "plan" {
+		t.Errorf("runnerCommand() = %q, want plan", got)
+	}
+
+	policy := runnerArgPolicy([]string{"--auto-detect", "--denied-args", "-auto-approve,-target", "--allowed-args=-target,-var"})
+	if !slices.Equal(policy.Denied, []string{"-auto-approve", "-target"}) || !slices.Equal(policy.Allowed, []string{"-target", "-var"}) {
+		t.Errorf("runnerArgPolicy() = %+v", policy)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/spf13/cobra"
+)
+
+// Build the command listing the directories directly below a key prefix
+func (t *PublishTarget) listCommand(prefix string) *exec.Cmd {
+	prefix = path.Join(t.Prefix, prefix) + "/"
+	switch t.Scheme {
+	case "s3":
+		return exec.Command("aws", "s3", "ls", fmt.Sprintf("s3://%s/%s", t.Bucket, prefix))
+	case "gs":
+		return exec.Command("gcloud", "storage", "ls", fmt.Sprintf("gs://%s/%s", t.Bucket, prefix))
+	default:
+		return exec.Command("az", "storage", "blob", "list", "--only-show-errors",
+			"--auth-mode", "login", "--account-name", t.Bucket, "--container-name", t.Container,
+			"--prefix", prefix, "--delimiter", "/", "--query", "[].name", "--output", "tsv")
+	}
+}
+
+// Build the command writing an object to stdout
+func (t *PublishTarget) readCommand(key string) *exec.Cmd {
+	key = path.Join(t.Prefix, key)
+	switch t.Scheme {
+	case "s3":
+		return exec.Command("aws", "s3", "cp", "--only-show-errors", fmt.Sprintf("s3://%s/%s", t.Bucket, key), "-")
+	case "gs":
+		return exec.Command("gcloud", "storage", "cat", fmt.Sprintf("gs://%s/%s", t.Bucket, key))
+	default:
+		// Without --output none, az prints the blob properties after the content
+		return exec.Command("az", "storage", "blob", "download", "--only-show-errors", "--output", "none",
+			"--auth-mode", "login", "--account-name", t.Bucket, "--container-name", t.Container,
+			"--name", key, "--file", "/dev/stdout")
+	}
+}
+
+// Build the command deleting every object below a key prefix
+func (t *PublishTarget) deleteCommand(prefix string) *exec.Cmd {
+	prefix = path.Join(t.Prefix, prefix) + "/"
+	switch t.Scheme {
+	case "s3":
+		return exec.Command("aws", "s3", "rm", "--recursive", "--only-show-errors", fmt.Sprintf("s3://%s/%s", t.Bucket, prefix))
+	case "gs":
+		return exec.Command("gcloud", "storage", "rm", "--recursive", fmt.Sprintf("gs://%s/%s", t.Bucket, prefix))
+	default:
+		return exec.Command("az", "storage", "blob", "delete-batch", "--only-show-errors",
+			"--auth-mode", "login", "--account-name", t.Bucket, "--source", t.Container,
+			"--pattern", prefix+"*")
+	}
+}
+
+// Parse the directory names of a listing, whichever CLI produced it: S3 lists
+// "PRE name/", GCS full URLs and Azure prefixes, all ending with a slash
+func parseListedDirs(output string) []string {
+	var dirs []string
+	for line := range strings.SplitSeq(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasSuffix(fields[len(fields)-1], "/") {
+			continue
+		}
+		dirs = append(dirs, path.Base(strings.TrimSuffix(fields[len(fields)-1], "/")))
+	}
+	return dirs
+}
+
+// Run a storage CLI command, returning its standard output
+func (r *Runner) runStorageCommand(cmd *exec.Cmd) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := r.runCommand(cmd); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args[:min(3, len(cmd.Args))], " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Check whether a pull request is closed (merged or not), caching the answers.
+// Pull requests whose state cannot be read are kept.
+func (r *Runner) prClosed(ctx context.Context, client *github.Client, number int, cache map[int]bool) bool {
+	if closed, ok := cache[number]; ok {
+		return closed
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	pr, _, err := client.PullRequests.Get(ctx, parts[0], parts[1], number)
+	if err != nil {
+		r.logger.Warn("Failed to get the pull request state, keeping its plans", "pull_request", number, "error", err)
+		return false
+	}
+	cache[number] = pr.GetState() == "closed"
+	return cache[number]
+}
+
+// Delete the published runs (results, reports and plan files) of the
+// repository that are older than maxAge or belong to closed pull requests,
+// returning how many runs were deleted and kept
+func (r *Runner) cleanupPlans(ctx context.Context, client *github.Client, dest string, maxAge time.Duration, closedPRs, dryRun bool, now time.Time) (int, int, error) {
+	target, err := parsePublishTarget(dest)
+	if err != nil {
+		return 0, 0, err
+	}
+	repoDir := strings.ReplaceAll(r.config.Repository, "/", "_")
+	listing, err := r.runStorageCommand(target.listCommand(repoDir))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list the published runs: %w", err)
+	}
+
+	deleted, kept := 0, 0
+	states := map[int]bool{}
+	for _, runID := range parseListedDirs(string(listing)) {
+		base := path.Join(repoDir, runID)
+		data, err := r.runStorageCommand(target.readCommand(path.Join(base, "results.json")))
+		var run PublishedRun
+		if err == nil {
+			err = json.Unmarshal(data, &run)
+		}
+		if err != nil {
+			r.logger.Warn("Failed to read the published run, keeping it", "run", runID, "error", err)
+			kept++
+			continue
+		}
+
+		reason := ""
+		if maxAge > 0 && now.Sub(run.Time) > maxAge {
+			reason = "older than the retention"
+		} else if closedPRs && run.PullRequest > 0 && r.prClosed(ctx, client, run.PullRequest, states) {
+			reason = fmt.Sprintf("pull request #%d is closed", run.PullRequest)
+		}
+		if reason == "" {
+			kept++
+			continue
+		}
+		if dryRun {
+			r.logger.Info("Would delete published run (dry run)", "run", runID, "time", run.Time.Format(time.RFC3339), "reason", reason)
+			deleted++
+			continue
+		}
+		if _, err := r.runStorageCommand(target.deleteCommand(base)); err != nil {
+			return deleted, kept, fmt.Errorf("failed to delete run %s: %w", runID, err)
+		}
+		r.logger.Info("Deleted published run", "run", runID, "reason", reason)
+		deleted++
+	}
+	return deleted, kept, nil
+}
+
+// Create the cleanup-plans subcommand
+func newCleanupPlansCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var dest string
+	var days int
+	var closedPRs, dryRun bool
+	cmd := &cobra.Command{
+		Use:   "cleanup-plans",
+		Short: "Delete published runs and plan files past their retention",
+		Long: `Delete the runs published with publish-results (results JSON, HTML report and
+plan files) of the repository that are older than the retention or belong to
+closed or merged pull requests, so the store does not grow without bounds.
+Meant for scheduled workflows, with the credentials of the store and a token
+reading the pull requests.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if days < 0 {
+				return fmt.Errorf("invalid --older-than-days: %d (must not be negative)", days)
+			}
+			if config.Repository == "" {
+				return fmt.Errorf("--repository is required")
+			}
+			r := NewRunner(config, logger)
+			if config.GithubToken != "" {
+				fmt.Printf("::add-mask::%s\n", config.GithubToken)
+			}
+			maxAge := time.Duration(days) * 24 * time.Hour
+			deleted, kept, err := r.cleanupPlans(cmd.Context(), r.createGitHubClient(), dest, maxAge, closedPRs, dryRun, time.Now())
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %d published runs, kept %d\n", verb, deleted, kept)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&dest, "destination", "", "Store the runs were published to: s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix (as publish-results)")
+	cmd.Flags().IntVar(&days, "older-than-days", 30, "Delete runs published more than this many days ago (0 = keep regardless of age)")
+	cmd.Flags().BoolVar(&closedPRs, "closed-prs", true, "Delete the runs of closed and merged pull requests")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only log the runs that would be deleted")
+	cmd.MarkFlagRequired("destination")
+	return cmd
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseListedDirs(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"s3", "                           PRE 100/\n                           PRE 101-2/\n2025-01-01 10:00:00        12 stray.json\n", []string{"100", "101-2"}},
+		{"gs", "gs://bucket/prefix/owner_repo/100/\ngs://bucket/prefix/owner_repo/101/\n", []string{"100", "101"}},
+		{"az", "prefix/owner_repo/100/\nprefix/owner_repo/101/\n", []string{"100", "101"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseListedDirs(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseListedDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageCommands(t *testing.T) {
+	tests := []struct {
+		dest               string
+		list, read, delete string
+	}{
+		{
+			"s3://bucket/prefix",
+			"aws s3 ls s3://bucket/prefix/owner_repo/",
+			"aws s3 cp --only-show-errors s3://bucket/prefix/owner_repo/100/results.json -",
+			"aws s3 rm --recursive --only-show-errors s3://bucket/prefix/owner_repo/100/",
+		},
+		{
+			"gs://bucket",
+			"gcloud storage ls gs://bucket/owner_repo/",
+			"gcloud storage cat gs://bucket/owner_repo/100/results.json",
+			"gcloud storage rm --recursive gs://bucket/owner_repo/100/",
+		},
+		{
+			"az://account/container/prefix",
+			"az storage blob list --only-show-errors --auth-mode login --account-name account --container-name container --prefix prefix/owner_repo/ --delimiter / --query [].name --output tsv",
+			"az storage blob download --only-show-errors --output none --auth-mode login --account-name account --container-name container --name prefix/owner_repo/100/results.json --file /dev/stdout",
+			"az storage blob delete-batch --only-show-errors --auth-mode login --account-name account --source container --pattern prefix/owner_repo/100/*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			target, err := parsePublishTarget(tt.dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range []struct{ got, want string }{
+				{strings.Join(target.listCommand("owner_repo").Args, " "), tt.list},
+				{strings.Join(target.readCommand("owner_repo/100/results.json").Args, " "), tt.read},
+				{strings.Join(target.deleteCommand("owner_repo/100").Args, " "), tt.delete},
+			} {
+				if c.got != c.want {
+					t.Errorf("command = %q, want %q", c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+// Fake aws CLI serving an S3 bucket from $FAKE_S3 and logging deletions
+const fakeStorageAWSScript = `#!/bin/sh
+case "$2" in
+ls) for d in "$FAKE_S3/${3#s3://}"*/; do [ -d "$d" ] && echo "                           PRE $(basename "$d")/"; done ;;
+cp) cat "$FAKE_S3/${4#s3://}" ;;
+rm) echo "$5" >> "$FAKE_S3/deleted.log" ;;
+esac
+`
+
+// Fake az CLI serving a blob container from $FAKE_AZ. Like az, the download
+// prints the blob properties to stdout unless --output none is passed.
+const fakeStorageAZScript = `#!/bin/sh
+output=json
+while [ $# -gt 0 ]; do
+  case "$1" in
+  --account-name) account=$2; shift ;;
+  --container-name) container=$2; shift ;;
+  --name) name=$2; shift ;;
+  --file) file=$2; shift ;;
+  --output) output=$2; shift ;;
+  esac
+  shift
+done
+cat "$FAKE_AZ/$account/$container/$name" > "$file"
+[ "$output" = none ] || echo '{"name": "'"$name"'", "properties": {}}'
+`
+
+func TestStorageReadAzure(t *testing.T) {
+	scripts, store := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "az"), []byte(fakeStorageAZScript), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_AZ", store)
+
+	dir := filepath.Join(store, "account/container/evidence/owner_repo/100")
+	os.MkdirAll(dir, 0o755)
+	want := PublishedRun{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), PullRequest: 5}
+	data, _ := json.Marshal(want)
+	os.WriteFile(filepath.Join(dir, "results.json"), data, 0o644)
+
+	target, err := parsePublishTarget("az://account/container/evidence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := newTestRunner(&Config{}).runStorageCommand(target.readCommand("owner_repo/100/results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PublishedRun
+	if err := json.Unmarshal(out, &got); err != nil || !got.Time.Equal(want.Time) || got.PullRequest != want.PullRequest {
+		t.Errorf("read %q (%v), want the blob content only", out, err)
+	}
+}
+
+func TestCleanupPlans(t *testing.T) {
+	scripts, store := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "aws"), []byte(fakeStorageAWSScript), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_S3", store)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	runs := map[string]*PublishedRun{
+		"100": {Time: now.AddDate(0, 0, -60)},                // expired
+		"101": {Time: now.AddDate(0, 0, -2), PullRequest: 5}, // closed pull request
+		"102": {Time: now.AddDate(0, 0, -2), PullRequest: 6}, // open pull request
+		"103": {Time: now.AddDate(0, 0, -3), PullRequest: 5}, // closed pull request, state cached
+		"104": nil,                                           // unreadable
+	}
+	for id, run := range runs {
+		dir := filepath.Join(store, "bucket/evidence/owner_repo", id)
+		os.MkdirAll(dir, 0o755)
+		if run != nil {
+			data, _ := json.Marshal(run)
+			os.WriteFile(filepath.Join(dir, "results.json"), data, 0o644)
+		}
+	}
+	lookups := 0
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/owner/repo/pulls/5":
+			lookups++
+			w.Write([]byte(`{"number": 5, "state": "closed"}`))
+		case "/repos/owner/repo/pulls/6":
+			w.Write([]byte(`{"number": 6, "state": "open"}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo"})
+	deleted, kept, err := r.cleanupPlans(t.Context(), client, "s3://bucket/evidence", 30*24*time.Hour, true, true, now)
+	if err != nil || deleted != 3 || kept != 2 {
+		t.Fatalf("dry run deleted %d, kept %d, error %v, want 3 and 2", deleted, kept, err)
+	}
+	if _, err := os.Stat(filepath.Join(store, "deleted.log")); err == nil {
+		t.Fatal("dry run deleted runs")
+	}
+
+	deleted, kept, err = r.cleanupPlans(t.Context(), client, "s3://bucket/evidence", 30*24*time.Hour, true, false, now)
+	if err != nil || deleted != 3 || kept != 2 {
+		t.Fatalf("deleted %d, kept %d, error %v, want 3 and 2", deleted, kept, err)
+	}
+	log, _ := os.ReadFile(filepath.Join(store, "deleted.log"))
+	want := "s3://bucket/evidence/owner_repo/100/\ns3://bucket/evidence/owner_repo/101/\ns3://bucket/evidence/owner_repo/103/\n"
+	if string(log) != want {
+		t.Errorf("deleted:\n%s\nwant:\n%s", log, want)
+	}
+	if lookups != 2 {
+		t.Errorf("looked up pull request 5 %d times, want once per cleanup", lookups)
+	}
+
+	os.Remove(filepath.Join(store, "deleted.log"))
+	if deleted, _, _ := r.cleanupPlans(t.Context(), client, "s3://bucket/evidence", 0, false, false, now); deleted != 0 {
+		t.Errorf("deleted %d runs without age limit and closed-prs, want none", deleted)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import "os"
+
+// Color modes of the console output, selectable with --color
+const (
+	ColorAuto   = "auto"   // Colored unless NO_COLOR/CLICOLOR=0, or off a terminal outside GitHub Actions
+	ColorAlways = "always" // Always colored
+	ColorNever  = "never"  // Never colored, also passing -no-color to Terragrunt
+)
+
+var colorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+// Decide whether the console output is colored. In auto mode NO_COLOR (any
+// value) disables colors and CLICOLOR_FORCE forces them; CLICOLOR=0 disables
+// them too. GitHub Actions renders colors although its output is not a terminal.
+func colorEnabled(mode string) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+	return os.Getenv("GITHUB_ACTIONS") == "true" || isTerminal(os.Stdout)
+}
+
+// Check whether a file is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Color a console decoration of the runner, leaving it plain when the console
+// is not colored
+func (r *Runner) colorize(color, text string) string {
+	if !colorEnabled(r.config.Color) {
+		return text
+	}
+	return color + text + Reset
+}
+
+// Get the Terraform argument disabling colors when the console is not colored.
+// Comments are stripped of escape codes either way.
+func (r *Runner) noColorArgs(args string) []string {
+	if colorEnabled(r.config.Color) || hasArgFlag(args, "-no-color") {
+		return nil
+	}
+	return []string{"-no-color"}
+}
+
+// Get the environment disabling the colors of Terragrunt's own log output
+// when the console is not colored
+func (r *Runner) noColorEnv() []string {
+	if colorEnabled(r.config.Color) {
+		return nil
+	}
+	return []string{"TG_NO_COLOR=true", "TERRAGRUNT_NO_COLOR=true"}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		env  map[string]string
+		want bool
+	}{
+		{name: "github actions", mode: ColorAuto, env: map[string]string{"GITHUB_ACTIONS": "true"}, want: true},
+		{name: "not a terminal", mode: ColorAuto},
+		{name: "no color", mode: ColorAuto, env: map[string]string{"GITHUB_ACTIONS": "true", "NO_COLOR": "1"}},
+		{name: "clicolor off", mode: "", env: map[string]string{"GITHUB_ACTIONS": "true", "CLICOLOR": "0"}},
+		{name: "clicolor force", mode: ColorAuto, env: map[string]string{"CLICOLOR_FORCE": "1"}, want: true},
+		{name: "no color wins over force", mode: ColorAuto, env: map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}},
+		{name: "always", mode: ColorAlways, env: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "never", mode: ColorNever, env: map[string]string{"GITHUB_ACTIONS": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"GITHUB_ACTIONS", "NO_COLOR", "CLICOLOR", "CLICOLOR_FORCE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := colorEnabled(tt.mode); got != tt.want {
+				t.Errorf("colorEnabled(%q) = %t, want %t", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoColorArgs(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	r := newTestRunner(&Config{Color: ColorNever})
+	if got := r.noColorArgs("-lock=false"); !slices.Equal(got, []string{"-no-color"}) {
+		t.Errorf("noColorArgs() = %v, want -no-color", got)
+	}
+	if got := r.noColorArgs("-no-color -lock=false"); got != nil {
+		t.Errorf("noColorArgs() = %v, want none when args already disable colors", got)
+	}
+	if env := r.subprocessEnv(); !slices.Contains(env, "TG_NO_COLOR=true") {
+		t.Errorf("subprocess env lacks TG_NO_COLOR: %v", env)
+	}
+
+	r.config.Color = ColorAlways
+	if got := r.noColorArgs(""); got != nil {
+		t.Errorf("noColorArgs() = %v, want none with colors", got)
+	}
+	if env := r.subprocessEnv(); slices.Contains(env, "TG_NO_COLOR=true") {
+		t.Errorf("subprocess env disables colors: %v", env)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	plain, colored := newTestRunner(&Config{Color: ColorNever}), newTestRunner(&Config{Color: ColorAlways})
+	if got := plain.colorize(Red, "###"); got != "###" {
+		t.Errorf("colorize() = %q, want plain text", got)
+	}
+	if got := colored.colorize(Red, "###"); got != Red+"###"+Reset {
+		t.Errorf("colorize() = %q, want colored text", got)
+	}
+	// Runners do not share color state
+	if got := plain.colorize(Red, "###"); got != "###" || Red == "" {
+		t.Errorf("colorize() = %q with Red = %q after a colored runner", got, Red)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+// Which folders get an individual comment, selectable with --comment-on; the
+// summary table covers every folder either way
+const (
+	CommentOnFailure = "failure" // Failed folders only
+	CommentOnChanges = "changes" // Failed folders and folders with changes
+	CommentOnAlways  = "always"  // Every folder
+)
+
+var commentOnModes = []string{CommentOnFailure, CommentOnChanges, CommentOnAlways}
+
+// Check whether a result gets an individual comment under comment-on. Results
+// whose changes are unknown (e.g. an apply without a parsed summary) count as
+// changed.
+func (r *Runner) postsComment(result ExecutionResult) bool {
+	if !result.Success {
+		return true
+	}
+	switch r.config.CommentOn {
+	case CommentOnFailure:
+		return false
+	case CommentOnChanges:
+		return result.ResourceChanges == nil || !result.ResourceChanges.NoChanges
+	default:
+		return true
+	}
+}
+
+// Format the summary line counting the folders left without a comment by
+// comment-on (empty when every folder got one)
+func (r *Runner) formatCommentOnSummary(results []ExecutionResult) string {
+	if r.config.CommentOn == "" || r.config.CommentOn == CommentOnAlways {
+		return ""
+	}
+	count := 0
+	for _, result := range results {
+		if !r.postsComment(result) {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return "- " + r.msg("summary.comment_on", "count", count, "mode", r.config.CommentOn) + "\n"
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPostsComment(t *testing.T) {
+	failed := ExecutionResult{Folder: "failed", Error: errors.New("exit status 1")}
+	changed := ExecutionResult{Folder: "changed", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}}
+	unchanged := ExecutionResult{Folder: "unchanged", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}}
+	unknown := ExecutionResult{Folder: "unknown", Success: true}
+
+	tests := []struct {
+		mode     string
+		expected []string
+	}{
+		{mode: "", expected: []string{"failed", "changed", "unchanged", "unknown"}},
+		{mode: CommentOnAlways, expected: []string{"failed", "changed", "unchanged", "unknown"}},
+		{mode: CommentOnChanges, expected: []string{"failed", "changed", "unknown"}},
+		{mode: CommentOnFailure, expected: []string{"failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			r := newTestRunner(&Config{CommentOn: tt.mode})
+			var posted []string
+			for _, result := range []ExecutionResult{failed, changed, unchanged, unknown} {
+				if r.postsComment(result) {
+					posted = append(posted, result.Folder)
+				}
+			}
+			if strings.Join(posted, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("postsComment() posts %v, want %v", posted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCommentOnSummary(t *testing.T) {
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+		{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{ToChange: 1}},
+	}
+	r := newTestRunner(&Config{Command: "plan", CommentOn: CommentOnFailure})
+	summary := r.formatSummary(results)
+	if !strings.Contains(summary, "| live/db |") || !strings.Contains(summary, "- 2 folders without an individual comment (comment-on: failure)\n") {
+		t.Errorf("formatSummary() = %q, want every folder and the comment-on count", summary)
+	}
+	if got := newTestRunner(&Config{CommentOn: CommentOnAlways}).formatCommentOnSummary(results); got != "" {
+		t.Errorf("formatCommentOnSummary() with always = %q, want empty", got)
+	}
+}
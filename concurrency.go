@@ -0,0 +1,26 @@
+package main
+
+import (
+	"sync"
+)
+
+// Get the lock of each folder in a concurrency group: folders sharing a group
+// (e.g. one state bucket or AWS account) share a lock, so they never run in
+// parallel with each other while other folders still do. Folders without a
+// group have no lock.
+func (r *Runner) concurrencyLocks(folders []string) map[string]*sync.Mutex {
+	groupLocks := map[string]*sync.Mutex{}
+	locks := map[string]*sync.Mutex{}
+	for _, folder := range folders {
+		group := resolveFolderMapping(r.config.ConcurrencyGroups, folder)
+		if group == "" {
+			continue
+		}
+		if groupLocks[group] == nil {
+			groupLocks[group] = &sync.Mutex{}
+		}
+		locks[folder] = groupLocks[group]
+		r.logger.Debug("Folder in concurrency group", "folder", folder, "group", group)
+	}
+	return locks
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConcurrencyLocks(t *testing.T) {
+	r := newTestRunner(&Config{ConcurrencyGroups: []string{"live/prod/**=prod-state", "live/prod/dns=dns-account"}})
+	locks := r.concurrencyLocks([]string{"live/prod/app", "live/prod/db", "live/prod/dns", "live/dev/app"})
+
+	if locks["live/prod/app"] == nil || locks["live/prod/app"] != locks["live/prod/db"] {
+		t.Errorf("prod folders do not share a lock")
+	}
+	if locks["live/prod/dns"] == nil || locks["live/prod/dns"] == locks["live/prod/app"] {
+		t.Errorf("live/prod/dns should have its own group (last match wins)")
+	}
+	if locks["live/dev/app"] != nil {
+		t.Errorf("folder without a group has a lock")
+	}
+}
+
+// Fake terragrunt logging when each unit starts and ends, slow enough for
+// parallel units to overlap
+const fakeSlowTerragruntScript = `#!/bin/sh
+unit=$(basename "$PWD")
+echo "start $unit" >> "$TG_FAKE_TERRAGRUNT_DIR/calls.log"
+sleep 0.3
+echo "end $unit" >> "$TG_FAKE_TERRAGRUNT_DIR/calls.log"
+`
+
+func TestExecuteWithConcurrencyGroups(t *testing.T) {
+	scripts := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(fakeSlowTerragruntScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TG_FAKE_TERRAGRUNT_DIR", scripts)
+
+	root := t.TempDir()
+	var folders []string
+	for _, unit := range []string{"app", "db", "vpc"} {
+		folder := filepath.Join(root, unit)
+		os.MkdirAll(folder, 0o755)
+		folders = append(folders, folder)
+	}
+
+	r := newTestRunner(&Config{
+		Command:           "plan",
+		Folders:           folders,
+		ParallelExec:      true,
+		MaxParallel:       5,
+		ConcurrencyGroups: []string{"**/app=state", "**/db=state"},
+	})
+	r.executeTerragruntPerFolder()
+
+	content, err := os.ReadFile(filepath.Join(scripts, "calls.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Members of a group never overlap; the ungrouped folder runs alongside them
+	running := map[string]bool{}
+	overlapped := false
+	for line := range strings.SplitSeq(strings.TrimSpace(string(content)), "\n") {
+		event, unit, _ := strings.Cut(line, " ")
+		if event == "end" {
+			delete(running, unit)
+			continue
+		}
+		if unit != "vpc" && (running["app"] || running["db"]) {
+			t.Errorf("%s started while another member of its group was running:\n%s", unit, content)
+		}
+		if len(running) > 0 {
+			overlapped = true
+		}
+		running[unit] = true
+	}
+	if !overlapped {
+		t.Errorf("no folders ran in parallel:\n%s", content)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/spf13/pflag"
+)
+
+// Repository config file, relative to the repository root. Organization
+// config repositories keep their defaults at the same path.
+const defaultConfigFile = ".github/terragrunt-runner.yaml"
+
+// Inputs the repository config file may set. The file is read at the base of
+// the pull request, but the runner's security settings (command, arguments,
+// secrets, webhooks, policies) are only ever set by the workflow.
+var repoConfigInputs = map[string]bool{
+	"account-depth": true, "account-marker": true, "auto-detect": true, "color": true,
+	"comment-on": true, "comment-threading": true, "delete-comments-older-than": true,
+	"delete-dry-run": true, "delete-max": true, "delete-old-comments": true, "detail-level": true,
+	"execution-plan": true, "explain-detection": true, "explain-detection-comment": true,
+	"failure-detail-level": true, "file-patterns": true, "fmt-suggestions": true,
+	"folder-names-file": true, "granularity": true, "hide-unchanged-attributes": true,
+	"log-format": true, "max-output-bytes": true, "max-parallel": true, "max-walk-up": true,
+	"mention-owners": true, "messages-file": true, "owners-file": true, "parallel": true,
+	"plan-diff": true, "progress-comment": true, "progress-interval": true, "retry-failed": true,
+	"reuse-comments": true, "risk-score": true, "run-delta": true, "run-label": true,
+	"shard-run-all": true, "skip-no-change-comments": true, "stack-markers": true,
+	"terragrunt-detection": true, "terragrunt-file": true,
+}
+
+// --org-config value discovering the defaults in the owner's .github repository
+const OrgConfigDiscover = "org"
+
+// Timeout of fetching organization defaults from a URL
+const orgConfigTimeout = 30 * time.Second
+
+var errConfigNotFound = errors.New("config file not found")
+
+// Parse a config file setting action inputs by name, as flat YAML or JSON
+// (lists are comma separated, as in the inputs)
+func parseConfigFile(name string, data []byte) (map[string]any, error) {
+	name, _, _ = strings.Cut(name, "?")
+	inputs := map[string]any{}
+	if strings.EqualFold(path.Ext(name), ".json") {
+		if err := json.Unmarshal(data, &inputs); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", name, err)
+		}
+		return inputs, nil
+	}
+	values, err := parseFlatYAML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", name, err)
+	}
+	for key, value := range values {
+		inputs[key] = value
+	}
+	return inputs, nil
+}
+
+// Set the flags that neither the command line nor the action inputs set from
+// the repository config file, then the ones still unset from the organization
+// defaults. Repository settings therefore override the organization's.
+func applyConfigFiles(ctx context.Context, client *github.Client, flags *pflag.FlagSet, config *Config, logger *slog.Logger) error {
+	ref := getPRBaseSHA()
+	data, err := fetchRepoConfig(ctx, client, config.Repository, config.ConfigFile, ref)
+	switch {
+	case err == nil:
+		if err := applyConfigFile(flags, config.ConfigFile, data, repoConfigInputs); err != nil {
+			return err
+		}
+		logger.Info("Loaded repository config", "file", config.ConfigFile, "ref", ref)
+	case !errors.Is(err, errConfigNotFound) || flags.Changed("config-file"):
+		// Only the default repository config file is optional
+		return fmt.Errorf("failed to read config file %s: %w", config.ConfigFile, err)
+	}
+
+	if config.OrgConfig == "" {
+		return nil
+	}
+	owner, _, _ := strings.Cut(config.Repository, "/")
+	name, data, err := fetchOrgConfig(ctx, client, config.OrgConfig, owner)
+	if errors.Is(err, errConfigNotFound) && config.OrgConfig == OrgConfigDiscover {
+		logger.Info("No organization config to discover", "source", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load org-config %s: %w", config.OrgConfig, err)
+	}
+	if err := applyConfigFile(flags, name, data, nil); err != nil {
+		return err
+	}
+	logger.Info("Loaded organization defaults", "source", name)
+	return nil
+}
+
+// Read the repository config file through the contents API at the base of the
+// pull request (the default branch outside pull requests), so a pull request
+// cannot change the configuration its own run uses
+func fetchRepoConfig(ctx context.Context, client *github.Client, repository, file, ref string) ([]byte, error) {
+	owner, repo, _ := strings.Cut(repository, "/")
+	if owner == "" || repo == "" {
+		return nil, errConfigNotFound
+	}
+	return fetchRepoFile(ctx, client, owner, repo, path.Clean(file), ref)
+}
+
+// Set the flags not set yet from the inputs of a config file, limited to the
+// allowed inputs when given
+func applyConfigFile(flags *pflag.FlagSet, name string, data []byte, allowed map[string]bool) error {
+	inputs, err := parseConfigFile(name, data)
+	if err != nil {
+		return err
+	}
+	if allowed != nil {
+		for input := range inputs {
+			if _, ok := actionOnlyInputs[input]; !ok && !allowed[input] {
+				return fmt.Errorf("invalid config file %s: %s can only be set by the workflow", name, input)
+			}
+		}
+	}
+	if err := applyInputs(flags, inputs); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", name, err)
+	}
+	return nil
+}
+
+// Create the GitHub client reading organization config repositories
+func newConfigClient(token string) *github.Client {
+	client := github.NewClient(nil)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		if u, err := url.Parse(strings.TrimSuffix(apiURL, "/") + "/"); err == nil && u.Host != "" {
+			client.BaseURL = u
+		}
+	}
+	return client
+}
+
+// Fetch the organization defaults from --org-config, returning the name of the
+// source and its content. Sources are org (the owner's .github repository),
+// github://owner/repo[/path][@ref] read through the API, an http(s) URL, or a
+// local file such as a checkout of the config repository.
+func fetchOrgConfig(ctx context.Context, client *github.Client, source, owner string) (string, []byte, error) {
+	if source == OrgConfigDiscover {
+		if owner == "" {
+			return source, nil, fmt.Errorf("no repository owner to discover the config of")
+		}
+		source = "github://" + owner + "/.github"
+	}
+	u, err := url.Parse(source)
+	switch {
+	case err == nil && u.Scheme == "github":
+		rest, ref, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "@")
+		repo, file, _ := strings.Cut(rest, "/")
+		if u.Host == "" || repo == "" {
+			return source, nil, fmt.Errorf("expected github://owner/repo[/path][@ref]")
+		}
+		if file == "" {
+			file = defaultConfigFile
+		}
+		name := u.Host + "/" + repo + "/" + file
+		data, err := fetchRepoFile(ctx, client, u.Host, repo, file, ref)
+		return name, data, err
+	case err == nil && (u.Scheme == "https" || u.Scheme == "http"):
+		data, err := fetchURL(ctx, source)
+		return source, data, err
+	}
+	data, err := os.ReadFile(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = errConfigNotFound
+	}
+	return source, data, err
+}
+
+// Read a file of a repository through the contents API
+func fetchRepoFile(ctx context.Context, client *github.Client, owner, repo, file, ref string) ([]byte, error) {
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	content, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, file, opts)
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return nil, errConfigNotFound
+	case err != nil:
+		return nil, err
+	case content == nil:
+		return nil, fmt.Errorf("%s is not a file", file)
+	}
+	text, err := content.GetContent()
+	return []byte(text), err
+}
+
+// Fetch a config file from a URL. The GitHub token is not sent to other hosts.
+func fetchURL(ctx context.Context, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, orgConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errConfigNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    map[string]any
+		wantErr bool
+	}{
+		{"config.yaml", "# limits\nmax-runs: 10\ncommand: \"run --all plan\"\n", map[string]any{"max-runs": "10", "command": "run --all plan"}, false},
+		{"config.json", `{"max-runs": 10, "parallel": false}`, map[string]any{"max-runs": float64(10), "parallel": false}, false},
+		{"https://example.com/config.json?token=x", `{"max-runs": 10}`, map[string]any{"max-runs": float64(10)}, false},
+		{"nested.yaml", "limits:\n  max-runs: 10\n", nil, true},
+		{"invalid.json", `{"max-runs":`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := parseConfigFile(tt.file, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("parseConfigFile()[%s] = %v, want %v", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+// Serve the repository config file from a fake contents API, recording the
+// refs it is read at
+func newTestConfigClient(t *testing.T, repoConfig string, refs *[]string) *github.Client {
+	content := base64.StdEncoding.EncodeToString([]byte(repoConfig))
+	return newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if repoConfig == "" || req.URL.Path != "/repos/acme/infra/contents/.github/terragrunt-runner.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		*refs = append(*refs, req.URL.Query().Get("ref"))
+		w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "` + content + `"}`))
+	}))
+}
+
+func TestApplyConfigFilesPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	orgConfig := filepath.Join(dir, "org.yaml")
+	os.WriteFile(orgConfig, []byte("detail-level: summary\nmax-runs: 5\nparallel: false\ncommand: validate\n"), 0644)
+	eventFile := filepath.Join(dir, "event.json")
+	os.WriteFile(eventFile, []byte(`{"pull_request": {"base": {"sha": "base123"}}}`), 0644)
+	t.Setenv("GITHUB_EVENT_PATH", eventFile)
+	// The working tree copy belongs to the pull request and must be ignored
+	os.MkdirAll(filepath.Join(dir, ".github"), 0755)
+	os.WriteFile(filepath.Join(dir, defaultConfigFile), []byte("command: destroy\n"), 0644)
+	t.Chdir(dir)
+
+	var refs []string
+	client := newTestConfigClient(t, "detail-level: full\n", &refs)
+	flags, config := newTestFlagSet()
+	if err := flags.Parse([]string{"--command", "apply"}); err != nil {
+		t.Fatal(err)
+	}
+	// The action passes defaults too, they must not mask the config files
+	if err := applyActionInputs(flags, `{"parallel": "true", "max-runs": "20"}`); err != nil {
+		t.Fatal(err)
+	}
+	config.Repository, config.ConfigFile, config.OrgConfig = "acme/infra", defaultConfigFile, orgConfig
+	logger := slog.New(slog.DiscardHandler)
+	if err := applyConfigFiles(context.Background(), client, flags, config, logger); err != nil {
+		t.Fatalf("applyConfigFiles() error = %v", err)
+	}
+
+	if strings.Join(refs, ",") != "base123" {
+		t.Errorf("repository config read at refs %v, want the pull request base", refs)
+	}
+	if config.Command != "apply" {
+		t.Errorf("command = %q, want the command line value", config.Command)
+	}
+	if config.DetailLevel != DetailFull {
+		t.Errorf("detail-level = %q, want the repository config value", config.DetailLevel)
+	}
+	if config.MaxRuns != 5 || config.ParallelExec {
+		t.Errorf("max-runs = %d, parallel = %v, want the organization defaults", config.MaxRuns, config.ParallelExec)
+	}
+}
+
+func TestApplyConfigFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+
+	tests := []struct {
+		name       string
+		repoConfig string
+		orgConfig  string
+		wantErr    string
+	}{
+		{"missing default repository config", "", "", ""},
+		{"unknown key", "detail-levl: full\n", "", "can only be set by the workflow"},
+		{"security key", "command: destroy\n", "", "command can only be set by the workflow"},
+		{"allowed key", "parallel: false\n", "", ""},
+		{"missing org config", "", filepath.Join(dir, "org.yaml"), "failed to load org-config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var refs []string
+			client := newTestConfigClient(t, tt.repoConfig, &refs)
+			flags, config := newTestFlagSet()
+			config.Repository, config.ConfigFile, config.OrgConfig = "acme/infra", defaultConfigFile, tt.orgConfig
+			err := applyConfigFiles(context.Background(), client, flags, config, logger)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("applyConfigFiles() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("applyConfigFiles() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchOrgConfig(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString([]byte("max-runs: 5\n"))
+	var refs []string
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/acme/.github/contents/.github/terragrunt-runner.yaml", "/repos/acme/platform/contents/runner/defaults.yaml":
+			refs = append(refs, req.URL.Query().Get("ref"))
+			w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "` + content + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		source   string
+		owner    string
+		wantName string
+		wantErr  error
+	}{
+		{"discovered", OrgConfigDiscover, "acme", "acme/.github/.github/terragrunt-runner.yaml", nil},
+		{"repository path and ref", "github://acme/platform/runner/defaults.yaml@v2", "other", "acme/platform/runner/defaults.yaml", nil},
+		{"not found", OrgConfigDiscover, "nobody", "nobody/.github/.github/terragrunt-runner.yaml", errConfigNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, data, err := fetchOrgConfig(ctx, client, tt.source, tt.owner)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fetchOrgConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			if name != tt.wantName {
+				t.Errorf("fetchOrgConfig() name = %q, want %q", name, tt.wantName)
+			}
+			if tt.wantErr == nil && string(data) != "max-runs: 5\n" {
+				t.Errorf("fetchOrgConfig() data = %q", data)
+			}
+		})
+	}
+	if strings.Join(refs, ",") != ",v2" {
+		t.Errorf("refs = %v, want the default branch then v2", refs)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "" {
+			t.Error("URL fetch sent credentials")
+		}
+		w.Write([]byte(`{"max-runs": 5}`))
+	}))
+	defer server.Close()
+	if _, data, err := fetchOrgConfig(ctx, client, server.URL+"/defaults.json", "acme"); err != nil || string(data) != `{"max-runs": 5}` {
+		t.Errorf("fetchOrgConfig() URL = %q, %v", data, err)
+	}
+}
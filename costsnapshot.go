@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/spf13/cobra"
+)
+
+const (
+	costPlanFile   = "tfplan.cost"                      // Plan file written by the cost snapshot plans
+	costIssueLabel = "terragrunt-cost"                  // Label of the monthly cost breakdown issues
+	costMarker     = "terragrunt-runner-cost:v1 month=" // Hidden marker of a cost breakdown issue
+)
+
+// Monthly cost breakdown of the units of a repository
+type CostSnapshot struct {
+	Repository string     `json:"repository"`
+	Month      string     `json:"month"` // YYYY-MM the snapshot belongs to
+	Time       time.Time  `json:"time"`
+	RunID      string     `json:"run_id"`
+	Currency   string     `json:"currency"`
+	Total      float64    `json:"total_monthly_cost"`
+	Units      []UnitCost `json:"units"` // Most expensive first, failed units last
+}
+
+// Monthly cost of a unit
+type UnitCost struct {
+	Folder  string  `json:"folder"`
+	Monthly float64 `json:"monthly_cost"`
+	Error   string  `json:"error,omitempty"` // Why the unit could not be costed
+}
+
+// Create the cost-snapshot subcommand
+func newCostSnapshotCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var root, outputFile, publish string
+	var issue bool
+	cmd := &cobra.Command{
+		Use:   "cost-snapshot",
+		Short: "Plan every unit with Infracost and report the monthly cost breakdown",
+		Long: `Discover every Terragrunt unit below the root directory, plan them, price the
+plans with Infracost and report the monthly cost breakdown, independently of pull
+requests. Meant for scheduled workflows: the breakdown updates the cost issue of the
+month, and is written as dashboard JSON to a file or a publish-results destination.
+Requires the infracost CLI, authenticated with INFRACOST_API_KEY.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return NewRunner(config, logger).costSnapshot(root, outputFile, publish, issue)
+		},
+	}
+	cmd.Flags().StringVar(&root, "root", ".", "Directory whose units are costed, relative to the repository root")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "Write the cost breakdown as dashboard JSON to this file")
+	cmd.Flags().StringVar(&publish, "publish", "", "Upload the dashboard JSON to s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix")
+	cmd.Flags().BoolVar(&issue, "issue", true, "Create or update the cost breakdown issue of the month")
+	cmd.Flags().StringVar(&config.FolderNamesFile, "folder-names-file", "", "JSON or YAML file mapping folder paths to display names shown in the breakdown")
+	return cmd
+}
+
+// Plan and price every unit, then report the breakdown
+func (r *Runner) costSnapshot(root, outputFile, publish string, issue bool) error {
+	if r.config.GithubToken != "" {
+		fmt.Printf("::add-mask::%s\n", r.config.GithubToken)
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to determine repo root: %w", err)
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(repoRoot, root)
+	}
+	units, err := discoverUnits(root, r.config.TerragruntFiles)
+	if err != nil {
+		return fmt.Errorf("failed to discover units in %s: %w", root, err)
+	}
+	var folders []string
+	for _, unit := range units {
+		if rel, err := filepath.Rel(repoRoot, unit); err == nil {
+			unit = rel
+		}
+		folders = append(folders, unit)
+	}
+	if len(folders) == 0 {
+		return fmt.Errorf("no Terragrunt units found in %s", root)
+	}
+	r.logger.Info("Costing units", "count", len(folders))
+	names, err := loadFolderNames(r.config.FolderNamesFile)
+	if err != nil {
+		return err
+	}
+	r.folderNames = names
+
+	r.config.Folders = folders
+	r.config.Command = "plan"
+	r.config.TerragruntArgs = strings.TrimSpace(r.config.TerragruntArgs + " -out=" + costPlanFile)
+	snapshot := CostSnapshot{
+		Repository: r.config.Repository,
+		Month:      time.Now().UTC().Format("2006-01"),
+		Time:       time.Now().UTC(),
+		RunID:      getRunID(),
+		Currency:   "USD",
+	}
+	for _, result := range r.executeTerragrunt() {
+		unit := UnitCost{Folder: result.Folder}
+		if !result.Success {
+			unit.Error = "plan failed"
+			if result.Error != nil {
+				unit.Error += ": " + result.Error.Error()
+			}
+		} else if cost, currency, err := r.unitCost(result.Folder); err != nil {
+			unit.Error = err.Error()
+		} else {
+			unit.Monthly = cost
+			snapshot.Total += cost
+			if currency != "" {
+				snapshot.Currency = currency
+			}
+		}
+		snapshot.Units = append(snapshot.Units, unit)
+	}
+	sortUnitCosts(snapshot.Units)
+
+	markdown := r.formatCostSnapshot(snapshot)
+	if err := writeStepSummary(markdown); err != nil {
+		r.logger.Warn("Failed to write the step summary", "error", err)
+	}
+	if err := writeActionOutput("total-monthly-cost", strconv.FormatFloat(snapshot.Total, 'f', 2, 64)); err != nil {
+		r.logger.Warn("Failed to write total monthly cost output", "error", err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, data, 0o644); err != nil {
+			return err
+		}
+	}
+	if publish != "" {
+		if err := r.publishCostSnapshot(publish, snapshot, data); err != nil {
+			return err
+		}
+	}
+	if issue {
+		parts := strings.Split(r.config.Repository, "/")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid repository: %s (expected owner/repo)", r.config.Repository)
+		}
+		url, err := r.upsertCostIssue(context.Background(), r.createGitHubClient(), parts[0], parts[1], snapshot.Month, markdown)
+		if err != nil {
+			return fmt.Errorf("failed to update the cost issue: %w", err)
+		}
+		r.logger.Info("Updated the cost breakdown issue", "url", url)
+	}
+
+	if failed := slices.IndexFunc(snapshot.Units, func(u UnitCost) bool { return u.Error != "" }); failed >= 0 {
+		return fmt.Errorf("%d units could not be costed", len(snapshot.Units)-failed)
+	}
+	return nil
+}
+
+// Find the units below a directory: directories holding a Terragrunt file,
+// other than the directory itself (whose file is the root configuration).
+// Hidden directories and Terragrunt caches are skipped.
+func discoverUnits(root string, terragruntFiles []string) ([]string, error) {
+	var units []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && slices.Contains(terragruntFiles, d.Name()) && filepath.Dir(p) != filepath.Clean(root) {
+			units = append(units, filepath.Dir(p))
+		}
+		return nil
+	})
+	slices.Sort(units)
+	return slices.Compact(units), err
+}
+
+// Price the saved plan of a unit with Infracost, returning its monthly cost
+// and currency
+func (r *Runner) unitCost(folder string) (float64, string, error) {
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		repoRoot, err := r.getRepoRoot()
+		if err != nil {
+			return 0, "", err
+		}
+		absFolder = filepath.Join(repoRoot, folder)
+	}
+
+	show := exec.Command("terragrunt", "show", "-json", costPlanFile)
+	show.Dir = absFolder
+	show.Env = r.subprocessEnv()
+	var plan, stderr bytes.Buffer
+	show.Stdout, show.Stderr = &plan, &stderr
+	if err := r.runCommand(show); err != nil {
+		return 0, "", fmt.Errorf("terragrunt show failed: %w: %s", err, strings.TrimSpace(stripAnsiCodes(stderr.String())))
+	}
+	planFile, err := os.CreateTemp("", "terragrunt-runner-cost-*.json")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(planFile.Name())
+	_, err = planFile.Write(plan.Bytes())
+	planFile.Close()
+	if err != nil {
+		return 0, "", err
+	}
+
+	infracost := exec.Command("infracost", "breakdown", "--path", planFile.Name(), "--format", "json", "--no-color")
+	infracost.Env = r.subprocessEnv(filterEnv(os.Environ(), []string{"INFRACOST_*"}, r.config.EnvDenylist)...)
+	var breakdown bytes.Buffer
+	stderr.Reset()
+	infracost.Stdout, infracost.Stderr = &breakdown, &stderr
+	if err := r.runCommand(infracost); err != nil {
+		return 0, "", fmt.Errorf("infracost failed: %w: %s", err, strings.TrimSpace(stripAnsiCodes(stderr.String())))
+	}
+	return parseInfracostTotal(breakdown.Bytes())
+}
+
+// Read the total monthly cost and currency of an infracost breakdown JSON
+func parseInfracostTotal(data []byte) (float64, string, error) {
+	var breakdown struct {
+		Currency         string  `json:"currency"`
+		TotalMonthlyCost *string `json:"totalMonthlyCost"`
+	}
+	if err := json.Unmarshal(data, &breakdown); err != nil {
+		return 0, "", fmt.Errorf("invalid infracost output: %w", err)
+	}
+	if breakdown.TotalMonthlyCost == nil || *breakdown.TotalMonthlyCost == "" {
+		return 0, breakdown.Currency, nil
+	}
+	total, err := strconv.ParseFloat(*breakdown.TotalMonthlyCost, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid infracost total %q: %w", *breakdown.TotalMonthlyCost, err)
+	}
+	return total, breakdown.Currency, nil
+}
+
+// Sort unit costs most expensive first, failed units last, by folder on ties
+func sortUnitCosts(units []UnitCost) {
+	slices.SortStableFunc(units, func(a, b UnitCost) int {
+		switch {
+		case (a.Error != "") != (b.Error != ""):
+			if a.Error != "" {
+				return 1
+			}
+			return -1
+		case a.Monthly != b.Monthly:
+			if a.Monthly > b.Monthly {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Folder, b.Folder)
+	})
+}
+
+// Format the cost breakdown issue body
+func (r *Runner) formatCostSnapshot(snapshot CostSnapshot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- %s%s -->\n", costMarker, snapshot.Month)
+	fmt.Fprintf(&b, "## 💰 Infrastructure Cost Breakdown: %s\n\n", snapshot.Month)
+	fmt.Fprintf(&b, "**Total monthly cost:** %.2f %s\n", snapshot.Total, snapshot.Currency)
+	updated := snapshot.Time.Format("2006-01-02 15:04 UTC")
+	if runURL := getRunURL(); runURL != "" {
+		updated = fmt.Sprintf("[%s](%s)", updated, runURL)
+	}
+	fmt.Fprintf(&b, "**Updated:** %s\n\n", updated)
+	b.WriteString("| Folder | Monthly cost |\n|--------|--------------|\n")
+	for _, unit := range snapshot.Units {
+		cost := fmt.Sprintf("%.2f", unit.Monthly)
+		if unit.Error != "" {
+			cost = "❌ " + strings.ReplaceAll(strings.ReplaceAll(unit.Error, "\n", " "), "|", "\\|")
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", strings.ReplaceAll(r.displayName(unit.Folder), "|", "\\|"), cost)
+	}
+	return truncateComment(b.String())
+}
+
+// Create the cost breakdown issue of the month, or update it when a run of
+// the same month already created it, returning its URL
+func (r *Runner) upsertCostIssue(ctx context.Context, client *github.Client, owner, repo, month, body string) (string, error) {
+	marker := "<!-- " + costMarker + month + " -->"
+	opts := &github.IssueListByRepoOptions{State: "all", Labels: []string{costIssueLabel}, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, issue := range issues {
+			if issue.IsPullRequest() || !strings.Contains(issue.GetBody(), marker) {
+				continue
+			}
+			updated, _, err := client.Issues.Edit(ctx, owner, repo, issue.GetNumber(), &github.IssueRequest{Body: &body})
+			if err != nil {
+				return "", err
+			}
+			return updated.GetHTMLURL(), nil
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.ListOptions.Page = resp.NextPage
+	}
+	title := "Infrastructure cost breakdown " + month
+	created, _, err := client.Issues.Create(ctx, owner, repo, &github.IssueRequest{Title: &title, Body: &body, Labels: &[]string{costIssueLabel}})
+	if err != nil {
+		return "", err
+	}
+	return created.GetHTMLURL(), nil
+}
+
+// Upload the dashboard JSON to a publish-results destination, as the latest
+// snapshot and as the snapshot of its month
+func (r *Runner) publishCostSnapshot(dest string, snapshot CostSnapshot, data []byte) error {
+	target, err := parsePublishTarget(dest)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp("", "terragrunt-runner-cost-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	base := path.Join(strings.ReplaceAll(snapshot.Repository, "/", "_"), "cost")
+	for _, key := range []string{path.Join(base, snapshot.Month+".json"), path.Join(base, "latest.json")} {
+		cmd := target.uploadCommand(file.Name(), key)
+		var out bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := r.runCommand(cmd); err != nil {
+			return fmt.Errorf("failed to upload %s: %w: %s", key, err, strings.TrimSpace(out.String()))
+		}
+	}
+	r.logger.Info("Published cost snapshot", "destination", dest, "month", snapshot.Month)
+	return nil
+}
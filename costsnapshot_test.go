@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscoverUnits(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"terragrunt.hcl",
+		"prod/vpc/terragrunt.hcl",
+		"prod/app/terragrunt.hcl",
+		"prod/app/.terragrunt-cache/abc/terragrunt.hcl",
+		"dev/db/terragrunt.hcl.json",
+		"modules/vpc/main.tf",
+	} {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := discoverUnits(root, []string{"terragrunt.hcl", "terragrunt.hcl.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(root, "dev/db"), filepath.Join(root, "prod/app"), filepath.Join(root, "prod/vpc")}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("discoverUnits() = %v, want %v", got, expected)
+	}
+}
+
+func TestParseInfracostTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		total    float64
+		currency string
+		wantErr  bool
+	}{
+		{name: "total", data: `{"currency": "EUR", "totalMonthlyCost": "123.45", "projects": []}`, total: 123.45, currency: "EUR"},
+		{name: "free resources", data: `{"currency": "USD", "totalMonthlyCost": null}`, currency: "USD"},
+		{name: "invalid total", data: `{"totalMonthlyCost": "lots"}`, wantErr: true},
+		{name: "invalid JSON", data: `Error: no API key`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, currency, err := parseInfracostTotal([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseInfracostTotal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (total != tt.total || currency != tt.currency) {
+				t.Errorf("parseInfracostTotal() = %v %q, want %v %q", total, currency, tt.total, tt.currency)
+			}
+		})
+	}
+}
+
+func TestFormatCostSnapshot(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "")
+	r := newTestRunner(&Config{})
+	r.folderNames = map[string]string{"live/prod/vpc": "VPC (prod)"}
+	units := []UnitCost{
+		{Folder: "live/dev/app", Monthly: 12.5},
+		{Folder: "live/prod/db", Error: "plan failed: exit status 1"},
+		{Folder: "live/prod/vpc", Monthly: 80},
+		{Folder: "live/prod/app", Monthly: 12.5},
+	}
+	sortUnitCosts(units)
+	snapshot := CostSnapshot{Month: "2026-10", Time: time.Date(2026, 10, 5, 6, 0, 0, 0, time.UTC), Currency: "USD", Total: 105, Units: units}
+
+	expected := `<!-- terragrunt-runner-cost:v1 month=2026-10 -->
+## 💰 Infrastructure Cost Breakdown: 2026-10
+
+**Total monthly cost:** 105.00 USD
+**Updated:** 2026-10-05 06:00 UTC
+
+| Folder | Monthly cost |
+|--------|--------------|
+| VPC (prod) | 80.00 |
+| live/dev/app | 12.50 |
+| live/prod/app | 12.50 |
+| live/prod/db | ❌ plan failed: exit status 1 |
+`
+	if got := r.formatCostSnapshot(snapshot); got != expected {
+		t.Errorf("formatCostSnapshot() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestUpsertCostIssue(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		wantMethod string
+		wantPath   string
+	}{
+		{name: "creates the issue of a new month", existing: `[{"number": 3, "body": "<!-- terragrunt-runner-cost:v1 month=2026-09 -->"}]`, wantMethod: http.MethodPost, wantPath: "/repos/owner/repo/issues"},
+		{name: "updates the issue of the month", existing: `[{"number": 3, "body": "<!-- terragrunt-runner-cost:v1 month=2026-10 -->"}]`, wantMethod: http.MethodPatch, wantPath: "/repos/owner/repo/issues/3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path string
+			var request map[string]any
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodGet {
+					if got := req.URL.Query().Get("labels"); got != costIssueLabel {
+						t.Errorf("issues listed with labels %q, want %q", got, costIssueLabel)
+					}
+					w.Write([]byte(tt.existing))
+					return
+				}
+				method, path = req.Method, req.URL.Path
+				body, _ := io.ReadAll(req.Body)
+				json.Unmarshal(body, &request)
+				w.Write([]byte(`{"number": 3, "html_url": "https://github.com/owner/repo/issues/3"}`))
+			}))
+
+			r := newTestRunner(&Config{})
+			url, err := r.upsertCostIssue(t.Context(), client, "owner", "repo", "2026-10", "breakdown")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.wantMethod || path != tt.wantPath {
+				t.Errorf("request = %s %s, want %s %s", method, path, tt.wantMethod, tt.wantPath)
+			}
+			if request["body"] != "breakdown" || url != "https://github.com/owner/repo/issues/3" {
+				t.Errorf("request = %v, url = %q", request, url)
+			}
+			if tt.wantMethod == http.MethodPost && !strings.Contains(request["title"].(string), "2026-10") {
+				t.Errorf("issue title = %v, want the month", request["title"])
+			}
+		})
+	}
+}
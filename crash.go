@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Lines of a provider plugin crash, capturing the plugin name
+var (
+	reCrashHeader  = regexp.MustCompile(`Stack trace from the (\S+) plugin:`)
+	reCrashError   = regexp.MustCompile(`Error: The (\S+) plugin crashed!`)
+	reNoResponse   = regexp.MustCompile(`Error: Plugin did not respond`)
+	reGoroutine    = regexp.MustCompile(`^goroutine \d+ \[[^\]]+\]:$`)
+	reStackFrame   = regexp.MustCompile(`^(?:\t|\s{2,}\S+\.go:\d+|created by |\S+\(.*\)$|\[signal |\.\.\.)`)
+	reCrashDumpKey = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// Detect a provider plugin crash or Go panic in the output and name the
+// plugin that crashed ("unknown provider" when the output does not say)
+func detectProviderCrash(output string) (string, bool) {
+	for _, re := range []*regexp.Regexp{reCrashError, reCrashHeader} {
+		if m := re.FindStringSubmatch(output); m != nil {
+			return m[1], true
+		}
+	}
+	if reNoResponse.MatchString(output) || (strings.Contains(output, "panic: ") && strings.Contains(output, "goroutine ")) {
+		return "unknown provider", true
+	}
+	return "", false
+}
+
+// Cut the stack dumps out of the output, keeping the panic message in place.
+// Returns the cleaned output and the dump that was removed.
+func stripCrashDump(output string) (string, string) {
+	var kept, dump []string
+	inDump, inStack := false, false
+	for line := range strings.SplitSeq(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case reCrashHeader.MatchString(line):
+			inDump = true
+			dump = append(dump, line)
+			kept = append(kept, line)
+		case inDump && (strings.HasPrefix(trimmed, "Error:") || strings.HasPrefix(strings.TrimLeft(trimmed, "│ "), "Error:")):
+			inDump, inStack = false, false
+			kept = append(kept, line)
+		case inDump:
+			dump = append(dump, line)
+			if strings.HasPrefix(trimmed, "panic: ") {
+				kept = append(kept, line)
+			}
+		case reGoroutine.MatchString(trimmed):
+			inStack = true
+			dump = append(dump, line)
+		case inStack && (trimmed == "" || reStackFrame.MatchString(line) || reGoroutine.MatchString(trimmed)):
+			dump = append(dump, line)
+		default:
+			inStack = false
+			kept = append(kept, line)
+		}
+	}
+	if len(dump) == 0 {
+		return output, ""
+	}
+	return strings.Join(kept, "\n"), strings.Join(dump, "\n")
+}
+
+// Save a folder's crash output for the crash dump artifact, returning the path
+// (empty when no dump directory is configured)
+func (r *Runner) saveCrashDump(folder, output string) (string, error) {
+	if r.config.CrashDumpDir == "" {
+		return "", nil
+	}
+	if err := os.MkdirAll(r.config.CrashDumpDir, 0o755); err != nil {
+		return "", err
+	}
+	name := strings.Trim(reCrashDumpKey.ReplaceAllString(filepath.ToSlash(folder), "_"), "_")
+	if name == "" {
+		name = "root"
+	}
+	path := filepath.Join(r.config.CrashDumpDir, name+".log")
+	return path, os.WriteFile(path, []byte(output), 0o644)
+}
+
+// Classify a result as a provider crash when a plugin crashed: the stack dump
+// is saved for the crash dump artifact and cut from the comment output, and
+// the partial plan is not counted as changes
+func (r *Runner) classifyProviderCrash(result ExecutionResult) ExecutionResult {
+	full := result.FullOutput
+	if full == "" {
+		full = result.Output
+	}
+	provider, ok := detectProviderCrash(full)
+	if !ok {
+		return result
+	}
+	r.logger.Warn("Provider plugin crashed", "folder", result.Folder, "provider", provider)
+	if _, err := r.saveCrashDump(result.Folder, full); err != nil {
+		r.logger.Warn("Failed to save crash dump", "folder", result.Folder, "error", err)
+	}
+
+	result.ProviderCrash = provider
+	result.Success = false
+	if result.Error == nil {
+		result.Error = fmt.Errorf("provider %s crashed", provider)
+	}
+	result.ResourceChanges = nil
+	result.PlannedOutputs = nil
+	result.Output, _ = stripCrashDump(result.Output)
+	result.FullOutput, _ = stripCrashDump(result.FullOutput)
+	return result
+}
+
+// Format the note of a comment explaining a provider crash and where to find
+// the stack trace
+func (r *Runner) formatProviderCrashNote(result ExecutionResult) string {
+	if result.ProviderCrash == "" {
+		return ""
+	}
+	where := "the job log"
+	if r.config.CrashDumpDir != "" {
+		where = "the `terragrunt-crash-dumps` artifact"
+		if runURL := getRunURL(); runURL != "" {
+			where = fmt.Sprintf("the [`terragrunt-crash-dumps` artifact](%s#artifacts)", runURL)
+		}
+	}
+	return fmt.Sprintf("💥 **Provider crash:** `%s` crashed; this is a provider bug, not a configuration error, and no changes were counted. The stack trace is in %s.\n", result.ProviderCrash, where)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const providerCrashOutput = `aws_instance.web: Refreshing state... [id=i-0123]
+
+Stack trace from the terraform-provider-aws_v5.31.0_x5 plugin:
+
+panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x5f3c2a1]
+
+goroutine 1234 [running]:
+github.com/hashicorp/terraform-provider-aws/internal/service/ec2.resourceInstanceRead(0xc000a1b2c0)
+	github.com/hashicorp/terraform-provider-aws/internal/service/ec2/ec2_instance.go:1187 +0x1a1
+created by google.golang.org/grpc.(*Server).serveStreams.func1.1
+	google.golang.org/grpc@v1.59.0/server.go:1019 +0x169
+
+Error: The terraform-provider-aws_v5.31.0_x5 plugin crashed!
+
+This is always indicative of a bug within the plugin. It would be immensely
+helpful if you could report the crash with the plugin's maintainers so that it
+can be fixed. The output above should help diagnose the issue.`
+
+func TestDetectProviderCrash(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		provider string
+		crashed  bool
+	}{
+		{"plugin crash", providerCrashOutput, "terraform-provider-aws_v5.31.0_x5", true},
+		{"plugin did not respond", "Error: Plugin did not respond\n\nThe plugin encountered an error", "unknown provider", true},
+		{"bare panic", "panic: boom\n\ngoroutine 1 [running]:\nmain.main()", "unknown provider", true},
+		{"plan", "Plan: 1 to add, 0 to change, 0 to destroy.", "", false},
+		{"regular error", "Error: Invalid reference", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, crashed := detectProviderCrash(tt.output)
+			if provider != tt.provider || crashed != tt.crashed {
+				t.Errorf("detectProviderCrash() = %q, %v, want %q, %v", provider, crashed, tt.provider, tt.crashed)
+			}
+		})
+	}
+}
+
+func TestStripCrashDump(t *testing.T) {
+	cleaned, dump := stripCrashDump(providerCrashOutput)
+	for _, want := range []string{"Refreshing state", "Stack trace from the terraform-provider-aws_v5.31.0_x5 plugin:", "panic: runtime error", "Error: The terraform-provider-aws_v5.31.0_x5 plugin crashed!", "This is always indicative"} {
+		if !strings.Contains(cleaned, want) {
+			t.Errorf("cleaned output is missing %q:\n%s", want, cleaned)
+		}
+	}
+	for _, unwanted := range []string{"goroutine 1234", "ec2_instance.go:1187", "created by", "[signal SIGSEGV"} {
+		if strings.Contains(cleaned, unwanted) {
+			t.Errorf("cleaned output still contains %q:\n%s", unwanted, cleaned)
+		}
+		if !strings.Contains(dump, unwanted) {
+			t.Errorf("dump is missing %q", unwanted)
+		}
+	}
+
+	plain := "Plan: 1 to add, 0 to change, 0 to destroy."
+	if cleaned, dump := stripCrashDump(plain); cleaned != plain || dump != "" {
+		t.Errorf("stripCrashDump(plan) = %q, %q, want the plan unchanged", cleaned, dump)
+	}
+}
+
+func TestClassifyProviderCrash(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRunner(&Config{CrashDumpDir: dir})
+
+	result := r.classifyProviderCrash(ExecutionResult{
+		Folder:          "live/prod/app",
+		Output:          providerCrashOutput,
+		FullOutput:      providerCrashOutput,
+		Error:           errors.New("exit status 1"),
+		ResourceChanges: &ResourceChanges{ToAdd: 2},
+	})
+	if result.ProviderCrash != "terraform-provider-aws_v5.31.0_x5" || result.Success {
+		t.Errorf("result = crash %q, success %v, want an aws provider crash", result.ProviderCrash, result.Success)
+	}
+	if result.ResourceChanges != nil {
+		t.Errorf("crashed plan counted changes: %+v", result.ResourceChanges)
+	}
+	if strings.Contains(result.Output, "goroutine") || strings.Contains(result.FullOutput, "goroutine") {
+		t.Errorf("stack dump kept in the comment output")
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, "live_prod_app.log"))
+	if err != nil || !strings.Contains(string(saved), "goroutine 1234") {
+		t.Errorf("crash dump not saved: %v", err)
+	}
+	if note := r.formatProviderCrashNote(result); !strings.Contains(note, "terragrunt-crash-dumps") {
+		t.Errorf("formatProviderCrashNote() = %q, want a link to the artifact", note)
+	}
+
+	plan := ExecutionResult{Folder: "live/app", Output: "Plan: 1 to add", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}}
+	if got := r.classifyProviderCrash(plan); got.ProviderCrash != "" || !got.Success || got.ResourceChanges == nil {
+		t.Errorf("classifyProviderCrash() changed a regular plan: %+v", got)
+	}
+}
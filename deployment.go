@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Interval between two checks of a deployment awaiting approval (var for tests)
+var deploymentPollInterval = 15 * time.Second
+
+// Get the URL of the current workflow run (empty outside GitHub Actions)
+func getRunURL() string {
+	server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+}
+
+// Get the commit deployed by the apply: the PR head, or the workflow commit
+func deploymentRef() string {
+	if sha := getPRHeadSHA(); sha != "" {
+		return sha
+	}
+	return os.Getenv("GITHUB_SHA")
+}
+
+// Classify the latest deployment status: approved once GitHub lets the
+// deployment start, rejected when a reviewer or a rule refused it, and still
+// pending otherwise
+func deploymentApproval(state string) (approved, rejected bool) {
+	switch state {
+	case "", "waiting", "pending", "queued":
+		return false, false
+	case "in_progress", "success":
+		return true, false
+	default: // failure, error, inactive
+		return false, true
+	}
+}
+
+// Find the deployment GitHub created for a job of the workflow run in the
+// environment, returning its ID and latest state (0 if there is none). Actions
+// deployments link their statuses to the run.
+func (r *Runner) findRunDeployment(ctx context.Context, client *github.Client, owner, repo, environment, runID string) (int64, string, error) {
+	opts := &github.DeploymentsListOptions{Environment: environment, SHA: os.Getenv("GITHUB_SHA"), ListOptions: github.ListOptions{PerPage: 20}}
+	deployments, _, err := client.Repositories.ListDeployments(ctx, owner, repo, opts)
+	if err != nil {
+		return 0, "", err
+	}
+	runPath := "/actions/runs/" + runID + "/"
+	for _, deployment := range deployments {
+		statuses, _, err := client.Repositories.ListDeploymentStatuses(ctx, owner, repo, deployment.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return 0, "", err
+		}
+		if len(statuses) == 0 {
+			continue
+		}
+		status := statuses[0]
+		if strings.Contains(status.GetLogURL()+"/", runPath) || strings.Contains(status.GetTargetURL()+"/", runPath) {
+			return deployment.GetID(), status.GetState(), nil
+		}
+	}
+	return 0, "", nil
+}
+
+// Wait until the deployment of this workflow run to the configured environment
+// is approved. Deployments created through the API never get reviews, so the
+// job must declare the environment (environment: production): GitHub then
+// creates the deployment and holds the job until the protection rules (required
+// reviewers, wait timers) pass. Returns the deployment ID, to report the apply
+// outcome on.
+func (r *Runner) awaitDeploymentApproval(ctx context.Context, client *github.Client) (int64, error) {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	environment := r.config.DeploymentEnvironment
+
+	runID := os.Getenv("GITHUB_RUN_ID")
+	if runID == "" {
+		return 0, fmt.Errorf("deployment-environment %s requires a GitHub Actions job running in the environment", environment)
+	}
+	deadline := time.Now().Add(time.Duration(r.config.DeploymentTimeout) * time.Minute)
+	for {
+		id, state, err := r.findRunDeployment(ctx, client, owner, repo, environment, runID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the deployment to %s: %w", environment, err)
+		}
+		if id == 0 {
+			return 0, fmt.Errorf("no deployment of this workflow run to %s: set `environment: %s` on the job so that GitHub enforces its protection rules", environment, environment)
+		}
+		approved, rejected := deploymentApproval(state)
+		if approved {
+			r.logger.Info("Deployment approved", "environment", environment, "deployment", id)
+			return id, r.setDeploymentStatus(ctx, client, id, "in_progress", "Applying")
+		}
+		if rejected {
+			return id, fmt.Errorf("deployment to %s was not approved (status %s)", environment, state)
+		}
+		if time.Now().After(deadline) {
+			return id, fmt.Errorf("deployment to %s not approved within %d minutes", environment, r.config.DeploymentTimeout)
+		}
+		r.logger.Info("Waiting for deployment approval", "environment", environment, "deployment", id, "status", state)
+		select {
+		case <-ctx.Done():
+			return id, ctx.Err()
+		case <-time.After(deploymentPollInterval):
+		}
+	}
+}
+
+// Report a deployment status, linking to the workflow run
+func (r *Runner) setDeploymentStatus(ctx context.Context, client *github.Client, id int64, state, description string) error {
+	parts := strings.Split(r.config.Repository, "/")
+	request := &github.DeploymentStatusRequest{State: github.Ptr(state), Description: github.Ptr(description)}
+	if runURL := getRunURL(); runURL != "" {
+		request.LogURL = github.Ptr(runURL)
+	}
+	if _, _, err := client.Repositories.CreateDeploymentStatus(ctx, parts[0], parts[1], id, request); err != nil {
+		return fmt.Errorf("failed to set deployment status %s: %w", state, err)
+	}
+	return nil
+}
+
+// Report the apply outcome on the deployment; failures are logged only, the
+// apply already happened
+func (r *Runner) finishDeployment(ctx context.Context, client *github.Client, id int64, results []ExecutionResult) {
+	state, description := "success", fmt.Sprintf("Applied %d folders", len(results))
+	for _, result := range results {
+		if !result.Success {
+			state, description = "failure", "Apply failed in "+result.Folder
+			break
+		}
+	}
+	if err := r.setDeploymentStatus(ctx, client, id, state, description); err != nil {
+		r.logger.Warn("Failed to report the apply on the deployment", "deployment", id, "error", err)
+	}
+}
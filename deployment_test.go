@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeploymentApproval(t *testing.T) {
+	tests := []struct {
+		state              string
+		approved, rejected bool
+	}{
+		{"", false, false},
+		{"waiting", false, false},
+		{"queued", false, false},
+		{"in_progress", true, false},
+		{"success", true, false},
+		{"failure", false, true},
+		{"error", false, true},
+		{"inactive", false, true},
+	}
+	for _, tt := range tests {
+		approved, rejected := deploymentApproval(tt.state)
+		if approved != tt.approved || rejected != tt.rejected {
+			t.Errorf("deploymentApproval(%q) = %v, %v, want %v, %v", tt.state, approved, rejected, tt.approved, tt.rejected)
+		}
+	}
+}
+
+// Fake deployments API: the deployments of the environment, with the run
+// their statuses link to, the states returned by successive status checks of
+// deployment 42, and the statuses posted
+type fakeDeployments struct {
+	runs   map[int64]string
+	states []string
+	posted []string
+	query  string
+}
+
+func (f *fakeDeployments) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/repos/owner/repo/deployments" && req.Method == http.MethodGet:
+			f.query = req.URL.RawQuery
+			var deployments []string
+			for _, id := range slices.Sorted(maps.Keys(f.runs)) {
+				deployments = append(deployments, fmt.Sprintf(`{"id": %d}`, id))
+			}
+			w.Write([]byte("[" + strings.Join(deployments, ",") + "]"))
+		case strings.HasSuffix(req.URL.Path, "/statuses") && req.Method == http.MethodGet:
+			var id int64
+			fmt.Sscanf(req.URL.Path, "/repos/owner/repo/deployments/%d/statuses", &id)
+			state := "success"
+			if id == 42 {
+				state = f.states[0]
+				if len(f.states) > 1 {
+					f.states = f.states[1:]
+				}
+			}
+			logURL := "https://github.com/owner/repo/actions/runs/" + f.runs[id] + "/job/1"
+			w.Write([]byte(`[{"state": "` + state + `", "log_url": "` + logURL + `"}]`))
+		case req.URL.Path == "/repos/owner/repo/deployments/42/statuses" && req.Method == http.MethodPost:
+			var status map[string]any
+			json.NewDecoder(req.Body).Decode(&status)
+			f.posted = append(f.posted, status["state"].(string))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestAwaitDeploymentApproval(t *testing.T) {
+	deploymentPollInterval = 0
+	t.Setenv("GITHUB_SHA", "abc123")
+	t.Setenv("GITHUB_RUN_ID", "500")
+
+	tests := []struct {
+		name      string
+		runs      map[int64]string
+		states    []string
+		wantErr   string
+		wantPosts []string
+	}{
+		{name: "job approved before it started", runs: map[int64]string{41: "400", 42: "500"}, states: []string{"in_progress"}, wantPosts: []string{"in_progress"}},
+		{name: "approved after waiting", runs: map[int64]string{42: "500"}, states: []string{"waiting", "waiting", "in_progress"}, wantPosts: []string{"in_progress"}},
+		{name: "rejected by a reviewer", runs: map[int64]string{42: "500"}, states: []string{"waiting", "failure"}, wantErr: "was not approved (status failure)"},
+		{name: "job without the environment", runs: map[int64]string{41: "400", 43: "5000"}, wantErr: "set `environment: production` on the job"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeDeployments{runs: tt.runs, states: tt.states}
+			client := newTestGitHubClient(t, fake.handler(t))
+			r := newTestRunner(&Config{
+				Repository:            "owner/repo",
+				PullRequest:           7,
+				Folders:               []string{"live/app"},
+				DeploymentEnvironment: "production",
+				DeploymentTimeout:     1,
+			})
+
+			id, err := r.awaitDeploymentApproval(context.Background(), client)
+			if !strings.Contains(fake.query, "environment=production") || !strings.Contains(fake.query, "sha=abc123") {
+				t.Errorf("deployments query = %q, want the environment and commit", fake.query)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("awaitDeploymentApproval() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("awaitDeploymentApproval() error = %v", err)
+			}
+			if id != 42 {
+				t.Errorf("deployment id = %d, want 42", id)
+			}
+			if strings.Join(fake.posted, ",") != strings.Join(tt.wantPosts, ",") {
+				t.Errorf("posted statuses = %v, want %v", fake.posted, tt.wantPosts)
+			}
+		})
+	}
+}
+
+func TestAwaitDeploymentApprovalCanceled(t *testing.T) {
+	deploymentPollInterval = time.Hour
+	t.Setenv("GITHUB_RUN_ID", "500")
+	fake := &fakeDeployments{runs: map[int64]string{42: "500"}, states: []string{"waiting"}}
+	client := newTestGitHubClient(t, fake.handler(t))
+	r := newTestRunner(&Config{Repository: "owner/repo", DeploymentEnvironment: "production", DeploymentTimeout: 60})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.awaitDeploymentApproval(ctx, client); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("awaitDeploymentApproval() error = %v, want the context error", err)
+	}
+}
+
+func TestFinishDeployment(t *testing.T) {
+	fake := &fakeDeployments{}
+	client := newTestGitHubClient(t, fake.handler(t))
+	r := newTestRunner(&Config{Repository: "owner/repo"})
+
+	r.finishDeployment(context.Background(), client, 42, []ExecutionResult{{Folder: "live/a", Success: true}, {Folder: "live/b", Success: false}})
+	r.finishDeployment(context.Background(), client, 42, []ExecutionResult{{Folder: "live/a", Success: true}})
+	if got := strings.Join(fake.posted, ","); got != "failure,success" {
+		t.Errorf("posted statuses = %s, want failure,success", got)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	reDependencyBlock   = regexp.MustCompile(`(?m)^\s*dependency\s+"[^"]*"\s*\{`)
+	reDependenciesBlock = regexp.MustCompile(`(?m)^\s*dependencies\s*\{`)
+	reConfigPath        = regexp.MustCompile(`config_path\s*=\s*"([^"]+)"`)
+	reDependencyPaths   = regexp.MustCompile(`paths\s*=\s*\[([^\]]*)\]`)
+	reQuotedString      = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// Get the folders a unit depends on through its dependency and dependencies
+// blocks, relative to the repository like the folder itself, from the unit
+// index when there is one
+func (r *Runner) unitDependencies(folder string) []string {
+	if !filepath.IsAbs(folder) {
+		if unit, indexed, ok := r.indexedUnit(folder); ok {
+			if !indexed {
+				return nil
+			}
+			return slices.Clone(unit.Dependencies)
+		}
+	}
+	return r.parseUnitDependencies(folder)
+}
+
+// Parse the dependencies of a unit from its unit file. Only literal paths are
+// understood.
+func (r *Runner) parseUnitDependencies(folder string) []string {
+	name := r.statUnitFile(folder)
+	if name == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(folder, name))
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+
+	var paths []string
+	for _, loc := range reDependencyBlock.FindAllStringIndex(content, -1) {
+		if m := reConfigPath.FindStringSubmatch(blockBody(content, loc[1])); m != nil {
+			paths = append(paths, m[1])
+		}
+	}
+	for _, loc := range reDependenciesBlock.FindAllStringIndex(content, -1) {
+		if m := reDependencyPaths.FindStringSubmatch(blockBody(content, loc[1])); m != nil {
+			for _, p := range reQuotedString.FindAllStringSubmatch(m[1], -1) {
+				paths = append(paths, p[1])
+			}
+		}
+	}
+
+	var deps []string
+	for _, p := range paths {
+		if strings.Contains(p, "${") {
+			continue // Interpolated, e.g. find_in_parent_folders()
+		}
+		dep := filepath.Clean(filepath.Join(folder, p))
+		if !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// Split the folders into waves where each folder only depends on folders of
+// earlier waves; dependencies on folders outside the run are ignored. A
+// dependency cycle ends up in a last wave, where Terragrunt reports it.
+func dependencyWaves(folders []string, dependencies map[string][]string) [][]string {
+	pending := slices.Clone(folders)
+	done := map[string]bool{}
+	var waves [][]string
+	for len(pending) > 0 {
+		var wave, rest []string
+		for _, folder := range pending {
+			ready := true
+			for _, dep := range dependencies[filepath.Clean(folder)] {
+				if !done[dep] && slices.ContainsFunc(pending, func(f string) bool { return filepath.Clean(f) == dep }) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, folder)
+			} else {
+				rest = append(rest, folder)
+			}
+		}
+		if len(wave) == 0 {
+			return append(waves, rest)
+		}
+		for _, folder := range wave {
+			done[filepath.Clean(folder)] = true
+		}
+		waves = append(waves, wave)
+		pending = rest
+	}
+	return waves
+}
+
+// Get the apply waves of the folders from their unit dependencies, reversed for
+// destroy so consumers go before their producers
+func (r *Runner) applyWaves(folders []string) ([][]string, map[string][]string) {
+	dependencies := map[string][]string{}
+	for _, folder := range folders {
+		dependencies[filepath.Clean(folder)] = r.unitDependencies(folder)
+	}
+	waves := dependencyWaves(folders, dependencies)
+	if slices.Contains(strings.Fields(r.config.Command), "destroy") {
+		slices.Reverse(waves)
+	}
+	return waves, dependencies
+}
+
+// Apply the folders wave by wave with the usual parallelism inside a wave. A
+// folder whose dependency (or dependent, for destroy) failed is not applied.
+func (r *Runner) executeDependencyWaves(waves [][]string, dependencies map[string][]string) []ExecutionResult {
+	allFolders := r.config.Folders
+	defer func() { r.config.Folders = allFolders }()
+	destroy := slices.Contains(strings.Fields(r.config.Command), "destroy")
+
+	failed := map[string]bool{}
+	var results []ExecutionResult
+	for i, wave := range waves {
+		var runnable []string
+		for _, folder := range wave {
+			if blocker := blockingFolder(folder, dependencies, failed, destroy); blocker != "" {
+				failed[filepath.Clean(folder)] = true
+				results = append(results, ExecutionResult{Folder: folder, Success: false, Error: fmt.Errorf("not applied: %s did not complete", blocker)})
+				continue
+			}
+			runnable = append(runnable, folder)
+		}
+		if len(runnable) == 0 {
+			continue
+		}
+
+		r.logger.Info("Applying dependency wave", "wave", i+1, "waves", len(waves), "folders", runnable)
+		r.config.Folders = runnable
+		waveResults := r.executeTerragruntPerFolder()
+		for _, result := range waveResults {
+			if !result.Success {
+				failed[filepath.Clean(result.Folder)] = true
+			}
+		}
+		results = append(results, waveResults...)
+	}
+	return results
+}
+
+// Get a failed folder the folder waits for: one of its dependencies, or for
+// destroy one of its dependents (empty if none)
+func blockingFolder(folder string, dependencies map[string][]string, failed map[string]bool, destroy bool) string {
+	folder = filepath.Clean(folder)
+	if !destroy {
+		for _, dep := range dependencies[folder] {
+			if failed[dep] {
+				return dep
+			}
+		}
+		return ""
+	}
+	for _, dependent := range slices.Sorted(maps.Keys(dependencies)) {
+		if failed[dependent] && slices.Contains(dependencies[dependent], folder) {
+			return dependent
+		}
+	}
+	return ""
+}
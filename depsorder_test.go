@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnitDependencies(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.MkdirAll("live/app", 0o755)
+	os.WriteFile("live/app/terragrunt.hcl", []byte(`include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+dependency "db" {
+  config_path  = "../db"
+  mock_outputs = { endpoint = "mock" }
+}
+
+dependency "shared" {
+  config_path = "${get_repo_root()}/live/shared"
+}
+
+dependencies {
+  paths = ["../vpc", "../../global/iam"]
+}
+`), 0o644)
+
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}})
+	want := []string{"live/vpc", "live/db", "global/iam"}
+	if got := r.unitDependencies("live/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("unitDependencies() = %v, want %v", got, want)
+	}
+	if got := r.unitDependencies("live/missing"); got != nil {
+		t.Errorf("unitDependencies() without a unit file = %v, want none", got)
+	}
+}
+
+func TestDependencyWaves(t *testing.T) {
+	tests := []struct {
+		name    string
+		folders []string
+		deps    map[string][]string
+		want    [][]string
+	}{
+		{
+			name:    "independent",
+			folders: []string{"live/a", "live/b"},
+			want:    [][]string{{"live/a", "live/b"}},
+		},
+		{
+			name:    "chain and fan out",
+			folders: []string{"live/app", "live/db", "live/vpc", "live/dns"},
+			deps:    map[string][]string{"live/app": {"live/db", "live/vpc"}, "live/db": {"live/vpc"}},
+			want:    [][]string{{"live/vpc", "live/dns"}, {"live/db"}, {"live/app"}},
+		},
+		{
+			name:    "dependency outside the run",
+			folders: []string{"live/app"},
+			deps:    map[string][]string{"live/app": {"live/vpc"}},
+			want:    [][]string{{"live/app"}},
+		},
+		{
+			name:    "cycle",
+			folders: []string{"live/a", "live/b", "live/c"},
+			deps:    map[string][]string{"live/a": {"live/b"}, "live/b": {"live/a"}},
+			want:    [][]string{{"live/c"}, {"live/a", "live/b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependencyWaves(tt.folders, tt.deps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencyWaves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteDependencyWaves(t *testing.T) {
+	scripts := t.TempDir()
+	// Fake terragrunt logging the units in order; the vpc unit fails
+	script := "#!/bin/sh\nunit=$(basename \"$PWD\")\necho \"$unit\" >> \"$TG_FAKE_TERRAGRUNT_DIR/calls.log\"\n[ \"$unit\" != vpc ]\n"
+	if err := os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TG_FAKE_TERRAGRUNT_DIR", scripts)
+
+	root := t.TempDir()
+	folder := func(unit string) string { return filepath.Join(root, unit) }
+	for _, unit := range []string{"vpc", "app", "dns"} {
+		os.MkdirAll(folder(unit), 0o755)
+	}
+	deps := map[string][]string{folder("app"): {folder("vpc")}}
+
+	r := newTestRunner(&Config{Command: "apply", ParallelExec: true, MaxParallel: 5})
+	r.config.Folders = []string{folder("app"), folder("vpc"), folder("dns")}
+	results := r.executeDependencyWaves(dependencyWaves(r.config.Folders, deps), deps)
+
+	byFolder := map[string]ExecutionResult{}
+	for _, result := range results {
+		byFolder[filepath.Base(result.Folder)] = result
+	}
+	if len(results) != 3 || !byFolder["dns"].Success || byFolder["vpc"].Success {
+		t.Fatalf("results = %+v", results)
+	}
+	if app := byFolder["app"]; app.Success || app.Error == nil || !strings.Contains(app.Error.Error(), "not applied") {
+		t.Errorf("app result = %+v, want not applied after its dependency failed", app)
+	}
+	calls, _ := os.ReadFile(filepath.Join(scripts, "calls.log"))
+	if strings.Contains(string(calls), "app") {
+		t.Errorf("terragrunt ran in app although vpc failed:\n%s", calls)
+	}
+	if len(r.config.Folders) != 3 {
+		t.Errorf("folders = %v, want restored", r.config.Folders)
+	}
+}
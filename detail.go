@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Detail levels for --detail-level and --failure-detail-level
+const (
+	DetailSummary  = "summary"  // Resource list and plan summary only
+	DetailStandard = "standard" // Extracted plan (or error message on failure)
+	DetailFull     = "full"     // Entire cleaned output
+)
+
+var detailLevels = []string{DetailSummary, DetailStandard, DetailFull}
+
+var rePlanResourceLine = regexp.MustCompile(`^\s*# \S+.* (?:will be|must be|has moved|has been)`)
+
+// Get the detail level applicable to a result
+func (r *Runner) detailLevelFor(result ExecutionResult) string {
+	if !result.Success && r.config.FailureDetailLevel != "" {
+		return r.config.FailureDetailLevel
+	}
+	if r.config.DetailLevel == "" {
+		return DetailStandard
+	}
+	return r.config.DetailLevel
+}
+
+// Build the comment content of a result according to its detail level
+func (r *Runner) commentContent(result ExecutionResult) string {
+	full := result.FullOutput
+	if full == "" {
+		full = result.Output
+	}
+
+	switch r.detailLevelFor(result) {
+	case DetailSummary:
+		if !result.Success {
+			return firstErrorLine(result)
+		}
+		return summarizePlanResources(result.Output)
+	case DetailFull:
+		if !result.Success && result.Error != nil {
+			return strings.TrimRight(full, "\n") + "\n\n" + result.Error.Error()
+		}
+		return full
+	default:
+		if !result.Success {
+			return result.Error.Error()
+		}
+		return result.Output
+	}
+}
+
+// Reduce a plan to its resource action lines and the plan summary line
+func summarizePlanResources(output string) string {
+	var lines []string
+	for line := range strings.SplitSeq(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if rePlanResourceLine.MatchString(line) || strings.HasPrefix(trimmed, "Plan:") {
+			lines = append(lines, trimmed)
+		}
+	}
+	if len(lines) == 0 {
+		return output
+	}
+	return strings.Join(lines, "\n")
+}
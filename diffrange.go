@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Special starts of --diff-from, resolved against the --diff-to commit
+const (
+	DiffFromLastTag   = "last-tag"    // Latest tag before the diff-to commit, e.g. the previous release
+	DiffFromMergeBase = "merge-base:" // Prefix of a ref whose merge base with diff-to starts the diff
+)
+
+// Resolve --diff-from and --diff-to to the revision range of git diff:
+// from..to, or ref...to for the merge base of a ref
+func (r *Runner) resolveDiffRange(from, to string) (string, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	if err := r.verifyCommit(to); err != nil {
+		return "", err
+	}
+	switch {
+	case from == DiffFromLastTag:
+		out, err := r.commandOutput(exec.Command("git", "describe", "--tags", "--abbrev=0", to+"^"))
+		if err != nil {
+			return "", fmt.Errorf("no tag before %s to diff from (fetch tags, e.g. fetch-depth: 0)", to)
+		}
+		from = strings.TrimSpace(string(out))
+	case strings.HasPrefix(from, DiffFromMergeBase):
+		ref := strings.TrimPrefix(from, DiffFromMergeBase)
+		if err := r.verifyCommit(ref); err != nil {
+			return "", err
+		}
+		return ref + "..." + to, nil
+	}
+	if err := r.verifyCommit(from); err != nil {
+		return "", err
+	}
+	return from + ".." + to, nil
+}
+
+// Check that a ref names a commit available locally
+func (r *Runner) verifyCommit(ref string) error {
+	if ref == "" || r.runCommand(exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")) != nil {
+		return fmt.Errorf("unknown diff ref %q (fetch it, e.g. fetch-depth: 0)", ref)
+	}
+	return nil
+}
+
+// Get the files changed between --diff-from and --diff-to. Unlike diff-base
+// there is no fallback, a release diff must not silently cover other changes.
+func (r *Runner) changedFilesInRange() ([]string, error) {
+	revRange, err := r.resolveDiffRange(r.config.DiffFrom, r.config.DiffTo)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.getChangedFilesFromGit(revRange)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Info("Detecting changed files", "range", revRange, "files", len(files))
+	return files, nil
+}
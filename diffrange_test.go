@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChangedFilesInRange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(file, message string) {
+		t.Helper()
+		os.WriteFile(file, []byte(message), 0o644)
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	commit("a.hcl", "first")
+	git("tag", "v1.0.0")
+	commit("b.hcl", "second")
+	git("branch", "feature")
+	commit("c.hcl", "third")
+	git("tag", "v1.1.0")
+	git("checkout", "-q", "feature")
+	commit("d.hcl", "feature")
+	git("checkout", "-q", "-")
+
+	tests := []struct {
+		from, to string
+		want     []string
+		wantErr  bool
+	}{
+		{from: "v1.0.0", want: []string{"b.hcl", "c.hcl"}},
+		{from: "v1.0.0", to: "HEAD~1", want: []string{"b.hcl"}},
+		{from: DiffFromLastTag, want: []string{"b.hcl", "c.hcl"}},
+		{from: DiffFromLastTag, to: "feature", want: []string{"b.hcl", "d.hcl"}},
+		{from: "feature", want: []string{"c.hcl", "d.hcl"}},
+		{from: DiffFromMergeBase + "feature", want: []string{"c.hcl"}},
+		{from: DiffFromLastTag, to: "v1.0.0", wantErr: true},
+		{from: "v9.9.9", wantErr: true},
+		{from: DiffFromMergeBase + "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := newTestRunner(&Config{DiffFrom: tt.from, DiffTo: tt.to})
+		got, err := r.changedFilesInRange()
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("changedFilesInRange(%s, %s) = %v, %v, want %v", tt.from, tt.to, got, err, tt.want)
+		}
+	}
+
+	// The git lookups are recorded in the audit log like the rest of the run
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	r := newTestRunner(&Config{DiffFrom: DiffFromLastTag, AuditLog: auditLog})
+	if _, err := r.changedFilesInRange(); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(auditLog)
+	for _, want := range []string{`"argv":["git","rev-parse","--verify"`, `"argv":["git","describe","--tags"`, `"argv":["git","diff","--name-only"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("audit log lacks %s:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Doctor check statuses
+const (
+	DoctorPass = "pass"
+	DoctorWarn = "warn" // Works, but some features will be limited
+	DoctorFail = "fail" // Runs will fail
+)
+
+// Outcome of a single doctor check
+type DoctorCheck struct {
+	Name   string `json:"name"`   // What was checked
+	Status string `json:"status"` // pass, warn or fail
+	Detail string `json:"detail"` // Version or value found, or what is wrong
+}
+
+// Create the doctor subcommand
+func newDoctorCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var foldersStr, format string
+	cmd := &cobra.Command{
+		Use:   "doctor [--folders <folders>]",
+		Short: "Check the runner environment and report what is misconfigured",
+		Long: `Check that terragrunt and terraform/tofu are installed, the git checkout, the GitHub
+token and event payload, and that each folder resolves to a Terragrunt unit. Exits
+non-zero when a check fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config.Folders = parseFolders(foldersStr)
+			r := NewRunner(config, logger)
+			checks := r.doctorChecks(context.Background())
+			if err := printDoctorReport(cmd.OutOrStdout(), checks, format); err != nil {
+				return err
+			}
+			for _, check := range checks {
+				if check.Status == DoctorFail {
+					return fmt.Errorf("doctor found failing checks")
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&foldersStr, "folders", "", "Folders to check (comma, space, or newline separated)")
+	cmd.Flags().StringSliceVar(&config.TerragruntFiles, "terragrunt-file", []string{"terragrunt.hcl"}, "Names of the Terragrunt unit files to look for")
+	cmd.Flags().StringVarP(&format, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+// Run every doctor check
+func (r *Runner) doctorChecks(ctx context.Context) []DoctorCheck {
+	checks := checkTools()
+	checks = append(checks, r.checkGit()...)
+	checks = append(checks, r.checkToken(ctx), checkEventPayload())
+	for _, folder := range r.config.Folders {
+		checks = append(checks, r.checkFolder(folder))
+	}
+	return checks
+}
+
+// Check terragrunt and the Terraform/OpenTofu binaries; either of terraform and
+// tofu is enough
+func checkTools() []DoctorCheck {
+	terraform, tofu := checkBinary("terraform", "-version", false), checkBinary("tofu", "-version", false)
+	if terraform.Status != DoctorPass && tofu.Status != DoctorPass {
+		terraform.Status, tofu.Status = DoctorFail, DoctorFail
+	}
+	return []DoctorCheck{checkBinary("terragrunt", "--version", true), terraform, tofu}
+}
+
+// Check that a binary is installed and report its version; optional binaries
+// only warn when missing
+func checkBinary(name, versionFlag string, required bool) DoctorCheck {
+	check := DoctorCheck{Name: name, Status: DoctorPass}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		check.Status, check.Detail = DoctorWarn, "not found in PATH"
+		if required {
+			check.Status = DoctorFail
+		}
+		return check
+	}
+	out, err := exec.Command(path, versionFlag).CombinedOutput()
+	version, _, _ := strings.Cut(strings.TrimSpace(stripAnsiCodes(string(out))), "\n")
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("%s %s failed: %v", path, versionFlag, err)
+		return check
+	}
+	check.Detail = fmt.Sprintf("%s (%s)", version, path)
+	return check
+}
+
+// Check the git installation and the checkout the runner works in
+func (r *Runner) checkGit() []DoctorCheck {
+	if _, err := exec.LookPath("git"); err != nil {
+		return []DoctorCheck{{Name: "git", Status: DoctorFail, Detail: "not found in PATH"}}
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return []DoctorCheck{{Name: "git checkout", Status: DoctorFail, Detail: "not inside a git work tree: " + err.Error()}}
+	}
+	checks := []DoctorCheck{{Name: "git checkout", Status: DoctorPass, Detail: repoRoot}}
+
+	head, err := exec.Command("git", "-C", repoRoot, "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		checks = append(checks, DoctorCheck{Name: "git HEAD", Status: DoctorFail, Detail: "no commit checked out"})
+	} else {
+		checks = append(checks, DoctorCheck{Name: "git HEAD", Status: DoctorPass, Detail: strings.TrimSpace(string(head))})
+	}
+
+	// Auto-detection diffs against the PR base, which shallow clones usually lack
+	if out, err := exec.Command("git", "-C", repoRoot, "rev-parse", "--is-shallow-repository").Output(); err == nil && strings.TrimSpace(string(out)) == "true" {
+		checks = append(checks, DoctorCheck{Name: "git history", Status: DoctorWarn, Detail: "shallow clone; set fetch-depth: 0 so changed files can be diffed against the base"})
+	} else {
+		checks = append(checks, DoctorCheck{Name: "git history", Status: DoctorPass, Detail: "full history"})
+	}
+
+	if out, err := exec.Command("git", "-C", repoRoot, "status", "--porcelain").Output(); err == nil {
+		if changes := strings.TrimSpace(string(out)); changes != "" {
+			checks = append(checks, DoctorCheck{Name: "git work tree", Status: DoctorWarn, Detail: fmt.Sprintf("%d uncommitted changes", len(strings.Split(changes, "\n")))})
+		} else {
+			checks = append(checks, DoctorCheck{Name: "git work tree", Status: DoctorPass, Detail: "clean"})
+		}
+	}
+	return checks
+}
+
+// Check that the token can read the repository and report its permissions and scopes
+func (r *Runner) checkToken(ctx context.Context) DoctorCheck {
+	check := DoctorCheck{Name: "github token"}
+	if r.config.GithubToken == "" {
+		check.Status, check.Detail = DoctorFail, "no token (set github-token or GITHUB_TOKEN)"
+		return check
+	}
+	fmt.Printf("::add-mask::%s\n", r.config.GithubToken)
+	parts := strings.Split(r.config.Repository, "/")
+	if len(parts) != 2 {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("invalid repository %q (set repository or GITHUB_REPOSITORY)", r.config.Repository)
+		return check
+	}
+
+	repo, resp, err := r.createGitHubClient().Repositories.Get(ctx, parts[0], parts[1])
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("cannot read %s: %v", r.config.Repository, err)
+		return check
+	}
+	var granted []string
+	for _, perm := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if repo.GetPermissions()[perm] {
+			granted = append(granted, perm)
+		}
+	}
+	check.Status = DoctorPass
+	switch scopes := resp.Header.Get("X-OAuth-Scopes"); {
+	case len(granted) > 0 && scopes != "":
+		check.Detail = fmt.Sprintf("permissions: %s; scopes: %s", strings.Join(granted, ", "), scopes)
+	case len(granted) > 0:
+		check.Detail = "permissions: " + strings.Join(granted, ", ")
+	default:
+		// Installation tokens do not report permissions; they are checked on use
+		check.Detail = "repository readable (permissions not reported for this token type)"
+	}
+	if len(granted) > 0 && !repo.GetPermissions()["push"] && !repo.GetPermissions()["triage"] {
+		check.Status, check.Detail = DoctorWarn, check.Detail+"; read-only, results go to the job summary instead of PR comments"
+	}
+	return check
+}
+
+// Check that the GitHub event payload is available and names a pull request
+func checkEventPayload() DoctorCheck {
+	check := DoctorCheck{Name: "event payload"}
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		check.Status, check.Detail = DoctorWarn, "GITHUB_EVENT_PATH not set (not running in GitHub Actions); pass pull-request explicitly"
+		return check
+	}
+	if _, err := readEventPayload(); err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("cannot read %s: %v", path, err)
+		return check
+	}
+	event := os.Getenv("GITHUB_EVENT_NAME")
+	if number, err := extractPullRequestNumber(); err == nil && number > 0 {
+		check.Status, check.Detail = DoctorPass, fmt.Sprintf("%s event for pull request #%d", event, number)
+		return check
+	}
+	check.Status, check.Detail = DoctorWarn, fmt.Sprintf("%s event without a pull request; pass pull-request explicitly", event)
+	return check
+}
+
+// Check that a folder exists and contains a Terragrunt unit
+func (r *Runner) checkFolder(folder string) DoctorCheck {
+	check := DoctorCheck{Name: "folder " + folder}
+	if strings.Contains(folder, "..") {
+		check.Status, check.Detail = DoctorFail, "path traversal (..) is not allowed"
+		return check
+	}
+	absFolder, err := filepath.Abs(folder)
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, err.Error()
+		return check
+	}
+	info, err := os.Stat(absFolder)
+	if err != nil || !info.IsDir() {
+		check.Status, check.Detail = DoctorFail, absFolder+" is not a directory"
+		return check
+	}
+	if name := r.unitFile(absFolder); name != "" {
+		check.Status, check.Detail = DoctorPass, filepath.Join(absFolder, name)
+		return check
+	}
+	// Directories without a unit file are valid run --all roots
+	check.Status, check.Detail = DoctorWarn, fmt.Sprintf("%s has no %s (only valid as a run --all root)", absFolder, strings.Join(r.config.TerragruntFiles, " or "))
+	return check
+}
+
+// Print the doctor checks as text or JSON
+func printDoctorReport(w io.Writer, checks []DoctorCheck, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(checks)
+	case "text", "":
+		icons := map[string]string{DoctorPass: "✅", DoctorWarn: "⚠️", DoctorFail: "❌"}
+		counts := map[string]int{}
+		for _, check := range checks {
+			fmt.Fprintf(w, "%s %-24s %s\n", icons[check.Status], check.Name, check.Detail)
+			counts[check.Status]++
+		}
+		fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[DoctorPass], counts[DoctorWarn], counts[DoctorFail])
+		return nil
+	default:
+		return fmt.Errorf("invalid output format: %s (expected text or json)", format)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFolder(t *testing.T) {
+	root := t.TempDir()
+	unit := filepath.Join(root, "live", "app")
+	if err := os.MkdirAll(unit, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(unit, "terragrunt.hcl"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}})
+	tests := []struct {
+		folder string
+		want   string
+	}{
+		{unit, DoctorPass},
+		{filepath.Join(root, "live"), DoctorWarn},
+		{filepath.Join(root, "missing"), DoctorFail},
+		{"live/../../etc", DoctorFail},
+	}
+	for _, tt := range tests {
+		if got := r.checkFolder(tt.folder); got.Status != tt.want {
+			t.Errorf("checkFolder(%q) = %s (%s), want %s", tt.folder, got.Status, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestCheckEventPayload(t *testing.T) {
+	dir := t.TempDir()
+	prEvent := filepath.Join(dir, "pr.json")
+	pushEvent := filepath.Join(dir, "push.json")
+	os.WriteFile(prEvent, []byte(`{"number": 7}`), 0644)
+	os.WriteFile(pushEvent, []byte(`{"ref": "refs/heads/main"}`), 0644)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", DoctorWarn},
+		{prEvent, DoctorPass},
+		{pushEvent, DoctorWarn},
+		{filepath.Join(dir, "missing.json"), DoctorFail},
+	}
+	for _, tt := range tests {
+		t.Setenv("GITHUB_EVENT_PATH", tt.path)
+		if got := checkEventPayload(); got.Status != tt.want {
+			t.Errorf("checkEventPayload() with %q = %s (%s), want %s", tt.path, got.Status, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestCheckTokenMissing(t *testing.T) {
+	r := newTestRunner(&Config{Repository: "org/infra"})
+	if got := r.checkToken(context.Background()); got.Status != DoctorFail || !strings.Contains(got.Detail, "no token") {
+		t.Errorf("checkToken() = %+v, want missing token failure", got)
+	}
+}
+
+func TestCheckBinaryMissing(t *testing.T) {
+	if got := checkBinary("terragrunt-runner-no-such-binary", "--version", true); got.Status != DoctorFail {
+		t.Errorf("checkBinary() required = %s, want fail", got.Status)
+	}
+	if got := checkBinary("terragrunt-runner-no-such-binary", "--version", false); got.Status != DoctorWarn {
+		t.Errorf("checkBinary() optional = %s, want warn", got.Status)
+	}
+}
+
+func TestPrintDoctorReport(t *testing.T) {
+	checks := []DoctorCheck{
+		{Name: "terragrunt", Status: DoctorPass, Detail: "terragrunt version v0.88.1"},
+		{Name: "git history", Status: DoctorWarn, Detail: "shallow clone"},
+		{Name: "github token", Status: DoctorFail, Detail: "no token"},
+	}
+
+	var text bytes.Buffer
+	if err := printDoctorReport(&text, checks, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "❌ github token") || !strings.Contains(text.String(), "1 passed, 1 warnings, 1 failed") {
+		t.Errorf("printDoctorReport() text = %q", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := printDoctorReport(&out, checks, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []DoctorCheck
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded) != 3 || decoded[2].Status != DoctorFail {
+		t.Errorf("printDoctorReport() json = %s (%v)", out.String(), err)
+	}
+
+	if err := printDoctorReport(&out, checks, "yaml"); err == nil {
+		t.Error("printDoctorReport() accepted an invalid format")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	embeddedPlanPrefix  = "terragrunt-runner-plan:v1" // Prefix of the hidden block holding the embedded plan
+	embeddedPlanLineLen = 76                          // Base64 line length inside the block
+	commentMarkerSize   = 200                         // Room left for the comment marker prepended on creation
+)
+
+var reEmbeddedPlan = regexp.MustCompile(`(?s)<!-- ` + regexp.QuoteMeta(embeddedPlanPrefix) + `;([^\n]*)\n(.*?)\n-->`)
+
+// Encode a plan as a hidden comment block: gzip-compressed, base64-encoded and
+// wrapped, with the folder and the SHA-256 of the plan in the block header.
+// Base64 never contains "--" or ">", so the block can't end the HTML comment early.
+func encodeEmbeddedPlan(folder, plan string) (string, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write([]byte(plan)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	var b strings.Builder
+	sum := sha256.Sum256([]byte(plan))
+	b.WriteString(fmt.Sprintf("<!-- %s;folder=%s;sha256=%s;encoding=gzip+base64\n", embeddedPlanPrefix, url.QueryEscape(folder), hex.EncodeToString(sum[:])))
+	for len(encoded) > embeddedPlanLineLen {
+		b.WriteString(encoded[:embeddedPlanLineLen] + "\n")
+		encoded = encoded[embeddedPlanLineLen:]
+	}
+	b.WriteString(encoded + "\n-->")
+	return b.String(), nil
+}
+
+// Decode the plan embedded in a comment body, verifying its checksum
+func decodeEmbeddedPlan(body string) (folder, plan string, ok bool, err error) {
+	m := reEmbeddedPlan.FindStringSubmatch(body)
+	if m == nil {
+		return "", "", false, nil
+	}
+	fields := make(map[string]string)
+	for pair := range strings.SplitSeq(m[1], ";") {
+		if key, value, found := strings.Cut(pair, "="); found {
+			fields[key] = value
+		}
+	}
+	if folder, err = url.QueryUnescape(fields["folder"]); err != nil {
+		return "", "", true, fmt.Errorf("invalid embedded plan folder: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(m[2], "\n", ""))
+	if err != nil {
+		return folder, "", true, fmt.Errorf("invalid embedded plan encoding: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return folder, "", true, fmt.Errorf("invalid embedded plan compression: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return folder, "", true, fmt.Errorf("invalid embedded plan compression: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) != fields["sha256"] {
+		return folder, "", true, fmt.Errorf("embedded plan checksum mismatch")
+	}
+	return folder, string(raw), true, nil
+}
+
+// Append the embedded full plan of a result to a comment body when enabled and
+// it fits within the comment size limit
+func (r *Runner) withEmbeddedPlan(body string, result ExecutionResult) string {
+	if !r.config.EmbedPlan || !result.Success || isApplyCommand(r.config.Command) {
+		return body
+	}
+	plan := result.FullOutput
+	if plan == "" {
+		plan = result.Output
+	}
+	block, err := encodeEmbeddedPlan(result.Folder, plan)
+	if err != nil {
+		r.logger.Warn("Failed to encode plan for embedding", "folder", result.Folder, "error", err)
+		return body
+	}
+	if len(body)+len(block)+1 > maxCommentSize-commentMarkerSize {
+		r.logger.Warn("Plan too large to embed in the comment", "folder", result.Folder, "encoded_bytes", len(block))
+		return body + "\n\n_Full plan too large to embed in this comment._"
+	}
+	return body + "\n" + block
+}
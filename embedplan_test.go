@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedPlanRoundTrip(t *testing.T) {
+	plan := strings.Repeat("  # aws_s3_bucket.logs will be created\n  + resource \"aws_s3_bucket\" \"logs\" {}\n", 50) +
+		"Plan: 50 to add, 0 to change, 0 to destroy."
+
+	block, err := encodeEmbeddedPlan("live/prod app", plan)
+	if err != nil {
+		t.Fatalf("encodeEmbeddedPlan() error = %v", err)
+	}
+	if !strings.HasPrefix(block, "<!-- terragrunt-runner-plan:v1;folder=live%2Fprod+app;sha256=") || !strings.HasSuffix(block, "\n-->") {
+		t.Errorf("encodeEmbeddedPlan() unexpected block framing:\n%s", block)
+	}
+	if strings.Count(block, "-->") != 1 {
+		t.Errorf("encodeEmbeddedPlan() block ends the HTML comment early:\n%s", block)
+	}
+
+	body := "## ✅ Success Terragrunt: live/prod app\n\nNo Changes\n" + block
+	folder, got, ok, err := decodeEmbeddedPlan(body)
+	if !ok || err != nil {
+		t.Fatalf("decodeEmbeddedPlan() ok = %v, error = %v", ok, err)
+	}
+	if folder != "live/prod app" || got != plan {
+		t.Errorf("decodeEmbeddedPlan() = %q, %q; want the original folder and plan", folder, got)
+	}
+}
+
+func TestDecodeEmbeddedPlan(t *testing.T) {
+	if _, _, ok, err := decodeEmbeddedPlan("## Terragrunt Summary"); ok || err != nil {
+		t.Errorf("decodeEmbeddedPlan() without block: ok = %v, error = %v", ok, err)
+	}
+
+	block, _ := encodeEmbeddedPlan("live/app", "Plan: 1 to add, 0 to change, 0 to destroy.")
+	tampered := strings.Replace(block, "sha256=", "sha256=00", 1)
+	if _, _, ok, err := decodeEmbeddedPlan(tampered); !ok || err == nil {
+		t.Errorf("decodeEmbeddedPlan() tampered checksum: ok = %v, error = %v; want checksum error", ok, err)
+	}
+}
+
+func TestWithEmbeddedPlan(t *testing.T) {
+	result := ExecutionResult{Folder: "live/app", Success: true, Output: "Plan: 1 to add", FullOutput: "full plan\nPlan: 1 to add"}
+
+	tests := []struct {
+		name   string
+		config Config
+		result ExecutionResult
+		body   string
+		embed  bool
+		tooBig bool
+	}{
+		{name: "disabled", config: Config{Command: "plan"}, result: result, body: "header"},
+		{name: "plan", config: Config{Command: "plan", EmbedPlan: true}, result: result, body: "header", embed: true},
+		{name: "apply", config: Config{Command: "apply", EmbedPlan: true}, result: result, body: "header"},
+		{name: "failed", config: Config{Command: "plan", EmbedPlan: true}, result: ExecutionResult{Folder: "live/app", Output: "Error"}, body: "header"},
+		{name: "too large", config: Config{Command: "plan", EmbedPlan: true}, result: result, body: strings.Repeat("x", maxCommentSize-commentMarkerSize-50), tooBig: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestRunner(&tt.config).withEmbeddedPlan(tt.body, tt.result)
+			if embedded := strings.Contains(got, embeddedPlanPrefix); embedded != tt.embed {
+				t.Errorf("withEmbeddedPlan() embedded = %v, want %v", embedded, tt.embed)
+			}
+			if tt.embed {
+				if _, plan, _, err := decodeEmbeddedPlan(got); err != nil || plan != result.FullOutput {
+					t.Errorf("withEmbeddedPlan() embedded plan = %q, error = %v; want the full output", plan, err)
+				}
+			}
+			if tooBig := strings.Contains(got, "too large to embed"); tooBig != tt.tooBig {
+				t.Errorf("withEmbeddedPlan() too large note = %v, want %v", tooBig, tt.tooBig)
+			}
+		})
+	}
+}
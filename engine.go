@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Engines producing the plans
+const (
+	EngineOpenTofu  = "OpenTofu"
+	EngineTerraform = "Terraform"
+)
+
+// Engine selection of --engine detecting the engine from the output
+const EngineAuto = "auto"
+
+// Parser of the output of an engine. Engines or versions phrasing their
+// output differently only need a parser registered with RegisterOutputParser.
+type OutputParser interface {
+	Engine() string                                        // Name of the engine, shown in comments
+	Detect(output string) bool                             // Whether the engine produced the output
+	Extract(output string) string                          // Plan or apply output shown in comments
+	ResourceChanges(output string) *ResourceChanges        // Changes reported by the plan summary
+	PlannedOutputs(output string) map[string]PlannedOutput // Planned changes to output values
+}
+
+// Parser of the engines sharing Terraform's output phrasing (Terraform, OpenTofu)
+type terraformOutputParser struct {
+	name   string         // Engine name printed in its own phrases
+	phrase *regexp.Regexp // Phrases printed by the engine itself, naming it
+	prefix *regexp.Regexp // Binary name Terragrunt prefixes forwarded output with (e.g. "tofu: Plan: ...")
+}
+
+func newTerraformOutputParser(name, binary string) *terraformOutputParser {
+	return &terraformOutputParser{
+		name:   name,
+		phrase: regexp.MustCompile(`\b` + name + ` (?:will perform the following actions|used the selected providers|has been successfully initialized|has compared your real infrastructure)`),
+		prefix: regexp.MustCompile(`(?m)(?:^|\s)` + binary + `: `),
+	}
+}
+
+func (p *terraformOutputParser) Engine() string { return p.name }
+
+func (p *terraformOutputParser) Detect(output string) bool {
+	output = stripAnsiCodes(output)
+	return p.phrase.MatchString(output) || p.prefix.MatchString(output)
+}
+
+func (p *terraformOutputParser) Extract(output string) string { return extractTerraformOutput(output) }
+
+func (p *terraformOutputParser) ResourceChanges(output string) *ResourceChanges {
+	return parseResourceChanges(output)
+}
+
+func (p *terraformOutputParser) PlannedOutputs(output string) map[string]PlannedOutput {
+	return parsePlannedOutputs(output)
+}
+
+// Registered output parsers, in detection order
+var outputParsers = []OutputParser{
+	newTerraformOutputParser(EngineOpenTofu, "tofu"),
+	newTerraformOutputParser(EngineTerraform, "terraform"),
+}
+
+// Register the output parser of an engine. Parsers registered later are
+// detected first, so a parser can take over outputs of a more generic one.
+func RegisterOutputParser(parser OutputParser) error {
+	if findOutputParser(parser.Engine()) != nil {
+		return fmt.Errorf("output parser for %s is already registered", parser.Engine())
+	}
+	outputParsers = slices.Insert(outputParsers, 0, parser)
+	return nil
+}
+
+// Find the output parser of an engine by name, case-insensitively (nil if unknown)
+func findOutputParser(engine string) OutputParser {
+	for _, parser := range outputParsers {
+		if strings.EqualFold(parser.Engine(), engine) {
+			return parser
+		}
+	}
+	return nil
+}
+
+// Detect which engine produced an output (empty if unknown)
+func detectEngine(output string) string {
+	for _, parser := range outputParsers {
+		if parser.Detect(output) {
+			return parser.Engine()
+		}
+	}
+	return ""
+}
+
+// Select the parser of an output and name its engine: the engine configured
+// with --engine, else the detected one. Outputs of unknown engines are parsed
+// with Terraform's phrasing, which OpenTofu shares, and have no engine name.
+func (r *Runner) outputParser(output string) (OutputParser, string) {
+	if r.config.Engine != "" && r.config.Engine != EngineAuto {
+		if parser := findOutputParser(r.config.Engine); parser != nil {
+			return parser, parser.Engine()
+		}
+	}
+	engine := detectEngine(output)
+	if parser := findOutputParser(engine); parser != nil {
+		return parser, engine
+	}
+	return findOutputParser(EngineTerraform), ""
+}
+
+// Get the engine names accepted by --engine
+func engineChoices() []string {
+	choices := []string{EngineAuto}
+	for _, parser := range outputParsers {
+		choices = append(choices, strings.ToLower(parser.Engine()))
+	}
+	return choices
+}
+
+// Get the engine of a set of results ("mixed" if they disagree, empty if unknown)
+func resultsEngine(results []ExecutionResult) string {
+	var engines []string
+	for _, r := range results {
+		if r.Engine != "" && !slices.Contains(engines, r.Engine) {
+			engines = append(engines, r.Engine)
+		}
+	}
+	switch len(engines) {
+	case 0:
+		return ""
+	case 1:
+		return engines[0]
+	default:
+		return "mixed"
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectEngine(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"opentofu plan", "OpenTofu will perform the following actions:", EngineOpenTofu},
+		{"terraform plan", "Terraform used the selected providers to generate the following execution plan.", EngineTerraform},
+		{"terragrunt tofu prefix", "14:22:01.123 STDOUT [live/app] tofu: No changes.", EngineOpenTofu},
+		{"terragrunt terraform prefix", "14:22:01.123 STDOUT terraform: Plan: 1 to add, 0 to change, 0 to destroy.", EngineTerraform},
+		{"unknown", "Plan: 1 to add, 0 to change, 0 to destroy.", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectEngine(tt.output); got != tt.want {
+				t.Errorf("detectEngine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultsEngine(t *testing.T) {
+	if got := resultsEngine([]ExecutionResult{{Engine: EngineOpenTofu}, {}, {Engine: EngineOpenTofu}}); got != EngineOpenTofu {
+		t.Errorf("resultsEngine() = %q, want %q", got, EngineOpenTofu)
+	}
+	if got := resultsEngine([]ExecutionResult{{Engine: EngineOpenTofu}, {Engine: EngineTerraform}}); got != "mixed" {
+		t.Errorf("resultsEngine() = %q, want mixed", got)
+	}
+}
+
+// Parser of a fictional engine phrasing its plan summary differently
+type stubOutputParser struct{ terraformOutputParser }
+
+func (p *stubOutputParser) Engine() string { return "Stub" }
+
+func (p *stubOutputParser) Detect(output string) bool {
+	return strings.Contains(output, "Stub plan:")
+}
+
+func (p *stubOutputParser) ResourceChanges(output string) *ResourceChanges {
+	return &ResourceChanges{ToAdd: strings.Count(output, "+ create")}
+}
+
+func TestFindOutputParser(t *testing.T) {
+	tests := []struct {
+		engine string
+		want   string
+	}{
+		{"opentofu", EngineOpenTofu},
+		{"Terraform", EngineTerraform},
+		{"pulumi", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			got := ""
+			if parser := findOutputParser(tt.engine); parser != nil {
+				got = parser.Engine()
+			}
+			if got != tt.want {
+				t.Errorf("findOutputParser(%q) = %q, want %q", tt.engine, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerOutputParser(t *testing.T) {
+	tests := []struct {
+		name       string
+		engine     string
+		output     string
+		wantParser string
+		wantEngine string
+	}{
+		{"detected", EngineAuto, "OpenTofu will perform the following actions:", EngineOpenTofu, EngineOpenTofu},
+		{"unset detects", "", "terraform: No changes.", EngineTerraform, EngineTerraform},
+		{"unknown falls back to terraform phrasing", EngineAuto, "Plan: 1 to add", EngineTerraform, ""},
+		{"forced", "opentofu", "Plan: 1 to add", EngineOpenTofu, EngineOpenTofu},
+		{"forced over detection", "terraform", "tofu: No changes.", EngineTerraform, EngineTerraform},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{Engine: tt.engine})
+			parser, engine := r.outputParser(tt.output)
+			if parser.Engine() != tt.wantParser || engine != tt.wantEngine {
+				t.Errorf("outputParser() = (%q, %q), want (%q, %q)", parser.Engine(), engine, tt.wantParser, tt.wantEngine)
+			}
+		})
+	}
+}
+
+func TestRegisterOutputParser(t *testing.T) {
+	saved := outputParsers
+	t.Cleanup(func() { outputParsers = saved })
+	outputParsers = slices.Clone(saved)
+
+	if err := RegisterOutputParser(&stubOutputParser{}); err != nil {
+		t.Fatalf("RegisterOutputParser() error = %v", err)
+	}
+	if err := RegisterOutputParser(&stubOutputParser{}); err == nil {
+		t.Error("RegisterOutputParser() of a duplicate engine should fail")
+	}
+	if !slices.Contains(engineChoices(), "stub") {
+		t.Errorf("engineChoices() = %v, want stub", engineChoices())
+	}
+
+	r := newTestRunner(&Config{})
+	parser, engine := r.outputParser("Stub plan:\n  + create\n  + create")
+	if engine != "Stub" {
+		t.Fatalf("outputParser() engine = %q, want Stub", engine)
+	}
+	if got := parser.ResourceChanges("Stub plan:\n  + create\n  + create"); got == nil || got.ToAdd != 2 {
+		t.Errorf("ResourceChanges() = %+v, want 2 to add", got)
+	}
+	if _, engine := r.outputParser("OpenTofu will perform the following actions:"); engine != EngineOpenTofu {
+		t.Errorf("outputParser() engine = %q, want built-in %q", engine, EngineOpenTofu)
+	}
+}
+
+func TestValidateConfigEngine(t *testing.T) {
+	for engine, wantErr := range map[string]bool{"": false, "auto": false, "opentofu": false, "Terraform": false, "pulumi": true} {
+		r := newTestRunner(&Config{GithubToken: "t", Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, Command: "plan", Engine: engine})
+		if err := r.validateConfig(); (err != nil) != wantErr {
+			t.Errorf("validateConfig() with engine %q error = %v, wantErr %v", engine, err, wantErr)
+		}
+	}
+}
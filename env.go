@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// Host environment variables passed to Terragrunt subprocesses by default: what
+// the tools need to run, their own settings, and Terraform variables and AWS credentials
+var defaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "SHELL", "TMPDIR", "TMP", "TEMP", "TERM", "TZ", "LANG", "LC_*",
+	"CI", "RUNNER_TEMP", "SSL_CERT_FILE", "SSL_CERT_DIR",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	"TF_*", "TG_*", "TERRAGRUNT_*", "AWS_*",
+}
+
+// Check whether a variable name matches any of the glob patterns
+func envNameMatches(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter environment entries ("NAME=value") through the allowlist and denylist;
+// the denylist wins
+func filterEnv(environ, allowlist, denylist []string) []string {
+	var env []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if envNameMatches(name, allowlist) && !envNameMatches(name, denylist) {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+// Build the environment of a Terragrunt subprocess: the allowed host variables
+// (the default allowlist if none is configured, "*" for all) followed by the
+// runner's own settings
+func (r *Runner) subprocessEnv(extra ...string) []string {
+	allowlist := r.config.EnvAllowlist
+	if len(allowlist) == 0 {
+		allowlist = defaultEnvAllowlist
+	}
+	env := filterEnv(os.Environ(), allowlist, r.config.EnvDenylist)
+	env = append(env, "TF_IN_AUTOMATION=true", "TG_NON_INTERACTIVE=true")
+	env = append(env, r.noColorEnv()...)
+	return append(env, extra...)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/runner",
+		"LC_ALL=C.UTF-8",
+		"TF_VAR_region=eu-west-1",
+		"TF_CLI_ARGS_plan=-refresh=false",
+		"AWS_ACCESS_KEY_ID=AKIA",
+		"GITHUB_TOKEN=ghs_secret",
+		"NPM_TOKEN=npm_secret",
+	}
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		denylist  []string
+		want      []string
+	}{
+		{
+			name:      "default allowlist",
+			allowlist: defaultEnvAllowlist,
+			want:      []string{"PATH=/usr/bin", "HOME=/home/runner", "LC_ALL=C.UTF-8", "TF_VAR_region=eu-west-1", "TF_CLI_ARGS_plan=-refresh=false", "AWS_ACCESS_KEY_ID=AKIA"},
+		},
+		{
+			name:      "denylist wins",
+			allowlist: defaultEnvAllowlist,
+			denylist:  []string{"TF_CLI_ARGS*", "AWS_*"},
+			want:      []string{"PATH=/usr/bin", "HOME=/home/runner", "LC_ALL=C.UTF-8", "TF_VAR_region=eu-west-1"},
+		},
+		{
+			name:      "whole environment",
+			allowlist: []string{"*"},
+			denylist:  []string{"*_TOKEN"},
+			want:      []string{"PATH=/usr/bin", "HOME=/home/runner", "LC_ALL=C.UTF-8", "TF_VAR_region=eu-west-1", "TF_CLI_ARGS_plan=-refresh=false", "AWS_ACCESS_KEY_ID=AKIA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterEnv(environ, tt.allowlist, tt.denylist); !slices.Equal(got, tt.want) {
+				t.Errorf("filterEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubprocessEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghs_secret")
+	t.Setenv("TF_VAR_env", "dev")
+
+	env := newTestRunner(&Config{}).subprocessEnv("EXTRA=1")
+	if slices.Contains(env, "GITHUB_TOKEN=ghs_secret") {
+		t.Error("subprocessEnv() passes GITHUB_TOKEN with the default allowlist")
+	}
+	for _, want := range []string{"TF_VAR_env=dev", "TF_IN_AUTOMATION=true", "TG_NON_INTERACTIVE=true", "EXTRA=1"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("subprocessEnv() missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Prefix of the environment variables setting runner flags, e.g.
+// TGRUNNER_MAX_RUNS=10 for --max-runs. TG_ belongs to Terragrunt itself.
+const envFlagPrefix = "TGRUNNER_"
+
+// Get the environment variable setting a flag
+func envFlagName(flag string) string {
+	return envFlagPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Set the flags not given on the command line from their TGRUNNER_*
+// environment variables. Empty variables keep the flag default. Variables of
+// flags the command does not have are ignored, so one environment can
+// configure several subcommands (e.g. serve and the runs it starts).
+func applyEnvFlags(flags *pflag.FlagSet, environ []string) error {
+	env := map[string]string{}
+	for _, entry := range environ {
+		if name, value, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(name, envFlagPrefix) {
+			env[name] = value
+		}
+	}
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		value := env[envFlagName(f.Name)]
+		if err != nil || f.Name == "help" || f.Changed || value == "" {
+			return
+		}
+		if setErr := flags.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value for %s: %w", envFlagName(f.Name), setErr)
+		}
+	})
+	return err
+}
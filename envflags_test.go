@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvFlagName(t *testing.T) {
+	for flag, want := range map[string]string{"max-runs": "TGRUNNER_MAX_RUNS", "command": "TGRUNNER_COMMAND", "skip-stages": "TGRUNNER_SKIP_STAGES"} {
+		if got := envFlagName(flag); got != want {
+			t.Errorf("envFlagName(%q) = %q, want %q", flag, got, want)
+		}
+	}
+}
+
+func TestApplyEnvFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []string
+		cli     []string
+		inputs  string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "variables set flags",
+			env:  []string{"TGRUNNER_COMMAND=apply", "TGRUNNER_PARALLEL=false", "TGRUNNER_MAX_RUNS=5", "TGRUNNER_SKIP_STAGES=fmt,policy"},
+			want: Config{Command: "apply", ParallelExec: false, MaxRuns: 5, SkipStages: []string{"fmt", "policy"}},
+		},
+		{
+			name: "command line wins",
+			env:  []string{"TGRUNNER_COMMAND=apply"},
+			cli:  []string{"--command", "validate"},
+			want: Config{Command: "validate", ParallelExec: true, MaxRuns: 20},
+		},
+		{
+			name:   "variables win over inputs",
+			env:    []string{"TGRUNNER_MAX_RUNS=5"},
+			inputs: `{"max-runs": "8", "command": "apply"}`,
+			want:   Config{Command: "apply", ParallelExec: true, MaxRuns: 5},
+		},
+		{
+			name: "empty, other and unrelated variables are ignored",
+			env:  []string{"TGRUNNER_COMMAND=", "TGRUNNER_WORKERS=4", "TG_NON_INTERACTIVE=true", "MAX_RUNS=3"},
+			want: Config{Command: "plan", ParallelExec: true, MaxRuns: 20},
+		},
+		{name: "invalid value", env: []string{"TGRUNNER_PARALLEL=yes"}, wantErr: "invalid value for TGRUNNER_PARALLEL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, config := newTestFlagSet()
+			if err := flags.Parse(tt.cli); err != nil {
+				t.Fatal(err)
+			}
+			err := applyEnvFlags(flags, tt.env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyEnvFlags() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEnvFlags() error = %v", err)
+			}
+			if err := applyActionInputs(flags, tt.inputs); err != nil {
+				t.Fatal(err)
+			}
+			if config.Command != tt.want.Command || config.ParallelExec != tt.want.ParallelExec || config.MaxRuns != tt.want.MaxRuns || strings.Join(config.SkipStages, ",") != strings.Join(tt.want.SkipStages, ",") {
+				t.Errorf("config = %+v, want %+v", *config, tt.want)
+			}
+		})
+	}
+}
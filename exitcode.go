@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Exit status of a plan run with -detailed-exitcode when changes are present
+const planExitChanges = 2
+
+// Extra arguments requesting a detailed exit code from a single folder plan.
+// run --all aggregates the unit exit codes, so its per-unit results keep
+// relying on the parsed output.
+func (r *Runner) detailedExitCodeArgs(command string) []string {
+	if !r.config.DetailedExitCode || !isPlanCommand(command) || isRunAllCommand(command) {
+		return nil
+	}
+	return []string{"-detailed-exitcode"}
+}
+
+// Map the exit status of a plan run with -detailed-exitcode into the result
+// model: 0 means no changes, 2 means changes and is not a failure, anything
+// else is an error. The exit status takes precedence over the parsed output,
+// which may miss a summary that was truncated or worded differently.
+func applyDetailedExitCode(changes *ResourceChanges, err error) (*ResourceChanges, error) {
+	if changes == nil {
+		changes = &ResourceChanges{}
+	}
+	if err == nil {
+		changes.NoChanges = true
+		return changes, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == planExitChanges {
+		changes.NoChanges = false
+		return changes, nil
+	}
+	return changes, err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestDetailedExitCodeArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		command string
+		want    []string
+	}{
+		{"plan", true, "plan", []string{"-detailed-exitcode"}},
+		{"disabled", false, "plan", nil},
+		{"apply", true, "apply", nil},
+		{"run --all plan", true, "run --all plan", nil},
+		{"validate", true, "validate", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{DetailedExitCode: tt.enabled})
+			if got := r.detailedExitCodeArgs(tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detailedExitCodeArgs(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+// Run a shell exiting with code to get a real *exec.ExitError
+func exitWith(t *testing.T, code string) error {
+	t.Helper()
+	return exec.Command("sh", "-c", "exit "+code).Run()
+}
+
+func TestApplyDetailedExitCode(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		changes       *ResourceChanges
+		wantErr       bool
+		wantNoChanges bool
+	}{
+		{"exit 0 overrides unparsed output", nil, &ResourceChanges{}, false, true},
+		{"exit 0 without parsed changes", nil, nil, false, true},
+		{"exit 2 is success with changes", exitWith(t, "2"), &ResourceChanges{ToAdd: 1}, false, false},
+		{"exit 2 overrides a no changes match", exitWith(t, "2"), &ResourceChanges{NoChanges: true}, false, false},
+		{"exit 1 is an error", exitWith(t, "1"), &ResourceChanges{}, true, false},
+		{"start failure is an error", errors.New("exec: not found"), &ResourceChanges{}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := applyDetailedExitCode(tt.changes, tt.err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changes == nil || changes.NoChanges != tt.wantNoChanges {
+				t.Errorf("changes = %+v, want NoChanges %v", changes, tt.wantNoChanges)
+			}
+		})
+	}
+}
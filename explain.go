@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How auto-detection handled a changed file
+type DetectionExplanation struct {
+	File    string   // Changed file
+	Pattern string   // File pattern that matched (empty if none)
+	Checked []string // Directories checked while walking up
+	Folder  string   // Terragrunt folder found (empty if none)
+	Reason  string   // Why no folder was found
+}
+
+// Format the detection explanation as plain text
+func formatDetectionExplanation(explanations []DetectionExplanation, folders []string) string {
+	var b strings.Builder
+	for _, e := range explanations {
+		b.WriteString(e.File + "\n")
+		if e.Pattern == "" {
+			b.WriteString("  ✗ " + e.Reason + "\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  pattern: %s\n", e.Pattern))
+		b.WriteString(fmt.Sprintf("  checked: %s\n", strings.Join(e.Checked, " → ")))
+		if e.Folder != "" {
+			b.WriteString("  ✓ folder: " + e.Folder + "\n")
+		} else {
+			b.WriteString("  ✗ " + e.Reason + "\n")
+		}
+	}
+	b.WriteString(fmt.Sprintf("\nFinal folders (%d):\n", len(folders)))
+	for _, f := range folders {
+		b.WriteString("  " + f + "\n")
+	}
+	return b.String()
+}
+
+// Print the detection explanation to the console
+func (r *Runner) printDetectionExplanation(folders []string) {
+	fmt.Println("::group::Auto-detection explanation")
+	fmt.Print(formatDetectionExplanation(r.explanations, folders))
+	fmt.Println("::endgroup::")
+}
+
+// Format the detection explanation as a collapsed section for the summary comment
+func (r *Runner) formatDetectionSection(folders []string) string {
+	if len(r.explanations) == 0 {
+		return ""
+	}
+	return "\n<details><summary><b>Auto-detection explanation</b></summary>\n\n```\n" +
+		formatDetectionExplanation(r.explanations, folders) + "```\n</details>\n"
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceTerragruntDirectory(t *testing.T) {
+	root := t.TempDir()
+	unit := filepath.Join(root, "live", "app")
+	if err := os.MkdirAll(filepath.Join(unit, "policies", "iam"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(unit, "terragrunt.hcl"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}, MaxWalkUpLevels: 3})
+	dir, checked, reason := r.traceTerragruntDirectory(filepath.Join(unit, "policies", "iam", "base.json"))
+	if dir != unit || reason != "" {
+		t.Errorf("traceTerragruntDirectory() = %q, %q, want %q", dir, reason, unit)
+	}
+	expected := []string{filepath.Join(unit, "policies", "iam"), filepath.Join(unit, "policies"), unit}
+	if !reflect.DeepEqual(checked, expected) {
+		t.Errorf("traceTerragruntDirectory() checked = %v, want %v", checked, expected)
+	}
+
+	r.config.MaxWalkUpLevels = 1
+	dir, _, reason = r.traceTerragruntDirectory(filepath.Join(unit, "policies", "iam", "base.json"))
+	if dir != "" || !strings.Contains(reason, "max-walk-up (1 levels)") {
+		t.Errorf("traceTerragruntDirectory() = %q, %q, want max-walk-up failure", dir, reason)
+	}
+}
+
+func TestTraceTerragruntDirectoryMultipleFiles(t *testing.T) {
+	root := t.TempDir()
+	unit := filepath.Join(root, "live", "app")
+	if err := os.MkdirAll(unit, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(unit, "terragrunt.hcl.json"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl", "terragrunt.hcl.json"}, MaxWalkUpLevels: 3})
+	if dir, _, reason := r.traceTerragruntDirectory(filepath.Join(unit, "inputs.json")); dir != unit {
+		t.Errorf("traceTerragruntDirectory() = %q, %q, want %q", dir, reason, unit)
+	}
+	if got := r.unitFile(unit); got != "terragrunt.hcl.json" {
+		t.Errorf("unitFile() = %q, want terragrunt.hcl.json", got)
+	}
+
+	r.config.TerragruntFiles = []string{"terragrunt.hcl"}
+	if dir, _, _ := r.traceTerragruntDirectory(filepath.Join(unit, "inputs.json")); dir != "" {
+		t.Errorf("traceTerragruntDirectory() = %q, want no folder", dir)
+	}
+}
+
+func TestFormatDetectionExplanation(t *testing.T) {
+	explanations := []DetectionExplanation{
+		{File: "README.md", Reason: "no file pattern matched"},
+		{File: "live/app/terragrunt.hcl", Pattern: "*.hcl", Checked: []string{"live/app"}, Folder: "live/app"},
+	}
+
+	got := formatDetectionExplanation(explanations, []string{"live/app"})
+	for _, want := range []string{
+		"README.md\n  ✗ no file pattern matched\n",
+		"  pattern: *.hcl\n  checked: live/app\n  ✓ folder: live/app\n",
+		"Final folders (1):\n  live/app\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatDetectionExplanation() missing %q:\n%s", want, got)
+		}
+	}
+}
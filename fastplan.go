@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Get the addresses of the resources a prior plan changes, from either plan
+// text or the JSON of terraform show -json
+func parsePriorPlanTargets(plan string) []string {
+	var addresses []string
+	if trimmed := strings.TrimSpace(plan); strings.HasPrefix(trimmed, "{") {
+		var parsed struct {
+			ResourceChanges []struct {
+				Address string `json:"address"`
+				Change  struct {
+					Actions []string `json:"actions"`
+				} `json:"change"`
+			} `json:"resource_changes"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+			for _, rc := range parsed.ResourceChanges {
+				if rc.Address != "" && slices.ContainsFunc(rc.Change.Actions, func(a string) bool { return a != "no-op" && a != "read" }) {
+					addresses = append(addresses, rc.Address)
+				}
+			}
+			sort.Strings(addresses)
+			return addresses
+		}
+	}
+	for address := range parsePlannedActions(plan) {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// Collect the resources changed per folder in the plans embedded in earlier PR
+// comments; the most recent plan of a folder wins
+func (r *Runner) loadPriorPlanTargets(ctx context.Context, client *github.Client) map[string][]string {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+
+	targets := make(map[string][]string)
+	comments, err := r.listTargetComments(ctx, client, owner, repo)
+	if err != nil {
+		r.logger.Warn("Failed to list comments for prior plans, fast plan will not target resources", "error", err)
+		return targets
+	}
+	for _, comment := range comments {
+		folder, plan, ok, err := decodeEmbeddedPlan(comment.Body)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			r.logger.Warn("Ignoring unreadable embedded plan", "comment", comment.ID, "error", err)
+			continue
+		}
+		targets[filepath.Clean(folder)] = parsePriorPlanTargets(plan)
+	}
+	r.logger.Info("Loaded prior plans for fast plan", "folders", len(targets))
+	return targets
+}
+
+// Build the extra plan arguments of a fast plan in a folder: no refresh, and
+// targets limited to the resources changed in the folder's prior plan if known
+func (r *Runner) fastPlanArgs(folder string) []string {
+	if !r.config.FastPlan {
+		return nil
+	}
+	args := []string{"-refresh=false"}
+	for _, address := range r.priorTargets[filepath.Clean(folder)] {
+		args = append(args, "-target="+address)
+	}
+	return args
+}
+
+// Format the note labeling a fast plan result as approximate
+func (r *Runner) formatFastPlanNote(folder string) string {
+	if !r.config.FastPlan {
+		return ""
+	}
+	note := "⚡ **Fast plan:** approximate, planned with `-refresh=false`"
+	if n := len(r.priorTargets[filepath.Clean(folder)]); n > 0 {
+		note += fmt.Sprintf(" and targeted to the %d resources changed in the previous plan", n)
+	}
+	return note + ". Drift and changes to other resources are not shown; run a full plan before applying.\n"
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePriorPlanTargets(t *testing.T) {
+	text := `  # aws_instance.web must be replaced
+-/+ resource "aws_instance" "web" {}
+
+  # module.db.aws_db_instance.main will be updated in-place
+  ~ resource "aws_db_instance" "main" {}
+
+Plan: 1 to add, 1 to change, 1 to destroy.`
+	want := []string{"aws_instance.web", "module.db.aws_db_instance.main"}
+	if got := parsePriorPlanTargets(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePriorPlanTargets() text = %v, want %v", got, want)
+	}
+
+	showJSON := `{"format_version": "1.2", "resource_changes": [
+		{"address": "aws_s3_bucket.logs", "change": {"actions": ["update"]}},
+		{"address": "aws_iam_role.app", "change": {"actions": ["no-op"]}},
+		{"address": "data.aws_caller_identity.current", "change": {"actions": ["read"]}},
+		{"address": "aws_instance.web", "change": {"actions": ["delete", "create"]}}
+	]}`
+	want = []string{"aws_instance.web", "aws_s3_bucket.logs"}
+	if got := parsePriorPlanTargets(showJSON); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePriorPlanTargets() json = %v, want %v", got, want)
+	}
+
+	if got := parsePriorPlanTargets("No changes. Your infrastructure matches the configuration."); len(got) != 0 {
+		t.Errorf("parsePriorPlanTargets() no changes = %v, want none", got)
+	}
+}
+
+func TestFastPlanArgs(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	if got := r.fastPlanArgs("live/app"); got != nil {
+		t.Errorf("fastPlanArgs() disabled = %v, want nil", got)
+	}
+
+	r.config.FastPlan = true
+	r.priorTargets = map[string][]string{"live/app": {"aws_instance.web", "aws_s3_bucket.logs"}}
+	want := []string{"-refresh=false", "-target=aws_instance.web", "-target=aws_s3_bucket.logs"}
+	if got := r.fastPlanArgs("live/app/"); !reflect.DeepEqual(got, want) {
+		t.Errorf("fastPlanArgs() = %v, want %v", got, want)
+	}
+	if got := r.fastPlanArgs("live/other"); !reflect.DeepEqual(got, []string{"-refresh=false"}) {
+		t.Errorf("fastPlanArgs() without prior plan = %v, want refresh only", got)
+	}
+
+	if note := r.formatFastPlanNote("live/app"); !strings.Contains(note, "targeted to the 2 resources changed in the previous plan") {
+		t.Errorf("formatFastPlanNote() = %q", note)
+	}
+}
+
+func TestLoadPriorPlanTargets(t *testing.T) {
+	older, _ := encodeEmbeddedPlan("live/app", "  # aws_instance.old will be created")
+	newer, _ := encodeEmbeddedPlan("live/app", "  # aws_instance.web will be created")
+	other, _ := encodeEmbeddedPlan("live/db", "  # aws_db_instance.main will be destroyed")
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"id": 1, "body": "## plan\n" + older},
+			{"id": 2, "body": "## summary"},
+			{"id": 3, "body": "## plan\n" + newer},
+			{"id": 4, "body": "## plan\n" + other},
+		})
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, Command: "plan", FastPlan: true})
+	got := r.loadPriorPlanTargets(context.Background(), client)
+	want := map[string][]string{"live/app": {"aws_instance.web"}, "live/db": {"aws_db_instance.main"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPriorPlanTargets() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateConfigFastPlan(t *testing.T) {
+	base := Config{GithubToken: "t", Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, FastPlan: true}
+	tests := []struct {
+		command string
+		denied  []string
+		wantErr string
+	}{
+		{"plan", nil, ""},
+		{"apply", nil, "requires a plan command"},
+		{"plan", []string{"-target"}, "conflicts with denied-args"},
+		{"plan", []string{"-refresh=false"}, "conflicts with denied-args"},
+	}
+	for _, tt := range tests {
+		config := base
+		config.Command, config.DeniedArgs = tt.command, tt.denied
+		err := newTestRunner(&config).validateConfig()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("validateConfig() %s error = %v", tt.command, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("validateConfig() %s %v error = %v, want %q", tt.command, tt.denied, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Collect the folders given inline, in a file (- for stdin) and base64
+// encoded, for folder lists too long for an action input or environment
+// variable. Each source uses the separators of --folders.
+func loadFolderList(inline, file, encoded string, stdin io.Reader) ([]string, error) {
+	folders := parseFolders(inline)
+	if file != "" {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read folders-file: %w", err)
+		}
+		folders = append(folders, parseFolders(string(data))...)
+	}
+	if encoded = strings.Join(strings.Fields(encoded), ""); encoded != "" {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			// Also accept unpadded input
+			if data, err = base64.RawStdEncoding.DecodeString(encoded); err != nil {
+				return nil, fmt.Errorf("invalid folders-b64: %w", err)
+			}
+		}
+		folders = append(folders, parseFolders(string(data))...)
+	}
+	return folders, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFolderList(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "folders.txt")
+	os.WriteFile(file, []byte("live/a\r\nlive/b, live/c\n\n"), 0o644)
+	encoded := base64.StdEncoding.EncodeToString([]byte("live/d\nlive/e"))
+
+	tests := []struct {
+		name    string
+		inline  string
+		file    string
+		encoded string
+		stdin   string
+		want    []string
+		wantErr string
+	}{
+		{name: "inline only", inline: "live/x,live/y", want: []string{"live/x", "live/y"}},
+		{name: "file", inline: "live/x", file: file, want: []string{"live/x", "live/a", "live/b", "live/c"}},
+		{name: "stdin", file: "-", stdin: "live/s1 live/s2", want: []string{"live/s1", "live/s2"}},
+		{name: "base64", encoded: encoded, want: []string{"live/d", "live/e"}},
+		{name: "base64 wrapped and unpadded", encoded: strings.TrimRight(encoded[:8]+"\n"+encoded[8:], "="), want: []string{"live/d", "live/e"}},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: "failed to read folders-file"},
+		{name: "invalid base64", encoded: "not base64!", wantErr: "invalid folders-b64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadFolderList(tt.inline, tt.file, tt.encoded, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadFolderList() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadFolderList() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadFolderList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load display names of folders from a JSON object or a flat YAML mapping of
+// folder paths to names (no names if path is empty)
+func loadFolderNames(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]string{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid folder names file %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if entries, err = parseFlatYAML(string(data)); err != nil {
+			return nil, fmt.Errorf("invalid folder names file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid folder names file %s: must be .json, .yaml or .yml", path)
+	}
+
+	names := make(map[string]string, len(entries))
+	for folder, name := range entries {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid folder names file %s: empty name for %q", path, folder)
+		}
+		names[filepath.Clean(folder)] = strings.TrimSpace(name)
+	}
+	return names, nil
+}
+
+// Get the name a folder is shown with in comments and the summary: its
+// display name, or the folder path itself
+func (r *Runner) displayName(folder string) string {
+	if name, ok := r.folderNames[filepath.Clean(folder)]; ok {
+		return name
+	}
+	return folder
+}
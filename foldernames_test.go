@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFolderNames(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"names.yaml":  "live/prod/eu-west-1/payments-vpc/: Payments VPC (prod/eu-west-1)\n# comment\nlive/dev/app: \"App (dev)\"\n",
+		"names.json":  `{"live/prod/app": "App (prod)"}`,
+		"empty.yaml":  "live/app: ''\n",
+		"names.toml":  "",
+		"broken.json": "{",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file     string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{file: "", expected: nil},
+		{file: "names.yaml", expected: map[string]string{"live/prod/eu-west-1/payments-vpc": "Payments VPC (prod/eu-west-1)", "live/dev/app": "App (dev)"}},
+		{file: "names.json", expected: map[string]string{"live/prod/app": "App (prod)"}},
+		{file: "empty.yaml", wantErr: true},
+		{file: "names.toml", wantErr: true},
+		{file: "broken.json", wantErr: true},
+		{file: "missing.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		path := tt.file
+		if path != "" {
+			path = filepath.Join(dir, tt.file)
+		}
+		got, err := loadFolderNames(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadFolderNames(%q) error = %v, wantErr %v", tt.file, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("loadFolderNames(%q) = %v, want %v", tt.file, got, tt.expected)
+		}
+	}
+}
+
+func TestDisplayNameInComments(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	r.folderNames = map[string]string{"live/prod/vpc": "VPC | prod"}
+
+	if got := r.displayName("live/prod/vpc/"); got != "VPC | prod" {
+		t.Errorf("displayName() = %q, want the display name", got)
+	}
+	if got := r.displayName("live/dev/vpc"); got != "live/dev/vpc" {
+		t.Errorf("displayName() without a name = %q, want the folder", got)
+	}
+
+	result := ExecutionResult{Folder: "live/prod/vpc", Success: true}
+	header := r.formatCommentHeader(result)
+	if !strings.Contains(header, ": VPC | prod\n") || !strings.Contains(header, "**Folder:** live/prod/vpc\n") {
+		t.Errorf("formatCommentHeader() = %q, want the display name and the folder path", header)
+	}
+	if header := r.formatCommentHeaderWithPart(result, 1, 2); !strings.Contains(header, ": VPC | prod (1/2)\n") {
+		t.Errorf("formatCommentHeaderWithPart() = %q, want the part after the display name", header)
+	}
+	if header := r.formatCommentHeader(ExecutionResult{Folder: "live/dev/vpc", Success: true}); strings.Contains(header, "**Folder:**") {
+		t.Errorf("formatCommentHeader() without a name = %q, want no folder line", header)
+	}
+	if summary := r.formatSummary([]ExecutionResult{result}); !strings.Contains(summary, "| VPC \\| prod |") {
+		t.Errorf("formatSummary() = %q, want the escaped display name", summary)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Secret stores folder secrets are read from
+const (
+	SecretSourceVault          = "vault"          // HashiCorp Vault KV, read with the vault CLI
+	SecretSourceSSM            = "ssm"            // AWS SSM Parameter Store, read with the aws CLI
+	SecretSourceSecretsManager = "secretsmanager" // AWS Secrets Manager, read with the aws CLI
+)
+
+// Secret fetched just before a folder runs and injected into its environment
+type FolderSecret struct {
+	Folder string `json:"folder"`        // Folder glob, also matching the folders below it
+	Name   string `json:"name"`          // Environment variable, e.g. TF_VAR_db_password
+	Source string `json:"source"`        // vault, ssm or secretsmanager
+	Path   string `json:"path"`          // Secret path, parameter name or secret ID
+	Key    string `json:"key,omitempty"` // Field of a Vault secret or of a JSON Secrets Manager secret
+}
+
+// Load the folder secrets declared in a JSON file (none if path is empty)
+func loadFolderSecrets(path string, denylist []string) ([]FolderSecret, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var secrets []FolderSecret
+	if err := decoder.Decode(&secrets); err != nil {
+		return nil, fmt.Errorf("invalid folder secrets file %s: %w", path, err)
+	}
+	for i, s := range secrets {
+		switch {
+		case s.Folder == "" || s.Path == "":
+			return nil, fmt.Errorf("invalid folder secrets file %s: entry %d needs a folder and a path", path, i+1)
+		case !reEnvName.MatchString(s.Name) || envNameMatches(s.Name, denylist):
+			return nil, fmt.Errorf("invalid folder secrets file %s: %q is not a valid or allowed variable name", path, s.Name)
+		case s.Source != SecretSourceVault && s.Source != SecretSourceSSM && s.Source != SecretSourceSecretsManager:
+			return nil, fmt.Errorf("invalid folder secrets file %s: invalid source %q for %s (expected vault, ssm or secretsmanager)", path, s.Source, s.Name)
+		case s.Source == SecretSourceVault && s.Key == "":
+			return nil, fmt.Errorf("invalid folder secrets file %s: vault secret %s needs a key", path, s.Name)
+		}
+		secrets[i].Folder = strings.Trim(filepath.ToSlash(s.Folder), "/")
+	}
+	return secrets, nil
+}
+
+// Build the CLI command reading a secret
+func secretCommand(s FolderSecret) []string {
+	switch s.Source {
+	case SecretSourceVault:
+		return []string{"vault", "kv", "get", "-field=" + s.Key, s.Path}
+	case SecretSourceSSM:
+		return []string{"aws", "ssm", "get-parameter", "--with-decryption", "--name", s.Path, "--query", "Parameter.Value", "--output", "text"}
+	default:
+		return []string{"aws", "secretsmanager", "get-secret-value", "--secret-id", s.Path, "--query", "SecretString", "--output", "text"}
+	}
+}
+
+// Read the value of a secret from its store. The CLIs get the whole host
+// environment, which holds the credentials of the store.
+func (r *Runner) fetchSecret(s FolderSecret) (string, error) {
+	argv := secretCommand(s)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := r.runCommand(cmd); err != nil {
+		return "", fmt.Errorf("failed to read secret %s from %s %s: %w: %s", s.Name, s.Source, s.Path, err, firstLine(stderr.String()))
+	}
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	if s.Source == SecretSourceSecretsManager && s.Key != "" {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return "", fmt.Errorf("secret %s from secretsmanager %s is not a JSON object", s.Name, s.Path)
+		}
+		field, ok := fields[s.Key].(string)
+		if !ok {
+			return "", fmt.Errorf("secret %s from secretsmanager %s has no string field %s", s.Name, s.Path, s.Key)
+		}
+		value = field
+	}
+	if value == "" {
+		return "", fmt.Errorf("secret %s from %s %s is empty", s.Name, s.Source, s.Path)
+	}
+	return value, nil
+}
+
+// Fetch the secrets of a folder, returning its environment entries and the
+// values to mask. Values are masked in the job log as soon as they are read.
+func (r *Runner) fetchFolderSecrets(folder string) ([]string, []string, error) {
+	var env, values []string
+	for _, s := range r.secrets {
+		if matchingLabelGlob(filepath.ToSlash(folder), []string{s.Folder}) == "" {
+			continue
+		}
+		value, err := r.fetchSecret(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		for line := range strings.SplitSeq(value, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Printf("::add-mask::%s\n", line)
+			}
+		}
+		r.logger.Debug("Injecting folder secret", "folder", folder, "name", s.Name, "source", s.Source)
+		env = append(env, s.Name+"="+value)
+		values = append(values, value)
+	}
+	return env, values, nil
+}
+
+// Replace the secret values in a text with the secret mask
+func redactSecrets(text string, values []string) string {
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, secretMask)
+	}
+	return text
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFolderSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `[{"folder": "/live/prod/*/", "name": "TF_VAR_db_password", "source": "ssm", "path": "/prod/db"}]`, ""},
+		{"vault without key", `[{"folder": "live/*", "name": "TOKEN", "source": "vault", "path": "secret/app"}]`, "needs a key"},
+		{"unknown source", `[{"folder": "live/*", "name": "TOKEN", "source": "gcp", "path": "app"}]`, "invalid source"},
+		{"invalid name", `[{"folder": "live/*", "name": "DB-PASSWORD", "source": "ssm", "path": "/db"}]`, "not a valid or allowed"},
+		{"denied name", `[{"folder": "live/*", "name": "TF_CLI_ARGS", "source": "ssm", "path": "/db"}]`, "not a valid or allowed"},
+		{"missing path", `[{"folder": "live/*", "name": "TOKEN", "source": "ssm"}]`, "needs a folder and a path"},
+		{"unknown field", `[{"folder": "live/*", "name": "TOKEN", "source": "ssm", "path": "/db", "value": "x"}]`, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.json")
+			os.WriteFile(path, []byte(tt.content), 0o644)
+			secrets, err := loadFolderSecrets(path, []string{"TF_CLI_ARGS*"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(secrets) != 1 || secrets[0].Folder != "live/prod/*" {
+				t.Errorf("secrets = %+v, want one secret for live/prod/*", secrets)
+			}
+		})
+	}
+}
+
+// Fake aws CLI returning an SSM parameter or a JSON Secrets Manager secret
+const fakeSecretsAWSScript = `#!/bin/sh
+case "$1" in
+ssm) echo "s3cr3t-db-password" ;;
+secretsmanager) echo '{"token": "s3cr3t-token"}' ;;
+esac
+`
+
+// Fake terragrunt echoing the injected variables
+const fakeSecretsTerragruntScript = `#!/bin/sh
+echo "password=$TF_VAR_db_password token=$APP_TOKEN"
+`
+
+func TestFolderSecretsInjection(t *testing.T) {
+	scripts := t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "aws"), []byte(fakeSecretsAWSScript), 0o755)
+	os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(fakeSecretsTerragruntScript), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root := t.TempDir()
+	app, db := filepath.Join(root, "app"), filepath.Join(root, "db")
+	os.MkdirAll(app, 0o755)
+	os.MkdirAll(db, 0o755)
+	glob := strings.Trim(filepath.ToSlash(root), "/")
+
+	r := newTestRunner(&Config{Command: "plan", Folders: []string{app, db}})
+	r.secrets = []FolderSecret{
+		{Folder: glob + "/app", Name: "TF_VAR_db_password", Source: SecretSourceSSM, Path: "/prod/db"},
+		{Folder: glob + "/*", Name: "APP_TOKEN", Source: SecretSourceSecretsManager, Path: "prod/app", Key: "token"},
+	}
+
+	result := r.executeTerragruntInFolder(app)
+	if !result.Success {
+		t.Fatalf("app failed: %v", result.Error)
+	}
+	for _, output := range []string{result.Output, result.FullOutput} {
+		if strings.Contains(output, "s3cr3t") {
+			t.Errorf("output leaks a secret: %q", output)
+		}
+		if !strings.Contains(output, "password="+secretMask+" token="+secretMask) {
+			t.Errorf("output = %q, want both secrets injected and masked", output)
+		}
+	}
+
+	result = r.executeTerragruntInFolder(db)
+	if !strings.Contains(result.FullOutput, "password= token="+secretMask) {
+		t.Errorf("db output = %q, want only the token injected", result.FullOutput)
+	}
+
+	r.config.TriageRerun = true
+	if debug := r.triageDebugRerun(ExecutionResult{Folder: app}); !strings.Contains(debug, "password="+secretMask+" token="+secretMask) {
+		t.Errorf("triage rerun output = %q, want both secrets injected and masked", debug)
+	}
+}
+
+func TestValidateConfigFolderSecrets(t *testing.T) {
+	tests := []struct {
+		command     string
+		granularity string
+		wantErr     bool
+	}{
+		{"plan", "", false},
+		{"run --all plan", "", true},
+		{"plan", GranularityStack, true},
+	}
+	for _, tt := range tests {
+		r := newTestRunner(&Config{GithubToken: "t", Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, Command: tt.command, Granularity: tt.granularity, FolderSecrets: "secrets.json"})
+		if err := r.validateConfig(); (err != nil) != tt.wantErr {
+			t.Errorf("validateConfig() with command %q and granularity %q error = %v, wantErr %v", tt.command, tt.granularity, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFolderSecretsFetchFailure(t *testing.T) {
+	scripts := t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "vault"), []byte("#!/bin/sh\necho 'permission denied' >&2\nexit 2\n"), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	r := newTestRunner(&Config{Command: "plan"})
+	r.secrets = []FolderSecret{{Folder: "live/*", Name: "TOKEN", Source: SecretSourceVault, Path: "secret/app", Key: "token"}}
+	_, _, err := r.fetchFolderSecrets("live/app")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("error = %v, want the vault error", err)
+	}
+	if env, _, err := r.fetchFolderSecrets("other/app"); err != nil || env != nil {
+		t.Errorf("unmatched folder got env %v, error %v", env, err)
+	}
+}
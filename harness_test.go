@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files of the end-to-end tests")
+
+// Fake terragrunt: prints the scripted output of the unit it runs in (by
+// directory name) and exits with the unit's scripted code. Each call is logged.
+// Its directory is passed in a TG_ variable, which the subprocess env allows.
+const fakeTerragruntScript = `#!/bin/sh
+unit=$(basename "$PWD")
+echo "$unit $*" >> "$TG_FAKE_TERRAGRUNT_DIR/calls.log"
+if [ -f "$TG_FAKE_TERRAGRUNT_DIR/$unit.out" ]; then cat "$TG_FAKE_TERRAGRUNT_DIR/$unit.out"; fi
+exit "$(cat "$TG_FAKE_TERRAGRUNT_DIR/$unit.exit" 2>/dev/null || echo 0)"
+`
+
+// End-to-end test harness: a git repository of Terragrunt units, a scripted
+// fake terragrunt on PATH and a fake GitHub API recording the comments posted
+type harness struct {
+	t        *testing.T
+	repo     string // Git work tree holding the units (the working directory)
+	scripts  string // Fake terragrunt and the scripted outputs of the units
+	testdata string // Absolute path of testdata
+	base     string // Commit the changes are diffed against
+
+	mu       sync.Mutex
+	comments []string // Bodies of the comments posted, in order
+}
+
+// Set up a harness; the test runs inside the harness repository
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	for _, tool := range []string{"sh", "git"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not available: %v", tool, err)
+		}
+	}
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &harness{t: t, repo: t.TempDir(), scripts: t.TempDir(), testdata: testdata}
+
+	if err := os.WriteFile(filepath.Join(h.scripts, "terragrunt"), []byte(fakeTerragruntScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", h.scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TG_FAKE_TERRAGRUNT_DIR", h.scripts)
+
+	server := httptest.NewServer(http.HandlerFunc(h.serveGitHub))
+	t.Cleanup(server.Close)
+	t.Setenv("GITHUB_API_URL", server.URL)
+
+	// Deterministic workflow environment, outside of any pull request event
+	t.Setenv("GITHUB_RUN_ID", "1000")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(h.scripts, "missing-event.json"))
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(h.scripts, "output"))
+	t.Setenv("GITHUB_STEP_SUMMARY", filepath.Join(h.scripts, "summary.md"))
+
+	t.Chdir(h.repo)
+	h.git("init", "-q")
+	h.git("config", "user.email", "harness@example.com")
+	h.git("config", "user.name", "harness")
+	h.git("commit", "-q", "--allow-empty", "-m", "base")
+	h.base = h.git("rev-parse", "HEAD")
+	return h
+}
+
+// Run git in the harness repository, returning its trimmed output
+func (h *harness) git(args ...string) string {
+	h.t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", h.repo}, args...)...).CombinedOutput()
+	if err != nil {
+		h.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Add a unit whose terragrunt run prints the testdata fixture and exits with
+// the given code. Unit directory names must be unique across the repository.
+func (h *harness) addUnit(folder, fixture string, exitCode int) {
+	h.t.Helper()
+	dir := filepath.Join(h.repo, folder)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte("terraform {\n  source = \"../modules//app\"\n}\n"), 0o644); err != nil {
+		h.t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(h.testdata, "terragrunt", fixture))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	unit := filepath.Base(folder)
+	if err := os.WriteFile(filepath.Join(h.scripts, unit+".out"), output, 0o644); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(h.scripts, unit+".exit"), []byte(fmt.Sprint(exitCode)), 0o644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// Commit the units added so far
+func (h *harness) commit(message string) {
+	h.t.Helper()
+	h.git("add", "-A")
+	h.git("commit", "-q", "-m", message)
+}
+
+// Get the terragrunt invocations, one "unit args" line per call
+func (h *harness) calls() []string {
+	content, err := os.ReadFile(filepath.Join(h.scripts, "calls.log"))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+// Get the action outputs written by the run
+func (h *harness) outputs() map[string]string {
+	content, _ := os.ReadFile(filepath.Join(h.scripts, "output"))
+	outputs := map[string]string{}
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			outputs[name] = value
+		}
+	}
+	return outputs
+}
+
+// Run the runner against the harness with a configuration completed with the
+// GitHub settings of the fake API
+func (h *harness) run(config Config) error {
+	h.t.Helper()
+	config.GithubToken = "test-token"
+	config.Repository = "owner/repo"
+	if config.PullRequest == 0 && config.Target == "" {
+		config.PullRequest = 1
+	}
+	if config.Command == "" {
+		config.Command = "plan"
+	}
+	if len(config.FilePatterns) == 0 {
+		config.FilePatterns = []string{"*.hcl"}
+	}
+	if len(config.TerragruntFiles) == 0 {
+		config.TerragruntFiles = []string{"terragrunt.hcl"}
+	}
+	if config.MaxWalkUpLevels == 0 {
+		config.MaxWalkUpLevels = 3
+	}
+	if config.DetailLevel == "" {
+		config.DetailLevel = DetailStandard
+	}
+	config.DeleteOldComments = true
+	return newTestRunner(&config).run()
+}
+
+// Fake GitHub API: a writable repository whose comments are recorded
+func (h *harness) serveGitHub(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo":
+		w.Write([]byte(`{"full_name": "owner/repo", "permissions": {"push": true, "pull": true}}`))
+	case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/comments"):
+		w.Write([]byte(`[]`))
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/comments"):
+		var comment struct {
+			Body string `json:"body"`
+		}
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &comment)
+		h.mu.Lock()
+		h.comments = append(h.comments, comment.Body)
+		id := len(h.comments)
+		h.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id": %d}`, id)
+	default:
+		h.t.Logf("fake GitHub: unhandled %s %s", req.Method, req.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+// Timings change from run to run
+var reGoldenDuration = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ns|µs|ms|s|m)\b`)
+
+// Compare the posted comments with the golden file, or rewrite it with -update
+func (h *harness) assertGolden(name string) {
+	h.t.Helper()
+	h.mu.Lock()
+	got := strings.Join(h.comments, "\n\n---- next comment ----\n\n") + "\n"
+	h.mu.Unlock()
+	got = reGoldenDuration.ReplaceAllString(got, "<duration>")
+
+	path := filepath.Join(h.testdata, "golden", name+".md")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		h.t.Errorf("comments differ from %s (run with -update to accept):\n%s", path, got)
+	}
+}
+
+func TestEndToEndAutoDetectedPlans(t *testing.T) {
+	h := newHarness(t)
+	h.addUnit("live/prod/app", "terraform-1.9/plan-changes.txt", 0)
+	h.addUnit("live/prod/dns", "opentofu-1.8/plan-changes.txt", 0)
+	h.addUnit("live/prod/vpc", "terraform-1.9/plan-no-changes.txt", 0)
+	h.commit("add units")
+
+	if err := h.run(Config{AutoDetect: true, DiffBase: h.base}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if calls := h.calls(); len(calls) != 3 {
+		t.Errorf("terragrunt calls = %v, want one per changed unit", calls)
+	}
+	outputs := h.outputs()
+	if outputs["success"] != "true" || outputs["total-resources-to-add"] != "3" || outputs["total-resources-to-destroy"] != "2" || outputs["engine"] != "mixed" {
+		t.Errorf("outputs = %v", outputs)
+	}
+	if outputs["changed-folders"] != `["live/prod/app","live/prod/dns"]` || outputs["no-change-folders"] != `["live/prod/vpc"]` {
+		t.Errorf("folder outputs = %s, %s", outputs["changed-folders"], outputs["no-change-folders"])
+	}
+	h.assertGolden("auto-detected-plans")
+}
+
+func TestEndToEndFailedPlan(t *testing.T) {
+	h := newHarness(t)
+	h.addUnit("live/dev/app", "terraform-1.9/plan-changes.txt", 0)
+	h.addUnit("live/dev/rds", "opentofu-1.8/plan-error.txt", 1)
+	h.commit("add units")
+
+	err := h.run(Config{Folders: []string{"live/dev/app", "live/dev/rds"}})
+	if err == nil {
+		t.Fatal("run() succeeded, want the failed plan to fail the run")
+	}
+	if outputs := h.outputs(); outputs["success"] != "false" {
+		t.Errorf("success output = %q, want false", outputs["success"])
+	}
+	h.assertGolden("failed-plan")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var reEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Run description from an external planner (--inputs-from). Set values override
+// the command line flags; env and metadata apply to every folder, folder
+// entries override them key by key.
+type RunInputs struct {
+	Command  string            `json:"command,omitempty"`  // Command of the folders without their own
+	Args     string            `json:"args,omitempty"`     // Terragrunt arguments of the folders without their own
+	Env      map[string]string `json:"env,omitempty"`      // Environment variables of every folder
+	Metadata map[string]string `json:"metadata,omitempty"` // Metadata shown in every folder comment
+	Folders  []FolderInput     `json:"folders"`            // Folders to run, replacing folders and auto-detect
+}
+
+// Folder of a run description
+type FolderInput struct {
+	Folder   string            `json:"folder"`
+	Command  string            `json:"command,omitempty"`
+	Args     string            `json:"args,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Load a run description from a JSON file, or from stdin if path is "-"
+func loadRunInputs(path string, stdin io.Reader) (*RunInputs, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inputs-from %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var inputs RunInputs
+	if err := decoder.Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("invalid inputs-from %s: %w", path, err)
+	}
+	if len(inputs.Folders) == 0 {
+		return nil, fmt.Errorf("invalid inputs-from %s: no folders", path)
+	}
+	return &inputs, nil
+}
+
+// Apply a run description over the configuration, keeping the resolved
+// command, arguments, environment and metadata of each folder
+func (r *Runner) applyRunInputs(inputs *RunInputs) error {
+	if inputs.Command != "" {
+		r.config.Command = inputs.Command
+	}
+	if inputs.Args != "" {
+		r.config.TerragruntArgs = inputs.Args
+	}
+	if isRunAllCommand(r.config.Command) {
+		return fmt.Errorf("invalid inputs-from: command %q runs all units, folders need a single-unit command", r.config.Command)
+	}
+
+	r.config.Folders = nil
+	r.config.AutoDetect = false // The planner already chose the folders
+	r.folderInputs = map[string]FolderInput{}
+	for _, f := range inputs.Folders {
+		if f.Folder == "" {
+			return fmt.Errorf("invalid inputs-from: folder entry without folder")
+		}
+		folder := filepath.Clean(f.Folder)
+		if _, ok := r.folderInputs[folder]; ok {
+			return fmt.Errorf("invalid inputs-from: duplicate folder %s", folder)
+		}
+		if f.Command != "" && (isRunAllCommand(f.Command) || isApplyCommand(f.Command) != isApplyCommand(r.config.Command)) {
+			// Apply gates (approvals, deployments, apply order) are decided by the run command
+			return fmt.Errorf("invalid inputs-from: command %q of %s must be a single-unit command of the same kind as %q", f.Command, folder, r.config.Command)
+		}
+
+		resolved := FolderInput{Folder: folder, Command: r.config.Command, Args: r.config.TerragruntArgs, Env: maps.Clone(inputs.Env), Metadata: maps.Clone(inputs.Metadata)}
+		if f.Command != "" {
+			resolved.Command = f.Command
+		}
+		if f.Args != "" {
+			resolved.Args = f.Args
+		}
+		if resolved.Env == nil {
+			resolved.Env = map[string]string{}
+		}
+		maps.Copy(resolved.Env, f.Env)
+		if resolved.Metadata == nil {
+			resolved.Metadata = map[string]string{}
+		}
+		maps.Copy(resolved.Metadata, f.Metadata)
+		for name := range resolved.Env {
+			if !reEnvName.MatchString(name) || envNameMatches(name, r.config.EnvDenylist) {
+				return fmt.Errorf("invalid inputs-from: env %s of %s is not a valid or allowed variable name", name, folder)
+			}
+		}
+
+		r.folderInputs[folder] = resolved
+		r.config.Folders = append(r.config.Folders, folder)
+	}
+	return nil
+}
+
+// Check whether a command runs all units (run --all, run-all)
+func isRunAllCommand(command string) bool {
+	return strings.Contains(command, "--all") || strings.HasPrefix(command, "run-all")
+}
+
+// Get the command and Terragrunt arguments of a folder
+func (r *Runner) folderCommand(folder string) (string, string) {
+	if input, ok := r.folderInputs[filepath.Clean(folder)]; ok {
+		return input.Command, input.Args
+	}
+	return r.config.Command, r.config.TerragruntArgs
+}
+
+// Get the extra environment entries of a folder, sorted by name
+func (r *Runner) folderEnv(folder string) []string {
+	input := r.folderInputs[filepath.Clean(folder)]
+	var env []string
+	for _, name := range slices.Sorted(maps.Keys(input.Env)) {
+		env = append(env, name+"="+input.Env[name])
+	}
+	return env
+}
+
+// Format the metadata of a folder for its comment header (empty if none)
+func (r *Runner) formatFolderMetadata(folder string) string {
+	input := r.folderInputs[filepath.Clean(folder)]
+	if len(input.Metadata) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, key := range slices.Sorted(maps.Keys(input.Metadata)) {
+		parts = append(parts, fmt.Sprintf("`%s=%s`", key, input.Metadata[key]))
+	}
+	return fmt.Sprintf("**%s:** %s\n", r.msg("comment.metadata"), strings.Join(parts, " "))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRunInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.json")
+	if err := os.WriteFile(path, []byte(`{"command": "plan", "folders": [{"folder": "live/app"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		stdin   string
+		wantErr string
+	}{
+		{name: "file", path: path},
+		{name: "stdin", path: "-", stdin: `{"folders": [{"folder": "live/app", "env": {"TF_VAR_a": "1"}}]}`},
+		{name: "no folders", path: "-", stdin: `{"command": "plan"}`, wantErr: "no folders"},
+		{name: "unknown field", path: "-", stdin: `{"folders": [{"folder": "live/app", "cmd": "plan"}]}`, wantErr: "unknown field"},
+		{name: "missing file", path: filepath.Join(t.TempDir(), "missing.json"), wantErr: "failed to read inputs-from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := loadRunInputs(tt.path, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadRunInputs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadRunInputs() error = %v", err)
+			}
+			if inputs.Folders[0].Folder != "live/app" {
+				t.Errorf("folders = %+v", inputs.Folders)
+			}
+		})
+	}
+}
+
+func TestApplyRunInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  RunInputs
+		wantErr string
+	}{
+		{name: "run all command", inputs: RunInputs{Command: "run --all plan", Folders: []FolderInput{{Folder: "live/app"}}}, wantErr: "runs all units"},
+		{name: "folder without path", inputs: RunInputs{Folders: []FolderInput{{Command: "plan"}}}, wantErr: "without folder"},
+		{name: "duplicate folder", inputs: RunInputs{Folders: []FolderInput{{Folder: "live/app"}, {Folder: "live/app/"}}}, wantErr: "duplicate folder"},
+		{name: "apply in plan run", inputs: RunInputs{Folders: []FolderInput{{Folder: "live/app", Command: "apply"}}}, wantErr: "same kind"},
+		{name: "invalid env name", inputs: RunInputs{Folders: []FolderInput{{Folder: "live/app", Env: map[string]string{"A-B": "1"}}}}, wantErr: "env A-B"},
+		{name: "denied env", inputs: RunInputs{Env: map[string]string{"TF_CLI_ARGS": "-x"}, Folders: []FolderInput{{Folder: "live/app"}}}, wantErr: "env TF_CLI_ARGS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{Command: "plan", EnvDenylist: []string{"TF_CLI_ARGS*"}})
+			if err := r.applyRunInputs(&tt.inputs); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("applyRunInputs() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunInputsPerFolder(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan", TerragruntArgs: "--non-interactive", Folders: []string{"live/old"}, AutoDetect: true})
+	err := r.applyRunInputs(&RunInputs{
+		Env:      map[string]string{"TF_VAR_region": "eu-west-1", "TF_VAR_zone": "a"},
+		Metadata: map[string]string{"planner": "graph"},
+		Folders: []FolderInput{
+			{Folder: "live/app/", Args: "-lock-timeout=5m", Metadata: map[string]string{"owner": "team-app"}},
+			{Folder: "live/dns", Command: "plan -refresh=false", Env: map[string]string{"TF_VAR_zone": "b"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("applyRunInputs() error = %v", err)
+	}
+	if r.config.AutoDetect || !reflect.DeepEqual(r.config.Folders, []string{"live/app", "live/dns"}) {
+		t.Errorf("folders = %v (auto-detect %v), want the planner folders only", r.config.Folders, r.config.AutoDetect)
+	}
+
+	if command, args := r.folderCommand("live/app"); command != "plan" || args != "-lock-timeout=5m" {
+		t.Errorf("folderCommand(live/app) = %q, %q", command, args)
+	}
+	if command, args := r.folderCommand("live/dns"); command != "plan -refresh=false" || args != "--non-interactive" {
+		t.Errorf("folderCommand(live/dns) = %q, %q", command, args)
+	}
+	if command, _ := r.folderCommand("live/other"); command != "plan" {
+		t.Errorf("folderCommand(live/other) = %q, want the run command", command)
+	}
+
+	if got, want := r.folderEnv("live/dns"), []string{"TF_VAR_region=eu-west-1", "TF_VAR_zone=b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("folderEnv(live/dns) = %v, want %v", got, want)
+	}
+	if got := r.formatFolderMetadata("live/app"); got != "**Metadata:** `owner=team-app` `planner=graph`\n" {
+		t.Errorf("formatFolderMetadata(live/app) = %q", got)
+	}
+	if got := r.formatFolderMetadata("live/other"); got != "" {
+		t.Errorf("formatFolderMetadata(live/other) = %q, want empty", got)
+	}
+}
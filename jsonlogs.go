@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Terragrunt log formats selectable with --log-format
+const (
+	LogFormatAuto = "auto" // JSON logs when the installed Terragrunt supports them
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+var logFormats = []string{LogFormatAuto, LogFormatJSON, LogFormatText}
+
+// First Terragrunt release with the --log-format flag
+const minJSONLogsMinor = 73
+
+var reTerragruntVersion = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`)
+
+// Single entry of Terragrunt's JSON log stream
+type jsonLogEntry struct {
+	Prefix string `json:"prefix"` // Unit path the entry belongs to (empty for run-level entries)
+	Msg    string `json:"msg"`
+}
+
+// Check whether run --all should use Terragrunt's JSON log format
+func (r *Runner) useJSONLogs() bool {
+	switch r.config.LogFormat {
+	case LogFormatJSON:
+		return true
+	case LogFormatAuto:
+		// Respect a log format chosen explicitly through --args. Output forwarded
+		// with --tf-forward-stdout bypasses the log entries, so it is parsed as text.
+		if hasArgFlag(r.config.TerragruntArgs, "--log-format") || hasArgFlag(r.config.TerragruntArgs, "--tf-forward-stdout") {
+			return false
+		}
+		return terragruntSupportsJSONLogs(r.installedTerragruntVersion())
+	}
+	return false
+}
+
+// Check whether a whitespace separated argument string contains the flag
+func hasArgFlag(args, flag string) bool {
+	for _, f := range strings.Fields(args) {
+		if f == flag || strings.HasPrefix(f, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the version reported by the installed Terragrunt (empty if unavailable)
+func (r *Runner) installedTerragruntVersion() string {
+	out, err := r.commandOutput(exec.Command("terragrunt", "--version"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Check whether the Terragrunt version supports --log-format json
+func terragruntSupportsJSONLogs(version string) bool {
+	m := reTerragruntVersion.FindStringSubmatch(version)
+	if m == nil {
+		return false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return major > 0 || minor >= minJSONLogsMinor
+}
+
+// Parse a line of the JSON log stream (false for lines that are not log entries,
+// such as the run summary)
+func parseJSONLogLine(line string) (jsonLogEntry, bool) {
+	var entry jsonLogEntry
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &entry) != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// Normalize a unit prefix from the JSON log stream to a relative unit path
+func jsonLogUnit(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return filepath.Clean(strings.TrimPrefix(prefix, "./"))
+}
+
+// Split Terragrunt JSON log output by unit using each entry's prefix field.
+// Terraform output is wrapped in entries of its unit too, so units running in
+// parallel interleave safely. Run-level entries and lines outside entries are
+// gathered under the "_summary" entry like splitOutputByModule does.
+func splitJSONOutputByModule(output string) map[string]string {
+	moduleOutputs := make(map[string][]string)
+	var unmatchedLines []string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if entry, ok := parseJSONLogLine(line); ok {
+			if unit := jsonLogUnit(entry.Prefix); unit != "" {
+				moduleOutputs[unit] = append(moduleOutputs[unit], entry.Msg)
+				continue
+			}
+			line = entry.Msg
+		}
+		unmatchedLines = append(unmatchedLines, line)
+	}
+
+	result := make(map[string]string)
+	for mod, lines := range moduleOutputs {
+		result[mod] = strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+	if unmatchedText := strings.TrimSpace(strings.Join(unmatchedLines, "\n")); unmatchedText != "" {
+		result["_summary"] = unmatchedText
+	}
+	return result
+}
+
+// Render Terragrunt JSON log output as plain text, keeping only the messages
+func jsonLogText(output string) string {
+	var b strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if entry, ok := parseJSONLogLine(line); ok {
+			line = entry.Msg
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerragruntSupportsJSONLogs(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"terragrunt version v0.88.1", true},
+		{"terragrunt version v0.73.0", true},
+		{"terragrunt version v0.67.4", false},
+		{"terragrunt version v1.0.0", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := terragruntSupportsJSONLogs(tt.version); got != tt.want {
+			t.Errorf("terragruntSupportsJSONLogs(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestSplitJSONOutputByModule(t *testing.T) {
+	output := `{"time":"2025-01-01T00:00:00Z","level":"info","prefix":"./account1/baseline","msg":"Initializing the backend..."}
+{"time":"2025-01-01T00:00:01Z","level":"info","prefix":"account2/baseline","msg":"Initializing the backend..."}
+{"time":"2025-01-01T00:00:02Z","level":"stdout","prefix":"account2/baseline","tf-path":"tofu","msg":"Plan: 1 to add, 0 to change, 0 to destroy."}
+{"time":"2025-01-01T00:00:02Z","level":"error","prefix":"./account1/baseline","msg":"Error: Invalid reference"}
+{"time":"2025-01-01T00:00:02Z","level":"stdout","prefix":"account2/baseline","tf-path":"tofu","msg":"Saved the plan to: tfplan"}
+{"time":"2025-01-01T00:00:03Z","level":"info","msg":"Units that will be run"}
+
+❯❯ Run Summary  2 units  24s
+   Failed       1`
+
+	got := splitJSONOutputByModule(output)
+	expected := map[string]string{
+		"account1/baseline": "Initializing the backend...\nError: Invalid reference",
+		"account2/baseline": "Initializing the backend...\nPlan: 1 to add, 0 to change, 0 to destroy.\nSaved the plan to: tfplan",
+		"_summary":          "Units that will be run\n\n❯❯ Run Summary  2 units  24s\n   Failed       1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitJSONOutputByModule() = %#v, want %#v", got, expected)
+	}
+
+	if summary := parseRunSummary(jsonLogText(output)); summary == nil || summary.Failed != 1 {
+		t.Errorf("parseRunSummary(jsonLogText()) = %+v, want 1 failure", summary)
+	}
+}
+
+func TestJSONLogsWithForwardedOutput(t *testing.T) {
+	r := newTestRunner(&Config{LogFormat: LogFormatAuto, TerragruntArgs: "--non-interactive --tf-forward-stdout"})
+	if r.useJSONLogs() {
+		t.Error("useJSONLogs() = true, want text parsing of forwarded output")
+	}
+
+	r = newTestRunner(&Config{GithubToken: "t", Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, Command: "run --all plan",
+		LogFormat: LogFormatJSON, TerragruntArgs: "--tf-forward-stdout"})
+	if err := r.validateConfig(); err == nil || !strings.Contains(err.Error(), "--tf-forward-stdout") {
+		t.Errorf("validateConfig() error = %v, want log-format json rejected with forwarded output", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Prefixes of the pull request labels filtering the folders
+const (
+	labelSkipPrefix = "skip-tf:" // Leave out the matching folders
+	labelOnlyPrefix = "tf-only:" // Run only the matching folders
+)
+
+// Folder globs of the filter labels of a pull request
+type labelFilters struct {
+	skip []string
+	only []string
+}
+
+// Extract the folder globs of the filter labels
+func parseLabelFilters(labels []string) labelFilters {
+	var filters labelFilters
+	for _, label := range labels {
+		if glob, ok := strings.CutPrefix(label, labelSkipPrefix); ok && strings.TrimSpace(glob) != "" {
+			filters.skip = append(filters.skip, strings.Trim(strings.TrimSpace(glob), "/"))
+		} else if glob, ok := strings.CutPrefix(label, labelOnlyPrefix); ok && strings.TrimSpace(glob) != "" {
+			filters.only = append(filters.only, strings.Trim(strings.TrimSpace(glob), "/"))
+		}
+	}
+	return filters
+}
+
+// Get the first glob matching the folder or one of its parent directories, so
+// "live/dev/*" also covers "live/dev/app/eu-west-1"
+func matchingLabelGlob(folder string, globs []string) string {
+	folder = strings.Trim(path.Clean(folder), "/")
+	for _, glob := range globs {
+		for dir := folder; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+			if ok, _ := path.Match(glob, dir); ok {
+				return glob
+			}
+		}
+	}
+	return ""
+}
+
+// Apply the filters to the folders, returning the kept folders and the skipped
+// ones with the label that left them out
+func (f labelFilters) apply(folders []string) ([]string, []SkippedUnit) {
+	var kept []string
+	var skipped []SkippedUnit
+	for _, folder := range folders {
+		if len(f.only) > 0 && matchingLabelGlob(folder, f.only) == "" {
+			skipped = append(skipped, SkippedUnit{Folder: folder, Reason: "not matched by any " + labelOnlyPrefix + " label"})
+			continue
+		}
+		if glob := matchingLabelGlob(folder, f.skip); glob != "" {
+			skipped = append(skipped, SkippedUnit{Folder: folder, Reason: "label " + labelSkipPrefix + glob})
+			continue
+		}
+		kept = append(kept, folder)
+	}
+	return kept, skipped
+}
+
+// Get the labels of the pull request
+func (r *Runner) getPRLabels(ctx context.Context, client *github.Client) ([]string, error) {
+	parts := strings.Split(r.config.Repository, "/")
+	var names []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		labels, resp, err := client.Issues.ListLabelsByIssue(ctx, parts[0], parts[1], r.config.PullRequest, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			names = append(names, label.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Filter the folders with the skip-tf: and tf-only: labels of the pull request,
+// recording the folders left out for the summary. Labels that cannot be read
+// leave the folders unfiltered.
+func (r *Runner) filterByLabels(ctx context.Context, client *github.Client, folders []string) []string {
+	isPR := r.config.Target == "" || r.config.Target == TargetPR
+	if client == nil || !isPR || r.config.PullRequest <= 0 {
+		return folders
+	}
+	labels, err := r.getPRLabels(ctx, client)
+	if err != nil {
+		r.logger.Warn("Failed to read pull request labels, folders are not filtered", "error", err)
+		return folders
+	}
+	kept, skipped := parseLabelFilters(labels).apply(folders)
+	for _, unit := range skipped {
+		r.logger.Info("Skipping folder filtered by pull request labels", "folder", unit.Folder, "reason", unit.Reason)
+	}
+	r.labelSkipped = append(r.labelSkipped, skipped...)
+	return kept
+}
+
+// Format the folders left out by pull request labels for the summary comment
+func (r *Runner) formatLabelSkipped() string {
+	if len(r.labelSkipped) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n**" + r.msg("summary.label_skipped", "count", len(r.labelSkipped)) + "**\n\n")
+	for _, unit := range r.labelSkipped {
+		b.WriteString(fmt.Sprintf("- `%s`: %s\n", unit.Folder, unit.Reason))
+	}
+	return b.String()
+}
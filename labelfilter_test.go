@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLabelFiltersApply(t *testing.T) {
+	folders := []string{"live/dev/app", "live/dev/db/eu-west-1", "live/prod/vpc", "live/prod/app"}
+	tests := []struct {
+		name        string
+		labels      []string
+		wantKept    []string
+		wantSkipped []string
+	}{
+		{name: "no filter labels", labels: []string{"bug", "needs-review"}, wantKept: folders},
+		{name: "skip glob covers nested folders", labels: []string{"skip-tf:live/dev/*"}, wantKept: []string{"live/prod/vpc", "live/prod/app"}, wantSkipped: []string{"live/dev/app", "live/dev/db/eu-west-1"}},
+		{name: "only folder", labels: []string{"tf-only:live/prod/vpc"}, wantKept: []string{"live/prod/vpc"}, wantSkipped: []string{"live/dev/app", "live/dev/db/eu-west-1", "live/prod/app"}},
+		{name: "only parent directory", labels: []string{"tf-only: live/prod/ "}, wantKept: []string{"live/prod/vpc", "live/prod/app"}, wantSkipped: []string{"live/dev/app", "live/dev/db/eu-west-1"}},
+		{name: "only then skip", labels: []string{"tf-only:live/prod/*", "skip-tf:live/*/app"}, wantKept: []string{"live/prod/vpc"}, wantSkipped: []string{"live/dev/app", "live/dev/db/eu-west-1", "live/prod/app"}},
+		{name: "empty glob ignored", labels: []string{"skip-tf:"}, wantKept: folders},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, skipped := parseLabelFilters(tt.labels).apply(folders)
+			var skippedFolders []string
+			for _, unit := range skipped {
+				skippedFolders = append(skippedFolders, unit.Folder)
+			}
+			if !reflect.DeepEqual(kept, tt.wantKept) || !reflect.DeepEqual(skippedFolders, tt.wantSkipped) {
+				t.Errorf("apply() = %v, skipped %v, want %v, skipped %v", kept, skippedFolders, tt.wantKept, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFilterByLabels(t *testing.T) {
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet || req.URL.Path != "/repos/owner/repo/issues/7/labels" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[{"name": "skip-tf:live/dev/*"}, {"name": "infra"}]`))
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 7})
+	kept := r.filterByLabels(context.Background(), client, []string{"live/dev/app", "live/prod/app"})
+	if !reflect.DeepEqual(kept, []string{"live/prod/app"}) {
+		t.Errorf("filterByLabels() = %v", kept)
+	}
+	if summary := r.formatLabelSkipped(); !strings.Contains(summary, "Skipped by PR labels: 1 folders") || !strings.Contains(summary, "- `live/dev/app`: label skip-tf:live/dev/*") {
+		t.Errorf("formatLabelSkipped() = %q", summary)
+	}
+
+	// Commit and issue targets have no pull request labels
+	r = newTestRunner(&Config{Repository: "owner/repo", Target: TargetIssue, TargetID: "42"})
+	if kept := r.filterByLabels(context.Background(), client, []string{"live/dev/app"}); len(kept) != 1 {
+		t.Errorf("filterByLabels() on an issue target = %v, want unfiltered", kept)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Lock file fix modes
+const (
+	LockfileFixOff     = "off"
+	LockfileFixComment = "comment"
+	LockfileFixCommit  = "commit"
+)
+
+var lockfileFixModes = []string{LockfileFixOff, LockfileFixComment, LockfileFixCommit}
+
+const (
+	lockfileName         = ".terraform.lock.hcl" // Dependency lock file written next to the unit
+	lockfileMarkerFolder = "_lockfile"           // Marker folder key of the lock file comment
+)
+
+// Errors reported by Terraform/OpenTofu when the dependency lock file does not
+// match the providers required or the checksums of the current platform
+var reLockfileMismatch = regexp.MustCompile(`(?i)(doesn't|does not) match any of the checksums (previously )?recorded in the dependency lock file` +
+	`|inconsistent dependency lock file` +
+	`|locked provider \S+ \S+ does not match configured version constraint` +
+	`|provider \S+ is not present in the dependency lock file` +
+	`|required by this configuration but no version is selected`)
+
+// Outcome of regenerating the lock file of a folder
+type LockfileFix struct {
+	Folder    string // Folder whose lock file mismatched
+	Path      string // Lock file path relative to the repo root
+	Diff      string // Required lock file changes (unified diff)
+	Committed bool   // Whether the change was pushed to the PR branch
+	Error     string // Why the lock file could not be fixed (empty on success)
+}
+
+// Check whether an output shows a provider lock file mismatch
+func isLockfileMismatch(output string) bool {
+	return reLockfileMismatch.MatchString(stripAnsiCodes(output))
+}
+
+// Find the failed folders whose output shows a lock file mismatch
+func (r *Runner) lockfileMismatches(results []ExecutionResult) []string {
+	var folders []string
+	for _, result := range r.folderResults(results) {
+		if result.Success {
+			continue
+		}
+		output := result.FullOutput
+		if output == "" {
+			output = result.Output
+		}
+		if isLockfileMismatch(output) || (result.Error != nil && isLockfileMismatch(result.Error.Error())) {
+			folders = append(folders, result.Folder)
+		}
+	}
+	return uniqueStrings(folders)
+}
+
+// Build the providers lock command for the configured platforms
+func (r *Runner) providersLockArgs() []string {
+	args := []string{"providers", "lock"}
+	for _, platform := range r.config.LockfilePlatforms {
+		args = append(args, "-platform="+platform)
+	}
+	return args
+}
+
+// Warn about lock file mismatches, and regenerate the lock files when a fix mode is set
+func (r *Runner) handleLockfileMismatches(ctx context.Context, client *github.Client, results []ExecutionResult) []LockfileFix {
+	folders := r.lockfileMismatches(results)
+	if len(folders) == 0 {
+		return nil
+	}
+	if r.config.LockfileFix == LockfileFixOff {
+		for _, folder := range folders {
+			fmt.Printf("::warning title=Provider lock file mismatch::%s: run `terragrunt %s` or set lockfile-fix\n",
+				escapeAnnotation(folder), strings.Join(r.providersLockArgs(), " "))
+		}
+		return nil
+	}
+
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		r.logger.Warn("Failed to determine repo root, skipping lock file fix", "error", err)
+		return nil
+	}
+	var fixes []LockfileFix
+	for _, folder := range folders {
+		fix := r.fixLockfile(repoRoot, folder)
+		if fix.Error == "" && fix.Diff != "" && r.config.LockfileFix == LockfileFixCommit {
+			if err := r.commitLockfile(ctx, client, repoRoot, fix.Path); err != nil {
+				r.logger.Warn("Failed to commit lock file", "folder", folder, "error", err)
+				fix.Error = "commit failed: " + err.Error()
+			} else {
+				fix.Committed = true
+			}
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes
+}
+
+// Regenerate the lock file of a folder and compute the required changes
+func (r *Runner) fixLockfile(repoRoot, folder string) LockfileFix {
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		absFolder = filepath.Join(repoRoot, folder)
+		// run --all units may be reported relative to the root dir
+		if _, err := os.Stat(absFolder); err != nil {
+			absFolder = filepath.Join(repoRoot, r.config.RunAllRootDir, folder)
+		}
+	}
+	lockPath := filepath.Join(absFolder, lockfileName)
+	relPath, err := filepath.Rel(repoRoot, lockPath)
+	if err != nil {
+		relPath = lockPath
+	}
+	fix := LockfileFix{Folder: folder, Path: relPath}
+
+	r.logger.Info("Regenerating provider lock file", "folder", folder, "platforms", r.config.LockfilePlatforms)
+	cmd := exec.Command("terragrunt", r.providersLockArgs()...)
+	cmd.Dir = absFolder
+	cmd.Env = r.subprocessEnv()
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := r.runCommand(cmd); err != nil {
+		fix.Error = fmt.Sprintf("providers lock failed: %v", err)
+		r.logger.Warn("Failed to regenerate lock file", "folder", folder, "error", err, "output", strings.TrimSpace(stripAnsiCodes(out.String())))
+		return fix
+	}
+
+	diff, err := r.lockfileDiff(repoRoot, relPath)
+	if err != nil {
+		fix.Error = err.Error()
+		return fix
+	}
+	fix.Diff = diff
+	return fix
+}
+
+// Diff the lock file against HEAD (the whole file if it is not tracked yet)
+func (r *Runner) lockfileDiff(repoRoot, relPath string) (string, error) {
+	if r.runCommand(exec.Command("git", "-C", repoRoot, "ls-files", "--error-unmatch", relPath)) != nil {
+		// git diff --no-index exits 1 when the files differ
+		out, _ := r.commandOutput(exec.Command("git", "-C", repoRoot, "diff", "--no-color", "--no-index", os.DevNull, relPath))
+		return strings.TrimSpace(string(out)), nil
+	}
+	out, err := r.commandOutput(exec.Command("git", "-C", repoRoot, "diff", "--no-color", "HEAD", "--", relPath))
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Push the regenerated lock file to the PR branch through the GitHub API
+func (r *Runner) commitLockfile(ctx context.Context, client *github.Client, repoRoot, relPath string) error {
+	branch := os.Getenv("GITHUB_HEAD_REF")
+	if branch == "" {
+		return fmt.Errorf("PR head branch unknown (GITHUB_HEAD_REF is not set)")
+	}
+	if isForkPullRequest() {
+		return fmt.Errorf("cannot push to a pull request from a fork")
+	}
+	content, err := os.ReadFile(filepath.Join(repoRoot, relPath))
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	path := filepath.ToSlash(relPath)
+
+	var sha *string
+	file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: branch})
+	switch {
+	case err == nil && file != nil:
+		sha = file.SHA
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		// New lock file
+	case err == nil:
+		return fmt.Errorf("lock file path %s is not a file", path)
+	default:
+		return err
+	}
+
+	message := fmt.Sprintf("Update provider lock file in %s for %s", filepath.ToSlash(filepath.Dir(relPath)), strings.Join(r.config.LockfilePlatforms, ", "))
+	_, _, err = client.Repositories.UpdateFile(ctx, owner, repo, path, &github.RepositoryContentFileOptions{
+		Message: &message,
+		Content: content,
+		SHA:     sha,
+		Branch:  &branch,
+	})
+	return err
+}
+
+// Format the lock file fixes posted to the PR
+func (r *Runner) formatLockfileFixes(fixes []LockfileFix) string {
+	var b strings.Builder
+	b.WriteString("## " + r.msg("lockfile.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("The plan failed because `%s` does not match the required providers. Lock files were regenerated for: `%s`.\n\n",
+		lockfileName, strings.Join(r.config.LockfilePlatforms, "`, `")))
+	b.WriteString("| Folder | Status |\n|--------|--------|\n")
+	for _, fix := range fixes {
+		status := "📝 Changes below"
+		switch {
+		case fix.Error != "":
+			status = "❌ " + fix.Error
+		case fix.Diff == "":
+			status = "⚠️ Lock file unchanged"
+		case fix.Committed:
+			status = "✅ Fixup commit pushed"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s |\n", fix.Folder, status))
+	}
+
+	for _, fix := range fixes {
+		if fix.Committed || fix.Diff == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n<details><summary><b>%s</b></summary>\n\n```diff\n%s\n```\n</details>\n", fix.Path, fix.Diff))
+	}
+	if r.config.LockfileFix == LockfileFixComment {
+		b.WriteString(fmt.Sprintf("\nRun `terragrunt %s` in these folders and commit the result.\n", strings.Join(r.providersLockArgs(), " ")))
+	} else {
+		b.WriteString("\nPushed commits do not trigger a new workflow run with the default token; push again or re-run the workflow.\n")
+	}
+	return b.String()
+}
+
+// Post the lock file fixes as a PR comment
+func (r *Runner) postLockfileFixes(ctx context.Context, client *github.Client, fixes []LockfileFix) error {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	body := r.formatLockfileFixes(fixes)
+	if len(body) > maxCommentSize-headerSize {
+		body = cutUTF8(body, maxCommentSize-headerSize) + "\n```\n</details>\n\n_Truncated_"
+	}
+	return r.createComment(ctx, client, owner, repo, lockfileMarkerFolder, body)
+}
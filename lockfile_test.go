@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsLockfileMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{
+			name:   "checksum mismatch",
+			output: "Error: Failed to install provider\n\nError while installing hashicorp/aws v5.31.0: the local package for registry.terraform.io/hashicorp/aws 5.31.0 doesn't match any of the checksums previously recorded in the dependency lock file",
+			want:   true,
+		},
+		{
+			name:   "opentofu checksum mismatch",
+			output: "the current package for registry.opentofu.org/hashicorp/aws 5.31.0 does not match any of the checksums recorded in the dependency lock file",
+			want:   true,
+		},
+		{
+			name:   "inconsistent lock file",
+			output: "\x1b[31m│\x1b[0m \x1b[1m\x1b[31mError: \x1b[0m\x1b[1mInconsistent dependency lock file",
+			want:   true,
+		},
+		{
+			name:   "version constraint",
+			output: "- locked provider registry.terraform.io/hashicorp/aws 4.67.0 does not match configured version constraint ~> 5.0",
+			want:   true,
+		},
+		{
+			name:   "unrelated error",
+			output: "Error: Invalid reference\n\nA reference to a resource type must be followed by at least one attribute access",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLockfileMismatch(tt.output); got != tt.want {
+				t.Errorf("isLockfileMismatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockfileMismatches(t *testing.T) {
+	runner := newTestRunner(&Config{Command: "plan"})
+	results := []ExecutionResult{
+		{Folder: "live/ok", Success: true, Output: "Inconsistent dependency lock file"},
+		{Folder: "live/lock", Success: false, Output: "Error: Inconsistent dependency lock file"},
+		{Folder: "live/err", Success: false, Error: errors.New("provider registry.terraform.io/hashicorp/aws is not present in the dependency lock file")},
+		{Folder: "live/other", Success: false, Output: "Error: Invalid reference"},
+	}
+
+	got := runner.lockfileMismatches(results)
+	if strings.Join(got, ",") != "live/lock,live/err" {
+		t.Errorf("lockfileMismatches() = %v, want [live/lock live/err]", got)
+	}
+}
+
+func TestFormatLockfileFixes(t *testing.T) {
+	runner := newTestRunner(&Config{LockfileFix: LockfileFixComment, LockfilePlatforms: []string{"linux_amd64", "darwin_arm64"}})
+	fixes := []LockfileFix{
+		{Folder: "live/app", Path: "live/app/.terraform.lock.hcl", Diff: "-    \"h1:old=\",\n+    \"h1:new=\","},
+		{Folder: "live/db", Path: "live/db/.terraform.lock.hcl", Committed: true, Diff: "+x"},
+		{Folder: "live/net", Path: "live/net/.terraform.lock.hcl", Error: "providers lock failed: exit status 1"},
+	}
+
+	got := runner.formatLockfileFixes(fixes)
+	for _, want := range []string{
+		"## 🔒 Provider Lock File Mismatch",
+		"`linux_amd64`, `darwin_arm64`",
+		"| live/app | 📝 Changes below |",
+		"| live/db | ✅ Fixup commit pushed |",
+		"| live/net | ❌ providers lock failed: exit status 1 |",
+		"<b>live/app/.terraform.lock.hcl</b>",
+		"+    \"h1:new=\",",
+		"terragrunt providers lock -platform=linux_amd64 -platform=darwin_arm64",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatLockfileFixes() missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<b>live/db/.terraform.lock.hcl</b>") {
+		t.Errorf("formatLockfileFixes() shows the diff of a committed fix:\n%s", got)
+	}
+}
+
+func TestValidateConfigLockfileFix(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		platforms []string
+		wantErr   bool
+	}{
+		{name: "off", mode: LockfileFixOff},
+		{name: "commit", mode: LockfileFixCommit, platforms: []string{"linux_amd64"}},
+		{name: "unknown mode", mode: "push", platforms: []string{"linux_amd64"}, wantErr: true},
+		{name: "no platforms", mode: LockfileFixComment, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newTestRunner(&Config{
+				GithubToken:       "token",
+				Repository:        "owner/repo",
+				PullRequest:       1,
+				Command:           "plan",
+				Folders:           []string{"live/app"},
+				LockfileFix:       tt.mode,
+				LockfilePlatforms: tt.platforms,
+			})
+			if err := runner.validateConfig(); (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,11 +2,11 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,6 +15,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v75/github"
 	"github.com/spf13/cobra"
@@ -24,16 +26,10 @@ import (
 const (
 	maxCommentSize = 65536 // GitHub comment size limit
 	headerSize     = 500   // Estimated size for headers and markdown
+	commentRoom    = 1024  // Room kept below the limit for the marker, footer and thread reference
+	maxPartNumber  = 999   // Widest part number assumed when sizing split comments
 )
 
-var botCommentHeaders = []string{
-	"Terragrunt Execution",
-	"Failed Terragrunt",
-	"Terragrunt Summary",
-	"Success Terragrunt",
-	"✅ Success Terragrunt",
-}
-
 var (
 	Reset   = "\033[0m"
 	Red     = "\033[31m"
@@ -47,31 +43,145 @@ var (
 )
 
 type Config struct {
-	GithubToken       string   // GitHub token for API access
-	Repository        string   // GitHub repository in "owner/repo" format
-	Owner             string   // GitHub repository owner
-	PullRequest       int      // Pull request number
-	Folders           []string // List of folders to run Terragrunt in
-	Command           string   // Terragrunt CLI command
-	RunAllRootDir     string   // Run --all directory root
-	TerragruntArgs    string   // Additional Terragrunt arguments
-	ParallelExec      bool     // Whether to execute in parallel
-	MaxParallel       int      // Maximum parallel executions (0 = unlimited)
-	DeleteOldComments bool     // Whether to delete old bot comments
-	AutoDetect        bool     // Whether to auto-detect folders from changed files
-	FilePatterns      []string // File patterns to track for auto-detection
-	TerragruntFile    string   // Name of the Terragrunt file to look for
-	ChangedFiles      []string // List of changed files (for auto-detection)
-	MaxWalkUpLevels   int      // Maximum directory levels to walk up when searching for Terragrunt file
-	MaxRuns           int      // Maximum number of Terragrunt executions allowed (0 = unlimited)
+	GithubToken             string   // GitHub token for API access
+	Repository              string   // GitHub repository in "owner/repo" format
+	Owner                   string   // GitHub repository owner
+	PullRequest             int      // Pull request number
+	Folders                 []string // List of folders to run Terragrunt in
+	Command                 string   // Terragrunt CLI command
+	RunAllRootDir           string   // Run --all directory root (comma separated roots run concurrently)
+	TerragruntArgs          string   // Additional Terragrunt arguments
+	ParallelExec            bool     // Whether to execute in parallel
+	MaxParallel             int      // Maximum parallel executions (0 = unlimited)
+	DeleteOldComments       bool     // Whether to delete old bot comments
+	AutoDetect              bool     // Whether to auto-detect folders from changed files
+	FilePatterns            []string // File patterns to track for auto-detection
+	TerragruntFiles         []string // Names of the Terragrunt unit files to look for
+	ChangedFiles            []string // List of changed files (for auto-detection)
+	MaxWalkUpLevels         int      // Maximum directory levels to walk up when searching for Terragrunt file (0 = up to the root boundary)
+	MaxRuns                 int      // Maximum number of Terragrunt executions allowed (0 = unlimited)
+	DiffBase                string   // Base ref/SHA to diff against when computing changed files
+	ScanSecrets             bool     // Whether to scan and mask secrets in output before posting
+	FailOnSecretLeak        bool     // Whether to fail instead of posting when secrets are detected
+	MentionOwners           string   // When to @-mention folder owners (on-failure, on-destroy, always, never)
+	OwnersFile              string   // Name of the per-folder owners file
+	OutputsFile             string   // Path of the JSON file receiving planned output values
+	DetailLevel             string   // Amount of output included in comments (summary, standard, full)
+	FailureDetailLevel      string   // Detail level for failed folders (empty = same as DetailLevel)
+	RetryFailed             bool     // Whether to re-run failed plan folders sequentially once
+	PreChecks               []string // Pre-checks to run before executing the command (hclfmt, validate-inputs)
+	PreChecksFailFast       bool     // Whether to stop before executing the command when pre-checks fail
+	ChangelogFile           string   // File receiving a changelog entry after apply
+	ChangelogCommit         bool     // Whether to commit the changelog entry through the GitHub API
+	ChangelogBranch         string   // Branch receiving the changelog commit (empty = default branch)
+	ExplainDetection        bool     // Whether to print how changed files map to folders
+	ExplainDetectionComment bool     // Whether to include the detection explanation in the summary comment
+	MaxOutputBytes          int      // Maximum output bytes kept in memory per execution (0 = unlimited)
+	LogFormat               string   // Terragrunt log format for run --all (auto, json, text)
+	PublishResults          string   // Destination URL receiving the run results (s3://, gs://, az://)
+	CompareWithBase         bool     // Whether to also plan folders at the base ref and compare the plans
+	SkipNoChangeComments    bool     // Whether to skip individual comments for folders without changes
+	WebhookURL              string   // Endpoint receiving the signed run results after each run
+	WebhookSecret           string   // Secret used to sign the results webhook payload
+	ShardRunAll             bool     // Whether to split run --all into one invocation per account
+	AccountMarker           string   // File marking an account directory (e.g. account.hcl)
+	AccountDepth            int      // Directory levels below the root dir forming an account (0 = use the marker file)
+	LockfileFix             string   // How to fix provider lock file mismatches (off, comment, commit)
+	LockfilePlatforms       []string // Platforms recorded when regenerating lock files
+	SkipStages              []string // Pipeline stages to skip (fmt, validate, plan, policy, comment, report or custom)
+	EmbedPlan               bool     // Whether to embed the compressed full plan in a hidden block of each comment
+	AutofixFmt              bool     // Whether to push hcl fmt/terraform fmt fixes to the PR branch
+	AllowedArgs             []string // Flags permitted in --args and ChatOps args (empty = any flag not denied)
+	DeniedArgs              []string // Flags rejected in --args and ChatOps args (e.g. -auto-approve, -refresh=false)
+	EnvAllowlist            []string // Host environment variables passed to Terragrunt (globs, "*" = all)
+	EnvDenylist             []string // Host environment variables never passed to Terragrunt (globs)
+	RunLabel                string   // Human-friendly label of the run (e.g. nightly-drift)
+	NotifyOn                string   // When to send notifications (failure, destroy, always)
+	EnforceReadonlyPlan     bool     // Whether plans must run with the configured read-only credentials
+	PlanAWSRoles            []string // IAM roles assumed for plans ("[folder-glob=]role-arn", last match wins)
+	PlanGCPServiceAccounts  []string // Service accounts impersonated for plans ("[folder-glob=]email", last match wins)
+	MessagesFile            string   // JSON/YAML file overriding comment text per key
+	ApplyOrder              []string // Apply groups in order ("group=folder-glob"), each applied after the previous succeeded
+	ApplyGates              []string // Commands gating apply groups ("group=command"), run before the group
+	FastPlan                bool     // Whether to plan without refresh, targeting resources changed in the prior plan
+	HideUnchangedAttributes bool     // Whether to drop unchanged attribute lines of updated resources from comments
+	DeploymentEnvironment   string   // GitHub Environment whose protection rules gate applies (empty = no deployment)
+	DeploymentTimeout       int      // Minutes to wait for the deployment approval
+	CrashDumpDir            string   // Directory receiving the output of provider crashes (empty = not saved)
+	Target                  string   // Where results are posted (pr, commit, issue)
+	TargetID                string   // Commit SHA or issue number of a commit or issue target
+	ProgressComment         bool     // Whether to keep a live progress comment updated during the run
+	ProgressInterval        int      // Seconds between two progress comment updates
+	InputsFrom              string   // JSON run description of an external planner (file, or - for stdin)
+	SummaryFile             string   // File the rendered summary markdown is written to
+	SignSummary             string   // Sign the summary results: sigstore or key (empty: unsigned)
+	SigningKey              string   // PEM PKCS#8 private key file of key signatures
+	LabelFilters            bool     // Whether skip-tf: and tf-only: PR labels filter the folders
+	TFCRuns                 bool     // Whether folders with an HCP Terraform workspace plan there through its API
+	TFCToken                string   // HCP Terraform API token
+	ConcurrencyGroups       []string // Concurrency group per folder ("[folder-glob=]group", last match wins)
+	ApplyDependencyOrder    bool     // Whether applies run in waves following the unit dependencies
+	TerragruntDetection     string   // How auto-detection uses the units Terragrunt would queue (off, report, use)
+	PlanEncryptKey          string   // AWS or GCP KMS key encrypting the saved plan files (empty = plaintext)
+	CommentThreading        string   // How folder comments relate to earlier runs (off, reference, review)
+	SoftFail                bool     // Whether failed executions are reported without failing the run
+	DeployMilestone         string   // Milestone set on the deployed pull request after a successful apply
+	DeployProject           string   // GitHub Project (owner/number or URL) receiving the deployed pull request
+	DeployProjectStatus     string   // Status column of the deployed pull request on the project
+	DeployRecord            bool     // Whether a deployment record is commented on the deployed pull request
+	DiffFrom                string   // Start of the diff of auto-detection (ref, last-tag or merge-base:<ref>; empty = diff-base)
+	DiffTo                  string   // End of the diff of auto-detection (empty = HEAD)
+	Color                   string   // Console colors (auto, always, never)
+	Engine                  string   // Engine whose output parser reads the outputs (auto = detected)
+	Refresh                 bool     // Rerun only the given folders, replacing just their comments
+	ConfigFile              string   // Repository config file setting inputs (optional at the default path)
+	OrgConfig               string   // Organization defaults under the repository config (org, github://, URL or file)
+	PlanDiff                bool     // Whether to show what changed in each folder's plan since its previous comment
+	AuditLog                string   // JSONL file every executed command is appended to (empty = off)
+	Granularity             string   // Execution and reporting unit: unit (each folder) or stack
+	StackMarkers            []string // Files marking a stack directory for stack granularity
+	TriageDir               string   // Directory receiving a triage bundle per failed folder (empty = none)
+	TriageRerun             bool     // Whether triage bundles include a rerun with --log-level debug
+	FolderNamesFile         string   // JSON/YAML file mapping folder paths to display names
+	ScaffoldReview          bool     // Whether new scaffold-only units are rendered for review instead of planned
+	DeleteCommentsOlderThan int      // Hours a bot comment must be old before it is deleted (0 = any age)
+	DeleteMax               int      // Maximum bot comments deleted per run, oldest first (0 = unlimited)
+	DeleteDryRun            bool     // Whether old comments are only reported, not deleted
+	ExecutionPlan           bool     // Whether run --all summaries show the queued units in run order
+	PROverrides             []string // Options PR authors may set in the description's terragrunt-runner block
+	UnitIndex               string   // File caching the index of units and their dependencies between runs
+	Runbook                 string   // JSON runbook of ordered steps executed instead of the folders
+	RunbookState            string   // File keeping the completed runbook steps, to resume from
+	CommentOn               string   // Folders getting an individual comment (failure, changes, always)
+	CheckUpdate             bool     // Whether to compare the running version with the latest release
+	DetailedExitCode        bool     // Whether single folder plans use -detailed-exitcode to detect changes
+	ReuseComments           bool     // Whether reruns with unchanged folder results only edit the summary
+	FolderSecrets           string   // JSON file declaring secrets fetched for the folders that need them
+	RiskScore               bool     // Whether the summary scores the risk of each folder's changes
+	FmtSuggestions          bool     // Whether hclfmt pre-check failures get suggestion comments
+	RunDelta                bool     // Whether the summary shows the changes since the previous run
 }
 
 type ExecutionResult struct {
-	Folder          string           // Folder where Terragrunt was executed
-	Output          string           // Cleaned output from Terragrunt
-	Error           error            // Error if execution failed
-	ResourceChanges *ResourceChanges // Parsed resource changes
-	Success         bool             // Whether the command was successful
+	Folder          string                   // Folder where Terragrunt was executed
+	Output          string                   // Cleaned output from Terragrunt
+	Error           error                    // Error if execution failed
+	ResourceChanges *ResourceChanges         // Parsed resource changes
+	Success         bool                     // Whether the command was successful
+	RunSummary      *RunSummary              // Parsed run --all summary (overall run --all result only)
+	PlannedOutputs  map[string]PlannedOutput // Planned changes to output values
+	FullOutput      string                   // Entire output without ANSI codes (for full detail level)
+	Retried         bool                     // Whether the result comes from a second (retry) pass
+	Duration        time.Duration            // Time taken by the Terragrunt execution
+	Engine          string                   // Engine that produced the plan (OpenTofu, Terraform; empty if unknown)
+	SetupError      bool                     // Whether the failure comes from the runner environment (missing binary, PATH)
+	ProviderCrash   string                   // Provider plugin that crashed (empty if none), classified as provider-crash
+	RemoteRunURL    string                   // HCP Terraform run of the folder (empty for local runs)
+	MockedDeps      []string                 // Dependencies whose mock outputs the run used
+	StackUnits      []string                 // Folders run within the stack (stack granularity only)
+	TriageBundle    string                   // Directory of the failure triage bundle (empty if none)
+	Scaffolded      bool                     // Whether the unit is new scaffold boilerplate, rendered instead of planned
+	ResourceTypes   map[string]int           // Planned changes per resource type from the saved plan JSON (nil = parse the output)
 }
 
 type ResourceChanges struct {
@@ -80,54 +190,232 @@ type ResourceChanges struct {
 	ToDestroy int
 	ToImport  int
 	ToMove    int
+	ToForget  int
 	ToReplace int
 	NoChanges bool
 }
 
-var (
-	Version    = "dev"
-	BuildTime  = "unknown"
-	Commit     = "unknown"
-	logger     *slog.Logger
-	config     = &Config{}
-	foldersStr string
-)
+// Runner executes Terragrunt and reports the results of a single run. All run
+// state lives here so independent runs can execute concurrently in one process.
+type Runner struct {
+	config       *Config                // Run configuration
+	logger       *slog.Logger           // Logger for this run
+	caps         *TokenCapabilities     // Effective GitHub token capabilities
+	explanations []DetectionExplanation // Auto-detection explanations
+	comparisons  []BaseComparison       // Plan comparisons with the base ref
+	stages       []Stage                // Pipeline stages in execution order
+	stageTimings []StageTiming          // Timings of the stages run so far
+	results      []ExecutionResult      // Results of the plan stage
+	fmtAutofix   *FmtAutofix            // Formatting fixes pushed before planning
+	workRoot     string                 // Checkout holding the folders (empty = repo root)
+	skippedUnits []SkippedUnit          // Units excluded by their configuration
+	labelSkipped []SkippedUnit          // Folders left out by skip-tf: and tf-only: PR labels
+	messages     map[string]string      // Comment text overrides from --messages-file
+	applyComment int64                  // Apply progress comment, once posted
+	priorTargets map[string][]string    // Resources changed per folder in earlier embedded plans (fast plan)
+	progress     *runProgress           // Live progress of the folders (nil unless progress-comment)
+	folderInputs map[string]FolderInput // Resolved run description per folder (inputs-from)
+	threads      *commentThreads        // Earlier comment threads of the folders (nil unless comment-threading)
+	apiMetrics   *apiMetricsTransport   // GitHub API calls of the run (nil until the client is created)
+	prevStats    *RunStats              // Statistics of the previous run on the target (nil if none)
+	prevPlans    map[string]string      // Plan output of the previous comment per folder (nil unless plan-diff)
+	folderNames  map[string]string      // Display names per folder from --folder-names-file
+	execPlan     []QueuedUnit           // Units queued by run --all in run order (nil unless execution-plan)
+	unitIndex    *UnitIndex             // Cached units and dependencies (nil unless unit-index)
+	runbook      *Runbook               // Ordered steps of the run (nil unless runbook)
+	overrides    prOverrides            // Options set in the PR description and the folders they left out
+	prevSummary  *PriorSummary          // Summary comment of the previous run (nil unless reuse-comments)
+	secrets      []FolderSecret         // Secrets injected into matching folders (nil unless folder-secrets)
+	reusedFrom   string                 // Run whose folder comments were kept (empty if reposted)
+}
+
+// Create a runner for the given configuration
+func NewRunner(config *Config, logger *slog.Logger) *Runner {
+	return &Runner{
+		config: config,
+		logger: logger,
+		caps:   &TokenCapabilities{CanComment: true, CanDelete: true},
+		stages: defaultStages(),
+	}
+}
 
 func main() {
-	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	logger := newLogger()
 	slog.SetDefault(logger)
 
+	if err := newRootCmd(logger).Execute(); err != nil {
+		logger.Error("Failed to execute command", "error", err)
+		os.Exit(1)
+	}
+}
+
+// Create the root command with its flags and subcommands
+func newRootCmd(logger *slog.Logger) *cobra.Command {
+	config := &Config{}
+	var foldersStr, foldersFile, foldersB64 string
+
 	var rootCmd = &cobra.Command{
 		Use:   "terragrunt-runner",
 		Short: "Execute Terragrunt commands and post results to GitHub PR",
 		Long:  `A tool to run Terragrunt CLI commands in multiple folders and post formatted results to GitHub Pull Requests.`,
-		RunE:  run,
+		// TGRUNNER_* variables configure every subcommand, under its command line
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return applyEnvFlags(cmd.Flags(), os.Environ())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Flags given on the command line or the environment take precedence over action inputs
+			if err := applyActionInputs(cmd.Flags(), os.Getenv(actionInputsEnv)); err != nil {
+				return err
+			}
+			// Config files only fill the flags still unset
+			if err := applyConfigFiles(context.Background(), newConfigClient(config.GithubToken), cmd.Flags(), config, logger); err != nil {
+				return err
+			}
+			// Parse folders from input string (comma, space, newline separated)
+			folders, err := loadFolderList(foldersStr, foldersFile, foldersB64, os.Stdin)
+			if err != nil {
+				return err
+			}
+			config.Folders = folders
+			runLogger := logger.With("run_id", getRunID())
+			if config.RunLabel != "" {
+				runLogger = runLogger.With("run_label", config.RunLabel)
+			}
+			return NewRunner(config, runLogger).run()
+		},
 	}
 
-	rootCmd.Flags().StringVar(&config.GithubToken, "github-token", os.Getenv("GITHUB_TOKEN"), "GitHub token for API access")
-	rootCmd.Flags().StringVar(&config.Repository, "repository", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository (owner/repo)")
-	rootCmd.Flags().StringVar(&config.Owner, "owner", os.Getenv("GITHUB_REPOSITORY_OWNER"), "GitHub repository owner (optional, extracted from repository if not set)")
-	rootCmd.Flags().IntVar(&config.PullRequest, "pull-request", getPRNumber(), "Pull request number")
+	rootCmd.PersistentFlags().StringVar(&config.GithubToken, "github-token", os.Getenv("GITHUB_TOKEN"), "GitHub token for API access")
+	rootCmd.PersistentFlags().StringVar(&config.Repository, "repository", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository (owner/repo)")
+	rootCmd.PersistentFlags().StringVar(&config.Owner, "owner", os.Getenv("GITHUB_REPOSITORY_OWNER"), "GitHub repository owner (optional, extracted from repository if not set)")
+	rootCmd.PersistentFlags().IntVar(&config.PullRequest, "pull-request", getPRNumber(), "Pull request number")
 	rootCmd.Flags().StringVar(&foldersStr, "folders", "", "Folders to run Terragrunt in (comma, space, or newline separated)")
+	rootCmd.Flags().StringVar(&foldersFile, "folders-file", "", "File listing more folders to run Terragrunt in, separated like --folders (- for stdin)")
+	rootCmd.Flags().StringVar(&foldersB64, "folders-b64", "", "Base64 encoded list of more folders to run Terragrunt in, separated like --folders")
 	rootCmd.Flags().StringVar(&config.Command, "command", "plan", "Terragrunt CLI command (e.g., 'plan', 'run --all plan')")
-	rootCmd.Flags().StringVar(&config.RunAllRootDir, "root-dir", "live", "Run --all root directory from where to run terragrunt")
-	rootCmd.Flags().StringVar(&config.TerragruntArgs, "args", "--non-interactive", "Additional Terragrunt arguments")
+	rootCmd.Flags().StringVar(&config.RunAllRootDir, "root-dir", "live", "Run --all root directory from where to run terragrunt (comma separated for several roots, e.g. live/prod,live/staging, each run concurrently)")
+	rootCmd.PersistentFlags().StringVar(&config.TerragruntArgs, "args", "--non-interactive", "Additional Terragrunt arguments")
 	rootCmd.Flags().BoolVar(&config.ParallelExec, "parallel", true, "Execute in parallel (for multi-folder runs)")
 	rootCmd.Flags().IntVar(&config.MaxParallel, "max-parallel", 5, "Maximum parallel executions (0 = unlimited)")
 	rootCmd.Flags().BoolVar(&config.DeleteOldComments, "delete-old-comments", true, "Delete previous bot comments")
 	rootCmd.Flags().BoolVar(&config.AutoDetect, "auto-detect", false, "Auto-detect Terragrunt folders from changed files")
 	rootCmd.Flags().StringSliceVar(&config.FilePatterns, "file-patterns", []string{"*.hcl", "*.json", "*.yaml", "*.yml"}, "File patterns to track for auto-detection")
-	rootCmd.Flags().StringVar(&config.TerragruntFile, "terragrunt-file", "terragrunt.hcl", "Name of the Terragrunt file to look for")
+	rootCmd.Flags().StringSliceVar(&config.TerragruntFiles, "terragrunt-file", []string{"terragrunt.hcl"}, "Names of the Terragrunt unit files to look for (e.g. terragrunt.hcl,terragrunt.hcl.json)")
 	rootCmd.Flags().StringSliceVar(&config.ChangedFiles, "changed-files", []string{}, "List of changed files (for auto-detection)")
-	rootCmd.Flags().IntVar(&config.MaxWalkUpLevels, "max-walk-up", 3, "Maximum directory levels to walk up when searching for Terragrunt file")
+	rootCmd.Flags().IntVar(&config.MaxWalkUpLevels, "max-walk-up", 0, "Maximum directory levels to walk up when searching for Terragrunt file (0 = up to the repository root, a .terragrunt-root marker or root-dir)")
 	rootCmd.Flags().IntVar(&config.MaxRuns, "max-runs", 20, "Maximum number of Terragrunt executions allowed (0 = unlimited)")
-
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Failed to execute command", "error", err)
-		os.Exit(1)
-	}
+	rootCmd.Flags().BoolVar(&config.ScanSecrets, "scan-secrets", true, "Scan output for secrets and mask them before posting comments")
+	rootCmd.Flags().BoolVar(&config.FailOnSecretLeak, "fail-on-secret-leak", false, "Fail without posting comments when secrets are detected in output")
+	rootCmd.Flags().StringVar(&config.MentionOwners, "mention-owners", MentionNever, "When to @-mention folder owners: on-failure, on-destroy, always, never")
+	rootCmd.Flags().StringVar(&config.OwnersFile, "owners-file", "OWNERS", "Name of the per-folder owners file (falls back to CODEOWNERS)")
+	rootCmd.Flags().StringVar(&config.OutputsFile, "planned-outputs-file", "", "Write planned output values per folder to this JSON file")
+	rootCmd.Flags().StringVar(&config.DetailLevel, "detail-level", DetailStandard, "Amount of output included in comments: summary, standard, full")
+	rootCmd.Flags().StringVar(&config.FailureDetailLevel, "failure-detail-level", "", "Detail level for failed folders (defaults to --detail-level)")
+	rootCmd.Flags().BoolVar(&config.RetryFailed, "retry-failed", false, "Re-run failed folders once sequentially before reporting (per-folder plans only)")
+	rootCmd.Flags().StringSliceVar(&config.PreChecks, "pre-checks", []string{}, "Pre-checks to run before the command (hclfmt, validate-inputs)")
+	rootCmd.Flags().BoolVar(&config.PreChecksFailFast, "pre-checks-fail-fast", false, "Fail without executing the command when pre-checks fail")
+	rootCmd.Flags().StringVar(&config.ChangelogFile, "changelog-file", "", "Append an infrastructure changelog entry to this file after apply")
+	rootCmd.Flags().BoolVar(&config.ChangelogCommit, "changelog-commit", false, "Commit the changelog entry through the GitHub API instead of writing it locally")
+	rootCmd.Flags().StringVar(&config.ChangelogBranch, "changelog-branch", "", "Branch receiving the changelog commit (defaults to the repository default branch)")
+	rootCmd.Flags().BoolVar(&config.ExplainDetection, "explain-detection", false, "Print how each changed file was mapped to a Terragrunt folder")
+	rootCmd.Flags().BoolVar(&config.ExplainDetectionComment, "explain-detection-comment", false, "Include the auto-detection explanation in the summary comment")
+	rootCmd.Flags().IntVar(&config.MaxOutputBytes, "max-output-bytes", 20*1024*1024, "Maximum output bytes kept in memory per execution (0 = unlimited); the rest spills to disk")
+	rootCmd.Flags().StringVar(&config.LogFormat, "log-format", LogFormatAuto, "Terragrunt log format for run --all: auto (json when supported), json, text")
+	rootCmd.Flags().StringVar(&config.PublishResults, "publish-results", "", "Upload results JSON, HTML report and plan files to s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix")
+	rootCmd.Flags().BoolVar(&config.CompareWithBase, "compare-with-base", false, "Also plan folders at the diff base to separate changes introduced by the PR from pre-existing drift")
+	rootCmd.Flags().BoolVar(&config.SkipNoChangeComments, "skip-no-change-comments", false, "Skip individual comments for folders without changes (they only appear in the summary)")
+	rootCmd.Flags().StringVar(&config.WebhookURL, "webhook-url", "", "POST a JSON payload of the run results to this URL after each run")
+	rootCmd.Flags().StringVar(&config.WebhookSecret, "webhook-secret", os.Getenv("RESULTS_WEBHOOK_SECRET"), "Secret signing the results webhook payload (HMAC-SHA256)")
+	rootCmd.Flags().BoolVar(&config.ShardRunAll, "shard-run-all", true, "Split run --all into one parallel invocation per account when folders span several accounts")
+	rootCmd.Flags().StringVar(&config.AccountMarker, "account-marker", "account.hcl", "File marking an account directory for run --all sharding")
+	rootCmd.Flags().IntVar(&config.AccountDepth, "account-depth", 0, "Directory levels below root-dir forming an account for run --all sharding (0 = use account-marker)")
+	rootCmd.Flags().StringVar(&config.LockfileFix, "lockfile-fix", LockfileFixOff, "Regenerate mismatching provider lock files: off, comment (post the diff), commit (push a fixup commit)")
+	rootCmd.Flags().StringSliceVar(&config.LockfilePlatforms, "lockfile-platforms", []string{"linux_amd64", "linux_arm64", "darwin_amd64", "darwin_arm64"}, "Platforms recorded when regenerating provider lock files")
+	rootCmd.Flags().StringSliceVar(&config.SkipStages, "skip-stages", []string{}, "Pipeline stages to skip (fmt, validate, plan, policy, comment, report)")
+	rootCmd.Flags().BoolVar(&config.EmbedPlan, "embed-plan", false, "Embed the gzip-compressed, base64-encoded full plan in a hidden block of each comment (when it fits)")
+	rootCmd.Flags().BoolVar(&config.AutofixFmt, "autofix-fmt", false, "Run terragrunt hcl fmt and terraform/tofu fmt in the folders and push the fixes to the PR branch")
+	rootCmd.Flags().StringSliceVar(&config.AllowedArgs, "allowed-args", []string{}, "Flags permitted in --args and ChatOps args, e.g. -var,-lock-timeout (empty = any flag not denied)")
+	rootCmd.Flags().StringSliceVar(&config.DeniedArgs, "denied-args", []string{}, "Flags rejected in --args and ChatOps args, e.g. -auto-approve,-target,-refresh=false")
+	rootCmd.Flags().StringSliceVar(&config.EnvAllowlist, "env-allowlist", defaultEnvAllowlist, "Host environment variables passed to Terragrunt subprocesses (globs, \"*\" passes the whole environment)")
+	rootCmd.Flags().StringSliceVar(&config.EnvDenylist, "env-denylist", []string{}, "Host environment variables never passed to Terragrunt subprocesses (globs, e.g. TF_CLI_ARGS*)")
+	rootCmd.Flags().StringVar(&config.RunLabel, "run-label", "", "Human-friendly label of the run (e.g. nightly-drift) shown in comments, logs and results")
+	rootCmd.Flags().StringVar(&config.NotifyOn, "notify-on", NotifyAlways, "When to send the results webhook: failure, destroy (failures or destroys), always")
+	rootCmd.Flags().BoolVar(&config.EnforceReadonlyPlan, "enforce-readonly-plan", false, "Fail plans of folders without a configured read-only plan role or service account")
+	rootCmd.Flags().StringSliceVar(&config.PlanAWSRoles, "plan-aws-roles", []string{}, "IAM roles assumed for plans: [folder-glob=]role-arn, last match wins")
+	rootCmd.Flags().StringSliceVar(&config.PlanGCPServiceAccounts, "plan-gcp-service-accounts", []string{}, "Service accounts impersonated for plans: [folder-glob=]email, last match wins")
+	rootCmd.Flags().StringVar(&config.MessagesFile, "messages-file", "", "JSON or YAML file overriding comment text (titles, status words, labels) per key")
+	rootCmd.Flags().StringSliceVar(&config.ApplyOrder, "apply-order", []string{}, "Apply groups in order as group=folder-glob (e.g. dev=live/dev/**,prod=live/prod/**); a group applies only after the previous one succeeded")
+	rootCmd.Flags().StringSliceVar(&config.ApplyGates, "apply-gates", []string{}, "Commands run before an apply group as group=command; a non-zero exit stops the apply")
+	rootCmd.Flags().BoolVar(&config.FastPlan, "fast-plan", false, "Plan with -refresh=false, targeting the resources changed in the plans embedded in earlier PR comments (approximate)")
+	rootCmd.Flags().BoolVar(&config.HideUnchangedAttributes, "hide-unchanged-attributes", false, "Drop unchanged attribute lines inside updated resources from comments, keeping only changed lines and their headers")
+	rootCmd.Flags().StringVar(&config.DeploymentEnvironment, "deployment-environment", "", "GitHub Environment gating applies; the job must declare it with environment: so GitHub enforces its protection rules, and the runner checks that the run's deployment to it was approved before applying")
+	rootCmd.Flags().IntVar(&config.DeploymentTimeout, "deployment-timeout", 60, "Minutes to wait for a pending deployment of the run before failing")
+	rootCmd.Flags().StringVar(&config.CrashDumpDir, "crash-dump-dir", "", "Directory receiving the output of provider plugin crashes, e.g. for upload as an artifact (empty = not saved)")
+	rootCmd.Flags().StringVar(&config.Target, "target", TargetPR, "Where results are posted: pr, commit (commit comments, e.g. for push-triggered plans) or issue (e.g. a drift tracking issue)")
+	rootCmd.Flags().StringVar(&config.TargetID, "target-id", "", "Commit SHA (defaults to GITHUB_SHA) or issue number receiving the results of a commit or issue target")
+	rootCmd.Flags().BoolVar(&config.ProgressComment, "progress-comment", false, "Post a progress comment when the run starts and update it with per-folder status until the results are posted")
+	rootCmd.Flags().IntVar(&config.ProgressInterval, "progress-interval", 30, "Seconds between two progress comment updates")
+	rootCmd.Flags().StringVar(&config.InputsFrom, "inputs-from", "", "JSON file (- for stdin) describing the folders to run with their commands, args, env and metadata; overrides folders, command and args")
+	rootCmd.Flags().StringVar(&config.SignSummary, "sign-summary", "", "Sign the results JSON of the summary comment: sigstore (keyless, needs cosign and id-token: write) or key")
+	rootCmd.Flags().StringVar(&config.SigningKey, "signing-key", "", "PEM PKCS#8 private key file (Ed25519, ECDSA or RSA) for sign-summary key")
+	rootCmd.Flags().BoolVar(&config.LabelFilters, "label-filters", false, "Filter the folders with skip-tf:<glob> and tf-only:<glob> pull request labels")
+	rootCmd.Flags().BoolVar(&config.TFCRuns, "tfc-runs", false, "Plan folders using an HCP Terraform cloud block or remote backend as speculative runs through the HCP Terraform API")
+	rootCmd.Flags().StringVar(&config.TFCToken, "tfc-token", os.Getenv("TF_API_TOKEN"), "HCP Terraform API token (tfc-runs)")
+	rootCmd.Flags().StringSliceVar(&config.ConcurrencyGroups, "concurrency-groups", []string{}, "Concurrency groups of folders that never run in parallel with each other: [folder-glob=]group, last match wins")
+	rootCmd.Flags().StringVar(&config.SummaryFile, "summary-file", "", "Write the rendered summary markdown to this file for later workflow steps")
+	rootCmd.Flags().BoolVar(&config.ApplyDependencyOrder, "apply-dependency-order", true, "Apply folders in waves following their dependency blocks (producers first, reversed for destroy)")
+	rootCmd.Flags().StringVar(&config.TerragruntDetection, "terragrunt-detection", TerragruntDetectionOff, "Ask Terragrunt which units it would queue for the changed files: off, report (warn about discrepancies with auto-detection), use (run Terragrunt's units)")
+	rootCmd.Flags().StringVar(&config.PlanEncryptKey, "plan-encrypt-key", "", "AWS KMS key ARN or GCP KMS key name encrypting the plan files saved with -out (decrypted again for apply)")
+	rootCmd.Flags().StringVar(&config.CommentThreading, "comment-threading", ThreadingOff, "Thread folder comments across runs: off, reference (keep earlier comments and link the latest), review (reply in a review thread on the unit file)")
+	rootCmd.Flags().BoolVar(&config.SoftFail, "soft-fail", false, "Report failed executions with a neutral check run and a summary banner instead of failing the run")
+	rootCmd.Flags().StringVar(&config.DeployMilestone, "deploy-milestone", "", "Open milestone set on the deployed pull request after a successful apply")
+	rootCmd.Flags().StringVar(&config.DeployProject, "deploy-project", "", "GitHub Project (owner/number or project URL) the deployed pull request is added to after a successful apply")
+	rootCmd.Flags().StringVar(&config.DeployProjectStatus, "deploy-project-status", "", "Status column the deployed pull request is moved to on deploy-project (e.g. Deployed)")
+	rootCmd.Flags().BoolVar(&config.DeployRecord, "deploy-record", false, "Comment a deployment record (commit, run, folders) on the deployed pull request after a successful apply")
+	rootCmd.Flags().StringVar(&config.DiffFrom, "diff-from", "", "Ref whose diff to diff-to auto-detects the changed files, instead of the PR diff: a tag, branch or SHA, last-tag (the tag before diff-to) or merge-base:<ref>")
+	rootCmd.Flags().StringVar(&config.DiffTo, "diff-to", "", "End ref of the diff-from diff (defaults to HEAD)")
+	rootCmd.Flags().StringVar(&config.Color, "color", ColorAuto, "Console colors: auto (colored unless NO_COLOR or CLICOLOR=0, or off a terminal outside GitHub Actions), always, never (also passes -no-color to Terragrunt)")
+	rootCmd.Flags().StringVar(&config.Engine, "engine", EngineAuto, "Engine whose output parser reads the Terragrunt output: auto (detected from the output), terraform or opentofu")
+	rootCmd.Flags().BoolVar(&config.Refresh, "refresh", false, "Rerun only the given folders and replace just their comments, leaving the other comments and the summary (the refresh ChatOps command)")
+	rootCmd.Flags().StringVar(&config.ConfigFile, "config-file", defaultConfigFile, "Repository config file setting presentation inputs by name (flat YAML or JSON), read at the pull request base")
+	rootCmd.Flags().StringVar(&config.OrgConfig, "org-config", "", "Organization defaults merged under the repository config: org (the owner's .github repository), github://owner/repo[/path][@ref], an https URL or a file")
+	rootCmd.Flags().BoolVar(&config.PlanDiff, "plan-diff", false, "Show a unified diff of each folder's plan output against the plan in its previous comment")
+	rootCmd.Flags().StringVar(&config.AuditLog, "audit-log", "", "Append every executed Terragrunt, gate and upload command to this JSONL file: argv, directory, environment fingerprint, times, exit code, actor and PR")
+	rootCmd.Flags().StringVar(&config.Granularity, "granularity", GranularityUnit, "Execute and report per unit (each folder) or per stack, running each stack with run --all from its directory")
+	rootCmd.Flags().StringSliceVar(&config.StackMarkers, "stack-markers", []string{"terragrunt.stack.hcl", "env.hcl"}, "Files marking a stack directory for stack granularity (nearest directory containing one)")
+	rootCmd.Flags().StringVar(&config.TriageDir, "triage-dir", "", "Directory receiving a triage bundle per failed folder, e.g. for upload as an artifact (empty = none)")
+	rootCmd.Flags().BoolVar(&config.TriageRerun, "triage-rerun", true, "Rerun failed plans with --log-level debug for the triage bundle (applies are never rerun)")
+	rootCmd.Flags().StringVar(&config.FolderNamesFile, "folder-names-file", "", "JSON or YAML file mapping folder paths to display names shown in comments and the summary table")
+	rootCmd.Flags().BoolVar(&config.ScaffoldReview, "scaffold-review", false, "Render units new in the pull request that only hold terragrunt scaffold/catalog boilerplate, showing their inputs instead of a plan")
+	rootCmd.Flags().IntVar(&config.DeleteCommentsOlderThan, "delete-comments-older-than", 0, "Only delete bot comments older than this many hours (0 = any age)")
+	rootCmd.Flags().IntVar(&config.DeleteMax, "delete-max", 50, "Maximum bot comments deleted per run, oldest first; the rest are deleted by later runs (0 = unlimited)")
+	rootCmd.Flags().BoolVar(&config.DeleteDryRun, "delete-dry-run", false, "Report the bot comments that would be deleted without deleting them")
+	rootCmd.Flags().BoolVar(&config.ExecutionPlan, "execution-plan", false, "Show the units run --all queues, in run order with their dependencies, in a collapsed summary section")
+	rootCmd.Flags().StringSliceVar(&config.PROverrides, "pr-overrides", nil, "Options PR authors may set in a terragrunt-runner block of the PR description (detail, skip, only, max-parallel, hide-unchanged-attributes)")
+	rootCmd.Flags().StringVar(&config.UnitIndex, "unit-index", "", "File caching the index of units and their dependencies between runs, updated from the diff since it was saved (empty = no index)")
+	rootCmd.Flags().StringVar(&config.Runbook, "runbook", "", "JSON runbook of ordered steps (folders, command, gate) executed one after the other, replacing folders and auto-detect")
+	rootCmd.Flags().StringVar(&config.RunbookState, "runbook-state", "", "File keeping the completed runbook steps, so a rerun resumes after them")
+	rootCmd.Flags().StringVar(&config.CommentOn, "comment-on", CommentOnAlways, "Folders getting an individual comment: failure (failed only), changes (failed or with changes) or always; the summary covers every folder")
+	rootCmd.Flags().BoolVar(&config.CheckUpdate, "check-update", false, "Compare the running version with the latest release, logging it at debug level and setting the runner-update-available output")
+	rootCmd.Flags().BoolVar(&config.DetailedExitCode, "detailed-exitcode", false, "Plan single folders with -detailed-exitcode and detect changes from the exit status (2 = changes, 0 = none, other = error) instead of the output text")
+	rootCmd.Flags().BoolVar(&config.ReuseComments, "reuse-comments", false, "When a rerun produces the same folder results as the previous run, keep its comments and only update its summary comment")
+	rootCmd.Flags().StringVar(&config.FolderSecrets, "folder-secrets", "", "JSON file declaring secrets (Vault, SSM, Secrets Manager) fetched just before a matching folder runs and injected into its environment only")
+	rootCmd.Flags().BoolVar(&config.RiskScore, "risk-score", false, "Score the risk of each folder's changes (destroys, replaces, IAM and network resources, production paths) in a summary column and the risk-score output")
+	rootCmd.Flags().BoolVar(&config.FmtSuggestions, "fmt-suggestions", false, "When the hclfmt or validate-inputs pre-check fails, post the formatting fixes and the missing inputs with an empty default as suggestions on the changed lines of the PR")
+	rootCmd.Flags().BoolVar(&config.RunDelta, "run-delta", false, "Start the summary with the changes since the previous run's summary (lists the comments of the target once more)")
+	rootCmd.Flags().StringVar(&config.DiffBase, "diff-base", getPRBaseSHA(), "Base ref/SHA to compare against for changed files (defaults to the PR base SHA)")
+
+	rootCmd.AddCommand(newVersionCmd())
+	rootCmd.AddCommand(newServeCmd(logger))
+	rootCmd.AddCommand(newPromoteCmd(config, logger))
+	rootCmd.AddCommand(newDoctorCmd(config, logger))
+	rootCmd.AddCommand(newSmokeCmd(config, logger))
+	rootCmd.AddCommand(newInputsSchemaCmd())
+	rootCmd.AddCommand(newVerifyCmd())
+	rootCmd.AddCommand(newCostSnapshotCmd(config, logger))
+	rootCmd.AddCommand(newUpdateCmd(config, logger))
+	rootCmd.AddCommand(newCleanupPlansCmd(config, logger))
+	return rootCmd
 }
 
 func getPRNumber() int {
@@ -154,20 +442,12 @@ func getPRNumber() int {
 }
 
 func extractPullRequestNumber() (int, error) {
-	github_event_file := "/github/workflow/event.json"
-	file, err := os.ReadFile(github_event_file)
+	payload, err := readEventPayload()
 	if err != nil {
-		fail(fmt.Sprintf("GitHub event payload not found in %s", github_event_file))
+		// Not fatal: subcommands such as "version" must work outside of GitHub Actions
 		return -1, err
 	}
 
-	var data any
-	err = json.Unmarshal(file, &data)
-	if err != nil {
-		return -1, err
-	}
-	payload := data.(map[string]any)
-
 	prNumber, err := strconv.Atoi(fmt.Sprintf("%v", payload["number"]))
 	if err != nil {
 		return 0, fmt.Errorf("not a valid PR")
@@ -175,82 +455,213 @@ func extractPullRequestNumber() (int, error) {
 	return prNumber, nil
 }
 
+// Read the GitHub event payload from GITHUB_EVENT_PATH, falling back to the container path
+func readEventPayload() (map[string]any, error) {
+	github_event_file := os.Getenv("GITHUB_EVENT_PATH")
+	if github_event_file == "" {
+		github_event_file = "/github/workflow/event.json"
+	}
+	file, err := os.ReadFile(github_event_file)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub event payload not found in %s: %w", github_event_file, err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(file, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Get the PR base commit SHA from the event payload (empty if not a PR event)
+func getPRBaseSHA() string {
+	payload, err := readEventPayload()
+	if err != nil {
+		return ""
+	}
+	pr, _ := payload["pull_request"].(map[string]any)
+	base, _ := pr["base"].(map[string]any)
+	sha, _ := base["sha"].(string)
+	return sha
+}
+
 // Main execution function
-func run(cmd *cobra.Command, args []string) error {
-	setupLogging()
-	fmt.Printf("\n\nTerragrunt Runner Version: %s, BuildTime: %s, Commit: %s\n", Version, BuildTime, Commit)
+func (r *Runner) run() error {
+	info := getBuildInfo()
+	fmt.Printf("\n\nTerragrunt Runner Version: %s, BuildTime: %s, Commit: %s, Platform: %s\n", info.Version, info.BuildTime, info.Commit, info.Platform)
+	fmt.Printf("Run ID: %s\n", getRunID())
 
-	// Parse folders from input string (comma, space, newline separated)
-	config.Folders = parseFolders(foldersStr)
+	if r.config.GithubToken != "" {
+		fmt.Printf("::add-mask::%s\n", r.config.GithubToken)
+	}
+	if r.config.WebhookSecret != "" {
+		fmt.Printf("::add-mask::%s\n", r.config.WebhookSecret)
+	}
+	if r.config.TFCToken != "" {
+		fmt.Printf("::add-mask::%s\n", r.config.TFCToken)
+	}
+
+	ctx := context.Background()
+	client := r.createGitHubClient()
+	defer r.logAPIUsage()
 
-	if config.GithubToken != "" {
-		fmt.Printf("::add-mask::%s\n", config.GithubToken)
+	if r.config.CheckUpdate {
+		r.checkForUpdate(ctx, client)
+	}
+
+	// A run description from an external planner replaces the folders, command and args
+	if r.config.InputsFrom != "" {
+		inputs, err := loadRunInputs(r.config.InputsFrom, os.Stdin)
+		if err != nil {
+			return err
+		}
+		if err := r.applyRunInputs(inputs); err != nil {
+			return err
+		}
+	}
+
+	// Units are looked up in the index instead of the tree on large repositories
+	if r.config.UnitIndex != "" {
+		index, err := r.loadUnitIndex(r.config.UnitIndex)
+		if err != nil {
+			r.logger.Warn("Unit index unavailable, walking the tree", "error", err)
+		}
+		r.unitIndex = index
+	}
+
+	// A runbook replaces the folders with those of its steps
+	if r.config.Runbook != "" {
+		runbook, err := loadRunbook(r.config.Runbook)
+		if err != nil {
+			return err
+		}
+		r.applyRunbook(runbook)
+	}
+
+	// A refresh reruns only the requested folders
+	if r.config.Refresh {
+		r.config.AutoDetect = false
 	}
 
 	// Auto-detect folders if enabled and no folders provided
-	if config.AutoDetect {
-		detectedFolders := detectTerragruntFolders()
+	if r.config.AutoDetect && r.config.DiffFrom != "" {
+		files, err := r.changedFilesInRange()
+		if err != nil {
+			return err
+		}
+		r.config.ChangedFiles = files
+	}
+	if r.config.AutoDetect {
+		detectedFolders := r.reconcileDetection(r.detectTerragruntFolders(ctx, client))
 		if len(detectedFolders) > 0 {
-			logger.Info("Auto-detected Terragrunt folders", "folders", detectedFolders)
-			config.Folders = append(config.Folders, detectedFolders...)
+			r.logger.Info("Auto-detected Terragrunt folders", "folders", detectedFolders)
+			r.config.Folders = append(r.config.Folders, detectedFolders...)
 		}
 	}
 
 	// Ensure unique folders
-	config.Folders = uniqueFolders(config.Folders)
+	r.config.Folders = uniqueFolders(r.config.Folders)
 
-	// Validate max runs
-	if config.MaxRuns > 0 && len(config.Folders) > config.MaxRuns {
-		fmt.Printf("::error::Too many Terragrunt folders: %d > %d\n", len(config.Folders), config.MaxRuns)
-		return fmt.Errorf("exceeds max runs: %d folders vs %d limit", len(config.Folders), config.MaxRuns)
+	if r.config.AutoDetect && r.config.ExplainDetection {
+		r.printDetectionExplanation(r.config.Folders)
 	}
 
-	if err := validateConfig(); err != nil {
-		return err
+	// Leave out units whose own configuration excludes them from the command
+	r.config.Folders = r.filterSkippedUnits(r.config.Folders)
+	if len(r.config.Folders) == 0 && len(r.skippedUnits) > 0 {
+		fmt.Printf("::notice::All %d folders are skipped by their configuration\n", len(r.skippedUnits))
+		return nil
 	}
 
-	ctx := context.Background()
-	client := createGitHubClient()
+	// Reviewers can narrow the folders with pull request labels
+	if r.config.LabelFilters && len(r.config.Folders) > 0 {
+		r.config.Folders = r.filterByLabels(ctx, client, r.config.Folders)
+		if len(r.config.Folders) == 0 {
+			fmt.Printf("::notice::All %d folders are skipped by pull request labels\n", len(r.labelSkipped))
+			return nil
+		}
+	}
 
-	if config.DeleteOldComments {
-		if err := deleteOldComments(ctx, client); err != nil {
-			logger.Warn("Failed to delete old comments", "error", err)
+	// Authors can tune the run in the pull request description, within the allowed options
+	if len(r.config.PROverrides) > 0 && len(r.config.Folders) > 0 {
+		folders, err := r.overrideFromDescription(ctx, client, r.config.Folders)
+		if err != nil {
+			return err
+		}
+		r.config.Folders = folders
+		if len(r.config.Folders) == 0 {
+			fmt.Printf("::notice::All %d folders are skipped by the pull request description\n", len(r.overrides.skipped))
+			return nil
 		}
 	}
 
-	results := executeTerragrunt()
+	// Validate max runs
+	if r.config.MaxRuns > 0 && len(r.config.Folders) > r.config.MaxRuns {
+		fmt.Printf("::error::Too many Terragrunt folders: %d > %d\n", len(r.config.Folders), r.config.MaxRuns)
+		return fmt.Errorf("exceeds max runs: %d folders vs %d limit", len(r.config.Folders), r.config.MaxRuns)
+	}
 
-	if err := postComments(ctx, client, results); err != nil {
+	if err := r.validateConfig(); err != nil {
 		return err
 	}
-
-	if err := postSummary(ctx, client, results); err != nil {
+	messages, err := loadMessages(r.config.MessagesFile)
+	if err != nil {
 		return err
 	}
+	r.messages = messages
+	folderNames, err := loadFolderNames(r.config.FolderNamesFile)
+	if err != nil {
+		return err
+	}
+	r.folderNames = folderNames
+	secrets, err := loadFolderSecrets(r.config.FolderSecrets, r.config.EnvDenylist)
+	if err != nil {
+		return err
+	}
+	r.secrets = secrets
 
-	totalAdd, totalChange, totalDestroy, totalReplace := 0, 0, 0, 0
-	hasErrors := false
-	for _, result := range results {
-		if !result.Success {
-			hasErrors = true
+	r.probeTokenPermissions(ctx, client)
 
-			fmt.Printf("Terragrunt execution failed for folder: %s\n", result.Folder)
-			if result.Error != nil {
-				fmt.Printf("Error: %v\n", result.Error)
-			}
-		}
-		if result.ResourceChanges != nil {
-			totalAdd += result.ResourceChanges.ToAdd
-			totalChange += result.ResourceChanges.ToChange
-			totalDestroy += result.ResourceChanges.ToDestroy
-			totalReplace += result.ResourceChanges.ToReplace
+	// Prior plans are read from the comments before they are deleted
+	if r.config.FastPlan {
+		r.priorTargets = r.loadPriorPlanTargets(ctx, client)
+	}
+
+	// Threads are read from the comments before they are deleted
+	if r.config.CommentThreading != "" && r.config.CommentThreading != ThreadingOff {
+		r.threads = r.loadCommentThreads(ctx, client)
+	}
+
+	// The previous run's statistics are read from its summary before it is deleted
+	if r.config.RunDelta {
+		r.prevStats = r.loadPreviousStats(ctx, client)
+	}
+	if r.config.PlanDiff {
+		r.prevPlans = r.loadPreviousPlans(ctx, client)
+	}
+	if r.config.ReuseComments {
+		r.prevSummary = r.loadPriorSummary(ctx, client)
+	}
+
+	// With reuse-comments, old comments are only deleted once the results
+	// show they are outdated
+	if r.config.DeleteOldComments && !r.config.ReuseComments {
+		if err := r.deleteOldComments(ctx, client); err != nil {
+			r.logger.Warn("Failed to delete old comments", "error", err)
 		}
 	}
 
-	setActionOutputs(hasErrors, totalAdd, totalChange, totalDestroy, totalReplace)
+	if err := r.runPipeline(ctx, client); err != nil {
+		return err
+	}
 
-	if hasErrors {
-		return fmt.Errorf("some executions failed")
+	for _, result := range r.results {
+		if !result.Success && (!r.config.SoftFail || result.SetupError) {
+			return fmt.Errorf("some executions failed")
+		}
+	}
+	if failed := r.softFailedFolders(r.results); len(failed) > 0 {
+		fmt.Printf("::warning::%d folders failed, not failing the run because soft-fail is enabled\n", len(failed))
 	}
 	return nil
 }
@@ -264,7 +675,7 @@ func parseFolders(input string) []string {
 }
 
 // Set GitHub Action outputs and warnings
-func setActionOutputs(hasErrors bool, totalAdd, totalChange, totalDestroy, totalReplace int) error {
+func setActionOutputs(hasErrors bool, totalAdd, totalChange, totalDestroy, totalReplace int, folders FolderStatusLists) error {
 	outputFile := os.Getenv("GITHUB_OUTPUT")
 	if outputFile == "" {
 		return nil
@@ -281,6 +692,10 @@ func setActionOutputs(hasErrors bool, totalAdd, totalChange, totalDestroy, total
 		fmt.Sprintf("total-resources-to-change=%d", totalChange),
 		fmt.Sprintf("total-resources-to-destroy=%d", totalDestroy),
 		fmt.Sprintf("total-resources-to-replace=%d", totalReplace),
+		"failed-folders=" + jsonFolderList(folders.Failed),
+		"changed-folders=" + jsonFolderList(folders.Changed),
+		"no-change-folders=" + jsonFolderList(folders.NoChange),
+		fmt.Sprintf("changes-present=%t", len(folders.Changed) > 0),
 	}
 	for _, output := range outputs {
 		fmt.Fprintln(f, output)
@@ -295,39 +710,300 @@ func setActionOutputs(hasErrors bool, totalAdd, totalChange, totalDestroy, total
 	return nil
 }
 
-// Setup logging based on DEBUG env var
-func setupLogging() {
+// Folders of a run by status, written as JSON array outputs
+type FolderStatusLists struct {
+	Failed   []string // Folders whose command failed
+	Changed  []string // Successful folders with planned or applied changes
+	NoChange []string // Successful folders without changes
+}
+
+// Group the per-folder results by status, sorted by folder
+func (r *Runner) folderStatusLists(results []ExecutionResult) FolderStatusLists {
+	var lists FolderStatusLists
+	for _, result := range r.folderResults(results) {
+		switch {
+		case !result.Success:
+			lists.Failed = append(lists.Failed, result.Folder)
+		case result.ResourceChanges != nil && !result.ResourceChanges.NoChanges:
+			lists.Changed = append(lists.Changed, result.Folder)
+		default:
+			lists.NoChange = append(lists.NoChange, result.Folder)
+		}
+	}
+	// Parallel runs finish in any order
+	slices.Sort(lists.Failed)
+	slices.Sort(lists.Changed)
+	slices.Sort(lists.NoChange)
+	return lists
+}
+
+// Encode folders as a JSON array output ("[]" when empty)
+func jsonFolderList(folders []string) string {
+	if folders == nil {
+		folders = []string{}
+	}
+	data, _ := json.Marshal(folders)
+	return string(data)
+}
+
+// Get the per-folder results, skipping the overall summary result of run --all
+func (r *Runner) folderResults(results []ExecutionResult) []ExecutionResult {
+	isRunAll := strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all")
+	if isRunAll && len(results) > 1 && results[0].Folder == r.config.RunAllRootDir {
+		return results[1:]
+	}
+	return results
+}
+
+// Emit GitHub annotations for failed folders and planned destroys so they show up
+// in the Actions annotations panel without expanding the log groups
+func (r *Runner) emitAnnotations(results []ExecutionResult) {
+	for _, result := range r.folderResults(results) {
+		if result.SetupError {
+			fmt.Printf("::error title=Terragrunt setup error::%s: %s\n", escapeAnnotation(result.Folder), escapeAnnotation(setupErrorLine(result)))
+		} else if result.ProviderCrash != "" {
+			fmt.Printf("::error title=Terraform provider crash::%s: %s crashed\n", escapeAnnotation(result.Folder), escapeAnnotation(result.ProviderCrash))
+		} else if !result.Success {
+			fmt.Printf("::error title=Terragrunt failed::%s: %s\n", escapeAnnotation(result.Folder), escapeAnnotation(firstErrorLine(result)))
+		}
+		if result.ResourceChanges != nil && result.ResourceChanges.ToDestroy > 0 {
+			fmt.Printf("::warning title=Terragrunt destroy::%s: %d resources to destroy\n", escapeAnnotation(result.Folder), result.ResourceChanges.ToDestroy)
+		}
+	}
+}
+
+// Return the first "Error:" line from the output, falling back to the execution error
+func firstErrorLine(result ExecutionResult) string {
+	for line := range strings.SplitSeq(stripAnsiCodes(result.Output), "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "Error:") {
+			return trimmed
+		}
+	}
+	if result.Error != nil {
+		return result.Error.Error()
+	}
+	return "unknown error"
+}
+
+// Escape annotation data as required by GitHub workflow commands
+func escapeAnnotation(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// Create the logger, with debug level if the DEBUG env var is set
+func newLogger() *slog.Logger {
+	level := slog.LevelInfo
 	if os.Getenv("DEBUG") == "true" {
-		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
-		slog.SetDefault(logger)
+		level = slog.LevelDebug
 	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 }
 
 // Validate configuration parameters
-func validateConfig() error {
-	if config.GithubToken == "" || config.Repository == "" || config.PullRequest <= 0 || len(config.Folders) == 0 {
+func (r *Runner) validateConfig() error {
+	if err := r.validateTarget(); err != nil {
+		return err
+	}
+	missingPR := (r.config.Target == "" || r.config.Target == TargetPR) && r.config.PullRequest <= 0
+	if r.config.GithubToken == "" || r.config.Repository == "" || missingPR || len(r.config.Folders) == 0 {
 		fmt.Printf("::error::Missing required config: GithubToken=%t, Repository=%s, PullRequest=%d, Folders=%d\n",
-			config.GithubToken == "", config.Repository, config.PullRequest, len(config.Folders))
+			r.config.GithubToken == "", r.config.Repository, r.config.PullRequest, len(r.config.Folders))
 		return fmt.Errorf("missing required config")
 	}
 
-	repoParts := strings.Split(config.Repository, "/")
+	repoParts := strings.Split(r.config.Repository, "/")
 	if len(repoParts) != 2 || !regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-_.]*$`).MatchString(repoParts[0]) || !regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-_.]*$`).MatchString(repoParts[1]) {
 		return fmt.Errorf("invalid repository format")
 	}
 
-	for _, folder := range config.Folders {
+	for _, folder := range r.config.Folders {
 		if strings.Contains(folder, "..") || (filepath.IsAbs(folder) && !strings.HasPrefix(folder, "/workspace")) {
 			return fmt.Errorf("invalid folder: %s", folder)
 		}
 	}
 
-	if config.MaxParallel < 0 || config.MaxParallel > 50 {
+	if r.config.MaxParallel < 0 || r.config.MaxParallel > 50 {
 		return fmt.Errorf("invalid max-parallel")
 	}
 
+	if r.config.AccountDepth < 0 {
+		return fmt.Errorf("invalid account-depth")
+	}
+
+	for _, key := range r.config.PROverrides {
+		if !slices.Contains(prOverrideKeys, key) {
+			return fmt.Errorf("invalid pr-overrides: %s (expected %s)", key, strings.Join(prOverrideKeys, ", "))
+		}
+	}
+
+	if r.config.DeleteCommentsOlderThan < 0 {
+		return fmt.Errorf("invalid delete-comments-older-than")
+	}
+
+	if r.config.DeleteMax < 0 {
+		return fmt.Errorf("invalid delete-max")
+	}
+
+	if r.config.MentionOwners != "" && !slices.Contains([]string{MentionNever, MentionOnFailure, MentionOnDestroy, MentionAlways}, r.config.MentionOwners) {
+		return fmt.Errorf("invalid mention-owners: %s", r.config.MentionOwners)
+	}
+
+	if r.config.CommentOn != "" && !slices.Contains(commentOnModes, r.config.CommentOn) {
+		return fmt.Errorf("invalid comment-on: %s (expected %s)", r.config.CommentOn, strings.Join(commentOnModes, ", "))
+	}
+
+	if r.config.DetailLevel != "" && !slices.Contains(detailLevels, r.config.DetailLevel) {
+		return fmt.Errorf("invalid detail-level: %s", r.config.DetailLevel)
+	}
+	if r.config.FailureDetailLevel != "" && !slices.Contains(detailLevels, r.config.FailureDetailLevel) {
+		return fmt.Errorf("invalid failure-detail-level: %s", r.config.FailureDetailLevel)
+	}
+
+	if r.config.LogFormat != "" && !slices.Contains(logFormats, r.config.LogFormat) {
+		return fmt.Errorf("invalid log-format: %s", r.config.LogFormat)
+	}
+	if r.config.LogFormat == LogFormatJSON && hasArgFlag(r.config.TerragruntArgs, "--tf-forward-stdout") {
+		return fmt.Errorf("log-format json cannot attribute output forwarded with --tf-forward-stdout: remove it from args")
+	}
+
+	if r.config.PlanEncryptKey != "" {
+		if _, _, err := planKMS(r.config.PlanEncryptKey); err != nil {
+			return err
+		}
+	}
+
+	if r.config.CommentThreading != "" && !slices.Contains(threadingModes, r.config.CommentThreading) {
+		return fmt.Errorf("invalid comment-threading: %s", r.config.CommentThreading)
+	}
+	if r.config.CommentThreading == ThreadingReview && r.config.Target != "" && r.config.Target != TargetPR {
+		return fmt.Errorf("comment-threading review requires the pr target")
+	}
+
+	if r.config.DeployProject != "" {
+		if _, _, err := parseProjectRef(r.config.DeployProject); err != nil {
+			return err
+		}
+	}
+	if r.config.DeployProjectStatus != "" && r.config.DeployProject == "" {
+		return fmt.Errorf("deploy-project-status requires deploy-project")
+	}
+
+	if r.config.Engine != "" && !slices.Contains(engineChoices(), strings.ToLower(r.config.Engine)) {
+		return fmt.Errorf("invalid engine: %s (expected one of %s)", r.config.Engine, strings.Join(engineChoices(), ", "))
+	}
+
+	if r.config.Color != "" && !slices.Contains(colorModes, r.config.Color) {
+		return fmt.Errorf("invalid color: %s (expected auto, always or never)", r.config.Color)
+	}
+
+	if r.config.Granularity != "" && !slices.Contains(granularities, r.config.Granularity) {
+		return fmt.Errorf("invalid granularity: %s (expected unit or stack)", r.config.Granularity)
+	}
+	if r.config.Granularity == GranularityStack && isRunAllCommand(r.config.Command) {
+		return fmt.Errorf("granularity stack requires a unit command (stacks already run with run --all)")
+	}
+	// Secrets are injected per folder, which run --all, shards and stacks bypass
+	if r.config.FolderSecrets != "" && (isRunAllCommand(r.config.Command) || r.config.Granularity == GranularityStack) {
+		return fmt.Errorf("folder-secrets requires per-folder runs (not run --all or granularity stack)")
+	}
+
+	if r.config.DiffFrom != "" && !r.config.AutoDetect {
+		return fmt.Errorf("diff-from requires auto-detect")
+	}
+	if r.config.DiffTo != "" && r.config.DiffFrom == "" {
+		return fmt.Errorf("diff-to requires diff-from")
+	}
+	if r.config.DiffFrom != "" && len(r.config.ChangedFiles) > 0 {
+		return fmt.Errorf("diff-from cannot be combined with changed-files")
+	}
+
+	if r.config.TerragruntDetection != "" && !slices.Contains(terragruntDetectionModes, r.config.TerragruntDetection) {
+		return fmt.Errorf("invalid terragrunt-detection: %s", r.config.TerragruntDetection)
+	}
+
+	if r.config.LockfileFix != "" && !slices.Contains(lockfileFixModes, r.config.LockfileFix) {
+		return fmt.Errorf("invalid lockfile-fix: %s", r.config.LockfileFix)
+	}
+	if r.config.LockfileFix != "" && r.config.LockfileFix != LockfileFixOff && len(r.config.LockfilePlatforms) == 0 {
+		return fmt.Errorf("lockfile-platforms is required with lockfile-fix %s", r.config.LockfileFix)
+	}
+
+	if _, ok := notifyPolicies[r.config.NotifyOn]; r.config.NotifyOn != "" && !ok {
+		return fmt.Errorf("invalid notify-on: %s", r.config.NotifyOn)
+	}
+
+	if r.config.WebhookURL != "" {
+		if u, err := url.Parse(r.config.WebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid webhook-url: %s", r.config.WebhookURL)
+		}
+	}
+
+	if r.config.PublishResults != "" {
+		if _, err := parsePublishTarget(r.config.PublishResults); err != nil {
+			return err
+		}
+	}
+
+	if err := r.validateApplyOrder(); err != nil {
+		return err
+	}
+
+	if err := r.validateRunbook(); err != nil {
+		return err
+	}
+
+	if err := r.validateRefresh(); err != nil {
+		return err
+	}
+
+	if r.config.FastPlan {
+		if !isPlanCommand(r.config.Command) {
+			return fmt.Errorf("fast-plan requires a plan command")
+		}
+		// The runner's own flags must still honor the org's denied args
+		if err := (ArgPolicy{Denied: r.config.DeniedArgs}).check([]string{"-refresh=false", "-target=resource"}); err != nil {
+			return fmt.Errorf("fast-plan conflicts with denied-args: %w", err)
+		}
+	}
+
+	if r.config.TFCRuns && r.config.TFCToken == "" {
+		return fmt.Errorf("tfc-runs requires tfc-token (or TF_API_TOKEN)")
+	}
+
+	switch r.config.SignSummary {
+	case "", SignSigstore:
+	case SignKey:
+		if r.config.SigningKey == "" {
+			return fmt.Errorf("sign-summary key requires signing-key")
+		}
+	default:
+		return fmt.Errorf("invalid sign-summary: %s (expected sigstore or key)", r.config.SignSummary)
+	}
+
+	if r.config.ProgressComment && r.config.ProgressInterval < 10 {
+		return fmt.Errorf("invalid progress-interval: %d (must be at least 10 seconds)", r.config.ProgressInterval)
+	}
+
+	if r.config.DeploymentEnvironment != "" {
+		if !isApplyCommand(r.config.Command) {
+			return fmt.Errorf("deployment-environment requires an apply command")
+		}
+		if r.config.DeploymentTimeout <= 0 {
+			return fmt.Errorf("invalid deployment-timeout: %d (must be positive)", r.config.DeploymentTimeout)
+		}
+	}
+
+	if err := validatePreChecks(r.config.PreChecks); err != nil {
+		return err
+	}
+	if err := r.validateSkipStages(); err != nil {
+		return err
+	}
+
 	// Validate CLI command format
-	cmdParts := strings.Fields(config.Command)
+	cmdParts := strings.Fields(r.config.Command)
 	if len(cmdParts) < 1 {
 		return fmt.Errorf("invalid command")
 	}
@@ -335,61 +1011,139 @@ func validateConfig() error {
 	return nil
 }
 
-// Create GitHub client with authentication
-func createGitHubClient() *github.Client {
+// Create GitHub client with authentication, using the API of GITHUB_API_URL
+// when set (GitHub Enterprise Server)
+func (r *Runner) createGitHubClient() *github.Client {
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: config.GithubToken})
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: r.config.GithubToken})
 	tc := oauth2.NewClient(ctx, ts)
-	return github.NewClient(tc)
+	r.apiMetrics = newAPIMetricsTransport(tc.Transport)
+	tc.Transport = r.apiMetrics
+	client := github.NewClient(tc)
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		if u, err := url.Parse(strings.TrimSuffix(apiURL, "/") + "/"); err == nil && u.Host != "" {
+			client.BaseURL = u
+		} else {
+			r.logger.Warn("Ignoring invalid GITHUB_API_URL", "url", apiURL)
+		}
+	}
+	return client
 }
 
-// Delete old bot comments from the PR
-func deleteOldComments(ctx context.Context, client *github.Client) error {
-	parts := strings.Split(config.Repository, "/")
+// Delete old bot comments from the PR (or the commit or issue target), oldest
+// first and at most delete-max of them, so that later runs delete the rest
+func (r *Runner) deleteOldComments(ctx context.Context, client *github.Client) error {
+	parts := strings.Split(r.config.Repository, "/")
 	owner, repo := parts[0], parts[1]
-	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
 
-	for {
-		comments, resp, err := client.Issues.ListComments(ctx, owner, repo, config.PullRequest, opts)
-		if err != nil {
-			return err
+	comments, err := r.listTargetComments(ctx, client, owner, repo)
+	if err != nil {
+		return err
+	}
+	var stale []targetComment
+	for _, comment := range comments {
+		if !strings.Contains(comment.Login, "[bot]") || !isRunnerComment(comment.Body) || r.keepsThreadComment(comment.Body) {
+			continue
 		}
-		for _, comment := range comments {
-			if comment.User == nil || !strings.Contains(*comment.User.Login, "[bot]") {
-				continue
-			}
-			if comment.Body != nil && slices.ContainsFunc(botCommentHeaders, func(header string) bool {
-				return strings.Contains(*comment.Body, header)
-			}) {
-				if _, err := client.Issues.DeleteComment(ctx, owner, repo, *comment.ID); err != nil {
-					logger.Warn("Failed to delete comment", "id", *comment.ID, "error", err)
-					// Continue; don't fail whole function on one delete error
-				}
-			}
+		// Deletion can be deferred until after this run posted its own comments
+		if fields, _ := parseCommentMarker(comment.Body); fields["run"] == getRunID() {
+			continue
 		}
-		if resp.NextPage == 0 {
-			break
+		if r.config.Refresh && !r.refreshReplaces(comment.Body) {
+			continue
+		}
+		minAge := time.Duration(r.config.DeleteCommentsOlderThan) * time.Hour
+		if minAge > 0 && time.Since(comment.Created) < minAge {
+			continue
+		}
+		stale = append(stale, comment)
+	}
+	slices.SortStableFunc(stale, func(a, b targetComment) int { return a.Created.Compare(b.Created) })
+	if r.config.DeleteMax > 0 && len(stale) > r.config.DeleteMax {
+		r.logger.Warn("More old comments than delete-max, keeping the newest for later runs", "comments", len(stale), "max", r.config.DeleteMax)
+		stale = stale[:r.config.DeleteMax]
+	}
+
+	for _, comment := range stale {
+		if r.config.DeleteDryRun {
+			r.logger.Info("Would delete comment (dry run)", "id", comment.ID, "url", comment.URL, "created", comment.Created.Format(time.RFC3339))
+			continue
+		}
+		if !r.caps.deletesAllowed() {
+			return nil
+		}
+		if err := r.deleteTargetComment(ctx, client, owner, repo, comment.ID); err != nil {
+			if isPermissionError(err) {
+				r.caps.disableDeletes("token is not allowed to delete comments")
+				return nil
+			}
+			r.logger.Warn("Failed to delete comment", "id", comment.ID, "error", err)
+			// Continue; don't fail whole function on one delete error
 		}
-		opts.Page = resp.NextPage
 	}
 	return nil
 }
 
 // Execute Terragrunt commands based on configuration
-func executeTerragrunt() []ExecutionResult {
-	isRunAll := strings.Contains(config.Command, "--all") || strings.HasPrefix(config.Command, "run-all")
+func (r *Runner) executeTerragrunt() []ExecutionResult {
+	isRunAll := strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all")
 
 	if isRunAll {
-		return executeTerragruntAll()
+		if roots := runAllRoots(r.config.RunAllRootDir); len(roots) > 1 {
+			if repoRoot, err := r.getRepoRoot(); err == nil {
+				return r.executeTerragruntAllSharded(r.groupFoldersByRoot(repoRoot, roots))
+			}
+		}
+		if r.config.ShardRunAll {
+			if repoRoot, err := r.getRepoRoot(); err == nil {
+				if shards := r.groupFoldersByAccount(repoRoot); len(shards) > 1 {
+					return r.executeTerragruntAllSharded(shards)
+				}
+			}
+		}
+		return r.executeTrackedRunAll()
 	} else {
-		return executeTerragruntPerFolder()
+		if r.config.Granularity == GranularityStack {
+			if repoRoot, err := r.getRepoRoot(); err == nil {
+				return r.executeStacks(r.groupFoldersByStack(repoRoot))
+			}
+		}
+		// Units depending on each other are applied in waves, producers first
+		if isApplyCommand(r.config.Command) && r.config.ApplyDependencyOrder {
+			if waves, dependencies := r.applyWaves(r.config.Folders); len(waves) > 1 {
+				return r.executeDependencyWaves(waves, dependencies)
+			}
+		}
+		results := r.executeTerragruntPerFolder()
+		if r.config.RetryFailed && isPlanCommand(r.config.Command) {
+			results = r.retryFailedFolders(results)
+		}
+		return results
 	}
 }
 
+// Re-run failed folders once, sequentially, since failures in highly parallel runs
+// often stem from contention (plugin cache races, API throttling). Only plans are
+// retried: re-running a partially applied or destroyed folder is not safe.
+func (r *Runner) retryFailedFolders(results []ExecutionResult) []ExecutionResult {
+	for i, result := range results {
+		if result.Success {
+			continue
+		}
+		r.logger.Info("Retrying failed folder sequentially", "folder", result.Folder)
+		retried := r.executeTrackedFolder(result.Folder)
+		retried.Retried = true
+		if !retried.Success {
+			r.logger.Warn("Folder failed again on retry", "folder", result.Folder)
+		}
+		results[i] = retried
+	}
+	return results
+}
+
 // getRepoRoot returns the absolute path of the current git repository root
-func getRepoRoot() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	out, err := cmd.Output()
+func (r *Runner) getRepoRoot() (string, error) {
+	out, err := r.commandOutput(exec.Command("git", "rev-parse", "--show-toplevel"))
 	if err == nil {
 		return strings.TrimSpace(string(out)), nil
 	}
@@ -405,15 +1159,15 @@ func getRepoRoot() (string, error) {
 }
 
 // Execute Terragrunt with --all across multiple folders
-func executeTerragruntAll() []ExecutionResult {
+func (r *Runner) executeTerragruntAll() []ExecutionResult {
 	// Set working directory to the repo root + specified root dir
-	repoRoot, errF := getRepoRoot()
+	repoRoot, errF := r.getRepoRoot()
 	if errF != nil {
 		return []ExecutionResult{{Folder: ".", Error: fmt.Errorf("failed to determine run root: %w", errF), Success: false}}
 	}
-	absRunAllDir := filepath.Join(repoRoot, config.RunAllRootDir)
+	absRunAllDir := filepath.Join(repoRoot, r.config.RunAllRootDir)
 
-	cmdParts := strings.Fields(config.Command)
+	cmdParts := strings.Fields(r.config.Command)
 	// Replace old "run-all" with new "run --all"
 	if cmdParts[0] == "run-all" {
 		cmdParts = append([]string{"run", "--all"}, cmdParts[1:]...)
@@ -444,8 +1198,8 @@ func executeTerragruntAll() []ExecutionResult {
 	}
 
 	// Build Terragrunt-specific flags that go AFTER "run --all" but BEFORE the Terraform subcommand
-	if config.MaxParallel > 0 {
-		terragruntFlags = append(terragruntFlags, "--parallelism", strconv.Itoa(config.MaxParallel))
+	if r.config.MaxParallel > 0 {
+		terragruntFlags = append(terragruntFlags, "--parallelism", strconv.Itoa(r.config.MaxParallel))
 	}
 
 	// Convert folder paths to be relative to absRunAllDir
@@ -458,7 +1212,8 @@ func executeTerragruntAll() []ExecutionResult {
 	//   - We need: account1/baseline (relative to absRunAllDir)
 	//
 	// Without this conversion, Terragrunt excludes all units because the paths don't match.
-	for _, folder := range config.Folders {
+	var includeDirs []string
+	for _, folder := range r.config.Folders {
 		// Convert folder to absolute path first (if it's not already)
 		absFolder := folder
 		if !filepath.IsAbs(folder) {
@@ -470,29 +1225,43 @@ func executeTerragruntAll() []ExecutionResult {
 		relPath, err := filepath.Rel(absRunAllDir, absFolder)
 		if err != nil {
 			// Fallback: try string manipulation if filepath.Rel fails
-			relPath, _ = strings.CutPrefix(folder, config.RunAllRootDir+"/")
-			relPath, _ = strings.CutPrefix(relPath, config.RunAllRootDir)
+			relPath, _ = strings.CutPrefix(folder, r.config.RunAllRootDir+"/")
+			relPath, _ = strings.CutPrefix(relPath, r.config.RunAllRootDir)
 			relPath = strings.TrimPrefix(relPath, "/")
 		}
 
-		logger.Debug("Queue include dir", "original", folder, "absolute", absFolder, "relative", relPath, "runDir", absRunAllDir)
+		r.logger.Debug("Queue include dir", "original", folder, "absolute", absFolder, "relative", relPath, "runDir", absRunAllDir)
 		terragruntFlags = append(terragruntFlags, "--queue-include-dir", relPath)
+		includeDirs = append(includeDirs, relPath)
 	}
 
 	// Include external dependencies for all units
 	terragruntFlags = append(terragruntFlags, "--queue-include-external")
 
+	// Structured logs attribute every line to its unit regardless of the Terragrunt version's
+	// prefix format. Terraform output stays wrapped in log entries (no --tf-forward-stdout).
+	jsonLogs := r.useJSONLogs()
+	if jsonLogs && !hasArgFlag(r.config.TerragruntArgs, "--log-format") {
+		terragruntFlags = append(terragruntFlags, "--log-format", "json")
+	}
+
 	// Append additional Terragrunt args to terragruntFlags
-	if config.TerragruntArgs != "" {
-		sArgs, err := sanitizeArgs(config.TerragruntArgs)
+	if r.config.TerragruntArgs != "" {
+		sArgs, err := sanitizeArgs(r.config.TerragruntArgs, r.argPolicy())
 		if err != nil {
 			return []ExecutionResult{{Folder: ".", Error: err, Success: false}}
 		}
 		terragruntFlags = append(terragruntFlags, sArgs...)
 	}
 
-	// Note: We intentionally do NOT add -no-color flag to preserve color output
-	// If users want to disable colors, they can add it via --args flag
+	// Colors are kept for the console unless disabled by --color/NO_COLOR;
+	// comments are stripped of escape codes separately
+	tfArgs = append(tfArgs, r.noColorArgs(r.config.TerragruntArgs)...)
+
+	// Targets are unit addresses, so a run --all fast plan only skips the refresh
+	if r.config.FastPlan {
+		tfArgs = append(tfArgs, "-refresh=false")
+	}
 
 	// Reassemble cmdParts in correct order:
 	// terragrunt run --all [TERRAGRUNT_FLAGS] [TERRAFORM_SUBCOMMAND] -- [TERRAFORM_ARGS]
@@ -504,36 +1273,58 @@ func executeTerragruntAll() []ExecutionResult {
 		cmdParts = append(cmdParts, tfArgs...) // terraform-specific args
 	}
 
+	planEnv, err := r.readonlyPlanEnv(r.config.Folders)
+	if err != nil {
+		return []ExecutionResult{{Folder: r.config.RunAllRootDir, Error: err, Success: false}}
+	}
+
+	if r.config.ExecutionPlan {
+		r.execPlan = r.captureExecutionPlan(absRunAllDir, includeDirs)
+	}
+
 	// Debug: Print the command that will be executed
-	logger.Info("Executing Terragrunt command", "args", cmdParts, "dir", absRunAllDir)
+	r.logger.Info("Executing Terragrunt command", "args", cmdParts, "dir", absRunAllDir)
 
 	cmd := exec.Command("terragrunt", cmdParts...)
 	cmd.Dir = absRunAllDir
-	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=true", "TG_NON_INTERACTIVE=true")
+	cmd.Env = r.subprocessEnv(planEnv...)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Bound memory usage: only head and tail of huge outputs are kept for parsing
+	outputBuf := newBoundedOutput(r.config.MaxOutputBytes)
+	defer outputBuf.Close()
+	cmd.Stdout, cmd.Stderr = outputBuf, outputBuf
 
-	err := cmd.Run()
-	output := stdout.String() + stderr.String()
+	start := time.Now()
+	err = r.runCommand(cmd)
+	duration := time.Since(start)
+	output := outputBuf.String()
+	if outputBuf.Truncated() {
+		r.logger.Warn("Output exceeded max-output-bytes and was truncated for comments", "limit", r.config.MaxOutputBytes)
+	}
 
-	fmt.Println(Red + "#########################################################" + Reset)
+	fmt.Println(r.colorize(Red, "#########################################################"))
 	fmt.Printf("::group::Terragrunt run --all from %s\n", absRunAllDir)
-	fmt.Print(output) // Print output with colors to console
+	outputBuf.WriteTo(os.Stdout) // Print complete output with colors to console
 	fmt.Println("::endgroup::")
-	fmt.Println(Red + "#########################################################" + Reset)
+	fmt.Println(r.colorize(Red, "#########################################################"))
 
 	// Split output by module to get individual results per folder for summary table
-	moduleOutputs := splitOutputByModule(output)
+	var moduleOutputs map[string]string
+	if jsonLogs {
+		moduleOutputs = splitJSONOutputByModule(output)
+		output = jsonLogText(output)
+	} else {
+		moduleOutputs = splitOutputByModule(output)
+	}
 	results := []ExecutionResult{}
 	var summaryOutput string
 
 	// Create a map of parsed folder names to original folder names for cleaner display
 	folderMap := make(map[string]string)
-	for _, folder := range config.Folders {
+	for _, folder := range r.config.Folders {
 		// Extract the part after root-dir for matching
-		cleanName := strings.TrimPrefix(folder, config.RunAllRootDir+"/")
-		cleanName = strings.TrimPrefix(cleanName, config.RunAllRootDir)
+		cleanName := strings.TrimPrefix(folder, r.config.RunAllRootDir+"/")
+		cleanName = strings.TrimPrefix(cleanName, r.config.RunAllRootDir)
 		cleanName = strings.TrimPrefix(cleanName, "/")
 		folderMap[cleanName] = folder
 	}
@@ -541,6 +1332,25 @@ func executeTerragruntAll() []ExecutionResult {
 	// Track total changes across all modules
 	totalChanges := &ResourceChanges{}
 
+	// Attribute failures to the units that actually failed instead of the run's shared error
+	runSummary := parseRunSummary(output)
+	failedUnits := parseFailedUnits(output)
+	unitErrors := make(map[string]string)
+	unknownUnits := make(map[string]bool)
+	for parsedFolder, modOutput := range moduleOutputs {
+		if parsedFolder == "_summary" {
+			continue
+		}
+		unitErr, known := unitOutcome(parsedFolder, modOutput, failedUnits)
+		if unitErr != "" {
+			unitErrors[parsedFolder] = unitErr
+		} else if !known {
+			unknownUnits[parsedFolder] = true
+		}
+	}
+	// Units with an unknown outcome only share the run's error if no failure was attributed
+	attributed := len(unitErrors) > 0 || (runSummary != nil && runSummary.Failed == 0)
+
 	for parsedFolder, modOutput := range moduleOutputs {
 		// Handle special _summary entry separately
 		if parsedFolder == "_summary" {
@@ -559,15 +1369,28 @@ func executeTerragruntAll() []ExecutionResult {
 
 		// Strip ANSI codes only for PR comments (not for console)
 		cleanOutput := stripAnsiCodes(modOutput)
-		changes := parseResourceChanges(modOutput)
-		success := err == nil && !strings.Contains(modOutput, "Error:")
-		resultErr := err
-		if success {
-			resultErr = nil
+		var resultErr error
+		if unitErr, ok := unitErrors[parsedFolder]; ok {
+			resultErr = fmt.Errorf("%s", unitErr)
+		} else if err != nil && unknownUnits[parsedFolder] && !attributed {
+			resultErr = err
 		}
+		success := resultErr == nil
+
+		parser, engine := r.outputParser(modOutput)
+		result := r.classifyMockedDeps(r.classifyProviderCrash(ExecutionResult{
+			Folder:          displayFolder,
+			Output:          cleanOutput,
+			Error:           resultErr,
+			ResourceChanges: parser.ResourceChanges(modOutput),
+			Success:         success,
+			PlannedOutputs:  parser.PlannedOutputs(modOutput),
+			FullOutput:      cleanOutput,
+			Engine:          engine,
+		}))
 
 		// Accumulate total changes
-		if changes != nil {
+		if changes := result.ResourceChanges; changes != nil {
 			totalChanges.ToAdd += changes.ToAdd
 			totalChanges.ToChange += changes.ToChange
 			totalChanges.ToDestroy += changes.ToDestroy
@@ -576,14 +1399,7 @@ func executeTerragruntAll() []ExecutionResult {
 				totalChanges.NoChanges = false
 			}
 		}
-
-		results = append(results, ExecutionResult{
-			Folder:          displayFolder,
-			Output:          cleanOutput,
-			Error:           resultErr,
-			ResourceChanges: changes,
-			Success:         success,
-		})
+		results = append(results, result)
 	}
 
 	// Append summary to the last result if available
@@ -595,30 +1411,38 @@ func executeTerragruntAll() []ExecutionResult {
 	// Fallback if splitting failed - create results from full output
 	if len(results) == 0 {
 		cleanOutput := stripAnsiCodes(output)
-		totalChanges = parseResourceChanges(output)
+		parser, _ := r.outputParser(output)
+		totalChanges = parser.ResourceChanges(output)
 		success := err == nil
 
 		// Create a result for each configured folder
-		for _, folder := range config.Folders {
-			results = append(results, ExecutionResult{
+		for _, folder := range r.config.Folders {
+			results = append(results, r.classifyProviderCrash(ExecutionResult{
 				Folder:          folder,
 				Output:          cleanOutput,
 				Error:           err,
 				ResourceChanges: totalChanges,
 				Success:         success,
-			})
+			}))
 		}
 	}
 
 	// Prepend a summary result for the overall run --all operation
 	// This shows the root-dir and total changes across all folders
+	_, engine := r.outputParser(output)
 	summaryResult := ExecutionResult{
-		Folder:          config.RunAllRootDir,
+		Folder:          r.config.RunAllRootDir,
 		Output:          stripAnsiCodes(output),
 		Error:           err,
 		ResourceChanges: totalChanges,
 		Success:         err == nil,
+		RunSummary:      runSummary,
+		Duration:        duration,
+		Engine:          engine,
+		SetupError:      isSetupError(err, output),
 	}
+	// The overall output keeps the totals of the units that did not crash
+	summaryResult.Output, _ = stripCrashDump(summaryResult.Output)
 	results = append([]ExecutionResult{summaryResult}, results...)
 
 	return results
@@ -685,26 +1509,32 @@ func splitOutputByModule(output string) map[string]string {
 }
 
 // Execute Terragrunt in each folder separately
-func executeTerragruntPerFolder() []ExecutionResult {
+func (r *Runner) executeTerragruntPerFolder() []ExecutionResult {
 	var results []ExecutionResult
 	var wg sync.WaitGroup
 
-	resultsChan := make(chan ExecutionResult, len(config.Folders))
-	sem := make(chan struct{}, getMaxParallel())
+	resultsChan := make(chan ExecutionResult, len(r.config.Folders))
+	sem := make(chan struct{}, r.getMaxParallel())
 
-	useParallel := config.ParallelExec && getMaxParallel() > 0
+	useParallel := r.config.ParallelExec && r.getMaxParallel() > 0
+	locks := r.concurrencyLocks(r.config.Folders)
 
-	for _, folder := range config.Folders {
+	for _, folder := range r.config.Folders {
 		if useParallel {
 			wg.Add(1)
 			go func(f string) {
 				defer wg.Done()
+				// Wait for the folder's group before taking a parallel slot
+				if lock := locks[f]; lock != nil {
+					lock.Lock()
+					defer lock.Unlock()
+				}
 				sem <- struct{}{}
 				defer func() { <-sem }()
-				resultsChan <- executeTerragruntInFolder(f)
+				resultsChan <- r.executeTrackedFolder(f)
 			}(folder)
 		} else {
-			results = append(results, executeTerragruntInFolder(folder))
+			results = append(results, r.executeTrackedFolder(folder))
 		}
 	}
 
@@ -718,40 +1548,38 @@ func executeTerragruntPerFolder() []ExecutionResult {
 	return results
 }
 
-// Get maximum parallel executions
-func getMaxParallel() int {
-	if config.MaxParallel == 0 {
-		return len(config.Folders)
-	}
-	return config.MaxParallel
+// Execute Terragrunt in a folder, reporting its progress
+func (r *Runner) executeTrackedFolder(folder string) ExecutionResult {
+	r.progress.start(folder)
+	result := r.executeTerragruntInFolder(folder)
+	r.progress.finish(folder, result.Success)
+	return result
 }
 
-// Sanitize additional Terragrunt arguments
-func sanitizeArgs(args string) ([]string, error) {
-	fields := strings.Fields(args)
-	sanitized := []string{}
-
-	forbidden := []string{";", "&&", "||", "|", ">", "<", "`", "$(", "${"}
+// Execute run --all from the root dir, reporting its progress
+func (r *Runner) executeTrackedRunAll() []ExecutionResult {
+	r.progress.start(r.config.RunAllRootDir)
+	results := r.executeTerragruntAll()
+	r.progress.finish(r.config.RunAllRootDir, len(results) > 0 && results[0].Success)
+	return results
+}
 
-	for _, field := range fields {
-		for _, pat := range forbidden {
-			if strings.Contains(field, pat) {
-				return nil, fmt.Errorf("forbidden pattern in arg: %s", field)
-			}
-		}
-		sanitized = append(sanitized, field)
+// Get maximum parallel executions
+func (r *Runner) getMaxParallel() int {
+	if r.config.MaxParallel == 0 {
+		return len(r.config.Folders)
 	}
-	return sanitized, nil
+	return r.config.MaxParallel
 }
 
 // Execute Terragrunt in a specific folder
-func executeTerragruntInFolder(folder string) ExecutionResult {
+func (r *Runner) executeTerragruntInFolder(folder string) ExecutionResult {
 	// Calculate absolute folder path correctly
 	// If folder is already absolute, use it as-is
 	// If folder is relative, join it with repo root (not current working directory)
 	absFolder := folder
 	if !filepath.IsAbs(folder) {
-		repoRoot, err := getRepoRoot()
+		repoRoot, err := r.getRepoRoot()
 		if err != nil {
 			return ExecutionResult{Folder: folder, Error: fmt.Errorf("failed to determine repo root: %w", err), Success: false}
 		}
@@ -759,48 +1587,98 @@ func executeTerragruntInFolder(folder string) ExecutionResult {
 	}
 	absFolder = filepath.Clean(absFolder)
 
-	logger.Debug("Execute in folder", "original", folder, "absolute", absFolder)
+	r.logger.Debug("Execute in folder", "original", folder, "absolute", absFolder)
+
+	if r.config.TFCRuns {
+		ws, err := r.detectTFCWorkspace(absFolder)
+		if err != nil {
+			return ExecutionResult{Folder: folder, Error: err, Success: false}
+		}
+		if ws != nil {
+			return r.executeTFCRun(folder, absFolder, ws)
+		}
+	}
+
+	if r.isScaffoldUnit(absFolder) {
+		return r.executeScaffoldRender(folder)
+	}
 
-	cmdParts := strings.Fields(config.Command)
-	if config.TerragruntArgs != "" {
-		sArgs, err := sanitizeArgs(config.TerragruntArgs)
+	command, args := r.folderCommand(folder)
+	cmdParts := strings.Fields(command)
+	if args != "" {
+		sArgs, err := sanitizeArgs(args, r.argPolicy())
 		if err != nil {
 			return ExecutionResult{Folder: folder, Error: err, Success: false}
 		}
 		cmdParts = append(cmdParts, sArgs...)
 	}
+	cmdParts = append(cmdParts, r.fastPlanArgs(folder)...)
+	detailedExit := r.detailedExitCodeArgs(command)
+	cmdParts = append(cmdParts, detailedExit...)
 
-	// Note: We intentionally do NOT add -no-color flag to preserve color output
-	// If users want to disable colors, they can add it via --args flag
+	// Colors are kept for the console unless disabled by --color/NO_COLOR;
+	// comments are stripped of escape codes separately
+	cmdParts = append(cmdParts, r.noColorArgs(args)...)
+
+	planEnv, err := r.readonlyPlanEnv([]string{absFolder})
+	if err != nil {
+		return ExecutionResult{Folder: folder, Error: err, Success: false}
+	}
+
+	// Secrets are read just in time and only reach this folder's process
+	secretEnv, secretValues, err := r.fetchFolderSecrets(folder)
+	if err != nil {
+		return ExecutionResult{Folder: folder, Error: err, Success: false}
+	}
 
 	cmd := exec.Command("terragrunt", cmdParts...)
 	cmd.Dir = absFolder
-	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=true", "TG_NON_INTERACTIVE=true")
+	cmd.Env = r.subprocessEnv(slices.Concat(r.folderEnv(folder), planEnv, secretEnv)...)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Bound memory usage: only head and tail of huge outputs are kept for parsing
+	outputBuf := newBoundedOutput(r.config.MaxOutputBytes)
+	defer outputBuf.Close()
+	cmd.Stdout, cmd.Stderr = outputBuf, outputBuf
 
-	err := cmd.Run()
-	output := stdout.String() + stderr.String()
+	start := time.Now()
+	err = r.runCommand(cmd)
+	duration := time.Since(start)
+	output := redactSecrets(outputBuf.String(), secretValues)
+	if outputBuf.Truncated() {
+		r.logger.Warn("Output exceeded max-output-bytes and was truncated for comments", "limit", r.config.MaxOutputBytes)
+	}
 	fmt.Println() // empty line for easier read in the console log
 
-	fmt.Println(Red + "#########################################################" + Reset)
+	fmt.Println(r.colorize(Red, "#########################################################"))
 	fmt.Printf("::group::Terragrunt in %s\n", folder)
-	fmt.Print(output) // Print output with colors to console
+	outputBuf.WriteTo(os.Stdout) // Print complete output with colors to console
 	fmt.Println("::endgroup::")
-	fmt.Println(Red + "#########################################################" + Reset)
+	fmt.Println(r.colorize(Red, "#########################################################"))
 
 	// Strip ANSI codes only for PR comments (not for console)
-	cleanOutput := extractTerraformOutput(output)
-	changes := parseResourceChanges(output)
+	parser, engine := r.outputParser(output)
+	cleanOutput := parser.Extract(output)
+	changes := parser.ResourceChanges(output)
+	if detailedExit != nil {
+		changes, err = applyDetailedExitCode(changes, err)
+	}
 
-	return ExecutionResult{
+	result := ExecutionResult{
 		Folder:          folder,
 		Output:          cleanOutput,
 		Error:           err,
 		ResourceChanges: changes,
 		Success:         err == nil,
+		PlannedOutputs:  parser.PlannedOutputs(output),
+		FullOutput:      stripAnsiCodes(output),
+		Duration:        duration,
+		Engine:          engine,
+		SetupError:      isSetupError(err, output),
 	}
+	if planFile := planOutFile(command + " " + args); result.Success && planFile != "" && isPlanCommand(command) {
+		result.ResourceTypes = r.savedPlanResourceTypes(folder, absFolder, planFile)
+	}
+	return r.classifyMockedDeps(r.classifyProviderCrash(result))
 }
 
 // stripAnsiCodes removes all ANSI escape sequences from a string
@@ -907,15 +1785,30 @@ func parseResourceChanges(output string) *ResourceChanges {
 	output = stripAnsiCodes(output)
 
 	changes := &ResourceChanges{}
-	r := regexp.MustCompile(`Plan:\s+(\d+)\s+to\s+add,?\s+(\d+)\s+to\s+change,?\s+(\d+)\s+to\s+destroy`)
-	m := r.FindStringSubmatch(output)
-	if len(m) == 4 {
-		changes.ToAdd, _ = strconv.Atoi(m[1])
-		changes.ToChange, _ = strconv.Atoi(m[2])
-		changes.ToDestroy, _ = strconv.Atoi(m[3])
+	// Terraform and OpenTofu prefix the summary with imports and OpenTofu appends forgotten resources:
+	// "Plan: 1 to import, 2 to add, 0 to change, 0 to destroy, 1 to forget."
+	r := regexp.MustCompile(`Plan:((?:,?\s+\d+\s+to\s+(?:import|add|change|destroy|forget))+)`)
+	if m := r.FindStringSubmatch(output); m != nil {
+		counts := regexp.MustCompile(`(\d+)\s+to\s+(import|add|change|destroy|forget)`).FindAllStringSubmatch(m[1], -1)
+		for _, c := range counts {
+			n, _ := strconv.Atoi(c[1])
+			switch c[2] {
+			case "import":
+				changes.ToImport = n
+			case "add":
+				changes.ToAdd = n
+			case "change":
+				changes.ToChange = n
+			case "destroy":
+				changes.ToDestroy = n
+			case "forget":
+				changes.ToForget = n
+			}
+		}
 	}
 
-	if strings.Contains(output, "No changes") {
+	// OpenTofu and Terraform word the no-changes message slightly differently across versions
+	if strings.Contains(output, "No changes") || strings.Contains(output, "no changes are needed") {
 		changes.NoChanges = true
 	}
 
@@ -923,49 +1816,79 @@ func parseResourceChanges(output string) *ResourceChanges {
 }
 
 // Post individual comments for each execution result
-func postComments(ctx context.Context, client *github.Client, results []ExecutionResult) error {
-	parts := strings.Split(config.Repository, "/")
+func (r *Runner) postComments(ctx context.Context, client *github.Client, results []ExecutionResult) error {
+	parts := strings.Split(r.config.Repository, "/")
 	owner, repo := parts[0], parts[1]
 
 	// For run --all, only post the first result (overall summary)
 	// Individual folder results are shown in the summary table only
-	isRunAll := strings.Contains(config.Command, "--all") || strings.HasPrefix(config.Command, "run-all")
+	isRunAll := strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all")
 	commentsToPost := results
-	if isRunAll && len(results) > 1 && results[0].Folder == config.RunAllRootDir {
+	if isRunAll && len(results) > 1 && results[0].Folder == r.config.RunAllRootDir {
 		commentsToPost = results[:1] // Only post the first result (overall summary)
 	}
 
 	for _, result := range commentsToPost {
-		header := formatCommentHeader(result)
+		if !r.postsComment(result) {
+			continue
+		}
+		header := r.formatCommentHeader(result)
+		if isRunAll && len(results) > 1 && result.Folder == r.config.RunAllRootDir {
+			// Mention owners of the individual units in the overall run --all comment
+			header += r.formatOwnerMentions(results[1:])
+		} else {
+			header += r.formatOwnerMentions([]ExecutionResult{result})
+		}
 
 		if result.ResourceChanges != nil && result.ResourceChanges.NoChanges {
-			body := header + "\nNo Changes"
-			if err := createComment(ctx, client, owner, repo, body); err != nil {
+			if r.config.SkipNoChangeComments && result.Success {
+				continue
+			}
+			body := r.withEmbeddedPlan(header+"\n"+r.formatProviderUpgrades(r.providerUpgrades(result.Folder))+r.msg("comment.no_changes"), result)
+			if err := r.createComment(ctx, client, owner, repo, result.Folder, body); err != nil {
 				return err
 			}
 			continue
 		}
 
-		content := result.Output
+		content := r.commentContent(result)
+		if r.config.HideUnchangedAttributes && result.Success {
+			content = hideUnchangedAttributes(content)
+		}
+		replacements, content := r.highlightReplacements(result, content)
+		// Provider upgrades explain plan churn, so they lead the highlights
+		if upgrades := r.formatProviderUpgrades(r.providerUpgrades(result.Folder)); upgrades != "" {
+			replacements = upgrades + "\n" + replacements
+		}
+		replacements += r.formatPlanDiff(result.Folder, content)
 
-		detailsTitle := "View Output"
+		detailsTitle := r.msg("comment.view_output")
 		if !result.Success {
-			detailsTitle = "View Error Details"
-			content = result.Error.Error()
+			detailsTitle = r.msg("comment.view_error")
 		}
 
-		if len(header)+len(content) <= maxCommentSize-headerSize {
-			body := header + "\n\n<details><summary><b>" + detailsTitle + "</b></summary>\n\n```hcl\n" + content + "\n```\n</details>"
-			if err := createComment(ctx, client, owner, repo, body); err != nil {
+		if body := header + "\n" + replacements + "\n" + formatOutputDetails(detailsTitle, content); len(body) <= maxCommentSize-commentRoom {
+			body = r.withEmbeddedPlan(body, result)
+			if err := r.createComment(ctx, client, owner, repo, result.Folder, body); err != nil {
 				return err
 			}
 		} else {
-			chunks := splitContent(content, maxCommentSize-headerSize-300)
+			// Parts are sized in bytes from their actual header and wrapping
+			widest := r.formatCommentHeaderWithPart(result, maxPartNumber, maxPartNumber) + "\n" + replacements + "\n\n" +
+				formatOutputDetails(r.msg("comment.part", "title", detailsTitle, "part", maxPartNumber, "total", maxPartNumber), "")
+			chunks := splitContent(content, maxCommentSize-commentRoom-len(widest))
 			for i, chunk := range chunks {
-				partHeader := formatCommentHeaderWithPart(result, i+1, len(chunks))
-				partTitle := fmt.Sprintf("%s (Part %d/%d)", detailsTitle, i+1, len(chunks))
-				body := partHeader + "\n\n<details><summary><b>" + partTitle + "</b></summary>\n\n```hcl\n" + chunk + "\n```\n</details>"
-				if err := createComment(ctx, client, owner, repo, body); err != nil {
+				partHeader := r.formatCommentHeaderWithPart(result, i+1, len(chunks))
+				if i == 0 {
+					// Replacements are highlighted once, above the first part
+					partHeader += "\n" + replacements
+				}
+				partTitle := r.msg("comment.part", "title", detailsTitle, "part", i+1, "total", len(chunks))
+				body := partHeader + "\n\n" + formatOutputDetails(partTitle, chunk)
+				if i == len(chunks)-1 {
+					body = r.withEmbeddedPlan(body, result)
+				}
+				if err := r.createComment(ctx, client, owner, repo, result.Folder, body); err != nil {
 					return err
 				}
 			}
@@ -975,34 +1898,51 @@ func postComments(ctx context.Context, client *github.Client, results []Executio
 }
 
 // Format comment header with status and changes
-func formatCommentHeader(result ExecutionResult) string {
-	status := "✅ Success"
+func (r *Runner) formatCommentHeader(result ExecutionResult) string {
+	status := r.msg("status.success")
 	if !result.Success {
-		status = "❌ Failed"
+		status = r.msg("status.failed")
 	}
 
 	// For run --all commands, show just the command instead of folder names
-	isRunAll := strings.Contains(config.Command, "--all") || strings.HasPrefix(config.Command, "run-all")
-	folderDisplay := result.Folder
-	if isRunAll {
-		folderDisplay = config.Command
-	}
-
-	header := fmt.Sprintf("## %s Terragrunt: %s\n", status, folderDisplay)
+	isRunAll := strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all")
+	folderDisplay := r.displayName(result.Folder)
 	if isRunAll {
-		header += fmt.Sprintf("**Folder:** %s\n", result.Folder)
-	}
-	header += fmt.Sprintf("**Command:** %s\n", config.Command)
+		folderDisplay = r.config.Command
+	}
+
+	header := fmt.Sprintf("## %s %s: %s\n", status, r.msg("comment.title"), folderDisplay)
+	// Folders shown by name keep their path below the title
+	if isRunAll || folderDisplay != result.Folder {
+		header += fmt.Sprintf("**%s:** %s\n", r.msg("comment.folder"), result.Folder)
+	}
+	command, _ := r.folderCommand(result.Folder)
+	if len(result.StackUnits) > 0 {
+		command = runAllCommand(command)
+	}
+	header += fmt.Sprintf("**%s:** %s\n", r.msg("comment.command"), command)
+	header += r.formatStackUnits(result)
+	header += r.formatFolderMetadata(result.Folder)
+	if result.Engine != "" {
+		header += fmt.Sprintf("**%s:** %s\n", r.msg("comment.engine"), result.Engine)
+	}
+	header += r.formatFastPlanNote(result.Folder)
+	header += r.formatProviderCrashNote(result)
+	header += formatTriageNote(result)
+	header += formatScaffoldNote(result)
+	header += r.formatMockedDepsNote(result)
+	header += formatTFCRunNote(result)
 	if result.ResourceChanges != nil && !result.ResourceChanges.NoChanges {
-		header += formatResourceChanges(result.ResourceChanges)
+		header += fmt.Sprintf("**%s:** %s", r.msg("comment.changes"), strings.TrimPrefix(formatResourceChanges(result.ResourceChanges), "**Changes:** "))
 	}
 	return header
 }
 
 // Format comment header with part information
-func formatCommentHeaderWithPart(result ExecutionResult, part, total int) string {
-	header := formatCommentHeader(result)
-	return strings.Replace(header, result.Folder, fmt.Sprintf("%s (%d/%d)", result.Folder, part, total), 1)
+func (r *Runner) formatCommentHeaderWithPart(result ExecutionResult, part, total int) string {
+	header := r.formatCommentHeader(result)
+	name := r.displayName(result.Folder)
+	return strings.Replace(header, name, fmt.Sprintf("%s (%d/%d)", name, part, total), 1)
 }
 
 // Format resource changes summary
@@ -1020,59 +1960,192 @@ func formatResourceChanges(changes *ResourceChanges) string {
 	if changes.ToReplace > 0 {
 		parts = append(parts, fmt.Sprintf("/%d replace", changes.ToReplace))
 	}
+	if changes.ToImport > 0 {
+		parts = append(parts, fmt.Sprintf("%d import", changes.ToImport))
+	}
+	if changes.ToForget > 0 {
+		parts = append(parts, fmt.Sprintf("%d forget", changes.ToForget))
+	}
 	return "**Changes:** " + strings.Join(parts, ", ") + "\n"
 }
 
-// Split content into manageable chunks for comments
+// Wrap command output in a collapsed code block
+func formatOutputDetails(title, content string) string {
+	return "<details><summary><b>" + title + "</b></summary>\n\n```hcl\n" + content + "\n```\n</details>"
+}
+
+// Split content into chunks of at most maxSize bytes, the unit of GitHub's
+// limit. Chunks end at line breaks, or at a rune boundary for lines too long
+// for a chunk. A chunk ending inside a fenced code block of the content closes
+// the fence and the next chunk reopens it, with room kept for the closing fence.
 func splitContent(content string, maxSize int) []string {
 	var chunks []string
 	var builder strings.Builder
-	scanner := bufio.NewScanner(strings.NewReader(content))
+	fence := "" // Opening line of the fenced block the content is in
+	flush := func() {
+		chunk := builder.String()
+		if fence != "" && len(chunk) > len(fence) && strings.HasSuffix(chunk, fence) {
+			// A fence opening the end of a chunk moves to the next one
+			chunk = strings.TrimSuffix(chunk, fence)
+		} else if fence != "" {
+			if !strings.HasSuffix(chunk, "\n") {
+				chunk += "\n"
+			}
+			chunk += fenceMarker(fence) + "\n"
+		}
+		chunks = append(chunks, chunk)
+		builder.Reset()
+		builder.WriteString(fence)
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text() + "\n"
-		if builder.Len()+len(line) > maxSize && builder.Len() > 0 {
-			chunks = append(chunks, builder.String())
-			builder.Reset()
+	for line := range strings.SplitAfterSeq(content, "\n") {
+		next := nextFence(fence, line)
+		if builder.Len() > len(fence) && builder.Len()+len(line)+fenceCloseSize(next) > maxSize {
+			flush()
+		}
+		// Lines too long for a chunk of their own are cut
+		for line != "" && builder.Len()+len(line)+fenceCloseSize(next) > maxSize {
+			// Cut lines need a line break before the closing fence
+			piece := cutUTF8(line, maxSize-builder.Len()-fenceCloseSize(fence)-min(fenceCloseSize(fence), 1))
+			if piece == "" {
+				_, size := utf8.DecodeRuneInString(line)
+				piece = line[:size]
+			}
+			builder.WriteString(piece)
+			line = line[len(piece):]
+			flush()
 		}
 		builder.WriteString(line)
+		fence = next
 	}
-	if builder.Len() > 0 {
+	if builder.Len() > len(fence) {
 		chunks = append(chunks, builder.String())
 	}
 	return chunks
 }
 
+// Get the fenced block state after a line: the opening line of the block the
+// content is in, or empty outside fenced blocks
+func nextFence(fence, line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	marker := fenceMarker(trimmed)
+	switch {
+	case len(line)-len(trimmed) > 3 || marker == "":
+		return fence
+	case fence == "":
+		return strings.TrimRight(line, "\n") + "\n"
+	case strings.HasPrefix(marker, fenceMarker(strings.TrimLeft(fence, " "))) && strings.TrimSpace(trimmed[len(marker):]) == "":
+		return ""
+	}
+	return fence
+}
+
+// Get the fence marker (``` or ~~~, at least three) starting a line
+func fenceMarker(line string) string {
+	line = strings.TrimLeft(line, " ")
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := len(line) - len(strings.TrimLeft(line, line[:1]))
+	if n < 3 {
+		return ""
+	}
+	return line[:n]
+}
+
+// Size of the line closing a fenced block (0 outside fenced blocks)
+func fenceCloseSize(fence string) int {
+	if fence == "" {
+		return 0
+	}
+	return len(fenceMarker(fence)) + 1
+}
+
+// Cut a text to at most size bytes without splitting a multi-byte rune
+func cutUTF8(text string, size int) string {
+	if size <= 0 {
+		return ""
+	}
+	if len(text) <= size {
+		return text
+	}
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size]
+}
+
+// Cut a comment body exceeding GitHub's limit, which would be rejected
+func truncateComment(body string) string {
+	const note = "\n\n_Truncated: the comment exceeded GitHub's size limit_"
+	if len(body) <= maxCommentSize {
+		return body
+	}
+	return cutUTF8(body, maxCommentSize-len(note)) + note
+}
+
 // Post a summary comment with overall results
-func postSummary(ctx context.Context, client *github.Client, results []ExecutionResult) error {
-	parts := strings.Split(config.Repository, "/")
+func (r *Runner) postSummary(ctx context.Context, client *github.Client, results []ExecutionResult) error {
+	parts := strings.Split(r.config.Repository, "/")
 	owner, repo := parts[0], parts[1]
-	summary := formatSummary(results)
-	return createComment(ctx, client, owner, repo, summary)
+	summary := r.formatSummary(results)
+	if r.config.SignSummary != "" {
+		signature, err := r.signSummary(results, summary)
+		if err != nil {
+			return err
+		}
+		summary += signature
+	}
+	if r.reusedFrom != "" {
+		err := r.editSummary(ctx, client, summary)
+		if err == nil {
+			return nil
+		}
+		r.logger.Warn("Failed to edit the previous summary comment, posting a new one", "error", err)
+	}
+	return r.createComment(ctx, client, owner, repo, summaryMarkerFolder, summary)
 }
 
 // Format summary of all execution results
-func formatSummary(results []ExecutionResult) string {
+func (r *Runner) formatSummary(results []ExecutionResult) string {
 	var b strings.Builder
 
 	// For run --all, skip the first result (which is the overall summary)
 	// and only show individual folder results in the table
-	isRunAll := strings.Contains(config.Command, "--all") || strings.HasPrefix(config.Command, "run-all")
+	isRunAll := strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all")
 	tableResults := results
-	if isRunAll && len(results) > 1 && results[0].Folder == config.RunAllRootDir {
+	if isRunAll && len(results) > 1 && results[0].Folder == r.config.RunAllRootDir {
 		tableResults = results[1:]
 	}
 
-	b.WriteString("## Terragrunt Summary\n\n**Command:** " + config.Command + "\n**Folders:** " + fmt.Sprint(len(tableResults)) + "\n\n")
+	b.WriteString("## " + r.msg("summary.title") + "\n\n**" + r.msg("comment.command") + ":** " + r.config.Command + "\n**" + r.msg("summary.folders") + ":** " + fmt.Sprint(len(tableResults)) + "\n\n")
 
-	b.WriteString("| Folder | Status | Add | Change | Destroy | Replace |\n|--------|--------|-----|--------|---------|---------|\n")
-	success, noChange := 0, 0
+	b.WriteString(r.formatSoftFailBanner(tableResults))
+	b.WriteString(r.formatRunDelta(tableResults))
+	b.WriteString(r.formatFastPlanNote(""))
+	riskColumn, riskSeparator := "", ""
+	if r.config.RiskScore {
+		riskColumn, riskSeparator = " "+r.msg("summary.column.risk")+" |", "------|"
+	}
+	b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |%s\n|--------|--------|-----|--------|---------|---------|%s\n",
+		r.msg("summary.column.folder"), r.msg("summary.column.status"), r.msg("summary.column.add"),
+		r.msg("summary.column.change"), r.msg("summary.column.destroy"), r.msg("summary.column.replace"), riskColumn, riskSeparator))
+	withRisk := r.config.RiskScore
+	success, noChange, passedOnRetry := 0, 0, 0
+	retryStatus, crashStatus := r.msg("status.passed_on_retry"), r.msg("status.provider_crash")
+	displayName := r.displayName
 	for _, r := range tableResults {
 		status := "✅"
-		if !r.Success {
+		if r.ProviderCrash != "" {
+			status = crashStatus
+		} else if !r.Success {
 			status = "❌"
 		} else {
 			success++
+			if r.Retried {
+				status = retryStatus
+				passedOnRetry++
+			}
 		}
 		add, change, destroy, replace := "0", "0", "0", "0"
 		if r.ResourceChanges != nil {
@@ -1093,45 +2166,139 @@ func formatSummary(results []ExecutionResult) string {
 				noChange++
 			}
 		}
-		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n", r.Folder, status, add, change, destroy, replace))
-	}
-
-	b.WriteString(fmt.Sprintf("\n- Success: %d/%d\n- No Changes: %d\n", success, len(tableResults), noChange))
+		if len(r.MockedDeps) > 0 {
+			status += " 🧪"
+		}
+		if r.Scaffolded {
+			status += " 🏗️"
+		}
+		folder := strings.ReplaceAll(displayName(r.Folder), "|", "\\|")
+		risk := ""
+		if withRisk {
+			risk = " " + formatRiskScore(riskScore(r)) + " |"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |%s\n", folder, status, add, change, destroy, replace, risk))
+	}
+
+	b.WriteString("\n- " + r.msg("summary.success", "success", success, "total", len(tableResults)) + "\n- " + r.msg("summary.no_changes", "count", noChange) + "\n")
+	if passedOnRetry > 0 {
+		b.WriteString("- " + r.msg("summary.passed_on_retry", "count", passedOnRetry) + "\n")
+	}
+	b.WriteString(r.formatMockedDepsSummary(tableResults))
+	if r.config.SkipNoChangeComments && noChange > 0 {
+		b.WriteString("- " + r.msg("summary.no_change_comments", "count", noChange) + "\n")
+	}
+	b.WriteString(r.formatCommentOnSummary(tableResults))
+	b.WriteString(r.formatReusedComments())
+	b.WriteString(r.formatSkippedUnits())
+	b.WriteString(r.formatLabelSkipped())
+	b.WriteString(r.formatPROverrides())
+	b.WriteString(r.formatUndeterminedFiles())
+	b.WriteString(formatTopResourceTypes(aggregateResourceTypes(tableResults)))
+	b.WriteString(formatBaseComparison(r.comparisons, r.config.DiffBase))
+	b.WriteString(formatFmtAutofix(r.fmtAutofix))
+	b.WriteString(formatStageTimings(r.stageTimings))
+	if r.config.ExplainDetectionComment {
+		b.WriteString(r.formatDetectionSection(r.config.Folders))
+	}
+	if isRunAll && len(results) > 0 && results[0].RunSummary != nil {
+		b.WriteString(formatRunSummary(results[0].RunSummary))
+	}
+	b.WriteString(formatExecutionPlan(r.execPlan))
+	b.WriteString(formatRunStats(runStats(tableResults)))
+	b.WriteString(formatResultsDigest(resultsDigest(r.config.Command, results)))
 	return b.String()
 }
 
-// Create a comment on the GitHub PR
-func createComment(ctx context.Context, client *github.Client, owner, repo, body string) error {
-	comment := &github.IssueComment{Body: &body}
-	_, _, err := client.Issues.CreateComment(ctx, owner, repo, config.PullRequest, comment)
+// Create a comment on the GitHub PR (or the commit or issue target), tagged
+// with a hidden marker for the folder. With comment-threading, folder comments
+// reply in the folder's review thread or link its previous comment.
+func (r *Runner) createComment(ctx context.Context, client *github.Client, owner, repo, folder, body string) error {
+	body += commentFooter(r.config.RunLabel)
+	if !r.caps.commentsAllowed() {
+		return writeStepSummary(body)
+	}
+	markedBody := commentMarker(folder, r.config.RunLabel) + "\n"
+	if isFolderMarker(folder) {
+		posted, err := r.postInReviewThread(ctx, client, owner, repo, folder, markedBody+body)
+		if posted {
+			return nil
+		}
+		if err != nil {
+			r.logger.Warn("Failed to post in the review thread, posting a comment instead", "folder", folder, "error", err)
+		}
+		markedBody += r.threadReference(folder)
+	}
+	markedBody += body
+	if len(markedBody) > maxCommentSize {
+		r.logger.Warn("Comment exceeds GitHub's size limit, truncating", "folder", folder, "bytes", len(markedBody))
+		markedBody = truncateComment(markedBody)
+	}
+	_, err := r.postTargetComment(ctx, client, owner, repo, markedBody)
+	if err != nil && isPermissionError(err) {
+		r.caps.disableComments("token is not allowed to create comments")
+		return writeStepSummary(body)
+	}
 	return err
 }
 
 // Detect Terragrunt folders based on changed files
-func detectTerragruntFolders() []string {
+func (r *Runner) detectTerragruntFolders(ctx context.Context, client *github.Client) []string {
 	found := make(map[string]bool)
-	if len(config.ChangedFiles) == 0 {
-		config.ChangedFiles = getChangedFilesFromGit()
-	}
-	for _, file := range config.ChangedFiles {
-		if matchesPatterns(file, config.FilePatterns) {
-			dir := findTerragruntDirectory(file)
-			if dir != "" {
-				found[dir] = true
+	if len(r.config.ChangedFiles) == 0 && r.config.DiffFrom == "" {
+		r.config.ChangedFiles = r.getChangedFiles(ctx, client)
+	}
+	for _, file := range r.config.ChangedFiles {
+		explanation := DetectionExplanation{File: file, Pattern: matchingPattern(file, r.config.FilePatterns)}
+		if explanation.Pattern != "" {
+			explanation.Folder, explanation.Checked, explanation.Reason = r.traceTerragruntDirectory(file)
+			if explanation.Folder != "" {
+				found[explanation.Folder] = true
 			}
+		} else {
+			explanation.Reason = "no file pattern matched"
 		}
+		r.explanations = append(r.explanations, explanation)
 	}
+	r.reportUndeterminedFiles()
 	var res []string
 	for k := range found {
 		res = append(res, k)
 	}
+	// Sorted so folders run and comment in a stable order
+	slices.Sort(res)
 	return res
 }
 
-// Get changed files from the last git commit
-func getChangedFilesFromGit() []string {
-	cmd := exec.Command("git", "diff", "--name-only", "HEAD~1")
-	out, _ := cmd.Output()
+// Get changed files by comparing against the diff base, falling back to the
+// GitHub API and finally to the last commit
+func (r *Runner) getChangedFiles(ctx context.Context, client *github.Client) []string {
+	if r.config.DiffBase != "" {
+		files, err := r.getChangedFilesFromGit(r.config.DiffBase + "...HEAD")
+		if err == nil {
+			return files
+		}
+		r.logger.Warn("Failed to diff against base, trying GitHub API", "base", r.config.DiffBase, "error", err)
+	}
+
+	if r.config.GithubToken != "" && r.config.PullRequest > 0 && r.config.Repository != "" {
+		files, err := r.getChangedFilesFromAPI(ctx, client)
+		if err == nil {
+			return files
+		}
+		r.logger.Warn("Failed to list PR files from GitHub API, falling back to HEAD~1", "error", err)
+	}
+
+	files, _ := r.getChangedFilesFromGit("HEAD~1")
+	return files
+}
+
+// Get changed files from git for the given revision range
+func (r *Runner) getChangedFilesFromGit(revRange string) ([]string, error) {
+	out, err := r.commandOutput(exec.Command("git", "diff", "--name-only", revRange))
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", revRange, err)
+	}
 	files := strings.Split(string(out), "\n")
 	var clean []string
 	for _, f := range files {
@@ -1139,33 +2306,101 @@ func getChangedFilesFromGit() []string {
 			clean = append(clean, f)
 		}
 	}
-	return uniqueStrings(clean)
+	return uniqueStrings(clean), nil
+}
+
+// Get changed files of the pull request from the GitHub API
+func (r *Runner) getChangedFilesFromAPI(ctx context.Context, client *github.Client) ([]string, error) {
+	parts := strings.Split(r.config.Repository, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid repository format")
+	}
+	owner, repo := parts[0], parts[1]
+	opts := &github.ListOptions{PerPage: 100}
+
+	var files []string
+	for {
+		prFiles, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, r.config.PullRequest, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range prFiles {
+			files = append(files, f.GetFilename())
+			// Renamed files impact both the old and the new location
+			if prev := f.GetPreviousFilename(); prev != "" {
+				files = append(files, prev)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return uniqueStrings(files), nil
 }
 
 // Check if file matches any of the specified patterns
 func matchesPatterns(file string, patterns []string) bool {
+	return matchingPattern(file, patterns) != ""
+}
+
+// Get the first pattern matching the file name (empty if none matches)
+func matchingPattern(file string, patterns []string) string {
 	for _, pat := range patterns {
 		if matched, _ := filepath.Match(pat, filepath.Base(file)); matched {
-			return true
+			return pat
 		}
 	}
-	return false
+	return ""
 }
 
 // Find the nearest Terragrunt directory by walking up the path
-func findTerragruntDirectory(filePath string) string {
+func (r *Runner) findTerragruntDirectory(filePath string) string {
+	dir, _, _ := r.traceTerragruntDirectory(filePath)
+	return dir
+}
+
+// Walk up from a file to find the nearest Terragrunt directory, stopping at the
+// first root boundary, and return the directories checked and the reason when
+// none was found
+func (r *Runner) traceTerragruntDirectory(filePath string) (string, []string, string) {
+	var checked []string
 	dir := filepath.Dir(filePath)
-	for i := 0; i < config.MaxWalkUpLevels; i++ {
-		tgPath := filepath.Join(dir, config.TerragruntFile)
-		if _, err := os.Stat(tgPath); err == nil {
-			return dir
+	names := strings.Join(r.config.TerragruntFiles, ", ")
+	for i := 0; r.config.MaxWalkUpLevels <= 0 || i < r.config.MaxWalkUpLevels; i++ {
+		checked = append(checked, dir)
+		if r.unitFile(dir) != "" {
+			return dir, checked, ""
+		}
+		if boundary := r.walkUpBoundary(dir); boundary != "" {
+			return "", checked, fmt.Sprintf("reached the %s without finding %s", boundary, names)
 		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			break
+			return "", checked, fmt.Sprintf("reached filesystem root without finding %s", names)
 		}
 		dir = parent
 	}
+	return "", checked, fmt.Sprintf("no %s within max-walk-up (%d levels)", names, r.config.MaxWalkUpLevels)
+}
+
+// Get the name of the first configured Terragrunt unit file present in a directory
+// (empty if the directory is not a unit), from the unit index when there is one
+func (r *Runner) unitFile(dir string) string {
+	if unit, _, ok := r.indexedUnit(dir); ok {
+		return unit.File
+	}
+	return r.statUnitFile(dir)
+}
+
+// Get the name of the first configured Terragrunt unit file present in a
+// directory on disk
+func (r *Runner) statUnitFile(dir string) string {
+	for _, name := range r.config.TerragruntFiles {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return name
+		}
+	}
 	return ""
 }
 
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"errors"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
+// Create a Runner for tests that only logs errors
+func newTestRunner(config *Config) *Runner {
+	return NewRunner(config, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+}
+
 func TestParseFolders(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -162,7 +170,7 @@ func TestSanitizeArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := sanitizeArgs(tt.input)
+			got, err := sanitizeArgs(tt.input, ArgPolicy{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("sanitizeArgs() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -210,6 +218,21 @@ func TestParseResourceChanges(t *testing.T) {
 			input:    `Plan: 5 to add, 2 to change, 1 to destroy.`,
 			expected: &ResourceChanges{ToAdd: 5, ToChange: 2, ToDestroy: 1},
 		},
+		{
+			name:     "plan with imports",
+			input:    `Plan: 1 to import, 2 to add, 0 to change, 0 to destroy.`,
+			expected: &ResourceChanges{ToImport: 1, ToAdd: 2},
+		},
+		{
+			name:     "opentofu plan with forget",
+			input:    `Plan: 0 to add, 1 to change, 0 to destroy, 2 to forget.`,
+			expected: &ResourceChanges{ToChange: 1, ToForget: 2},
+		},
+		{
+			name:     "opentofu no changes",
+			input:    `OpenTofu has compared your real infrastructure against your configuration and found no differences, so no changes are needed.`,
+			expected: &ResourceChanges{NoChanges: true},
+		},
 		{
 			name:     "no plan line",
 			input:    `Some other output without plan`,
@@ -353,23 +376,20 @@ func TestSplitContent(t *testing.T) {
 }
 
 func TestValidateConfig(t *testing.T) {
-	oldConfig := config
-	defer func() { config = oldConfig }()
-
-	config = &Config{
+	r := newTestRunner(&Config{
 		GithubToken: "token",
 		Repository:  "owner/repo",
 		PullRequest: 1,
 		Folders:     []string{"folder"},
 		Command:     "plan",
 		MaxParallel: 5,
-	}
-	if err := validateConfig(); err != nil {
+	})
+	if err := r.validateConfig(); err != nil {
 		t.Errorf("validateConfig() error = %v, want nil", err)
 	}
 
-	config.Repository = "invalid"
-	if err := validateConfig(); err == nil {
+	r.config.Repository = "invalid"
+	if err := r.validateConfig(); err == nil {
 		t.Error("validateConfig() expected error for invalid repo")
 	}
 }
@@ -522,26 +542,16 @@ Changes to Outputs:
 func TestExecuteTerragruntInFolder_PathResolution(t *testing.T) {
 	// This test verifies that path resolution works correctly and doesn't create
 	// duplicate path components like /repo/live/live/accounts/...
-	oldConfig := config
-	oldLogger := logger
-	defer func() {
-		config = oldConfig
-		logger = oldLogger
-	}()
-
-	// Initialize logger for the test
-	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
-
-	config = &Config{
+	r := newTestRunner(&Config{
 		Command:         "plan",
 		TerragruntArgs:  "--non-interactive",
 		Folders:         []string{"live/accounts/account1"},
 		ParallelExec:    false,
 		MaxParallel:     1,
-	}
+	})
 
 	// Test that relative paths are joined with repo root correctly
-	result := executeTerragruntInFolder("live/accounts/test")
+	result := r.executeTerragruntInFolder("live/accounts/test")
 
 	// We expect an error because the folder doesn't exist, but we can verify
 	// the folder path in the result doesn't have duplicated components
@@ -557,10 +567,7 @@ func TestExecuteTerragruntInFolder_PathResolution(t *testing.T) {
 }
 
 func TestFormatCommentHeader(t *testing.T) {
-	oldConfig := config
-	defer func() { config = oldConfig }()
-
-	config = &Config{Command: "plan"}
+	r := newTestRunner(&Config{Command: "plan"})
 
 	tests := []struct {
 		name     string
@@ -601,10 +608,192 @@ func TestFormatCommentHeader(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatCommentHeader(tt.result)
+			got := r.formatCommentHeader(tt.result)
 			if got != tt.expected {
 				t.Errorf("formatCommentHeader() = %q, want %q", got, tt.expected)
 			}
 		})
 	}
 }
+
+func TestFirstErrorLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   ExecutionResult
+		expected string
+	}{
+		{
+			name:     "error line in output",
+			result:   ExecutionResult{Output: "Initializing...\n  Error: Invalid provider\n  on main.tf line 3"},
+			expected: "Error: Invalid provider",
+		},
+		{
+			name:     "fallback to execution error",
+			result:   ExecutionResult{Output: "no details", Error: errors.New("exit status 1")},
+			expected: "exit status 1",
+		},
+		{
+			name:     "nothing available",
+			result:   ExecutionResult{},
+			expected: "unknown error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := firstErrorLine(tt.result)
+			if got != tt.expected {
+				t.Errorf("firstErrorLine() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEscapeAnnotation(t *testing.T) {
+	got := escapeAnnotation("100% failed\r\nnext")
+	expected := "100%25 failed%0D%0Anext"
+	if got != expected {
+		t.Errorf("escapeAnnotation() = %q, want %q", got, expected)
+	}
+}
+
+func TestGetPRBaseSHA(t *testing.T) {
+	eventFile := filepath.Join(t.TempDir(), "event.json")
+	payload := `{"number": 42, "pull_request": {"base": {"sha": "abc123"}, "head": {"sha": "def456"}}}`
+	if err := os.WriteFile(eventFile, []byte(payload), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", eventFile)
+
+	if got := getPRBaseSHA(); got != "abc123" {
+		t.Errorf("getPRBaseSHA() = %q, want %q", got, "abc123")
+	}
+	if got, err := extractPullRequestNumber(); err != nil || got != 42 {
+		t.Errorf("extractPullRequestNumber() = %d, %v, want 42", got, err)
+	}
+
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	if got := getPRBaseSHA(); got != "" {
+		t.Errorf("getPRBaseSHA() = %q, want empty without payload", got)
+	}
+}
+
+func TestCommentContent(t *testing.T) {
+	plan := "Terraform will perform the following actions:\n\n  # aws_s3_bucket.logs will be created\n  + resource \"aws_s3_bucket\" \"logs\" {\n    }\n\nPlan: 1 to add, 0 to change, 0 to destroy."
+	success := ExecutionResult{Success: true, Output: plan, FullOutput: "Initializing...\n" + plan}
+	failure := ExecutionResult{Success: false, Output: "Error: Invalid reference", FullOutput: "Initializing...\nError: Invalid reference", Error: errors.New("exit status 1")}
+
+	tests := []struct {
+		name          string
+		detail        string
+		failureDetail string
+		result        ExecutionResult
+		expected      string
+	}{
+		{"standard success", DetailStandard, "", success, plan},
+		{"standard failure", DetailStandard, "", failure, "exit status 1"},
+		{"summary success", DetailSummary, "", success, "# aws_s3_bucket.logs will be created\nPlan: 1 to add, 0 to change, 0 to destroy."},
+		{"summary failure", DetailSummary, "", failure, "Error: Invalid reference"},
+		{"full success", DetailFull, "", success, "Initializing...\n" + plan},
+		{"summary with full failures", DetailSummary, DetailFull, failure, "Initializing...\nError: Invalid reference\n\nexit status 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{DetailLevel: tt.detail, FailureDetailLevel: tt.failureDetail})
+			if got := r.commentContent(tt.result); got != tt.expected {
+				t.Errorf("commentContent() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatSummaryPassedOnRetry(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}},
+		{Folder: "live/db", Success: true, Retried: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+	}
+
+	got := r.formatSummary(results)
+	if !strings.Contains(got, "| live/db | ✅ (passed on retry) |") {
+		t.Errorf("formatSummary() missing retry status:\n%s", got)
+	}
+	if !strings.Contains(got, "- Passed on retry: 1\n") {
+		t.Errorf("formatSummary() missing retry count:\n%s", got)
+	}
+}
+
+func TestFormatSummarySkipNoChangeComments(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan", SkipNoChangeComments: true})
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}},
+		{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+		{Folder: "live/cache", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+	}
+
+	got := r.formatSummary(results)
+	if !strings.Contains(got, "| live/db | ✅ |") {
+		t.Errorf("formatSummary() missing no-change row:\n%s", got)
+	}
+	if !strings.Contains(got, "- 2 folders with no changes (no individual comments)\n") {
+		t.Errorf("formatSummary() missing no-change count:\n%s", got)
+	}
+}
+
+func TestSplitContentSizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxSize  int
+		expected []string
+	}{
+		{
+			name:     "lines",
+			content:  "aaaa\nbbbb\ncccc\n",
+			maxSize:  10,
+			expected: []string{"aaaa\nbbbb\n", "cccc\n"},
+		},
+		{
+			name:     "long line cut at rune boundary",
+			content:  "ééééé\n",
+			maxSize:  5,
+			expected: []string{"éé", "éé", "é\n"},
+		},
+		{
+			name:     "fenced block closed and reopened",
+			content:  "x\n```json\n{\n}\n```\ny\n",
+			maxSize:  16,
+			expected: []string{"x\n```json\n{\n```\n", "```json\n}\n```\ny\n"},
+		},
+		{
+			name:     "room kept for the closing fence of a cut line",
+			content:  "~~~\nabcdef\n~~~\n",
+			maxSize:  12,
+			expected: []string{"~~~\nabc\n~~~\n", "~~~\ndef\n~~~\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitContent(tt.content, tt.maxSize)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("splitContent() = %q, want %q", got, tt.expected)
+			}
+			for _, chunk := range got {
+				if !utf8.ValidString(chunk) {
+					t.Errorf("splitContent() chunk %q is not valid UTF-8", chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateComment(t *testing.T) {
+	if got := truncateComment("short"); got != "short" {
+		t.Errorf("truncateComment() = %q, want unchanged", got)
+	}
+	got := truncateComment(strings.Repeat("é", maxCommentSize))
+	if len(got) > maxCommentSize || !utf8.ValidString(got) || !strings.HasSuffix(got, "size limit_") {
+		t.Errorf("truncateComment() returned %d bytes, valid UTF-8 %v", len(got), utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	commentMarkerPrefix = "terragrunt-runner:"
+	summaryMarkerFolder = "_summary" // Folder key used for the summary comment
+)
+
+var reCommentMarker = regexp.MustCompile(`<!-- terragrunt-runner:([^>]*?) -->`)
+
+// Identifier generated for runs outside GitHub Actions, stable for the process
+var localRunID = sync.OnceValue(func() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("local-%s-%s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(b))
+})
+
+// Get the identifier of the current run: the workflow run ID, suffixed with the
+// attempt on re-runs, or a generated ID outside Actions
+func getRunID() string {
+	id := os.Getenv("GITHUB_RUN_ID")
+	if id == "" {
+		return localRunID()
+	}
+	if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" && attempt != "1" {
+		id += "-" + attempt
+	}
+	return id
+}
+
+// Build the hidden marker identifying a runner comment for the given folder
+func commentMarker(folder, label string) string {
+	marker := fmt.Sprintf("%sfolder=%s;run=%s", commentMarkerPrefix, url.QueryEscape(folder), url.QueryEscape(getRunID()))
+	if label != "" {
+		marker += ";label=" + url.QueryEscape(label)
+	}
+	return "<!-- " + marker + " -->"
+}
+
+// Build the visible footer correlating a comment with the run's logs and outputs
+func commentFooter(label string) string {
+	footer := fmt.Sprintf("\n\n<sub>Run `%s`", getRunID())
+	if label != "" {
+		footer += " · " + label
+	}
+	return footer + "</sub>"
+}
+
+// Parse the hidden marker of a runner comment into its key/value pairs
+func parseCommentMarker(body string) (map[string]string, bool) {
+	m := reCommentMarker.FindStringSubmatch(body)
+	if m == nil {
+		return nil, false
+	}
+	fields := make(map[string]string)
+	for pair := range strings.SplitSeq(m[1], ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		fields[strings.TrimSpace(key)] = value
+	}
+	return fields, true
+}
+
+// Check whether a comment body belongs to the runner: only comments carrying its
+// hidden marker match, never comments of other bots with similar headers
+func isRunnerComment(body string) bool {
+	_, ok := parseCommentMarker(body)
+	return ok
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentMarker(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "12345")
+
+	marker := commentMarker("live/prod;vpc", "")
+	fields, ok := parseCommentMarker(marker + "\n## ✅ Success Terragrunt: live/prod;vpc")
+	if !ok {
+		t.Fatalf("parseCommentMarker() did not find marker in %q", marker)
+	}
+	if fields["folder"] != "live/prod;vpc" || fields["run"] != "12345" {
+		t.Errorf("parseCommentMarker() = %v", fields)
+	}
+	if _, ok := fields["label"]; ok {
+		t.Errorf("parseCommentMarker() = %v, want no label", fields)
+	}
+
+	fields, _ = parseCommentMarker(commentMarker("live/app", "nightly drift"))
+	if fields["label"] != "nightly drift" {
+		t.Errorf("parseCommentMarker() label = %q, want %q", fields["label"], "nightly drift")
+	}
+}
+
+func TestGetRunID(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "12345")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "1")
+	if got := getRunID(); got != "12345" {
+		t.Errorf("getRunID() = %q, want 12345 on the first attempt", got)
+	}
+	t.Setenv("GITHUB_RUN_ATTEMPT", "3")
+	if got := getRunID(); got != "12345-3" {
+		t.Errorf("getRunID() = %q, want 12345-3 on a re-run", got)
+	}
+
+	t.Setenv("GITHUB_RUN_ID", "")
+	local := getRunID()
+	if !strings.HasPrefix(local, "local-") || getRunID() != local {
+		t.Errorf("getRunID() = %q, want a stable generated local ID", local)
+	}
+}
+
+func TestCommentFooter(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "12345")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	if got, want := commentFooter(""), "\n\n<sub>Run `12345`</sub>"; got != want {
+		t.Errorf("commentFooter() = %q, want %q", got, want)
+	}
+	if got, want := commentFooter("nightly-drift"), "\n\n<sub>Run `12345` · nightly-drift</sub>"; got != want {
+		t.Errorf("commentFooter() = %q, want %q", got, want)
+	}
+}
+
+func TestIsRunnerComment(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"marker", "<!-- terragrunt-runner:folder=a;run=1 -->\n## anything", true},
+		{"header without marker", "## Terragrunt Summary\n\n**Command:** plan", false},
+		{"other bot failure", "## ❌ Failed Terragrunt: live/app\n", false},
+		{"human mentioning header", "Can someone check the Terragrunt Summary above?", false},
+		{"quoted header", "> ## Terragrunt Summary", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRunnerComment(tt.body); got != tt.want {
+				t.Errorf("isRunnerComment(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
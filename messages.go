@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Built-in user-facing comment text, overridable per key with --messages-file.
+// Placeholders in braces ({count}) are filled in when the text is used.
+var defaultMessages = map[string]string{
+	"status.success":             "✅ Success",
+	"status.failed":              "❌ Failed",
+	"status.passed_on_retry":     "✅ (passed on retry)",
+	"status.provider_crash":      "💥 provider-crash",
+	"comment.title":              "Terragrunt",
+	"comment.folder":             "Folder",
+	"comment.command":            "Command",
+	"comment.engine":             "Engine",
+	"comment.stack_units":        "Stack units",
+	"comment.metadata":           "Metadata",
+	"comment.follow_up":          "↪️ Follow-up to the [previous results]({url}) for `{folder}`",
+	"comment.mocked_deps":        "**Planned with mocked dependencies:** the outputs of {dependencies} are not available yet, so their `mock_outputs` were used. Results may differ at apply.",
+	"comment.changes":            "Changes",
+	"comment.no_changes":         "No Changes",
+	"comment.view_output":        "View Output",
+	"comment.view_error":         "View Error Details",
+	"comment.part":               "{title} (Part {part}/{total})",
+	"comment.plan_diff":          "Changes since the previous plan",
+	"comment.plan_unchanged":     "Plan output unchanged since the previous run",
+	"summary.title":              "Terragrunt Summary",
+	"summary.folders":            "Folders",
+	"summary.column.folder":      "Folder",
+	"summary.column.status":      "Status",
+	"summary.column.add":         "Add",
+	"summary.column.change":      "Change",
+	"summary.column.destroy":     "Destroy",
+	"summary.column.replace":     "Replace",
+	"summary.column.risk":        "Risk",
+	"summary.success":            "Success: {success}/{total}",
+	"summary.no_changes":         "No Changes: {count}",
+	"summary.passed_on_retry":    "Passed on retry: {count}",
+	"summary.no_change_comments": "{count} folders with no changes (no individual comments)",
+	"summary.comment_on":         "{count} folders without an individual comment (comment-on: {mode})",
+	"summary.skipped":            "Skipped by config: {count} folders",
+	"summary.label_skipped":      "Skipped by PR labels: {count} folders",
+	"summary.pr_overrides":       "Set in the PR description",
+	"summary.mocked_deps":        "🧪 Planned with mocked dependencies: {count} folders ({folders}); results may differ at apply",
+	"summary.previous_run":       "Since the previous run `{run}`",
+	"summary.reused_comments":    "Folder results unchanged since run `{run}`, its folder comments were kept",
+	"summary.undetermined":       "Changed files without a unit: {count}",
+	"summary.soft_fail":          "**Soft fail:** {count} folders failed. The run is not failed because `soft-fail` is enabled, so this does not block merging yet.",
+	"deploy.record":              "🚀 Deployed {count} folders",
+	"providers.title":            "📦 Provider Upgrades",
+	"replacements.title":         "⚠️ Replacements",
+	"replacements.intro_one":     "1 resource will be replaced:",
+	"replacements.intro_other":   "{count} resources will be replaced:",
+	"lockfile.title":             "🔒 Provider Lock File Mismatch",
+}
+
+// Load comment text overrides from a JSON object or a flat YAML mapping of
+// keys to text (no overrides if path is empty)
+func loadMessages(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := map[string]string{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid messages file %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if messages, err = parseFlatYAML(string(data)); err != nil {
+			return nil, fmt.Errorf("invalid messages file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid messages file %s: must be .json, .yaml or .yml", path)
+	}
+
+	for key := range messages {
+		if _, ok := defaultMessages[key]; !ok {
+			keys := make([]string, 0, len(defaultMessages))
+			for k := range defaultMessages {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			return nil, fmt.Errorf("invalid messages file %s: unknown key %q (known keys: %s)", path, key, strings.Join(keys, ", "))
+		}
+	}
+	return messages, nil
+}
+
+// Parse a flat YAML mapping of string keys to string values ("key: value",
+// optionally quoted, with # comments)
+func parseFlatYAML(content string) (map[string]string, error) {
+	values := map[string]string{}
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: expected a top-level \"key: value\" entry", i+1)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if err := json.Unmarshal([]byte(value), &value); err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value: %w", i+1, err)
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Get the comment text for a key, filling in {name} placeholders from
+// name/value pairs. Keys without an override fall back to the built-in text.
+func (r *Runner) msg(key string, args ...any) string {
+	text, ok := r.messages[key]
+	if !ok {
+		text = defaultMessages[key]
+	}
+	if len(args) < 2 {
+		return text
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMessages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "no file"},
+		{
+			name: "json",
+			path: write("de.json", `{"status.success": "✅ Erfolgreich", "comment.no_changes": "Keine Änderungen"}`),
+			want: map[string]string{"status.success": "✅ Erfolgreich", "comment.no_changes": "Keine Änderungen"},
+		},
+		{
+			name: "yaml",
+			path: write("fr.yaml", "# French\nstatus.failed: \"❌ Échec\"\ncomment.title: 'Terragrunt d''infra'\nsummary.title: Résumé # inline comment\n"),
+			want: map[string]string{"status.failed": "❌ Échec", "comment.title": "Terragrunt d'infra", "summary.title": "Résumé"},
+		},
+		{name: "unknown key", path: write("typo.json", `{"status.sucess": "ok"}`), wantErr: `unknown key "status.sucess"`},
+		{name: "nested yaml", path: write("nested.yml", "status:\n  success: ok\n"), wantErr: "line 2"},
+		{name: "unsupported extension", path: write("messages.toml", ""), wantErr: "must be .json, .yaml or .yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadMessages(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMessages() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMessages() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("loadMessages() = %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("loadMessages()[%q] = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestMsg(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	if got := r.msg("summary.success", "success", 2, "total", 3); got != "Success: 2/3" {
+		t.Errorf("msg() default = %q", got)
+	}
+
+	r.messages = map[string]string{"summary.success": "Erfolgreich: {success} von {total}"}
+	if got := r.msg("summary.success", "success", 2, "total", 3); got != "Erfolgreich: 2 von 3" {
+		t.Errorf("msg() override = %q", got)
+	}
+	if got := r.msg("comment.no_changes"); got != "No Changes" {
+		t.Errorf("msg() fallback = %q, want built-in text", got)
+	}
+}
+
+func TestFormatCommentHeaderLocalized(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	r.messages = map[string]string{"status.success": "✅ Réussi", "comment.command": "Commande", "comment.changes": "Modifications"}
+	header := r.formatCommentHeader(ExecutionResult{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}})
+	want := "## ✅ Réussi Terragrunt: live/app\n**Commande:** plan\n**Modifications:** +1 add\n"
+	if header != want {
+		t.Errorf("formatCommentHeader() = %q, want %q", header, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Terragrunt warning when a dependency has no outputs yet (e.g. not applied)
+// and its mock_outputs are used instead, capturing the dependency config
+var reMockedDependency = regexp.MustCompile(`(\S+) is a dependency of \S+ that has no outputs, but mock outputs provided`)
+
+// Find the dependencies whose mock outputs a run used, as folders relative to
+// the working directory when possible
+func detectMockedDeps(output string) []string {
+	var dependencies []string
+	for _, m := range reMockedDependency.FindAllStringSubmatch(output, -1) {
+		dependency := m[1]
+		if filepath.Ext(dependency) == ".hcl" {
+			dependency = filepath.Dir(dependency)
+		}
+		if filepath.IsAbs(dependency) {
+			if wd, err := filepath.Abs("."); err == nil {
+				if rel, err := filepath.Rel(wd, dependency); err == nil && !strings.HasPrefix(rel, "..") {
+					dependency = rel
+				}
+			}
+		}
+		if !slices.Contains(dependencies, dependency) {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+// Record the dependencies a result was planned with mock outputs of
+func (r *Runner) classifyMockedDeps(result ExecutionResult) ExecutionResult {
+	output := result.FullOutput
+	if output == "" {
+		output = result.Output
+	}
+	result.MockedDeps = detectMockedDeps(stripAnsiCodes(output))
+	if len(result.MockedDeps) > 0 {
+		r.logger.Warn("Planned with mocked dependency outputs", "folder", result.Folder, "dependencies", result.MockedDeps)
+	}
+	return result
+}
+
+// Format the note of a comment warning that the plan used mocked dependency outputs
+func (r *Runner) formatMockedDepsNote(result ExecutionResult) string {
+	if len(result.MockedDeps) == 0 {
+		return ""
+	}
+	return "🧪 " + r.msg("comment.mocked_deps", "dependencies", "`"+strings.Join(result.MockedDeps, "`, `")+"`") + "\n"
+}
+
+// Format the summary line counting the folders planned with mocked dependencies
+func (r *Runner) formatMockedDepsSummary(results []ExecutionResult) string {
+	var folders []string
+	for _, result := range results {
+		if len(result.MockedDeps) > 0 {
+			folders = append(folders, result.Folder)
+		}
+	}
+	if len(folders) == 0 {
+		return ""
+	}
+	return "- " + r.msg("summary.mocked_deps", "count", len(folders), "folders", "`"+strings.Join(folders, "`, `")+"`") + "\n"
+}
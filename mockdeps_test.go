@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectMockedDeps(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "legacy log format",
+			output: "WARN[0000] Config " + wd + "/live/vpc/terragrunt.hcl is a dependency of " + wd + "/live/app/terragrunt.hcl that has no outputs, but mock outputs provided and returning those in dependency output.\n",
+			want:   []string{"live/vpc"},
+		},
+		{
+			name: "current log format, repeated",
+			output: "12:00:01.000 WARN   [live/app] " + wd + "/live/db/terragrunt.hcl is a dependency of " + wd + "/live/app/terragrunt.hcl that has no outputs, but mock outputs provided and returning those in dependency output.\n" +
+				"12:00:01.100 WARN   [live/app] " + wd + "/live/db/terragrunt.hcl is a dependency of " + wd + "/live/app/terragrunt.hcl that has no outputs, but mock outputs provided and returning those in dependency output.\n",
+			want: []string{"live/db"},
+		},
+		{
+			name:   "outside the working directory",
+			output: "Config /elsewhere/shared/terragrunt.hcl is a dependency of /elsewhere/app/terragrunt.hcl that has no outputs, but mock outputs provided and returning those in dependency output.",
+			want:   []string{"/elsewhere/shared"},
+		},
+		{
+			name:   "real outputs",
+			output: "Plan: 1 to add, 0 to change, 0 to destroy.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectMockedDeps(tt.output); !slices.Equal(got, tt.want) {
+				t.Errorf("detectMockedDeps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockedDepsInComments(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+	result := r.classifyMockedDeps(ExecutionResult{
+		Folder:     "live/app",
+		Success:    true,
+		FullOutput: "Config /repo/live/vpc/terragrunt.hcl is a dependency of /repo/live/app/terragrunt.hcl that has no outputs, but mock outputs provided and returning those in dependency output.",
+	})
+	if note := r.formatMockedDepsNote(result); !strings.Contains(note, "`/repo/live/vpc`") || !strings.Contains(note, "may differ at apply") {
+		t.Errorf("note = %q", note)
+	}
+
+	summary := r.formatSummary([]ExecutionResult{result, {Folder: "live/db", Success: true}})
+	if !strings.Contains(summary, "| live/app | ✅ 🧪 |") || !strings.Contains(summary, "| live/db | ✅ |") {
+		t.Errorf("summary rows do not mark the mocked folder:\n%s", summary)
+	}
+	if !strings.Contains(summary, "Planned with mocked dependencies: 1 folders (`live/app`)") {
+		t.Errorf("summary does not count the mocked folder:\n%s", summary)
+	}
+}
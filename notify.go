@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Notification policies for --notify-on
+const (
+	NotifyFailure = "failure" // Only when a folder failed
+	NotifyDestroy = "destroy" // When a folder failed or destroys/replaces resources
+	NotifyAlways  = "always"  // After every run
+)
+
+// Mention policies sharing the semantics of each notification policy
+var notifyPolicies = map[string]string{
+	NotifyFailure: MentionOnFailure,
+	NotifyDestroy: MentionOnDestroy,
+	NotifyAlways:  MentionAlways,
+}
+
+// Check whether the results warrant a notification under the configured policy
+func (r *Runner) shouldNotify(results []ExecutionResult) bool {
+	policy, ok := notifyPolicies[r.config.NotifyOn]
+	if !ok {
+		policy = MentionAlways
+	}
+	if policy == MentionAlways {
+		return true
+	}
+	for _, result := range r.folderResults(results) {
+		if shouldMentionOwners(policy, result) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format a one-message digest of the run: every drifted and failed folder in a
+// single summary, so scheduled drift runs notify once instead of once per folder.
+// Slack and Teams incoming webhooks display it as the message text.
+func (r *Runner) formatDigest(results []ExecutionResult) string {
+	folders := r.folderResults(results)
+	var drifted, failed []string
+	for _, result := range folders {
+		switch {
+		case !result.Success:
+			failed = append(failed, result.Folder)
+		case result.ResourceChanges != nil && !result.ResourceChanges.NoChanges:
+			drifted = append(drifted, fmt.Sprintf("%s (%s)", result.Folder, strings.TrimSuffix(strings.TrimPrefix(formatResourceChanges(result.ResourceChanges), "**Changes:** "), "\n")))
+		}
+	}
+
+	title := "Terragrunt " + r.config.Command
+	if r.config.RunLabel != "" {
+		title += " [" + r.config.RunLabel + "]"
+	}
+	if r.config.Repository != "" {
+		title += " in " + r.config.Repository
+	}
+	if os.Getenv("GITHUB_EVENT_NAME") == "schedule" {
+		title = "Scheduled " + title
+	}
+
+	var b strings.Builder
+	status := "✅"
+	if len(failed) > 0 {
+		status = "❌"
+	}
+	b.WriteString(fmt.Sprintf("%s %s: %d of %d folders changed, %d failed (run %s)", status, title, len(drifted), len(folders), len(failed), getRunID()))
+	for _, folder := range drifted {
+		b.WriteString("\n• " + folder)
+	}
+	for _, folder := range failed {
+		b.WriteString("\n• " + folder + " ❌ failed")
+	}
+	return b.String()
+}
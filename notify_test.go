@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestShouldNotify(t *testing.T) {
+	clean := ExecutionResult{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}}
+	destroy := ExecutionResult{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{ToDestroy: 1}}
+	failed := ExecutionResult{Folder: "live/net", Error: errors.New("exit status 1")}
+
+	tests := []struct {
+		policy  string
+		results []ExecutionResult
+		want    bool
+	}{
+		{NotifyAlways, []ExecutionResult{clean}, true},
+		{"", []ExecutionResult{clean}, true},
+		{NotifyFailure, []ExecutionResult{clean, destroy}, false},
+		{NotifyFailure, []ExecutionResult{clean, failed}, true},
+		{NotifyDestroy, []ExecutionResult{clean}, false},
+		{NotifyDestroy, []ExecutionResult{clean, destroy}, true},
+		{NotifyDestroy, []ExecutionResult{failed}, true},
+	}
+	for _, tt := range tests {
+		r := newTestRunner(&Config{Command: "plan", NotifyOn: tt.policy})
+		if got := r.shouldNotify(tt.results); got != tt.want {
+			t.Errorf("shouldNotify() policy %q = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDigest(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	t.Setenv("GITHUB_EVENT_NAME", "schedule")
+
+	r := newTestRunner(&Config{Repository: "org/infra", Command: "run --all plan", RunAllRootDir: "live", RunLabel: "nightly-drift"})
+	got := r.formatDigest([]ExecutionResult{
+		{Folder: "live", Success: false},
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+		{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1, ToChange: 2}},
+		{Folder: "live/net", Error: errors.New("exit status 1")},
+	})
+
+	for _, want := range []string{
+		"❌ Scheduled Terragrunt run --all plan [nightly-drift] in org/infra: 1 of 3 folders changed, 1 failed (run 42)",
+		"\n• live/db (+1 add, ~2 change)",
+		"\n• live/net ❌ failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatDigest() missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "live/app") {
+		t.Errorf("formatDigest() lists a folder without changes:\n%s", got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// A planned change to a root module output value
+type PlannedOutput struct {
+	Action string `json:"action"`           // create, update or delete
+	Before string `json:"before,omitempty"` // Previous value (update/delete)
+	After  string `json:"after,omitempty"`  // Planned value (create/update)
+}
+
+var rePlannedOutput = regexp.MustCompile(`^\s*([+~-])\s+([A-Za-z0-9_-]+)\s+=\s*(.*)$`)
+
+// Parse the "Changes to Outputs" section of a plan into structured values
+func parsePlannedOutputs(output string) map[string]PlannedOutput {
+	lines := strings.Split(strings.ReplaceAll(stripAnsiCodes(output), "\r\n", "\n"), "\n")
+	outputs := make(map[string]PlannedOutput)
+
+	inSection := false
+	var current string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Changes to Outputs:") {
+			inSection = true
+			current = ""
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		// The section ends at the first non-indented line (e.g. a note or next section)
+		if trimmed != "" && !strings.HasPrefix(line, " ") {
+			inSection = false
+			continue
+		}
+
+		if m := rePlannedOutput.FindStringSubmatch(line); m != nil && indentOf(line) <= 4 {
+			current = m[2]
+			outputs[current] = newPlannedOutput(m[1], m[3])
+			continue
+		}
+		// Continuation of a multi-line value (maps, lists, heredocs)
+		if current != "" && trimmed != "" {
+			o := outputs[current]
+			if o.Action == "delete" {
+				o.Before += "\n" + trimmed
+			} else {
+				o.After += "\n" + trimmed
+			}
+			outputs[current] = o
+		}
+	}
+	return outputs
+}
+
+// Build a planned output from its change symbol and rendered value
+func newPlannedOutput(symbol, value string) PlannedOutput {
+	value = strings.TrimSpace(value)
+	switch symbol {
+	case "+":
+		return PlannedOutput{Action: "create", After: unquoteOutput(value)}
+	case "-":
+		before, _, _ := strings.Cut(value, " -> ")
+		return PlannedOutput{Action: "delete", Before: unquoteOutput(before)}
+	default:
+		before, after, found := strings.Cut(value, " -> ")
+		if !found {
+			return PlannedOutput{Action: "update", After: unquoteOutput(value)}
+		}
+		return PlannedOutput{Action: "update", Before: unquoteOutput(before), After: unquoteOutput(after)}
+	}
+}
+
+// Remove the quotes Terraform renders around string values
+func unquoteOutput(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Count the leading spaces of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// Collect planned outputs of all results keyed by folder
+func collectPlannedOutputs(results []ExecutionResult) map[string]map[string]PlannedOutput {
+	all := make(map[string]map[string]PlannedOutput)
+	for _, r := range results {
+		if len(r.PlannedOutputs) > 0 {
+			all[r.Folder] = r.PlannedOutputs
+		}
+	}
+	return all
+}
+
+// Write planned outputs to the JSON file and the planned-outputs action output
+func writePlannedOutputs(results []ExecutionResult, path string) error {
+	all := collectPlannedOutputs(results)
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		pretty, _ := json.MarshalIndent(all, "", "  ")
+		if err := os.WriteFile(path, append(pretty, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write planned outputs file: %w", err)
+		}
+	}
+	return writeActionOutput("planned-outputs", string(data))
+}
+
+// Append a single-line value to the GitHub Action outputs file
+func writeActionOutput(name, value string) error {
+	outputFile := os.Getenv("GITHUB_OUTPUT")
+	if outputFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s=%s\n", name, value)
+	return err
+}
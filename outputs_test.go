@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlannedOutputs(t *testing.T) {
+	input := `Plan: 1 to add, 0 to change, 0 to destroy.
+
+Changes to Outputs:
+  + endpoint    = "https://api.example.com"
+  ~ bucket_name = "old-value" -> "new-value"
+  - legacy_arn  = "arn:aws:s3:::legacy" -> null
+  + role_arn    = (known after apply)
+  + tags        = {
+      + env = "prod"
+    }
+
+You can apply this plan to save these new output values to the Terraform state.`
+
+	expected := map[string]PlannedOutput{
+		"endpoint":    {Action: "create", After: "https://api.example.com"},
+		"bucket_name": {Action: "update", Before: "old-value", After: "new-value"},
+		"legacy_arn":  {Action: "delete", Before: "arn:aws:s3:::legacy"},
+		"role_arn":    {Action: "create", After: "(known after apply)"},
+		"tags":        {Action: "create", After: "{\n+ env = \"prod\"\n}"},
+	}
+
+	got := parsePlannedOutputs(input)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parsePlannedOutputs() = %#v, want %#v", got, expected)
+	}
+
+	if got := parsePlannedOutputs("No changes."); len(got) != 0 {
+		t.Errorf("parsePlannedOutputs() = %v, want empty", got)
+	}
+}
+
+func TestFolderStatusOutputs(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	r := newTestRunner(&Config{Command: "plan"})
+	lists := r.folderStatusLists([]ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}},
+		{Folder: "live/db", Success: false},
+		{Folder: "live/dns", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+		{Folder: "live/vpc", Success: true},
+	})
+	want := FolderStatusLists{Failed: []string{"live/db"}, Changed: []string{"live/app"}, NoChange: []string{"live/dns", "live/vpc"}}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("folderStatusLists() = %+v, want %+v", lists, want)
+	}
+
+	if err := setActionOutputs(true, 1, 0, 0, 0, FolderStatusLists{Changed: lists.Changed}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(outputFile)
+	for _, line := range []string{`failed-folders=[]`, `changed-folders=["live/app"]`, `no-change-folders=[]`} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("outputs missing %s:\n%s", line, content)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Key of the pull request description block overriding run options
+const prOverridesKey = "terragrunt-runner"
+
+// Options pull request authors may override, when allowed by --pr-overrides
+var prOverrideKeys = []string{"detail", "skip", "only", "max-parallel", "hide-unchanged-attributes"}
+
+// Fenced code blocks of a pull request description
+var reFencedBlock = regexp.MustCompile("(?ms)^[ \t]*```[^\n]*\n(.*?)^[ \t]*```")
+
+// Options set in the pull request description and the folders they left out
+type prOverrides struct {
+	applied []string      // Applied options as "key: value"
+	skipped []SkippedUnit // Folders left out by skip and only
+}
+
+// Extract the options of the terragrunt-runner block from the fenced blocks of
+// a pull request description, either as a flow mapping
+// (terragrunt-runner: {detail: full, skip: [live/dev/**]}) or as indented
+// "key: value" lines. A description without the block sets no options.
+func parsePROverrides(description string) (map[string]string, error) {
+	for _, block := range reFencedBlock.FindAllStringSubmatch(strings.ReplaceAll(description, "\r\n", "\n"), -1) {
+		lines := strings.Split(block[1], "\n")
+		for i, line := range lines {
+			rest, ok := strings.CutPrefix(line, prOverridesKey+":")
+			if !ok {
+				continue
+			}
+			var entries []string
+			if rest = strings.TrimSpace(rest); strings.HasPrefix(rest, "{") {
+				flow := strings.Join(append([]string{rest}, lines[i+1:]...), " ")
+				end := strings.LastIndex(flow, "}")
+				if end < 0 {
+					return nil, fmt.Errorf("%s: unterminated { mapping", prOverridesKey)
+				}
+				entries = splitFlowItems(flow[1:end])
+			} else if rest == "" {
+				for _, line := range lines[i+1:] {
+					if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+						break
+					}
+					entries = append(entries, strings.TrimSpace(line))
+				}
+			} else {
+				return nil, fmt.Errorf("%s: expected a mapping of options", prOverridesKey)
+			}
+			values, err := parseFlatYAML(strings.Join(entries, "\n"))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", prOverridesKey, err)
+			}
+			return values, nil
+		}
+	}
+	return nil, nil
+}
+
+// Split the items of a flow mapping or sequence at the commas outside brackets
+func splitFlowItems(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+// Parse an option value holding a list, either a [a, b] flow sequence or a
+// single item
+func parseOverrideList(value string) []string {
+	if len(value) >= 2 && value[0] == '[' && value[len(value)-1] == ']' {
+		value = value[1 : len(value)-1]
+	}
+	var items []string
+	for _, item := range splitFlowItems(value) {
+		if item = strings.Trim(strings.Trim(item, `"'`), "/"); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Apply the options of the pull request description to the run and its
+// folders. Options not allowed by --pr-overrides are ignored with a warning;
+// an allowed option can only narrow the run, never widen what the workflow set.
+func (r *Runner) applyPROverrides(values map[string]string, folders []string) ([]string, error) {
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		if !slices.Contains(r.config.PROverrides, key) {
+			fmt.Printf("::warning::Option %s in the pull request description is not allowed by pr-overrides, ignoring it\n", key)
+			continue
+		}
+		switch key {
+		case "detail":
+			if !slices.Contains(detailLevels, value) {
+				return nil, fmt.Errorf("invalid detail in the pull request description: %s", value)
+			}
+			r.config.DetailLevel = value
+		case "skip", "only":
+			globs := parseOverrideList(value)
+			filters := labelFilters{skip: globs}
+			if key == "only" {
+				filters = labelFilters{only: globs}
+			}
+			kept, skipped := filters.apply(folders)
+			for i := range skipped {
+				skipped[i].Reason = "pull request description " + key + ": " + strings.Join(globs, ", ")
+			}
+			folders = kept
+			r.overrides.skipped = append(r.overrides.skipped, skipped...)
+		case "max-parallel":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid max-parallel in the pull request description: %s", value)
+			}
+			if r.config.MaxParallel > 0 && n > r.config.MaxParallel {
+				fmt.Printf("::warning::max-parallel %d in the pull request description exceeds the workflow's %d, keeping %d\n", n, r.config.MaxParallel, r.config.MaxParallel)
+				continue
+			}
+			r.config.MaxParallel = n
+		case "hide-unchanged-attributes":
+			hide, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hide-unchanged-attributes in the pull request description: %s", value)
+			}
+			r.config.HideUnchangedAttributes = hide
+		}
+		r.overrides.applied = append(r.overrides.applied, key+": "+value)
+	}
+	return folders, nil
+}
+
+// Read the pull request description and apply the options of its
+// terragrunt-runner block. A description that cannot be read leaves the run
+// unchanged.
+func (r *Runner) overrideFromDescription(ctx context.Context, client *github.Client, folders []string) ([]string, error) {
+	isPR := r.config.Target == "" || r.config.Target == TargetPR
+	if client == nil || !isPR || r.config.PullRequest <= 0 {
+		return folders, nil
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	pr, _, err := client.PullRequests.Get(ctx, parts[0], parts[1], r.config.PullRequest)
+	if err != nil {
+		r.logger.Warn("Failed to read the pull request description, options are not overridden", "error", err)
+		return folders, nil
+	}
+	values, err := parsePROverrides(pr.GetBody())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pull request description: %w", err)
+	}
+	folders, err = r.applyPROverrides(values, folders)
+	if err != nil {
+		return nil, err
+	}
+	for _, unit := range r.overrides.skipped {
+		r.logger.Info("Skipping folder filtered by the pull request description", "folder", unit.Folder, "reason", unit.Reason)
+	}
+	if len(r.overrides.applied) > 0 {
+		r.logger.Info("Applied options of the pull request description", "options", r.overrides.applied)
+	}
+	return folders, nil
+}
+
+// Format the options set in the pull request description, and the folders they
+// left out, for the summary comment
+func (r *Runner) formatPROverrides() string {
+	if len(r.overrides.applied) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n**" + r.msg("summary.pr_overrides") + ":** `" + strings.Join(r.overrides.applied, "`, `") + "`\n")
+	if len(r.overrides.skipped) > 0 {
+		b.WriteString("\n")
+		for _, unit := range r.overrides.skipped {
+			b.WriteString(fmt.Sprintf("- `%s`: %s\n", unit.Folder, unit.Reason))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePROverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		expected    map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "flow mapping",
+			description: "Adds the cache.\n\n```yaml\nterragrunt-runner: {detail: full, skip: [live/dev/**, live/sandbox/*]}\n```\n",
+			expected:    map[string]string{"detail": "full", "skip": "[live/dev/**, live/sandbox/*]"},
+		},
+		{
+			name:        "indented mapping",
+			description: "```yaml\r\nterragrunt-runner:\r\n  detail: summary # quieter\r\n  max-parallel: 2\r\nother: value\r\n```",
+			expected:    map[string]string{"detail": "summary", "max-parallel": "2"},
+		},
+		{name: "no block", description: "terragrunt-runner: {detail: full}", expected: nil},
+		{name: "unterminated mapping", description: "```\nterragrunt-runner: {detail: full\n```", wantErr: true},
+		{name: "scalar", description: "```\nterragrunt-runner: full\n```", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePROverrides(tt.description)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePROverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parsePROverrides() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyPROverrides(t *testing.T) {
+	folders := []string{"live/dev/app", "live/prod/app", "live/prod/db"}
+	tests := []struct {
+		name    string
+		allowed []string
+		values  map[string]string
+		folders []string
+		config  Config
+		applied []string
+		wantErr bool
+	}{
+		{
+			name:    "allowed options",
+			allowed: []string{"detail", "skip", "hide-unchanged-attributes"},
+			values:  map[string]string{"detail": "full", "skip": "[live/dev/**]", "hide-unchanged-attributes": "true"},
+			folders: []string{"live/prod/app", "live/prod/db"},
+			config:  Config{DetailLevel: DetailFull, MaxParallel: 4, HideUnchangedAttributes: true},
+			applied: []string{"detail: full", "hide-unchanged-attributes: true", "skip: [live/dev/**]"},
+		},
+		{
+			name:    "options not allowed",
+			allowed: []string{"detail"},
+			values:  map[string]string{"skip": "live/**", "max-parallel": "1"},
+			folders: folders,
+			config:  Config{MaxParallel: 4},
+		},
+		{
+			name:    "only and a lower parallelism",
+			allowed: []string{"only", "max-parallel"},
+			values:  map[string]string{"only": "live/prod/db", "max-parallel": "2"},
+			folders: []string{"live/prod/db"},
+			config:  Config{MaxParallel: 2},
+			applied: []string{"max-parallel: 2", "only: live/prod/db"},
+		},
+		{
+			name:    "higher parallelism",
+			allowed: []string{"max-parallel"},
+			values:  map[string]string{"max-parallel": "10"},
+			folders: folders,
+			config:  Config{MaxParallel: 4},
+		},
+		{name: "invalid detail", allowed: []string{"detail"}, values: map[string]string{"detail": "verbose"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{PROverrides: tt.allowed, MaxParallel: 4})
+			got, err := r.applyPROverrides(tt.values, folders)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyPROverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.folders) || !reflect.DeepEqual(r.overrides.applied, tt.applied) {
+				t.Errorf("applyPROverrides() = %v with %v applied, want %v with %v", got, r.overrides.applied, tt.folders, tt.applied)
+			}
+			tt.config.PROverrides = tt.allowed
+			if !reflect.DeepEqual(*r.config, tt.config) {
+				t.Errorf("config = %+v, want %+v", *r.config, tt.config)
+			}
+		})
+	}
+}
+
+func TestFormatPROverrides(t *testing.T) {
+	r := newTestRunner(&Config{PROverrides: []string{"skip"}})
+	if got := r.formatPROverrides(); got != "" {
+		t.Errorf("formatPROverrides() without options = %q, want empty", got)
+	}
+	if _, err := r.applyPROverrides(map[string]string{"skip": "live/dev/*"}, []string{"live/dev/app", "live/prod/app"}); err != nil {
+		t.Fatal(err)
+	}
+	got := r.formatPROverrides()
+	for _, want := range []string{"**Set in the PR description:** `skip: live/dev/*`", "- `live/dev/app`: pull request description skip: live/dev/*"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatPROverrides() = %q, want it to contain %q", got, want)
+		}
+	}
+}
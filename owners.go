@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Mention policies for --mention-owners
+const (
+	MentionNever     = "never"
+	MentionOnFailure = "on-failure"
+	MentionOnDestroy = "on-destroy"
+	MentionAlways    = "always"
+)
+
+// Locations searched for a CODEOWNERS file, in GitHub's precedence order
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Check whether owners of a result should be mentioned under the given policy
+func shouldMentionOwners(policy string, result ExecutionResult) bool {
+	destroys := result.ResourceChanges != nil && result.ResourceChanges.ToDestroy > 0
+	switch policy {
+	case MentionAlways:
+		return true
+	case MentionOnFailure:
+		return !result.Success
+	case MentionOnDestroy:
+		// Failures are always worth a mention when destroys are watched
+		return !result.Success || destroys
+	default:
+		return false
+	}
+}
+
+// Resolve owners of a folder from the nearest owners file, falling back to CODEOWNERS
+func resolveOwners(repoRoot, folder, ownersFile, unitFile string) []string {
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		absFolder = filepath.Join(repoRoot, folder)
+	}
+	absFolder = filepath.Clean(absFolder)
+
+	// Walk up from the unit folder to the repo root looking for an owners file
+	for dir := absFolder; ; dir = filepath.Dir(dir) {
+		if owners, err := readOwnersFile(filepath.Join(dir, ownersFile)); err == nil && len(owners) > 0 {
+			return owners
+		}
+		if dir == repoRoot || dir == filepath.Dir(dir) || !strings.HasPrefix(dir, repoRoot) {
+			break
+		}
+	}
+
+	relFolder, err := filepath.Rel(repoRoot, absFolder)
+	if err != nil {
+		return nil
+	}
+	for _, p := range codeownersPaths {
+		if owners, err := matchCodeowners(filepath.Join(repoRoot, p), filepath.ToSlash(relFolder), unitFile); err == nil {
+			return owners
+		}
+	}
+	return nil
+}
+
+// Read an owners file containing one owner per line (# for comments)
+func readOwnersFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var owners []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		for _, owner := range strings.Fields(line) {
+			owners = append(owners, normalizeOwner(owner))
+		}
+	}
+	return uniqueStrings(owners), scanner.Err()
+}
+
+// Find the owners of a path in a CODEOWNERS file (last matching rule wins)
+func matchCodeowners(path, relFolder, unitFile string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var owners []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if codeownersPatternMatches(fields[0], relFolder, unitFile) {
+			owners = nil
+			for _, owner := range fields[1:] {
+				if strings.HasPrefix(owner, "#") {
+					break
+				}
+				owners = append(owners, normalizeOwner(owner))
+			}
+		}
+	}
+	return owners, scanner.Err()
+}
+
+// Match a CODEOWNERS pattern against a folder path relative to the repo root.
+// A folder matches when the pattern matches the folder itself, one of its
+// parents, or the unit's Terragrunt file inside it.
+func codeownersPatternMatches(pattern, relFolder, unitFile string) bool {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "*" || pattern == "**" {
+		return true
+	}
+
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `/\*\*/`, `/(.*/)?`)
+	expr = strings.ReplaceAll(expr, `\*\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\*`, `[^/]*`)
+	expr = strings.ReplaceAll(expr, `\?`, `[^/]`)
+	if anchored {
+		expr = "^" + expr
+	} else {
+		expr = "(^|/)" + expr
+	}
+	re, err := regexp.Compile(expr + "(/|$)")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(relFolder) || re.MatchString(relFolder+"/"+unitFile)
+}
+
+// Ensure owners are rendered as @-mentions (emails are left untouched)
+func normalizeOwner(owner string) string {
+	if strings.Contains(owner, "@") {
+		return owner
+	}
+	return "@" + owner
+}
+
+// Format a mention line for the owners of the given results
+func (r *Runner) formatOwnerMentions(results []ExecutionResult) string {
+	if r.config.MentionOwners == "" || r.config.MentionOwners == MentionNever {
+		return ""
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return ""
+	}
+
+	var mentions []string
+	for _, result := range results {
+		if shouldMentionOwners(r.config.MentionOwners, result) {
+			absFolder := result.Folder
+			if !filepath.IsAbs(absFolder) {
+				absFolder = filepath.Join(repoRoot, absFolder)
+			}
+			unitFile := r.unitFile(absFolder)
+			if unitFile == "" && len(r.config.TerragruntFiles) > 0 {
+				unitFile = r.config.TerragruntFiles[0]
+			}
+			for _, owner := range resolveOwners(repoRoot, result.Folder, r.config.OwnersFile, unitFile) {
+				// Only mention GitHub handles, never emails
+				if strings.HasPrefix(owner, "@") {
+					mentions = append(mentions, owner)
+				}
+			}
+		}
+	}
+	if len(mentions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("**Owners:** %s\n", strings.Join(uniqueStrings(mentions), " "))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodeownersPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		folder  string
+		want    bool
+	}{
+		{"*", "live/prod/vpc", true},
+		{"/live/prod/", "live/prod/vpc", true},
+		{"/live/prod/", "live/staging/vpc", false},
+		{"live/*/vpc", "live/prod/vpc", true},
+		{"vpc/", "live/prod/vpc", true},
+		{"*.hcl", "live/prod/vpc", true},
+		{"/live/**/rds", "live/prod/eu/rds", true},
+		{"/modules/", "live/prod/vpc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.folder, func(t *testing.T) {
+			if got := codeownersPatternMatches(tt.pattern, tt.folder, "terragrunt.hcl"); got != tt.want {
+				t.Errorf("codeownersPatternMatches(%q, %q) = %v, want %v", tt.pattern, tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOwners(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".github/CODEOWNERS", "* @org/platform\n/live/prod/ @org/prod-team # prod\n")
+	write("live/dev/app/OWNERS", "# app owners\nalice\n@org/app-team\n")
+	write("live/prod/db/terragrunt.hcl", "")
+
+	tests := []struct {
+		folder string
+		want   []string
+	}{
+		{"live/dev/app", []string{"@alice", "@org/app-team"}},
+		{"live/prod/db", []string{"@org/prod-team"}},
+		{"live/staging/vpc", []string{"@org/platform"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.folder, func(t *testing.T) {
+			got := resolveOwners(root, tt.folder, "OWNERS", "terragrunt.hcl")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveOwners(%q) = %v, want %v", tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldMentionOwners(t *testing.T) {
+	failed := ExecutionResult{Success: false}
+	destroy := ExecutionResult{Success: true, ResourceChanges: &ResourceChanges{ToDestroy: 1}}
+	clean := ExecutionResult{Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}}
+
+	tests := []struct {
+		policy string
+		result ExecutionResult
+		want   bool
+	}{
+		{MentionNever, failed, false},
+		{MentionOnFailure, failed, true},
+		{MentionOnFailure, destroy, false},
+		{MentionOnDestroy, destroy, true},
+		{MentionOnDestroy, clean, false},
+		{MentionAlways, clean, true},
+	}
+
+	for _, tt := range tests {
+		if got := shouldMentionOwners(tt.policy, tt.result); got != tt.want {
+			t.Errorf("shouldMentionOwners(%q, %+v) = %v, want %v", tt.policy, tt.result, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Effective capabilities of the GitHub token, degraded as permission errors are found
+type TokenCapabilities struct {
+	mu         sync.Mutex
+	CanComment bool     // Whether PR comments can be created
+	CanDelete  bool     // Whether old comments can be deleted
+	Reasons    []string // Human-readable reasons for each degradation
+}
+
+// Disable comment creation, falling back to the step summary
+func (c *TokenCapabilities) disableComments(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.CanComment {
+		return
+	}
+	c.CanComment = false
+	c.CanDelete = false
+	c.Reasons = append(c.Reasons, reason)
+	fmt.Printf("::warning title=Terragrunt Runner degraded::Cannot post PR comments (%s); results are written to the job summary instead\n", reason)
+}
+
+// Disable deletion of old comments
+func (c *TokenCapabilities) disableDeletes(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.CanDelete {
+		return
+	}
+	c.CanDelete = false
+	c.Reasons = append(c.Reasons, reason)
+	fmt.Printf("::warning title=Terragrunt Runner degraded::Cannot delete old comments (%s); skipping cleanup\n", reason)
+}
+
+// Check whether comments can be created
+func (c *TokenCapabilities) commentsAllowed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.CanComment
+}
+
+// Check whether comments can be deleted
+func (c *TokenCapabilities) deletesAllowed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.CanDelete
+}
+
+// Check whether an API error is caused by missing permissions
+func isPermissionError(err error) bool {
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		return errResp.Response.StatusCode == http.StatusForbidden || errResp.Response.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// Probe the token's effective permissions before doing any work
+func (r *Runner) probeTokenPermissions(ctx context.Context, client *github.Client) {
+	// pull_request events from forks always get a read-only token
+	if os.Getenv("GITHUB_EVENT_NAME") == "pull_request" && isForkPullRequest() {
+		r.caps.disableComments("pull request from a fork has a read-only token")
+		return
+	}
+
+	parts := strings.Split(r.config.Repository, "/")
+	repo, _, err := client.Repositories.Get(ctx, parts[0], parts[1])
+	if err != nil {
+		if isPermissionError(err) {
+			r.caps.disableComments("token cannot access the repository")
+		} else {
+			r.logger.Warn("Failed to probe token permissions", "error", err)
+		}
+		return
+	}
+	// Permissions are only reported for user tokens; installation tokens are checked on use
+	if perms := repo.GetPermissions(); perms != nil && !perms["push"] && !perms["triage"] {
+		r.caps.disableComments("token has read-only repository permissions")
+	}
+}
+
+// Check whether the pull request in the event payload comes from a fork
+func isForkPullRequest() bool {
+	payload, err := readEventPayload()
+	if err != nil {
+		return false
+	}
+	pr, _ := payload["pull_request"].(map[string]any)
+	head, _ := pr["head"].(map[string]any)
+	base, _ := pr["base"].(map[string]any)
+	headRepo, _ := head["repo"].(map[string]any)
+	baseRepo, _ := base["repo"].(map[string]any)
+	headName, _ := headRepo["full_name"].(string)
+	baseName, _ := baseRepo["full_name"].(string)
+	return headName != "" && baseName != "" && headName != baseName
+}
+
+// Append markdown to the GitHub Actions job summary
+func writeStepSummary(markdown string) error {
+	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFile == "" {
+		fmt.Println(markdown)
+		return nil
+	}
+	f, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\n\n", markdown)
+	return err
+}
+
+// Report degraded capabilities through action outputs
+func (r *Runner) writeDegradationOutputs() error {
+	r.caps.mu.Lock()
+	reasons := strings.Join(r.caps.Reasons, "; ")
+	degraded := len(r.caps.Reasons) > 0
+	r.caps.mu.Unlock()
+
+	if err := writeActionOutput("degraded", fmt.Sprintf("%t", degraded)); err != nil {
+		return err
+	}
+	return writeActionOutput("degradation-reasons", reasons)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Create a GitHub client talking to a test server
+func newTestGitHubClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestCreateCommentFallsBackToStepSummary(t *testing.T) {
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1})
+
+	summaryFile := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+
+	calls := 0
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+	}))
+
+	ctx := context.Background()
+	for range 2 {
+		if err := r.createComment(ctx, client, "owner", "repo", "live/app", "## Terragrunt Summary"); err != nil {
+			t.Fatalf("createComment() error = %v, want nil", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("API called %d times, want 1 (degraded after first 403)", calls)
+	}
+	if r.caps.CanComment || len(r.caps.Reasons) != 1 {
+		t.Errorf("caps = %+v, want comments disabled with one reason", r.caps)
+	}
+	data, _ := os.ReadFile(summaryFile)
+	if strings.Count(string(data), "## Terragrunt Summary") != 2 {
+		t.Errorf("step summary = %q, want both bodies", data)
+	}
+}
+
+func TestIsForkPullRequest(t *testing.T) {
+	eventFile := filepath.Join(t.TempDir(), "event.json")
+	payload := `{"pull_request": {"head": {"repo": {"full_name": "fork/repo"}}, "base": {"repo": {"full_name": "owner/repo"}}}}`
+	if err := os.WriteFile(eventFile, []byte(payload), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", eventFile)
+
+	if !isForkPullRequest() {
+		t.Error("isForkPullRequest() = false, want true")
+	}
+}
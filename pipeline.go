@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Built-in pipeline stages, in execution order
+const (
+	StageFormat   = "fmt"      // Formatting autofix
+	StageValidate = "validate" // Pre-checks
+	StagePlan     = "plan"     // Terragrunt execution and base comparison
+	StagePolicy   = "policy"   // Secret scanning of the output
+	StageComment  = "comment"  // PR comments and summary
+	StageReport   = "report"   // Annotations, outputs, changelog, publishing and webhooks
+)
+
+// A step of the run pipeline. Stages share state through the runner: the plan
+// stage stores its results in Runner.results for the stages that follow.
+// Returning an error stops the pipeline and fails the run.
+type Stage struct {
+	Name string
+	Run  func(r *Runner, ctx context.Context, client *github.Client) error
+}
+
+// Time taken by an executed stage
+type StageTiming struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// The built-in stages of a run
+func defaultStages() []Stage {
+	return []Stage{
+		{Name: StageFormat, Run: (*Runner).formatStage},
+		{Name: StageValidate, Run: (*Runner).validateStage},
+		{Name: StagePlan, Run: (*Runner).planStage},
+		{Name: StagePolicy, Run: (*Runner).policyStage},
+		{Name: StageComment, Run: (*Runner).commentStage},
+		{Name: StageReport, Run: (*Runner).reportStage},
+	}
+}
+
+// Register a custom stage running after the named stage (first if after is empty).
+// Use this to insert organization-specific steps, e.g. a policy engine after plan.
+func (r *Runner) RegisterStage(stage Stage, after string) error {
+	if stage.Name == "" || stage.Run == nil {
+		return fmt.Errorf("stage needs a name and a run function")
+	}
+	if r.stageIndex(stage.Name) >= 0 {
+		return fmt.Errorf("stage %s is already registered", stage.Name)
+	}
+	pos := 0
+	if after != "" {
+		i := r.stageIndex(after)
+		if i < 0 {
+			return fmt.Errorf("unknown stage: %s", after)
+		}
+		pos = i + 1
+	}
+	r.stages = slices.Insert(r.stages, pos, stage)
+	return nil
+}
+
+// Get the names of the registered stages in execution order
+func (r *Runner) StageNames() []string {
+	names := make([]string, len(r.stages))
+	for i, stage := range r.stages {
+		names[i] = stage.Name
+	}
+	return names
+}
+
+// Find the position of a stage (-1 if not registered)
+func (r *Runner) stageIndex(name string) int {
+	return slices.IndexFunc(r.stages, func(s Stage) bool { return s.Name == name })
+}
+
+// Validate that skipped stages exist
+func (r *Runner) validateSkipStages() error {
+	for _, name := range r.config.SkipStages {
+		if r.stageIndex(name) < 0 {
+			return fmt.Errorf("invalid skip-stages: unknown stage %s (expected one of %s)", name, strings.Join(r.StageNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// Run the enabled stages in order, recording their timings
+func (r *Runner) runPipeline(ctx context.Context, client *github.Client) error {
+	for _, stage := range r.stages {
+		if slices.Contains(r.config.SkipStages, stage.Name) {
+			r.logger.Info("Skipping stage", "stage", stage.Name)
+			continue
+		}
+		r.logger.Info("Running stage", "stage", stage.Name)
+		start := time.Now()
+		err := stage.Run(r, ctx, client)
+		duration := time.Since(start)
+		r.stageTimings = append(r.stageTimings, StageTiming{Name: stage.Name, Duration: duration, Err: err})
+		if err != nil {
+			r.logger.Error("Stage failed", "stage", stage.Name, "duration", duration, "error", err)
+			return err
+		}
+		r.logger.Info("Stage finished", "stage", stage.Name, "duration", duration)
+	}
+	return nil
+}
+
+// Format the timings of the stages run so far for the summary comment
+func formatStageTimings(timings []StageTiming) string {
+	if len(timings) == 0 {
+		return ""
+	}
+	parts := make([]string, len(timings))
+	for i, t := range timings {
+		status := ""
+		if t.Err != nil {
+			status = " ❌"
+		}
+		parts[i] = fmt.Sprintf("%s %s%s", t.Name, t.Duration.Round(time.Millisecond), status)
+	}
+	return "\n**Stages:** " + strings.Join(parts, " → ") + "\n"
+}
+
+// Push formatting fixes to the PR branch when enabled
+func (r *Runner) formatStage(ctx context.Context, client *github.Client) error {
+	if r.config.AutofixFmt && !isApplyCommand(r.config.Command) {
+		r.fmtAutofix = r.autofixFormatting(ctx, client)
+	}
+	return nil
+}
+
+// Run the configured pre-checks, failing the run when they fail in fail-fast mode
+func (r *Runner) validateStage(ctx context.Context, client *github.Client) error {
+	if len(r.config.PreChecks) == 0 {
+		return nil
+	}
+	preChecks := r.runPreChecks(r.config.Folders, r.config.PreChecks)
+	if err := r.postPreChecks(ctx, client, preChecks); err != nil {
+		r.logger.Warn("Failed to post pre-checks", "error", err)
+	}
+	if r.config.FmtSuggestions {
+		r.suggestFmtFixes(ctx, client, preChecks)
+	}
+	if preChecksPassed(preChecks) {
+		return nil
+	}
+	for _, r := range preChecks {
+		if !r.Passed {
+			fmt.Printf("::error title=Terragrunt pre-check failed::%s: %s\n", escapeAnnotation(r.Folder), r.Check)
+		}
+	}
+	if r.config.PreChecksFailFast {
+		setActionOutputs(true, 0, 0, 0, 0, FolderStatusLists{})
+		return fmt.Errorf("pre-checks failed")
+	}
+	return nil
+}
+
+// Execute the command (group by group for ordered applies, step by step for
+// runbooks) and compare the plans with the base ref. Applies to a deployment
+// environment first wait for the deployment to be approved, unless runbook
+// steps have their own approval gates. Saved plans are encrypted after a plan and
+// decrypted for the apply when plan-encrypt-key is set.
+func (r *Runner) planStage(ctx context.Context, client *github.Client) error {
+	if isApplyCommand(r.config.Command) && r.config.DeploymentEnvironment != "" && r.runbook == nil {
+		id, err := r.awaitDeploymentApproval(ctx, client)
+		if err != nil {
+			fmt.Printf("::error::%s\n", err)
+			return err
+		}
+		defer func() { r.finishDeployment(ctx, client, id, r.results) }()
+	}
+	if r.config.PlanEncryptKey != "" && isApplyCommand(r.config.Command) {
+		plain, err := r.decryptPlanFiles(r.config.Folders)
+		defer func() {
+			for _, p := range plain {
+				os.Remove(p)
+			}
+		}()
+		if err != nil {
+			fmt.Printf("::error::Failed to decrypt plan files: %v\n", err)
+			return err
+		}
+	} else if r.config.PlanEncryptKey != "" {
+		defer func() { r.encryptPlanFiles(r.results) }()
+	}
+	if r.config.ProgressComment {
+		stop := r.startProgressComment(ctx, client)
+		defer stop()
+	}
+	if r.runbook != nil {
+		r.results = r.collectTriageBundles(r.executeRunbook(ctx, client))
+		return nil
+	}
+	if isApplyCommand(r.config.Command) && len(r.config.ApplyOrder) > 0 {
+		r.results = r.collectTriageBundles(r.executeApplyOrder(ctx, client))
+		return nil
+	}
+	r.results = r.collectTriageBundles(r.executeTerragrunt())
+	if r.config.CompareWithBase && !isApplyCommand(r.config.Command) {
+		r.comparisons = r.compareWithBase(r.results)
+	}
+	return nil
+}
+
+// Mask secrets in the output, refusing to go further on leaks when configured
+func (r *Runner) policyStage(ctx context.Context, client *github.Client) error {
+	if !r.config.ScanSecrets && !r.config.FailOnSecretLeak {
+		return nil
+	}
+	if findings := maskSecretsInResults(r.results); len(findings) > 0 && r.config.FailOnSecretLeak {
+		fmt.Printf("::error::Detected %d possible secrets in Terragrunt output, refusing to post comments\n", len(findings))
+		return fmt.Errorf("possible secret leak detected in %d locations", len(findings))
+	}
+	return nil
+}
+
+// Post the result comments, the summary and lock file fixes. Setup failures
+// get a single setup error comment instead of one comment per folder.
+func (r *Runner) commentStage(ctx context.Context, client *github.Client) error {
+	if r.config.ReuseComments {
+		if r.reusesComments(r.results) {
+			r.reusedFrom = r.prevSummary.RunID
+			r.logger.Info("Folder results unchanged since the previous run, only updating its summary", "run", r.reusedFrom)
+			return r.postSummary(ctx, client, r.results)
+		}
+		if r.config.DeleteOldComments {
+			if err := r.deleteOldComments(ctx, client); err != nil {
+				r.logger.Warn("Failed to delete old comments", "error", err)
+			}
+		}
+	}
+	setupErrors, results := splitSetupErrors(r.results)
+	if len(setupErrors) > 0 {
+		if err := r.postSetupError(ctx, client, setupErrors); err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+	}
+	if err := r.postComments(ctx, client, results); err != nil {
+		return err
+	}
+	// A refresh keeps the summary of the full run
+	if !r.config.Refresh {
+		if err := r.postSummary(ctx, client, r.results); err != nil {
+			return err
+		}
+	}
+	if fixes := r.handleLockfileMismatches(ctx, client, r.results); len(fixes) > 0 {
+		if err := r.postLockfileFixes(ctx, client, fixes); err != nil {
+			r.logger.Warn("Failed to post lock file fixes", "error", err)
+		}
+	}
+	return nil
+}
+
+// Report the results through annotations, action outputs and the configured sinks
+func (r *Runner) reportStage(ctx context.Context, client *github.Client) error {
+	results := r.results
+	totalAdd, totalChange, totalDestroy, totalReplace := 0, 0, 0, 0
+	hasErrors := false
+	for _, result := range results {
+		if !result.Success {
+			hasErrors = true
+
+			fmt.Printf("Terragrunt execution failed for folder: %s\n", result.Folder)
+			if result.Error != nil {
+				fmt.Printf("Error: %v\n", result.Error)
+			}
+		}
+		if result.ResourceChanges != nil {
+			totalAdd += result.ResourceChanges.ToAdd
+			totalChange += result.ResourceChanges.ToChange
+			totalDestroy += result.ResourceChanges.ToDestroy
+			totalReplace += result.ResourceChanges.ToReplace
+		}
+	}
+
+	r.emitAnnotations(results)
+	if r.config.ChangelogFile != "" && isApplyCommand(r.config.Command) {
+		if err := r.recordChangelog(ctx, client, results); err != nil {
+			r.logger.Warn("Failed to record changelog entry", "error", err)
+		}
+	}
+	if r.tracksRollout() && isApplyCommand(r.config.Command) && !hasErrors && client != nil {
+		r.recordRollout(ctx, client, results)
+	}
+	if err := r.writeDegradationOutputs(); err != nil {
+		r.logger.Warn("Failed to write degradation outputs", "error", err)
+	}
+	if err := writePlannedOutputs(results, r.config.OutputsFile); err != nil {
+		r.logger.Warn("Failed to write planned outputs", "error", err)
+	}
+	if err := writeResourceTypeOutput(aggregateResourceTypes(r.folderResults(results))); err != nil {
+		r.logger.Warn("Failed to write resource type changes", "error", err)
+	}
+	if r.config.RiskScore {
+		if err := writeActionOutput("risk-score", fmt.Sprint(totalRiskScore(r.folderResults(results)))); err != nil {
+			r.logger.Warn("Failed to write risk score output", "error", err)
+		}
+	}
+	if err := writeActionOutput("run-id", getRunID()); err != nil {
+		r.logger.Warn("Failed to write run ID output", "error", err)
+	}
+	if err := writeActionOutput("engine", resultsEngine(results)); err != nil {
+		r.logger.Warn("Failed to write engine output", "error", err)
+	}
+	setupErrors, _ := splitSetupErrors(results)
+	if err := writeActionOutput("setup-error", fmt.Sprintf("%t", len(setupErrors) > 0)); err != nil {
+		r.logger.Warn("Failed to write setup error output", "error", err)
+	}
+	crashed := slices.ContainsFunc(results, func(result ExecutionResult) bool { return result.ProviderCrash != "" })
+	if err := writeActionOutput("provider-crash", fmt.Sprintf("%t", crashed)); err != nil {
+		r.logger.Warn("Failed to write provider crash output", "error", err)
+	}
+	triaged := slices.ContainsFunc(results, func(result ExecutionResult) bool { return result.TriageBundle != "" })
+	if err := writeActionOutput("triage-bundle", fmt.Sprintf("%t", triaged)); err != nil {
+		r.logger.Warn("Failed to write triage bundle output", "error", err)
+	}
+	failed := r.softFailedFolders(results)
+	if len(failed) > 0 && client != nil {
+		if err := r.postSoftFailCheck(ctx, client, failed); err != nil {
+			r.logger.Warn("Failed to post the soft fail check run", "error", err)
+		}
+	}
+	if err := writeActionOutput("soft-failed", fmt.Sprintf("%t", len(failed) > 0)); err != nil {
+		r.logger.Warn("Failed to write soft fail output", "error", err)
+	}
+	if r.config.PublishResults != "" {
+		if err := r.publishResults(results); err != nil {
+			r.logger.Warn("Failed to publish results", "error", err)
+		}
+	}
+	if r.config.WebhookURL != "" && !r.shouldNotify(results) {
+		r.logger.Info("Skipping results webhook under the notification policy", "notify_on", r.config.NotifyOn)
+	} else if r.config.WebhookURL != "" {
+		if err := r.sendResultsWebhook(results); err != nil {
+			r.logger.Warn("Failed to send results webhook", "error", err)
+		}
+	}
+	if r.config.SummaryFile != "" {
+		if err := os.WriteFile(r.config.SummaryFile, []byte(r.formatSummary(results)), 0o644); err != nil {
+			r.logger.Warn("Failed to write summary file", "error", err)
+		}
+	}
+	setActionOutputs(hasErrors, totalAdd, totalChange, totalDestroy, totalReplace, r.folderStatusLists(results))
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Replace every stage with a stub recording its name
+func stubStages(r *Runner, ran *[]string) {
+	for i := range r.stages {
+		name := r.stages[i].Name
+		r.stages[i].Run = func(*Runner, context.Context, *github.Client) error {
+			*ran = append(*ran, name)
+			return nil
+		}
+	}
+}
+
+func TestRegisterStage(t *testing.T) {
+	r := newTestRunner(&Config{})
+	noop := func(*Runner, context.Context, *github.Client) error { return nil }
+
+	if err := r.RegisterStage(Stage{Name: "opa", Run: noop}, StagePlan); err != nil {
+		t.Fatalf("RegisterStage() error = %v", err)
+	}
+	if err := r.RegisterStage(Stage{Name: "setup", Run: noop}, ""); err != nil {
+		t.Fatalf("RegisterStage() error = %v", err)
+	}
+	want := []string{"setup", StageFormat, StageValidate, StagePlan, "opa", StagePolicy, StageComment, StageReport}
+	if got := r.StageNames(); !slices.Equal(got, want) {
+		t.Errorf("StageNames() = %v, want %v", got, want)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		stage Stage
+		after string
+	}{
+		{name: "duplicate", stage: Stage{Name: StagePlan, Run: noop}},
+		{name: "unknown anchor", stage: Stage{Name: "cost", Run: noop}, after: "missing"},
+		{name: "no run function", stage: Stage{Name: "cost"}},
+	} {
+		if err := r.RegisterStage(tt.stage, tt.after); err == nil {
+			t.Errorf("RegisterStage() %s: expected error", tt.name)
+		}
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	r := newTestRunner(&Config{SkipStages: []string{StagePolicy}})
+	var ran []string
+	stubStages(r, &ran)
+	r.RegisterStage(Stage{Name: "gate", Run: func(*Runner, context.Context, *github.Client) error {
+		ran = append(ran, "gate")
+		return errors.New("denied")
+	}}, StagePolicy)
+
+	err := r.runPipeline(context.Background(), nil)
+	if err == nil || err.Error() != "denied" {
+		t.Fatalf("runPipeline() error = %v, want denied", err)
+	}
+	if want := []string{StageFormat, StageValidate, StagePlan, "gate"}; !slices.Equal(ran, want) {
+		t.Errorf("stages run = %v, want %v", ran, want)
+	}
+	if len(r.stageTimings) != 4 || r.stageTimings[3].Err == nil {
+		t.Errorf("stageTimings = %+v, want 4 timings with the last one failed", r.stageTimings)
+	}
+}
+
+func TestValidateSkipStages(t *testing.T) {
+	r := newTestRunner(&Config{SkipStages: []string{StageComment}})
+	if err := r.validateSkipStages(); err != nil {
+		t.Errorf("validateSkipStages() error = %v, want nil", err)
+	}
+	r.config.SkipStages = []string{"lint"}
+	if err := r.validateSkipStages(); err == nil || !strings.Contains(err.Error(), "unknown stage lint") {
+		t.Errorf("validateSkipStages() error = %v, want unknown stage", err)
+	}
+}
+
+func TestFormatStageTimings(t *testing.T) {
+	if got := formatStageTimings(nil); got != "" {
+		t.Errorf("formatStageTimings(nil) = %q, want empty", got)
+	}
+	got := formatStageTimings([]StageTiming{
+		{Name: StageValidate, Duration: 1500 * time.Millisecond},
+		{Name: StagePlan, Duration: 2 * time.Minute},
+		{Name: StagePolicy, Duration: time.Millisecond, Err: errors.New("leak")},
+	})
+	want := "\n**Stages:** validate 1.5s → plan 2m0s → policy 1ms ❌\n"
+	if got != want {
+		t.Errorf("formatStageTimings() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const (
+	planDiffContext  = 3       // Unchanged lines shown around each change
+	planDiffMaxCells = 4000000 // Largest line matrix compared; larger differing regions are replaced whole
+	planDiffMaxSize  = 20000   // Longest diff rendered in a comment
+)
+
+// Output block of a folder comment (one per part when the output is split)
+var reCommentOutput = regexp.MustCompile("(?s)```hcl\n(.*?)\n```\n</details>")
+
+// Line of a diff: ' ' unchanged, '-' removed, '+' added
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// Load the plan output shown in the latest earlier comments of each folder
+// with the same label, before the comments are deleted. Split outputs are
+// joined from their parts.
+func (r *Runner) loadPreviousPlans(ctx context.Context, client *github.Client) map[string]string {
+	parts := strings.Split(r.config.Repository, "/")
+	comments, err := r.listTargetComments(ctx, client, parts[0], parts[1])
+	if err != nil {
+		r.logger.Warn("Failed to list comments for the previous plans", "error", err)
+		return nil
+	}
+	plans := map[string]string{}
+	runs := map[string]string{}
+	// Comments are listed oldest first, so a newer run replaces the plan
+	for _, comment := range comments {
+		fields, ok := parseCommentMarker(comment.Body)
+		if !ok || !isFolderMarker(fields["folder"]) || fields["label"] != r.config.RunLabel || !strings.Contains(comment.Login, "[bot]") {
+			continue
+		}
+		var blocks []string
+		for _, m := range reCommentOutput.FindAllStringSubmatch(comment.Body, -1) {
+			blocks = append(blocks, strings.TrimRight(m[1], "\n"))
+		}
+		if len(blocks) == 0 || fields["run"] == getRunID() {
+			continue
+		}
+		folder := filepath.Clean(fields["folder"])
+		if runs[folder] != fields["run"] {
+			runs[folder] = fields["run"]
+			plans[folder] = ""
+		} else {
+			plans[folder] += "\n"
+		}
+		plans[folder] += strings.Join(blocks, "\n")
+	}
+	r.logger.Info("Loaded previous plans for plan diffs", "folders", len(plans))
+	return plans
+}
+
+// Compare two texts line by line. Common leading and trailing lines are
+// matched first; the region between is compared with a longest common
+// subsequence, or replaced whole when it is too large to compare.
+func diffTextLines(before, after string) []diffLine {
+	a, b := strings.Split(before, "\n"), strings.Split(after, "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// Diff the differing region of two texts
+func diffMiddle(a, b []string) []diffLine {
+	var lines []diffLine
+	if len(a)*len(b) > planDiffMaxCells {
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+		return lines
+	}
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Removals come first, as in diff -u
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// Format diff lines as unified diff hunks (empty if nothing changed)
+func formatUnifiedDiff(lines []diffLine, context int) string {
+	var b strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].Op == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while the next change is within twice the context
+		first := max(start-context, 0)
+		end := start
+		for k := start; k < len(lines) && k <= end+2*context; k++ {
+			if lines[k].Op != ' ' {
+				end = k
+			}
+		}
+		last := min(end+context, len(lines)-1)
+
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:first] {
+			if line.Op != '+' {
+				oldStart++
+			}
+			if line.Op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[first : last+1] {
+			if line.Op != '+' {
+				oldCount++
+			}
+			if line.Op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range lines[first : last+1] {
+			b.WriteString(string(line.Op) + line.Text + "\n")
+		}
+		start = last + 1
+	}
+	return b.String()
+}
+
+// Format what changed in a folder's plan output since its previous comment
+// (empty without plan-diff or a previous plan)
+func (r *Runner) formatPlanDiff(folder, content string) string {
+	if !r.config.PlanDiff {
+		return ""
+	}
+	previous, ok := r.prevPlans[filepath.Clean(folder)]
+	if !ok {
+		return ""
+	}
+	diff := formatUnifiedDiff(diffTextLines(previous, strings.TrimRight(content, "\n")), planDiffContext)
+	if diff == "" {
+		return "🔁 " + r.msg("comment.plan_unchanged") + "\n"
+	}
+	if len(diff) > planDiffMaxSize {
+		diff = strings.TrimRight(truncateAtLine(diff, planDiffMaxSize), "\n") + "\n... (diff truncated)\n"
+	}
+	return "<details><summary><b>🔁 " + r.msg("comment.plan_diff") + "</b></summary>\n\n```diff\n" + diff + "```\n</details>\n"
+}
+
+// Cut a text at the last line break within the size
+func truncateAtLine(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	if i := strings.LastIndex(text[:size], "\n"); i >= 0 {
+		return text[:i+1]
+	}
+	return cutUTF8(text, size)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFormatUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{"unchanged", "a\nb\nc", "a\nb\nc", ""},
+		{
+			name:   "changed line",
+			before: "1\n2\n3\n4\n5\n6\n7\n8",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8",
+			want:   "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "added and removed lines",
+			before: "a\nb\nc",
+			after:  "a\nc\nd",
+			want:   "@@ -1,3 +1,3 @@\n a\n-b\n c\n+d\n",
+		},
+		{
+			name:   "separate hunks",
+			before: "x\n1\n2\n3\n4\n5\n6\n7\n8\n9\ny",
+			after:  "X\n1\n2\n3\n4\n5\n6\n7\n8\n9\nY",
+			want:   "@@ -1,4 +1,4 @@\n-x\n+X\n 1\n 2\n 3\n@@ -8,4 +8,4 @@\n 7\n 8\n 9\n-y\n+Y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatUnifiedDiff(diffTextLines(tt.before, tt.after), planDiffContext); got != tt.want {
+				t.Errorf("formatUnifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPreviousPlans(t *testing.T) {
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[
+			{"id": 1, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=1 -->\n<details>\n\n` + "```hcl\\nold plan\\n```" + `\n</details>", "user": {"login": "github-actions[bot]"}},
+			{"id": 2, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=2 -->\n<details>\n\n` + "```hcl\\npart one\\n\\n```" + `\n</details>", "user": {"login": "github-actions[bot]"}},
+			{"id": 3, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=2 -->\n<details>\n\n` + "```hcl\\npart two\\n```" + `\n</details>", "user": {"login": "github-actions[bot]"}},
+			{"id": 4, "body": "<!-- terragrunt-runner:folder=live%2Fdb;run=2;label=prod -->\n<details>\n\n` + "```hcl\\nprod plan\\n```" + `\n</details>", "user": {"login": "github-actions[bot]"}},
+			{"id": 5, "body": "<!-- terragrunt-runner:folder=_summary;run=2 -->\n<details>\n\n` + "```hcl\\nsummary\\n```" + `\n</details>", "user": {"login": "github-actions[bot]"}}
+		]`))
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, PlanDiff: true})
+	plans := r.loadPreviousPlans(context.Background(), client)
+	if len(plans) != 1 || plans["live/app"] != "part one\npart two" {
+		t.Errorf("loadPreviousPlans() = %q, want the joined parts of the latest live/app run", plans)
+	}
+}
+
+func TestFormatPlanDiff(t *testing.T) {
+	r := newTestRunner(&Config{PlanDiff: true})
+	r.prevPlans = map[string]string{"live/app": "+ resource \"a\"\n+ resource \"b\""}
+
+	if got := r.formatPlanDiff("live/db", "anything"); got != "" {
+		t.Errorf("formatPlanDiff() without a previous plan = %q, want empty", got)
+	}
+	if got := r.formatPlanDiff("live/app", "+ resource \"a\"\n+ resource \"b\"\n"); !strings.Contains(got, "unchanged") {
+		t.Errorf("formatPlanDiff() of the same plan = %q, want the unchanged note", got)
+	}
+	got := r.formatPlanDiff("./live/app", "+ resource \"a\"\n+ resource \"c\"")
+	if !strings.Contains(got, "```diff\n@@ -1,2 +1,2 @@\n + resource \"a\"\n-+ resource \"b\"\n++ resource \"c\"\n```") {
+		t.Errorf("formatPlanDiff() = %q, want the unified diff", got)
+	}
+
+	r.config.PlanDiff = false
+	if got := r.formatPlanDiff("live/app", "changed"); got != "" {
+		t.Errorf("formatPlanDiff() without plan-diff = %q, want empty", got)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Suffix of plan files encrypted with --plan-encrypt-key
+const planEncryptedSuffix = ".enc"
+
+// Plan file encrypted with a random data key, itself encrypted with a KMS key
+type PlanEnvelope struct {
+	Version      int    `json:"version"`
+	KMS          string `json:"kms"`           // aws or gcp
+	KeyID        string `json:"key_id"`        // KMS key the data key is encrypted with
+	EncryptedKey []byte `json:"encrypted_key"` // Data key encrypted by KMS
+	Nonce        []byte `json:"nonce"`
+	Ciphertext   []byte `json:"ciphertext"` // Plan encrypted with AES-256-GCM
+}
+
+// Get the KMS of a key: aws for AWS KMS key or alias ARNs, gcp for Cloud KMS
+// key resource names (optionally prefixed with gcpkms://)
+func planKMS(key string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(key, "arn:aws:kms:") || strings.HasPrefix(key, "arn:aws-us-gov:kms:") || strings.HasPrefix(key, "arn:aws-cn:kms:"):
+		return "aws", key, nil
+	case strings.HasPrefix(key, "gcpkms://"):
+		return "gcp", strings.TrimPrefix(key, "gcpkms://"), nil
+	case strings.HasPrefix(key, "projects/") && strings.Contains(key, "/cryptoKeys/"):
+		return "gcp", key, nil
+	}
+	return "", "", fmt.Errorf("invalid plan-encrypt-key %q: use an AWS KMS key ARN or a GCP KMS key name (projects/.../cryptoKeys/...)", key)
+}
+
+// Run a KMS CLI, the way publish-results runs the cloud CLIs
+func (r *Runner) runKMS(name string, args ...string) ([]byte, error) {
+	out, err := r.commandOutput(exec.Command(name, args...))
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s kms: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s kms: %w", name, err)
+	}
+	return out, nil
+}
+
+// Encrypt the data key with the KMS key
+func (r *Runner) wrapDataKey(kms, keyID string, dataKey []byte) ([]byte, error) {
+	tmp, err := writeTempSecret(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	if kms == "aws" {
+		out, err := r.runKMS("aws", "kms", "encrypt", "--key-id", keyID, "--plaintext", "fileb://"+tmp, "--output", "text", "--query", "CiphertextBlob")
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	}
+	return r.runKMS("gcloud", "kms", "encrypt", "--key="+keyID, "--plaintext-file="+tmp, "--ciphertext-file=-")
+}
+
+// Decrypt the data key of an envelope with its KMS key
+func (r *Runner) unwrapDataKey(envelope *PlanEnvelope) ([]byte, error) {
+	tmp, err := writeTempSecret(envelope.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	if envelope.KMS == "aws" {
+		out, err := r.runKMS("aws", "kms", "decrypt", "--key-id", envelope.KeyID, "--ciphertext-blob", "fileb://"+tmp, "--output", "text", "--query", "Plaintext")
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	}
+	return r.runKMS("gcloud", "kms", "decrypt", "--key="+envelope.KeyID, "--ciphertext-file="+tmp, "--plaintext-file=-")
+}
+
+// Write key material to a private temporary file for the KMS CLIs
+func writeTempSecret(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "terragrunt-runner-key-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Encrypt a plan with a fresh data key encrypted by the KMS key
+func (r *Runner) sealPlan(plan []byte, key string) (*PlanEnvelope, error) {
+	kms, keyID, err := planKMS(key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	gcm, err := newPlanGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := r.wrapDataKey(kms, keyID, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanEnvelope{
+		Version:      1,
+		KMS:          kms,
+		KeyID:        keyID,
+		EncryptedKey: encryptedKey,
+		Nonce:        nonce,
+		Ciphertext:   gcm.Seal(nil, nonce, plan, []byte(keyID)),
+	}, nil
+}
+
+// Decrypt the plan of an envelope
+func (r *Runner) openPlan(envelope *PlanEnvelope) ([]byte, error) {
+	if envelope.Version != 1 {
+		return nil, fmt.Errorf("unsupported plan envelope version %d", envelope.Version)
+	}
+	dataKey, err := r.unwrapDataKey(envelope)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newPlanGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(envelope.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("plan does not decrypt: %w", err)
+	}
+	return plan, nil
+}
+
+// Create the AES-256-GCM cipher of a data key
+func newPlanGCM(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Replace a plan file with its encrypted envelope next to it
+func (r *Runner) encryptPlanFile(path, key string) error {
+	plan, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	envelope, err := r.sealPlan(plan, key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+planEncryptedSuffix, data, 0o600); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Decrypt an encrypted plan file next to it, returning the plaintext path
+// (empty for other files with the suffix)
+func (r *Runner) decryptPlanFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var envelope PlanEnvelope
+	if json.Unmarshal(data, &envelope) != nil || envelope.KMS == "" {
+		return "", nil
+	}
+	plan, err := r.openPlan(&envelope)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	plain := strings.TrimSuffix(path, planEncryptedSuffix)
+	if err := os.WriteFile(plain, plan, 0o600); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// Encrypt the plan files written with -out in the folders, so only envelopes
+// reach artifacts and publish-results. A plan that fails to encrypt is deleted
+// rather than left in plaintext.
+func (r *Runner) encryptPlanFiles(results []ExecutionResult) {
+	planFile := planOutFile(r.config.Command + " " + r.config.TerragruntArgs)
+	if planFile == "" {
+		return
+	}
+	for _, result := range r.folderResults(results) {
+		for _, p := range findPlanFiles(result.Folder, planFile) {
+			if err := r.encryptPlanFile(p, r.config.PlanEncryptKey); err != nil {
+				fmt.Printf("::error::Failed to encrypt plan file %s, deleting it: %v\n", p, err)
+				os.Remove(p)
+				continue
+			}
+			r.logger.Info("Encrypted plan file", "file", p+planEncryptedSuffix)
+		}
+	}
+}
+
+// Decrypt the encrypted plan files in the folders before an apply, returning
+// the plaintext files to delete afterwards
+func (r *Runner) decryptPlanFiles(folders []string) ([]string, error) {
+	var plain []string
+	for _, folder := range folders {
+		var encrypted []string
+		filepath.WalkDir(folder, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.HasSuffix(d.Name(), planEncryptedSuffix) {
+				encrypted = append(encrypted, p)
+			}
+			return nil
+		})
+		for _, p := range encrypted {
+			file, err := r.decryptPlanFile(p)
+			if err != nil {
+				return plain, err
+			}
+			if file == "" {
+				continue
+			}
+			r.logger.Info("Decrypted plan file", "file", file)
+			plain = append(plain, file)
+		}
+	}
+	return plain, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanKMS(t *testing.T) {
+	tests := []struct {
+		key       string
+		wantKMS   string
+		wantKeyID string
+	}{
+		{key: "arn:aws:kms:eu-west-1:123456789012:key/1234abcd", wantKMS: "aws", wantKeyID: "arn:aws:kms:eu-west-1:123456789012:key/1234abcd"},
+		{key: "arn:aws:kms:eu-west-1:123456789012:alias/plans", wantKMS: "aws", wantKeyID: "arn:aws:kms:eu-west-1:123456789012:alias/plans"},
+		{key: "projects/p/locations/global/keyRings/r/cryptoKeys/plans", wantKMS: "gcp", wantKeyID: "projects/p/locations/global/keyRings/r/cryptoKeys/plans"},
+		{key: "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/plans", wantKMS: "gcp", wantKeyID: "projects/p/locations/global/keyRings/r/cryptoKeys/plans"},
+		{key: "alias/plans"},
+		{key: "arn:aws:s3:::bucket"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			kms, keyID, err := planKMS(tt.key)
+			if tt.wantKMS == "" {
+				if err == nil {
+					t.Errorf("planKMS() = %s, want an error", kms)
+				}
+				return
+			}
+			if err != nil || kms != tt.wantKMS || keyID != tt.wantKeyID {
+				t.Errorf("planKMS() = %s, %s, %v, want %s, %s", kms, keyID, err, tt.wantKMS, tt.wantKeyID)
+			}
+		})
+	}
+}
+
+// Fake aws CLI "encrypting" a data key by prefixing it, printed base64 encoded
+// like aws kms encrypt --output text --query CiphertextBlob
+const fakeAWSKMSScript = `#!/bin/sh
+op=$2
+shift 2
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --plaintext|--ciphertext-blob) file=${2#fileb://}; shift ;;
+  esac
+  shift
+done
+if [ "$op" = encrypt ]; then
+  { printf wrapped; cat "$file"; } | base64 | tr -d '\n'
+else
+  tail -c +8 "$file" | base64 | tr -d '\n'
+fi
+`
+
+func setupFakeKMS(t *testing.T) {
+	t.Helper()
+	scripts := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scripts, "aws"), []byte(fakeAWSKMSScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+const testPlanKey = "arn:aws:kms:eu-west-1:123456789012:key/plans"
+
+func TestEncryptPlanFileRoundTrip(t *testing.T) {
+	setupFakeKMS(t)
+	path := filepath.Join(t.TempDir(), "tfplan")
+	os.WriteFile(path, []byte("binary plan with db_password=hunter2"), 0o644)
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	r := newTestRunner(&Config{AuditLog: auditLog})
+
+	if err := r.encryptPlanFile(path, testPlanKey); err != nil {
+		t.Fatalf("encryptPlanFile() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("plaintext plan still exists")
+	}
+	data, _ := os.ReadFile(path + planEncryptedSuffix)
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("envelope contains the plaintext plan")
+	}
+
+	plain, err := r.decryptPlanFile(path + planEncryptedSuffix)
+	if err != nil || plain != path {
+		t.Fatalf("decryptPlanFile() = %s, %v", plain, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "binary plan with db_password=hunter2" {
+		t.Errorf("decrypted plan = %q", got)
+	}
+	if audit, _ := os.ReadFile(auditLog); strings.Count(string(audit), `"argv":["aws","kms",`) != 2 {
+		t.Errorf("audit log = %s, want the KMS encrypt and decrypt calls", audit)
+	}
+
+	// A modified envelope does not decrypt
+	var envelope PlanEnvelope
+	json.Unmarshal(data, &envelope)
+	envelope.Ciphertext[0] ^= 1
+	tampered, _ := json.Marshal(envelope)
+	os.WriteFile(path+planEncryptedSuffix, tampered, 0o600)
+	if _, err := r.decryptPlanFile(path + planEncryptedSuffix); err == nil || !strings.Contains(err.Error(), "does not decrypt") {
+		t.Errorf("decryptPlanFile() of a modified envelope error = %v", err)
+	}
+}
+
+func TestEncryptAndDecryptPlanFiles(t *testing.T) {
+	setupFakeKMS(t)
+	folder := t.TempDir()
+	cache := filepath.Join(folder, ".terragrunt-cache", "abc")
+	os.MkdirAll(cache, 0o755)
+	os.WriteFile(filepath.Join(cache, "tfplan"), []byte("plan"), 0o644)
+	os.WriteFile(filepath.Join(folder, "secrets.enc"), []byte("not an envelope"), 0o644)
+
+	r := newTestRunner(&Config{Command: "plan", TerragruntArgs: "-out=tfplan", PlanEncryptKey: testPlanKey})
+	r.encryptPlanFiles([]ExecutionResult{{Folder: folder, Success: true}})
+	if _, err := os.Stat(filepath.Join(cache, "tfplan"+planEncryptedSuffix)); err != nil {
+		t.Fatalf("plan file not encrypted: %v", err)
+	}
+
+	r = newTestRunner(&Config{Command: "apply", TerragruntArgs: "tfplan", PlanEncryptKey: testPlanKey})
+	plain, err := r.decryptPlanFiles([]string{folder})
+	if err != nil {
+		t.Fatalf("decryptPlanFiles() error = %v", err)
+	}
+	if len(plain) != 1 || plain[0] != filepath.Join(cache, "tfplan") {
+		t.Errorf("decryptPlanFiles() = %v, want only the plan", plain)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const preChecksMarkerFolder = "_pre-checks" // Marker folder key of the pre-checks comment
+
+// Terragrunt commands backing each pre-check (Terragrunt 0.88+ CLI)
+var preCheckCommands = map[string][]string{
+	"hclfmt":          {"hcl", "fmt", "--check"},
+	"validate-inputs": {"hcl", "validate", "--inputs"},
+}
+
+type PreCheckResult struct {
+	Check  string // Name of the pre-check
+	Folder string // Folder the check ran in
+	Output string // Cleaned output of the check
+	Passed bool   // Whether the check passed
+}
+
+// Validate the requested pre-checks
+func validatePreChecks(checks []string) error {
+	for _, check := range checks {
+		if _, ok := preCheckCommands[check]; !ok {
+			return fmt.Errorf("invalid pre-check: %s (expected hclfmt or validate-inputs)", check)
+		}
+	}
+	return nil
+}
+
+// Run the configured pre-checks in every folder
+func (r *Runner) runPreChecks(folders, checks []string) []PreCheckResult {
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return []PreCheckResult{{Check: "setup", Folder: ".", Output: err.Error()}}
+	}
+
+	var results []PreCheckResult
+	for _, check := range checks {
+		for _, folder := range folders {
+			absFolder := folder
+			if !filepath.IsAbs(folder) {
+				absFolder = filepath.Join(repoRoot, folder)
+			}
+
+			cmd := exec.Command("terragrunt", preCheckCommands[check]...)
+			cmd.Dir = absFolder
+			cmd.Env = r.subprocessEnv()
+			var out bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &out, &out
+
+			err := r.runCommand(cmd)
+			r.logger.Debug("Pre-check finished", "check", check, "folder", folder, "error", err)
+			results = append(results, PreCheckResult{
+				Check:  check,
+				Folder: folder,
+				Output: strings.TrimSpace(stripAnsiCodes(out.String())),
+				Passed: err == nil,
+			})
+		}
+	}
+	return results
+}
+
+// Check whether all pre-checks passed
+func preChecksPassed(results []PreCheckResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Format the pre-checks section posted to the PR
+func formatPreChecks(results []PreCheckResult) string {
+	var b strings.Builder
+	status := "✅"
+	if !preChecksPassed(results) {
+		status = "❌"
+	}
+	b.WriteString(fmt.Sprintf("## %s Terragrunt Pre-checks\n\n", status))
+	b.WriteString("| Check | Folder | Status |\n|-------|--------|--------|\n")
+	for _, r := range results {
+		s := "✅"
+		if !r.Passed {
+			s = "❌"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", r.Check, r.Folder, s))
+	}
+
+	for _, r := range results {
+		if r.Passed || r.Output == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n<details><summary><b>%s: %s</b></summary>\n\n```hcl\n%s\n```\n</details>\n", r.Check, r.Folder, r.Output))
+	}
+	return b.String()
+}
+
+// Post the pre-checks section as a PR comment
+func (r *Runner) postPreChecks(ctx context.Context, client *github.Client, results []PreCheckResult) error {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	body := formatPreChecks(results)
+	if len(body) > maxCommentSize-headerSize {
+		body = cutUTF8(body, maxCommentSize-headerSize) + "\n```\n\n_Truncated_"
+	}
+	return r.createComment(ctx, client, owner, repo, preChecksMarkerFolder, body)
+}
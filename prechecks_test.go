@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePreChecks(t *testing.T) {
+	if err := validatePreChecks([]string{"hclfmt", "validate-inputs"}); err != nil {
+		t.Errorf("validatePreChecks() error = %v, want nil", err)
+	}
+	if err := validatePreChecks([]string{"tflint"}); err == nil {
+		t.Error("validatePreChecks() expected error for unknown check")
+	}
+}
+
+func TestFormatPreChecks(t *testing.T) {
+	results := []PreCheckResult{
+		{Check: "hclfmt", Folder: "live/app", Passed: true},
+		{Check: "validate-inputs", Folder: "live/app", Output: "unused input: foo", Passed: false},
+	}
+
+	got := formatPreChecks(results)
+	for _, want := range []string{
+		"## ❌ Terragrunt Pre-checks",
+		"| hclfmt | live/app | ✅ |",
+		"| validate-inputs | live/app | ❌ |",
+		"<b>validate-inputs: live/app</b>",
+		"unused input: foo",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatPreChecks() missing %q:\n%s", want, got)
+		}
+	}
+	if preChecksPassed(results) {
+		t.Error("preChecksPassed() = true, want false")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const progressMarkerFolder = "_progress" // Marker folder key of the live progress comment
+
+// Folder progress statuses
+const (
+	ProgressQueued  = "queued"
+	ProgressRunning = "running"
+	ProgressDone    = "done"
+	ProgressFailed  = "failed"
+)
+
+// Progress of one folder (or run --all root) of a run
+type FolderProgress struct {
+	Folder   string
+	Status   string
+	Started  time.Time
+	Finished time.Time
+}
+
+// Live progress of a run, shared by the executors and the comment updater.
+// Methods are safe on a nil progress, which tracks nothing.
+type runProgress struct {
+	mu      sync.Mutex
+	started time.Time
+	folders []FolderProgress
+}
+
+// Create the progress of a run with its folders queued
+func newRunProgress(folders []string) *runProgress {
+	p := &runProgress{started: time.Now()}
+	for _, folder := range folders {
+		p.folders = append(p.folders, FolderProgress{Folder: folder, Status: ProgressQueued})
+	}
+	return p
+}
+
+// Get the progress entry of a folder, adding it if unknown (run --all roots and shards)
+func (p *runProgress) entry(folder string) *FolderProgress {
+	for i := range p.folders {
+		if p.folders[i].Folder == folder {
+			return &p.folders[i]
+		}
+	}
+	p.folders = append(p.folders, FolderProgress{Folder: folder, Status: ProgressQueued})
+	return &p.folders[len(p.folders)-1]
+}
+
+// Mark a folder as running
+func (p *runProgress) start(folder string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.entry(folder)
+	e.Status, e.Started, e.Finished = ProgressRunning, time.Now(), time.Time{}
+}
+
+// Mark a folder as done or failed
+func (p *runProgress) finish(folder string, success bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.entry(folder)
+	e.Status, e.Finished = ProgressDone, time.Now()
+	if !success {
+		e.Status = ProgressFailed
+	}
+}
+
+// Copy the folder progress and the run start
+func (p *runProgress) snapshot() ([]FolderProgress, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FolderProgress(nil), p.folders...), p.started
+}
+
+// Format the progress comment at the given time
+func formatProgress(command string, folders []FolderProgress, started, now time.Time) string {
+	icons := map[string]string{ProgressQueued: "⏸️", ProgressRunning: "⏳", ProgressDone: "✅", ProgressFailed: "❌"}
+	finished := 0
+	for _, f := range folders {
+		if f.Status == ProgressDone || f.Status == ProgressFailed {
+			finished++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("## ⏳ Terragrunt Running: %s\n\n", command))
+	b.WriteString(fmt.Sprintf("Running terragrunt in %d folders… %d/%d finished, elapsed %s. Results are posted when the run completes.\n\n",
+		len(folders), finished, len(folders), now.Sub(started).Round(time.Second)))
+	b.WriteString("| Folder | Status | Elapsed |\n|--------|--------|---------|\n")
+	for _, f := range folders {
+		elapsed := ""
+		switch {
+		case !f.Finished.IsZero():
+			elapsed = f.Finished.Sub(f.Started).Round(time.Second).String()
+		case !f.Started.IsZero():
+			elapsed = now.Sub(f.Started).Round(time.Second).String()
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s %s | %s |\n", f.Folder, icons[f.Status], f.Status, elapsed))
+	}
+	return b.String()
+}
+
+// Post the progress comment and keep it updated every progress interval until
+// the returned stop function is called, which deletes it before the results
+// are posted. Progress is best effort: failures are logged and the run goes on.
+func (r *Runner) startProgressComment(ctx context.Context, client *github.Client) func() {
+	folders := r.config.Folders
+	if strings.Contains(r.config.Command, "--all") || strings.HasPrefix(r.config.Command, "run-all") {
+		folders = nil // Roots and shards are added as they start
+	}
+	r.progress = newRunProgress(folders)
+	if client == nil || !r.caps.commentsAllowed() {
+		return func() {}
+	}
+
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	render := func() string {
+		folders, started := r.progress.snapshot()
+		return commentMarker(progressMarkerFolder, r.config.RunLabel) + "\n" + formatProgress(r.config.Command, folders, started, time.Now()) + commentFooter(r.config.RunLabel)
+	}
+	id, err := r.postTargetComment(ctx, client, owner, repo, render())
+	if err != nil {
+		r.logger.Warn("Failed to post progress comment", "error", err)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Duration(r.config.ProgressInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := r.editTargetComment(ctx, client, owner, repo, id, render()); err != nil {
+					r.logger.Warn("Failed to update progress comment", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+		if err := r.deleteTargetComment(ctx, client, owner, repo, id); err != nil {
+			r.logger.Warn("Failed to delete progress comment, leaving its final state", "error", err)
+			r.editTargetComment(ctx, client, owner, repo, id, render())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFormatProgress(t *testing.T) {
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := started.Add(3 * time.Minute)
+	folders := []FolderProgress{
+		{Folder: "live/app", Status: ProgressDone, Started: started, Finished: started.Add(90 * time.Second)},
+		{Folder: "live/db", Status: ProgressRunning, Started: started.Add(time.Minute)},
+		{Folder: "live/dns", Status: ProgressQueued},
+		{Folder: "live/vpc", Status: ProgressFailed, Started: started, Finished: started.Add(10 * time.Second)},
+	}
+
+	got := formatProgress("plan", folders, started, now)
+	for _, want := range []string{
+		"## ⏳ Terragrunt Running: plan",
+		"4 folders… 2/4 finished, elapsed 3m0s",
+		"| live/app | ✅ done | 1m30s |",
+		"| live/db | ⏳ running | 2m0s |",
+		"| live/dns | ⏸️ queued |  |",
+		"| live/vpc | ❌ failed | 10s |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatProgress() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	var none *runProgress
+	none.start("live/app") // Nil progress tracks nothing
+	none.finish("live/app", true)
+
+	p := newRunProgress([]string{"live/app", "live/db"})
+	p.start("live/app")
+	p.finish("live/app", true)
+	p.start("live/db")
+	p.finish("live/db", false)
+	p.start(".") // Run --all root, added when it starts
+
+	folders, _ := p.snapshot()
+	var statuses []string
+	for _, f := range folders {
+		statuses = append(statuses, f.Folder+"="+f.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "live/app=done,live/db=failed,.=running" {
+		t.Errorf("statuses = %s", got)
+	}
+}
+
+func TestStartProgressComment(t *testing.T) {
+	var mu sync.Mutex
+	var posted, deleted int
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/issues/7/comments":
+			posted++
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 21}`))
+		case req.Method == http.MethodDelete && req.URL.Path == "/repos/owner/repo/issues/comments/21":
+			deleted++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 7, Command: "plan", Folders: []string{"live/app"}, ProgressInterval: 3600})
+	stop := r.startProgressComment(context.Background(), client)
+	r.executeTrackedFolder("live/missing")
+	stop()
+
+	if posted != 1 || deleted != 1 {
+		t.Errorf("posted %d and deleted %d comments, want one progress comment removed", posted, deleted)
+	}
+	folders, _ := r.progress.snapshot()
+	if len(folders) != 2 || folders[1].Status != ProgressFailed {
+		t.Errorf("progress = %+v, want the failed folder tracked", folders)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const promoteMarkerFolder = "_promote" // Marker folder key of the promotion report
+
+// Resolved Terragrunt configuration relevant for promotions
+type UnitConfig struct {
+	Source string         // Resolved terraform.source of the unit
+	Inputs map[string]any // Resolved inputs of the unit
+}
+
+// A difference between the inputs of two units
+type InputDiff struct {
+	Name   string // Input name
+	Source string // Rendered value in the source unit (empty if missing)
+	Target string // Rendered value in the target unit (empty if missing)
+}
+
+// Create the promote subcommand
+func newPromoteCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "promote --from <source-folder> --to <target-folder>",
+		Short: "Check whether a unit can be promoted from one environment to another",
+		Long: `Compare the inputs of a source and target unit, plan the target with the source's
+module version and post a promotion readiness report to the pull request.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return NewRunner(config, logger).promote(from, to)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Source environment unit folder (e.g. live/dev/app)")
+	cmd.Flags().StringVar(&to, "to", "", "Target environment unit folder (e.g. live/staging/app)")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// Run the promotion check and post the report
+func (r *Runner) promote(from, to string) error {
+	if r.config.GithubToken != "" {
+		fmt.Printf("::add-mask::%s\n", r.config.GithubToken)
+	}
+	r.config.Folders = []string{from, to}
+	if err := r.validateConfig(); err != nil {
+		return err
+	}
+
+	source, err := r.renderUnitConfig(from)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", from, err)
+	}
+	target, err := r.renderUnitConfig(to)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", to, err)
+	}
+
+	// Plan the target with the module version resolved in the source
+	r.config.Command = "plan"
+	if source.Source != "" {
+		fromDir := from
+		if !filepath.IsAbs(from) {
+			repoRoot, err := r.getRepoRoot()
+			if err != nil {
+				return err
+			}
+			fromDir = filepath.Join(repoRoot, from)
+		}
+		r.config.TerragruntArgs = strings.TrimSpace(r.config.TerragruntArgs + " --source " + resolveLocalSource(source.Source, fromDir))
+	}
+	result := r.executeTerragruntInFolder(to)
+
+	report := formatPromotionReport(from, to, source, target, diffInputs(source.Inputs, target.Inputs), result)
+	fmt.Println(report)
+
+	ctx := context.Background()
+	client := r.createGitHubClient()
+	parts := strings.Split(r.config.Repository, "/")
+	if err := r.createComment(ctx, client, parts[0], parts[1], promoteMarkerFolder, report); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		return fmt.Errorf("promotion plan failed for %s", to)
+	}
+	return nil
+}
+
+// Render a unit's resolved configuration with terragrunt render
+func (r *Runner) renderUnitConfig(folder string) (*UnitConfig, error) {
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		absFolder = filepath.Join(repoRoot, folder)
+	}
+
+	cmd := exec.Command("terragrunt", "render", "--format", "json")
+	cmd.Dir = absFolder
+	cmd.Env = r.subprocessEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := r.runCommand(cmd); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stripAnsiCodes(stderr.String())))
+	}
+	return parseRenderedConfig(stdout.Bytes())
+}
+
+// Resolve a relative local module source against the folder of the unit that
+// rendered it, so it points to the same module when another unit is planned with
+// it. Remote and absolute sources are returned unchanged.
+func resolveLocalSource(source, unitDir string) string {
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return source
+	}
+	// Keep the "//" separating the module directory from the package root
+	base, subdir, found := strings.Cut(source, "//")
+	resolved := filepath.Join(unitDir, base)
+	if found {
+		resolved += "//" + subdir
+	}
+	return resolved
+}
+
+// Parse the JSON produced by terragrunt render
+func parseRenderedConfig(data []byte) (*UnitConfig, error) {
+	var rendered struct {
+		Terraform struct {
+			Source string `json:"source"`
+		} `json:"terraform"`
+		Inputs map[string]any `json:"inputs"`
+	}
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		return nil, fmt.Errorf("invalid render output: %w", err)
+	}
+	return &UnitConfig{Source: rendered.Terraform.Source, Inputs: rendered.Inputs}, nil
+}
+
+// Compare inputs of the source and target units, sorted by name
+func diffInputs(source, target map[string]any) []InputDiff {
+	names := make(map[string]bool)
+	for name := range source {
+		names[name] = true
+	}
+	for name := range target {
+		names[name] = true
+	}
+
+	var diffs []InputDiff
+	for name := range names {
+		sv, inSource := source[name]
+		tv, inTarget := target[name]
+		if inSource && inTarget && reflect.DeepEqual(sv, tv) {
+			continue
+		}
+		diff := InputDiff{Name: name}
+		if inSource {
+			diff.Source = maskInputValue(name, renderInputValue(sv))
+		}
+		if inTarget {
+			diff.Target = maskInputValue(name, renderInputValue(tv))
+		}
+		diffs = append(diffs, diff)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// Render an input value compactly for the report
+func renderInputValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Format the promotion readiness report
+func formatPromotionReport(from, to string, source, target *UnitConfig, diffs []InputDiff, result ExecutionResult) string {
+	var b strings.Builder
+	status := "✅ Ready"
+	if !result.Success {
+		status = "❌ Not ready"
+	}
+	b.WriteString(fmt.Sprintf("## %s Terragrunt Promotion: %s → %s\n\n", status, from, to))
+
+	b.WriteString("| | Source | Target |\n|---|---|---|\n")
+	b.WriteString(fmt.Sprintf("| **Folder** | %s | %s |\n", from, to))
+	b.WriteString(fmt.Sprintf("| **Module source** | `%s` | `%s` |\n\n", source.Source, target.Source))
+
+	if len(diffs) == 0 {
+		b.WriteString("**Inputs:** identical\n")
+	} else {
+		b.WriteString(fmt.Sprintf("**Input differences:** %d\n\n| Input | Source | Target |\n|-------|--------|--------|\n", len(diffs)))
+		for _, d := range diffs {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", d.Name, markdownCell(d.Source), markdownCell(d.Target)))
+		}
+	}
+
+	b.WriteString("\n**Target plan with source version:** ")
+	if result.ResourceChanges != nil && result.ResourceChanges.NoChanges {
+		b.WriteString("no changes\n")
+	} else if result.ResourceChanges != nil && result.Success {
+		b.WriteString(strings.TrimPrefix(formatResourceChanges(result.ResourceChanges), "**Changes:** "))
+	} else {
+		b.WriteString("failed\n")
+	}
+
+	content := result.Output
+	if !result.Success && result.Error != nil {
+		content = strings.TrimSpace(content + "\n\n" + result.Error.Error())
+	}
+	content, _ = scanSecrets(to, content)
+	if content != "" {
+		if len(content) > maxCommentSize-headerSize-b.Len() {
+			content = cutUTF8(content, maxCommentSize-headerSize-b.Len())
+		}
+		b.WriteString("\n<details><summary><b>View Target Plan</b></summary>\n\n```hcl\n" + content + "\n```\n</details>\n")
+	}
+	return b.String()
+}
+
+// Mask a rendered input value for the report: values of sensitive inputs are
+// hidden entirely, others go through the secret scanner
+func maskInputValue(name, value string) string {
+	if reSensitiveEnvName.MatchString(name) {
+		return secretMask
+	}
+	masked, _ := scanSecrets(name, value)
+	return masked
+}
+
+// Escape a value for use inside a markdown table cell
+func markdownCell(s string) string {
+	if s == "" {
+		return "_(unset)_"
+	}
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return "`" + strings.ReplaceAll(s, "\n", " ") + "`"
+}
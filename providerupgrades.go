@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	reLockProvider    = regexp.MustCompile(`^provider\s+"([^"]+)"\s*\{`)
+	reLockVersion     = regexp.MustCompile(`^version\s*=\s*"([^"]*)"`)
+	reLockConstraints = regexp.MustCompile(`^constraints\s*=\s*"([^"]*)"`)
+)
+
+// Provider selected by a dependency lock file
+type lockedProvider struct {
+	Version     string
+	Constraints string
+}
+
+// Provider version change of a folder between the diff base and the run
+type ProviderUpgrade struct {
+	Provider        string // Provider address, e.g. registry.terraform.io/hashicorp/aws
+	From            string // Version locked at the base (empty = added)
+	To              string // Version locked after the run (empty = removed)
+	FromConstraints string
+	ToConstraints   string
+}
+
+// Parse the providers of a dependency lock file by address
+func parseLockfileProviders(content string) map[string]lockedProvider {
+	providers := map[string]lockedProvider{}
+	address := ""
+	for line := range strings.SplitSeq(content, "\n") {
+		line = strings.TrimSpace(line)
+		if m := reLockProvider.FindStringSubmatch(line); m != nil {
+			address = m[1]
+			providers[address] = lockedProvider{}
+			continue
+		}
+		if address == "" {
+			continue
+		}
+		provider := providers[address]
+		if m := reLockVersion.FindStringSubmatch(line); m != nil {
+			provider.Version = m[1]
+		} else if m := reLockConstraints.FindStringSubmatch(line); m != nil {
+			provider.Constraints = m[1]
+		} else if line == "}" {
+			// Provider blocks have no nested blocks, hashes are a list
+			address = ""
+			continue
+		}
+		providers[address] = provider
+	}
+	return providers
+}
+
+// Compare the providers of two lock files, sorted by address
+func diffLockfileProviders(before, after map[string]lockedProvider) []ProviderUpgrade {
+	var upgrades []ProviderUpgrade
+	for address, old := range before {
+		if _, ok := after[address]; !ok {
+			upgrades = append(upgrades, ProviderUpgrade{Provider: address, From: old.Version, FromConstraints: old.Constraints})
+		}
+	}
+	for address, locked := range after {
+		old := before[address]
+		if old == locked {
+			continue
+		}
+		upgrades = append(upgrades, ProviderUpgrade{Provider: address, From: old.Version, To: locked.Version, FromConstraints: old.Constraints, ToConstraints: locked.Constraints})
+	}
+	slices.SortFunc(upgrades, func(a, b ProviderUpgrade) int { return strings.Compare(a.Provider, b.Provider) })
+	return upgrades
+}
+
+// Get the provider changes of a folder: its lock file at the diff base
+// against the lock file after the run, which also reflects providers that
+// init upgraded for changed version constraints. None without a diff base.
+func (r *Runner) providerUpgrades(folder string) []ProviderUpgrade {
+	if r.config.DiffBase == "" {
+		return nil
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return nil
+	}
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		absFolder = filepath.Join(repoRoot, folder)
+		// run --all units may be reported relative to the root dir
+		if _, err := os.Stat(absFolder); err != nil {
+			absFolder = filepath.Join(repoRoot, r.config.RunAllRootDir, folder)
+		}
+	}
+	lockPath := filepath.Join(absFolder, lockfileName)
+	current, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil
+	}
+	relPath, err := filepath.Rel(repoRoot, lockPath)
+	if err != nil {
+		return nil
+	}
+	// A lock file new since the base lists every provider as added
+	base, _ := r.commandOutput(exec.Command("git", "-C", repoRoot, "show", r.config.DiffBase+":"+filepath.ToSlash(relPath)))
+	return diffLockfileProviders(parseLockfileProviders(string(base)), parseLockfileProviders(string(current)))
+}
+
+// Get the release notes URL of a provider version. Registry providers live in
+// GitHub repositories named terraform-provider-<name> of their namespace.
+func providerReleaseURL(address, version string) string {
+	parts := strings.Split(address, "/")
+	if len(parts) != 3 || version == "" || (parts[0] != "registry.terraform.io" && parts[0] != "registry.opentofu.org") {
+		return ""
+	}
+	return fmt.Sprintf("https://github.com/%s/terraform-provider-%s/releases/tag/v%s", parts[1], parts[2], version)
+}
+
+// Format the provider upgrades of a folder highlighted at the top of its comment
+func (r *Runner) formatProviderUpgrades(upgrades []ProviderUpgrade) string {
+	if len(upgrades) == 0 {
+		return ""
+	}
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + s + "`"
+	}
+	var b strings.Builder
+	b.WriteString("### " + r.msg("providers.title") + "\n\n")
+	b.WriteString("| Provider | Version | Constraints | Release notes |\n|----------|---------|-------------|---------------|\n")
+	for _, upgrade := range upgrades {
+		name := upgrade.Provider
+		if parts := strings.Split(name, "/"); len(parts) == 3 && strings.HasPrefix(parts[0], "registry.") {
+			name = parts[1] + "/" + parts[2]
+		}
+		version := upgrade.From + " → " + upgrade.To
+		switch {
+		case upgrade.From == "":
+			version = upgrade.To + " (added)"
+		case upgrade.To == "":
+			version = upgrade.From + " (removed)"
+		}
+		constraints := code(upgrade.ToConstraints)
+		if upgrade.From != "" && upgrade.To != "" && upgrade.FromConstraints != upgrade.ToConstraints {
+			constraints = valueOr(code(upgrade.FromConstraints), "_none_") + " → " + valueOr(code(upgrade.ToConstraints), "_none_")
+		}
+		notes := ""
+		if url := providerReleaseURL(upgrade.Provider, upgrade.To); url != "" {
+			notes = fmt.Sprintf("[v%s](%s)", upgrade.To, url)
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", name, version, constraints, notes)
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLockfile = `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "%s"
+  constraints = "%s"
+  hashes = [
+    "h1:abc=",
+    "zh:def",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.6.0"
+  hashes = [
+    "h1:ghi=",
+  ]
+}
+`
+
+func TestParseLockfileProviders(t *testing.T) {
+	got := parseLockfileProviders(strings.Replace(strings.Replace(testLockfile, "%s", "5.40.0", 1), "%s", "~> 5.0", 1))
+	want := map[string]lockedProvider{
+		"registry.terraform.io/hashicorp/aws":    {Version: "5.40.0", Constraints: "~> 5.0"},
+		"registry.terraform.io/hashicorp/random": {Version: "3.6.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLockfileProviders() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffLockfileProviders(t *testing.T) {
+	before := map[string]lockedProvider{
+		"registry.terraform.io/hashicorp/aws":    {Version: "5.1.0", Constraints: "~> 5.0"},
+		"registry.terraform.io/hashicorp/null":   {Version: "3.2.0"},
+		"registry.terraform.io/hashicorp/random": {Version: "3.6.0"},
+	}
+	after := map[string]lockedProvider{
+		"registry.terraform.io/hashicorp/aws":    {Version: "5.40.0", Constraints: ">= 5.40"},
+		"registry.terraform.io/hashicorp/random": {Version: "3.6.0"},
+		"registry.terraform.io/hashicorp/tls":    {Version: "4.0.5"},
+	}
+	want := []ProviderUpgrade{
+		{Provider: "registry.terraform.io/hashicorp/aws", From: "5.1.0", To: "5.40.0", FromConstraints: "~> 5.0", ToConstraints: ">= 5.40"},
+		{Provider: "registry.terraform.io/hashicorp/null", From: "3.2.0"},
+		{Provider: "registry.terraform.io/hashicorp/tls", To: "4.0.5"},
+	}
+	if got := diffLockfileProviders(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffLockfileProviders() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatProviderUpgrades(t *testing.T) {
+	r := newTestRunner(&Config{})
+	got := r.formatProviderUpgrades([]ProviderUpgrade{
+		{Provider: "registry.terraform.io/hashicorp/aws", From: "5.1.0", To: "5.40.0", FromConstraints: "~> 5.0", ToConstraints: ">= 5.40"},
+		{Provider: "registry.terraform.io/hashicorp/null", From: "3.2.0"},
+		{Provider: "example.com/acme/internal", To: "1.0.0", ToConstraints: "1.0.0"},
+	})
+	for _, want := range []string{
+		"### 📦 Provider Upgrades",
+		"| `hashicorp/aws` | 5.1.0 → 5.40.0 | `~> 5.0` → `>= 5.40` | [v5.40.0](https://github.com/hashicorp/terraform-provider-aws/releases/tag/v5.40.0) |",
+		"| `hashicorp/null` | 3.2.0 (removed) |  |  |",
+		"| `example.com/acme/internal` | 1.0.0 (added) | `1.0.0` |  |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatProviderUpgrades() missing %q:\n%s", want, got)
+		}
+	}
+	if r.formatProviderUpgrades(nil) != "" {
+		t.Error("formatProviderUpgrades(nil) should be empty")
+	}
+}
+
+func TestProviderUpgradesAgainstBase(t *testing.T) {
+	h := newHarness(t)
+	lockfile := func(version, constraints string) {
+		content := strings.Replace(strings.Replace(testLockfile, "%s", version, 1), "%s", constraints, 1)
+		if err := os.WriteFile(filepath.Join(h.repo, "live/app", lockfileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.addUnit("live/app", "terraform-1.9/plan-changes.txt", 0)
+	lockfile("5.1.0", "~> 5.0")
+	h.commit("app")
+	base := h.git("rev-parse", "HEAD")
+	lockfile("5.40.0", "~> 5.0")
+
+	r := newTestRunner(&Config{DiffBase: base})
+	want := []ProviderUpgrade{{Provider: "registry.terraform.io/hashicorp/aws", From: "5.1.0", To: "5.40.0", FromConstraints: "~> 5.0", ToConstraints: "~> 5.0"}}
+	if got := r.providerUpgrades("live/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("providerUpgrades() = %v, want %v", got, want)
+	}
+	if got := r.providerUpgrades("live/missing"); got != nil {
+		t.Errorf("providerUpgrades() without lock file = %v", got)
+	}
+	r.config.DiffBase = ""
+	if got := r.providerUpgrades("live/app"); got != nil {
+		t.Errorf("providerUpgrades() without diff base = %v", got)
+	}
+}
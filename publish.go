@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage location receiving published run results
+type PublishTarget struct {
+	Scheme    string // s3, gs or az
+	Bucket    string // Bucket name (S3, GCS) or storage account (Azure)
+	Container string // Blob container (Azure only)
+	Prefix    string // Key prefix inside the bucket or container
+}
+
+// Run evidence published for external compliance systems and dashboards
+type PublishedRun struct {
+	Repository  string            `json:"repository"`
+	PullRequest int               `json:"pull_request,omitempty"`
+	RunID       string            `json:"run_id"`
+	RunLabel    string            `json:"run_label,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Commit      string            `json:"commit,omitempty"`
+	Actor       string            `json:"actor,omitempty"`
+	Command     string            `json:"command"`
+	Version     string            `json:"runner_version"`
+	Time        time.Time         `json:"time"`
+	Success     bool              `json:"success"`
+	Results     []PublishedResult `json:"results"`
+	GitHubAPI   *APIUsage         `json:"github_api,omitempty"` // GitHub API consumption of the run so far
+}
+
+// Result of a single folder in the published run
+type PublishedResult struct {
+	Folder         string                   `json:"folder"`
+	Success        bool                     `json:"success"`
+	Error          string                   `json:"error,omitempty"`
+	ToAdd          int                      `json:"to_add"`
+	ToChange       int                      `json:"to_change"`
+	ToDestroy      int                      `json:"to_destroy"`
+	ToReplace      int                      `json:"to_replace"`
+	NoChanges      bool                     `json:"no_changes"`
+	PlannedOutputs map[string]PlannedOutput `json:"planned_outputs,omitempty"`
+	Duration       float64                  `json:"duration_seconds"`
+	Retried        bool                     `json:"retried,omitempty"`
+	Output         string                   `json:"output"`
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Terragrunt Report — {{.Repository}} run {{.RunID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>Terragrunt Report</h1>
+<p><b>Repository:</b> {{.Repository}}{{if .PullRequest}} (PR #{{.PullRequest}}){{end}}<br>
+<b>Command:</b> {{.Command}}<br>
+<b>Commit:</b> {{.Commit}}<br>
+<b>Run:</b> {{.RunID}}{{if .RunLabel}} ({{.RunLabel}}){{end}} at {{.Time.Format "2006-01-02 15:04:05 UTC"}}<br>
+<b>Status:</b> {{if .Success}}✅ Success{{else}}❌ Failed{{end}}</p>
+<table>
+<tr><th>Folder</th><th>Status</th><th>Add</th><th>Change</th><th>Destroy</th><th>Replace</th></tr>
+{{range .Results}}<tr><td>{{.Folder}}</td><td>{{if .Success}}✅{{else}}❌{{end}}</td><td>{{.ToAdd}}</td><td>{{.ToChange}}</td><td>{{.ToDestroy}}</td><td>{{.ToReplace}}</td></tr>
+{{end}}</table>
+{{range .Results}}<h2>{{.Folder}}</h2>
+{{if .Error}}<p><b>Error:</b> {{.Error}}</p>
+{{end}}<pre>{{.Output}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// Parse a --publish-results destination (s3://bucket/prefix, gs://bucket/prefix
+// or az://account/container/prefix)
+func parsePublishTarget(dest string) (*PublishTarget, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publish-results destination: %w", err)
+	}
+	target := &PublishTarget{Scheme: u.Scheme, Bucket: u.Host}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "s3", "gs":
+	case "az":
+		target.Container, prefix, _ = strings.Cut(prefix, "/")
+		if target.Container == "" {
+			return nil, fmt.Errorf("invalid publish-results destination: az:// requires az://account/container[/prefix]")
+		}
+	default:
+		return nil, fmt.Errorf("invalid publish-results destination: unsupported scheme %q (use s3://, gs:// or az://)", u.Scheme)
+	}
+	if target.Bucket == "" {
+		return nil, fmt.Errorf("invalid publish-results destination: missing bucket in %s", dest)
+	}
+	target.Prefix = prefix
+	return target, nil
+}
+
+// Build the command uploading a local file to the key under the target prefix.
+// The cloud CLIs preinstalled on GitHub runners are used, authenticated the usual way
+// (e.g. aws-actions/configure-aws-credentials, google-github-actions/auth, azure/login).
+func (t *PublishTarget) uploadCommand(localPath, key string) *exec.Cmd {
+	key = path.Join(t.Prefix, key)
+	switch t.Scheme {
+	case "s3":
+		return exec.Command("aws", "s3", "cp", "--only-show-errors", localPath, fmt.Sprintf("s3://%s/%s", t.Bucket, key))
+	case "gs":
+		return exec.Command("gcloud", "storage", "cp", localPath, fmt.Sprintf("gs://%s/%s", t.Bucket, key))
+	default:
+		return exec.Command("az", "storage", "blob", "upload", "--only-show-errors", "--overwrite",
+			"--auth-mode", "login", "--account-name", t.Bucket, "--container-name", t.Container,
+			"--name", key, "--file", localPath)
+	}
+}
+
+// Build the published run from the results
+func (r *Runner) buildPublishedRun(results []ExecutionResult, now time.Time) PublishedRun {
+	run := PublishedRun{
+		Repository:  r.config.Repository,
+		PullRequest: r.config.PullRequest,
+		RunID:       getRunID(),
+		RunLabel:    r.config.RunLabel,
+		Commit:      os.Getenv("GITHUB_SHA"),
+		Actor:       os.Getenv("GITHUB_ACTOR"),
+		Command:     r.config.Command,
+		Version:     getBuildInfo().Version,
+		Time:        now.UTC(),
+		Success:     true,
+	}
+	if r.apiMetrics != nil {
+		usage := r.apiMetrics.snapshot()
+		run.GitHubAPI = &usage
+	}
+	for _, result := range r.folderResults(results) {
+		pr := PublishedResult{
+			Folder:         result.Folder,
+			Success:        result.Success,
+			PlannedOutputs: result.PlannedOutputs,
+			Duration:       result.Duration.Seconds(),
+			Retried:        result.Retried,
+			Output:         result.Output,
+		}
+		if result.Error != nil {
+			pr.Error = result.Error.Error()
+		}
+		if c := result.ResourceChanges; c != nil {
+			pr.ToAdd, pr.ToChange, pr.ToDestroy, pr.ToReplace, pr.NoChanges = c.ToAdd, c.ToChange, c.ToDestroy, c.ToReplace, c.NoChanges
+		}
+		run.Success = run.Success && result.Success
+		run.Results = append(run.Results, pr)
+	}
+	return run
+}
+
+// Get the plan file name passed to Terraform with -out (empty if none)
+func planOutFile(command string) string {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if name, ok := strings.CutPrefix(field, "-out="); ok {
+			return name
+		}
+		if field == "-out" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// Find the plan files with the given name in a folder, including Terragrunt's cache
+func findPlanFiles(folder, name string) []string {
+	var files []string
+	filepath.WalkDir(folder, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && d.Name() == filepath.Base(name) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files
+}
+
+// Upload the results JSON, HTML report and plan files to the publish-results destination
+func (r *Runner) publishResults(results []ExecutionResult) error {
+	target, err := parsePublishTarget(r.config.PublishResults)
+	if err != nil {
+		return err
+	}
+	run := r.buildPublishedRun(results, time.Now())
+	base := path.Join(strings.ReplaceAll(run.Repository, "/", "_"), run.RunID)
+
+	dir, err := os.MkdirTemp("", "terragrunt-runner-publish-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	uploads := map[string]string{} // key -> local path
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	resultsPath := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(resultsPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	uploads[path.Join(base, "results.json")] = resultsPath
+
+	reportPath := filepath.Join(dir, "report.html")
+	f, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	err = reportTemplate.Execute(f, run)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	uploads[path.Join(base, "report.html")] = reportPath
+
+	if planFile := planOutFile(r.config.Command + " " + r.config.TerragruntArgs); planFile != "" {
+		repoRoot, err := r.getRepoRoot()
+		if err != nil {
+			return err
+		}
+		if r.config.PlanEncryptKey != "" {
+			planFile += planEncryptedSuffix
+		}
+		for _, result := range run.Results {
+			absFolder := result.Folder
+			if !filepath.IsAbs(absFolder) {
+				absFolder = filepath.Join(repoRoot, absFolder)
+			}
+			for i, p := range findPlanFiles(absFolder, planFile) {
+				name := filepath.Base(p)
+				if i > 0 {
+					name = fmt.Sprintf("%d-%s", i, name)
+				}
+				uploads[path.Join(base, "plans", filepath.ToSlash(result.Folder), name)] = p
+			}
+		}
+	}
+
+	for key, localPath := range uploads {
+		cmd := target.uploadCommand(localPath, key)
+		var out bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := r.runCommand(cmd); err != nil {
+			return fmt.Errorf("failed to upload %s: %w: %s", key, err, strings.TrimSpace(out.String()))
+		}
+		r.logger.Debug("Published result file", "key", key, "scheme", target.Scheme)
+	}
+	r.logger.Info("Published run results", "destination", r.config.PublishResults, "files", len(uploads))
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePublishTarget(t *testing.T) {
+	tests := []struct {
+		dest    string
+		want    *PublishTarget
+		wantErr bool
+	}{
+		{"s3://evidence/terragrunt", &PublishTarget{Scheme: "s3", Bucket: "evidence", Prefix: "terragrunt"}, false},
+		{"gs://evidence", &PublishTarget{Scheme: "gs", Bucket: "evidence"}, false},
+		{"az://account/runs/terragrunt/prod", &PublishTarget{Scheme: "az", Bucket: "account", Container: "runs", Prefix: "terragrunt/prod"}, false},
+		{"az://account", nil, true},
+		{"ftp://evidence", nil, true},
+		{"s3:///prefix", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePublishTarget(tt.dest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePublishTarget(%q) error = %v, wantErr %v", tt.dest, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePublishTarget(%q) = %+v, want %+v", tt.dest, got, tt.want)
+		}
+	}
+}
+
+func TestPlanOutFile(t *testing.T) {
+	tests := map[string]string{
+		"plan -out=tfplan":                "tfplan",
+		"run --all plan -- -out plan.bin": "plan.bin",
+		"plan":                            "",
+	}
+	for command, expected := range tests {
+		if got := planOutFile(command); got != expected {
+			t.Errorf("planOutFile(%q) = %q, want %q", command, got, expected)
+		}
+	}
+}
+
+func TestBuildPublishedRun(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "987")
+	t.Setenv("GITHUB_SHA", "abc123")
+	r := newTestRunner(&Config{Repository: "org/infra", PullRequest: 7, Command: "plan"})
+
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2}, Output: "Plan: 2 to add, 0 to change, 0 to destroy."},
+		{Folder: "live/db", Success: false, Error: errors.New("exit status 1"), Output: "Error: <boom>"},
+	}
+	run := r.buildPublishedRun(results, time.Date(2025, 3, 1, 14, 5, 0, 0, time.UTC))
+
+	if run.Success || run.RunID != "987" || run.Commit != "abc123" || len(run.Results) != 2 {
+		t.Fatalf("buildPublishedRun() = %+v", run)
+	}
+	if run.Results[0].ToAdd != 2 || run.Results[1].Error != "exit status 1" {
+		t.Errorf("buildPublishedRun() results = %+v", run.Results)
+	}
+
+	var b strings.Builder
+	if err := reportTemplate.Execute(&b, run); err != nil {
+		t.Fatalf("reportTemplate.Execute() error = %v", err)
+	}
+	if !strings.Contains(b.String(), "Error: &lt;boom&gt;") {
+		t.Errorf("report does not escape output:\n%s", b.String())
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// How auto-detection uses the units Terragrunt itself would queue, selectable
+// with --terragrunt-detection
+const (
+	TerragruntDetectionOff    = "off"    // Path heuristics only
+	TerragruntDetectionReport = "report" // Report where Terragrunt disagrees with the heuristics
+	TerragruntDetectionUse    = "use"    // Run the units Terragrunt would queue
+)
+
+var terragruntDetectionModes = []string{TerragruntDetectionOff, TerragruntDetectionReport, TerragruntDetectionUse}
+
+// Build the arguments of terragrunt find queueing the units in the directories
+// of the changed files and the units reading them, e.g. through read_terragrunt_config
+func terragruntFindArgs(files []string, destroy bool) []string {
+	queue := "plan"
+	if destroy {
+		queue = "destroy"
+	}
+	args := []string{"find", "--json", "--queue-construct-as=" + queue}
+	for _, dir := range changedFileDirs(files) {
+		args = append(args, "--queue-include-dir="+dir)
+	}
+	for _, file := range files {
+		args = append(args, "--queue-include-units-reading="+file)
+	}
+	return args
+}
+
+// Build the arguments of output-module-groups, the equivalent of find in
+// Terragrunt releases before it existed
+func outputModuleGroupsArgs(files []string) []string {
+	args := []string{"output-module-groups"}
+	for _, dir := range changedFileDirs(files) {
+		args = append(args, "--terragrunt-include-dir="+dir)
+	}
+	for _, file := range files {
+		args = append(args, "--terragrunt-modules-that-include="+file)
+	}
+	return args
+}
+
+// Get the sorted directories of the changed files
+func changedFileDirs(files []string) []string {
+	var dirs []string
+	for _, file := range files {
+		if dir := filepath.Dir(file); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs
+}
+
+// Parse the units queued by Terragrunt from either the JSON array of
+// terragrunt find or the groups of output-module-groups, relative to the root
+func parseTerragruntQueue(output []byte, root string) ([]string, error) {
+	var found []struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+	}
+	var groups map[string][]string
+	var paths []string
+	if err := json.Unmarshal(output, &found); err == nil {
+		for _, entry := range found {
+			if entry.Type == "" || entry.Type == "unit" {
+				paths = append(paths, entry.Path)
+			}
+		}
+	} else if err := json.Unmarshal(output, &groups); err == nil {
+		for _, group := range groups {
+			paths = append(paths, group...)
+		}
+	} else {
+		return nil, fmt.Errorf("unexpected output: %s", truncateOutput(string(output), 200))
+	}
+
+	var units []string
+	for _, p := range paths {
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(root, p); err == nil {
+				p = rel
+			}
+		}
+		if p = filepath.Clean(p); !slices.Contains(units, p) {
+			units = append(units, p)
+		}
+	}
+	slices.Sort(units)
+	return units, nil
+}
+
+// Shorten command output quoted in an error
+func truncateOutput(output string, limit int) string {
+	output = strings.TrimSpace(output)
+	if len(output) > limit {
+		return output[:limit] + "..."
+	}
+	return output
+}
+
+// Ask Terragrunt which units it would queue for the changed files, falling
+// back to output-module-groups for releases without terragrunt find
+func (r *Runner) terragruntQueuedUnits(files []string) ([]string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	destroy := slices.Contains(strings.Fields(r.config.Command), "destroy")
+	var lastErr error
+	for _, args := range [][]string{terragruntFindArgs(files, destroy), outputModuleGroupsArgs(files)} {
+		cmd := exec.Command("terragrunt", args...)
+		cmd.Env = r.subprocessEnv()
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		if err := r.runCommand(cmd); err != nil {
+			lastErr = fmt.Errorf("terragrunt %s: %w", args[0], err)
+			continue
+		}
+		return parseTerragruntQueue(output.Bytes(), root)
+	}
+	return nil, lastErr
+}
+
+// Compare the folders found by the path heuristics with the units Terragrunt
+// would queue, returning the folders only the heuristics found and the units
+// they missed
+func diffDetection(detected, queued []string) (onlyDetected, onlyQueued []string) {
+	for _, folder := range detected {
+		if !slices.Contains(queued, folder) {
+			onlyDetected = append(onlyDetected, folder)
+		}
+	}
+	for _, unit := range queued {
+		if !slices.Contains(detected, unit) {
+			onlyQueued = append(onlyQueued, unit)
+		}
+	}
+	return onlyDetected, onlyQueued
+}
+
+// Reconcile the auto-detected folders with the units Terragrunt would queue,
+// warning about every discrepancy. In use mode Terragrunt's list is run; when
+// Terragrunt cannot be asked the detected folders are kept.
+func (r *Runner) reconcileDetection(detected []string) []string {
+	mode := r.config.TerragruntDetection
+	if mode != TerragruntDetectionReport && mode != TerragruntDetectionUse {
+		return detected
+	}
+	var files []string
+	for _, file := range r.config.ChangedFiles {
+		if matchingPattern(file, r.config.FilePatterns) != "" {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return detected
+	}
+	queued, err := r.terragruntQueuedUnits(files)
+	if err != nil {
+		fmt.Printf("::warning::Could not ask Terragrunt which units to run, keeping the auto-detected folders: %v\n", err)
+		return detected
+	}
+
+	onlyDetected, onlyQueued := diffDetection(detected, queued)
+	for _, folder := range onlyDetected {
+		fmt.Printf("::warning::Auto-detected folder %s is not queued by Terragrunt\n", folder)
+	}
+	for _, unit := range onlyQueued {
+		fmt.Printf("::warning::Terragrunt queues %s, which auto-detection missed\n", unit)
+	}
+	r.logger.Info("Reconciled auto-detection with Terragrunt", "mode", mode, "detected", len(detected), "queued", len(queued),
+		"only_detected", onlyDetected, "only_queued", onlyQueued)
+	if mode == TerragruntDetectionUse {
+		return queued
+	}
+	return detected
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTerragruntQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "terragrunt find",
+			output: `[{"type": "unit", "path": "live/vpc"}, {"type": "stack", "path": "live"}, {"type": "unit", "path": "live/app"}]`,
+			want:   []string{"live/app", "live/vpc"},
+		},
+		{
+			name:   "output-module-groups",
+			output: `{"Group 1": ["/repo/live/vpc"], "Group 2": ["/repo/live/app", "/repo/live/vpc"]}`,
+			want:   []string{"live/app", "live/vpc"},
+		},
+		{name: "empty queue", output: `[]`},
+		{name: "not json", output: "Error: unknown command", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTerragruntQueue([]byte(tt.output), "/repo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTerragruntQueue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTerragruntQueue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerragruntFindArgs(t *testing.T) {
+	got := strings.Join(terragruntFindArgs([]string{"live/app/terragrunt.hcl", "common/env.hcl", "live/app/main.tf"}, true), " ")
+	want := "find --json --queue-construct-as=destroy --queue-include-dir=common --queue-include-dir=live/app" +
+		" --queue-include-units-reading=live/app/terragrunt.hcl --queue-include-units-reading=common/env.hcl --queue-include-units-reading=live/app/main.tf"
+	if got != want {
+		t.Errorf("terragruntFindArgs() = %s, want %s", got, want)
+	}
+}
+
+func TestDiffDetection(t *testing.T) {
+	onlyDetected, onlyQueued := diffDetection([]string{"live/app", "live/db"}, []string{"live/app", "live/dns"})
+	if !reflect.DeepEqual(onlyDetected, []string{"live/db"}) || !reflect.DeepEqual(onlyQueued, []string{"live/dns"}) {
+		t.Errorf("diffDetection() = %v, %v", onlyDetected, onlyQueued)
+	}
+}
+
+// Fake terragrunt without find, answering output-module-groups
+const fakeModuleGroupsScript = `#!/bin/sh
+if [ "$1" = find ]; then
+  echo "unknown command find" >&2
+  exit 1
+fi
+echo "{\"Group 1\": [\"$PWD/live/app\", \"$PWD/live/dns\"]}"
+`
+
+func TestReconcileDetection(t *testing.T) {
+	scripts := t.TempDir()
+	if err := os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(fakeModuleGroupsScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Chdir(t.TempDir())
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{mode: TerragruntDetectionOff, want: []string{"live/app"}},
+		{mode: TerragruntDetectionReport, want: []string{"live/app"}},
+		{mode: TerragruntDetectionUse, want: []string{"live/app", "live/dns"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			r := newTestRunner(&Config{
+				TerragruntDetection: tt.mode,
+				ChangedFiles:        []string{"live/app/terragrunt.hcl", "common/dns.hcl", "README.md"},
+				FilePatterns:        []string{"*.hcl"},
+			})
+			if got := r.reconcileDetection([]string{"live/app"}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcileDetection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Unit of the run --all queue with the queued units it depends on
+type QueuedUnit struct {
+	Path         string   // Unit path relative to the repository root
+	Dependencies []string // Queued units that run before this one
+	Group        int      // Position of the unit's group in the run order, from 1
+}
+
+// Build the arguments of terragrunt find listing the units a run --all would
+// queue from its directory, in dependency order with their dependencies
+func executionPlanArgs(command string, includeDirs []string) []string {
+	queue := "plan"
+	if slices.Contains(strings.Fields(command), "destroy") {
+		queue = "destroy"
+	}
+	args := []string{"find", "--json", "--dag", "--dependencies", "--queue-construct-as=" + queue}
+	for _, dir := range includeDirs {
+		args = append(args, "--queue-include-dir="+dir)
+	}
+	return append(args, "--queue-include-external")
+}
+
+// Parse the output of terragrunt find --dag into the queued units, relative to
+// the repository root, grouped so that a unit's dependencies are in earlier groups
+func parseExecutionPlan(output []byte, runDir, prefix string) ([]QueuedUnit, error) {
+	var found []struct {
+		Type         string   `json:"type"`
+		Path         string   `json:"path"`
+		Dependencies []string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(output, &found); err != nil {
+		return nil, fmt.Errorf("unexpected output: %s", truncateOutput(string(output), 200))
+	}
+
+	relPath := func(p string) string {
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(runDir, p); err == nil {
+				p = rel
+			}
+		}
+		return filepath.Join(prefix, p)
+	}
+	var units []QueuedUnit
+	index := make(map[string]int)
+	for _, entry := range found {
+		if entry.Type != "" && entry.Type != "unit" {
+			continue
+		}
+		unit := QueuedUnit{Path: relPath(entry.Path)}
+		for _, dep := range entry.Dependencies {
+			unit.Dependencies = append(unit.Dependencies, relPath(dep))
+		}
+		index[unit.Path] = len(units)
+		units = append(units, unit)
+	}
+
+	// Dependencies outside the queue are not run, so they do not order it
+	var group func(i int, visiting map[int]bool) int
+	group = func(i int, visiting map[int]bool) int {
+		if units[i].Group > 0 || visiting[i] {
+			return units[i].Group
+		}
+		visiting[i] = true
+		g := 1
+		var queued []string
+		for _, dep := range units[i].Dependencies {
+			if j, ok := index[dep]; ok {
+				g = max(g, group(j, visiting)+1)
+				queued = append(queued, dep)
+			}
+		}
+		units[i].Dependencies, units[i].Group = queued, g
+		return g
+	}
+	for i := range units {
+		group(i, map[int]bool{})
+	}
+	slices.SortStableFunc(units, func(a, b QueuedUnit) int { return a.Group - b.Group })
+	return units, nil
+}
+
+// Ask Terragrunt which units the run --all queues and in which order, so
+// reviewers can check the inclusion logic; failures only skip the section
+func (r *Runner) captureExecutionPlan(runDir string, includeDirs []string) []QueuedUnit {
+	cmd := exec.Command("terragrunt", executionPlanArgs(r.config.Command, includeDirs)...)
+	cmd.Dir = runDir
+	cmd.Env = r.subprocessEnv()
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if err := r.runCommand(cmd); err != nil {
+		r.logger.Warn("Could not ask Terragrunt for the execution plan", "error", err)
+		return nil
+	}
+	units, err := parseExecutionPlan(output.Bytes(), runDir, r.config.RunAllRootDir)
+	if err != nil {
+		r.logger.Warn("Could not parse the execution plan", "error", err)
+		return nil
+	}
+	r.logger.Info("Captured execution plan", "units", len(units))
+	return units
+}
+
+// Format the collapsed execution plan section of the summary: the queued units
+// by group, in run order, with their dependencies
+func formatExecutionPlan(units []QueuedUnit) string {
+	if len(units) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n<details><summary>📋 Execution plan: %d units in %d groups</summary>\n\n", len(units), units[len(units)-1].Group)
+	b.WriteString("Groups run in order; the units of a group run in parallel.\n\n| Group | Unit | After |\n|-------|------|-------|\n")
+	for _, unit := range units {
+		after := "—"
+		if len(unit.Dependencies) > 0 {
+			after = "`" + strings.Join(unit.Dependencies, "`, `") + "`"
+		}
+		fmt.Fprintf(&b, "| %d | `%s` | %s |\n", unit.Group, unit.Path, after)
+	}
+	b.WriteString("\n</details>\n")
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecutionPlanArgs(t *testing.T) {
+	expected := []string{"find", "--json", "--dag", "--dependencies", "--queue-construct-as=plan", "--queue-include-dir=app", "--queue-include-external"}
+	if got := executionPlanArgs("run --all plan", []string{"app"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("executionPlanArgs() = %v, want %v", got, expected)
+	}
+	if got := executionPlanArgs("run --all destroy", nil); got[4] != "--queue-construct-as=destroy" {
+		t.Errorf("executionPlanArgs() for destroy = %v, want a destroy queue", got)
+	}
+}
+
+func TestParseExecutionPlan(t *testing.T) {
+	output := `[
+		{"type": "unit", "path": "vpc", "dependencies": []},
+		{"type": "unit", "path": "/repo/live/db", "dependencies": ["/repo/live/vpc", "/repo/shared/dns"]},
+		{"type": "stack", "path": "stack"},
+		{"type": "unit", "path": "cache", "dependencies": ["vpc"]},
+		{"type": "unit", "path": "app", "dependencies": ["db", "cache"]}
+	]`
+	units, err := parseExecutionPlan([]byte(output), "/repo/live", "live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []QueuedUnit{
+		{Path: "live/vpc", Group: 1},
+		{Path: "live/db", Dependencies: []string{"live/vpc"}, Group: 2},
+		{Path: "live/cache", Dependencies: []string{"live/vpc"}, Group: 2},
+		{Path: "live/app", Dependencies: []string{"live/db", "live/cache"}, Group: 3},
+	}
+	if !reflect.DeepEqual(units, expected) {
+		t.Errorf("parseExecutionPlan() = %+v, want %+v", units, expected)
+	}
+
+	if _, err := parseExecutionPlan([]byte("Error: unknown flag --dag"), "/repo/live", "live"); err == nil {
+		t.Error("parseExecutionPlan() of an error message = nil error, want an error")
+	}
+}
+
+func TestFormatExecutionPlan(t *testing.T) {
+	if got := formatExecutionPlan(nil); got != "" {
+		t.Errorf("formatExecutionPlan(nil) = %q, want empty", got)
+	}
+	got := formatExecutionPlan([]QueuedUnit{
+		{Path: "live/vpc", Group: 1},
+		{Path: "live/app", Dependencies: []string{"live/vpc"}, Group: 2},
+	})
+	for _, want := range []string{"📋 Execution plan: 2 units in 2 groups", "| 1 | `live/vpc` | — |", "| 2 | `live/app` | `live/vpc` |", "</details>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatExecutionPlan() = %q, want it to contain %q", got, want)
+		}
+	}
+}
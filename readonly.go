@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Read-only credentials a plan runs with
+type PlanCredentials struct {
+	AWSRole           string // IAM role assumed by Terragrunt (TG_IAM_ASSUME_ROLE)
+	GCPServiceAccount string // Service account impersonated by the Google provider
+}
+
+// Check whether the command only plans
+func isPlanCommand(command string) bool {
+	if isApplyCommand(command) {
+		return false
+	}
+	for _, field := range strings.Fields(command) {
+		if field == "plan" {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve the value for a folder from "[glob=]value" entries. Like CODEOWNERS,
+// the last matching entry wins; entries without a glob match every folder.
+func resolveFolderMapping(entries []string, folder string) string {
+	value := ""
+	for _, entry := range entries {
+		glob, v, ok := strings.Cut(entry, "=")
+		if !ok {
+			value = entry
+			continue
+		}
+		if folderGlobMatches(glob, folder) {
+			value = v
+		}
+	}
+	return value
+}
+
+// Get the read-only plan credentials configured for a folder relative to the repo root
+func (r *Runner) planCredentials(folder string) PlanCredentials {
+	return PlanCredentials{
+		AWSRole:           resolveFolderMapping(r.config.PlanAWSRoles, folder),
+		GCPServiceAccount: resolveFolderMapping(r.config.PlanGCPServiceAccounts, folder),
+	}
+}
+
+// Build the environment making a plan of the folders (one Terragrunt invocation)
+// use the read-only credentials. With --enforce-readonly-plan, every folder must
+// have credentials and the folders must share them.
+func (r *Runner) readonlyPlanEnv(folders []string) ([]string, error) {
+	if !isPlanCommand(r.config.Command) || len(folders) == 0 {
+		return nil, nil
+	}
+	root := r.workRoot
+	if root == "" {
+		var err error
+		if root, err = r.getRepoRoot(); err != nil {
+			return nil, err
+		}
+	}
+
+	var creds PlanCredentials
+	for i, folder := range folders {
+		if filepath.IsAbs(folder) {
+			if rel, err := filepath.Rel(root, folder); err == nil {
+				folder = rel
+			}
+		}
+		c := r.planCredentials(filepath.ToSlash(filepath.Clean(folder)))
+		if r.config.EnforceReadonlyPlan {
+			if c.AWSRole == "" && c.GCPServiceAccount == "" {
+				return nil, fmt.Errorf("read-only plan enforced but no plan role or service account is configured for %s", folder)
+			}
+		}
+		if i == 0 {
+			creds = c
+		} else if c != creds {
+			if r.config.EnforceReadonlyPlan {
+				return nil, fmt.Errorf("read-only plan enforced but %s needs different plan credentials than %s; plan them separately", folder, folders[0])
+			}
+			r.logger.Warn("Folders planned together have different plan credentials, using the first folder's", "folder", folder, "first", folders[0])
+		}
+	}
+
+	var env []string
+	if creds.AWSRole != "" {
+		env = append(env, "TG_IAM_ASSUME_ROLE="+creds.AWSRole, "TG_IAM_ASSUME_ROLE_SESSION_NAME=terragrunt-runner-plan-"+getRunID())
+	}
+	if creds.GCPServiceAccount != "" {
+		env = append(env, "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT="+creds.GCPServiceAccount)
+	}
+	if len(env) > 0 {
+		r.logger.Info("Planning with read-only credentials", "folders", folders, "aws_role", creds.AWSRole, "gcp_service_account", creds.GCPServiceAccount)
+	}
+	return env, nil
+}
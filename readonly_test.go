@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsPlanCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"plan", true},
+		{"run --all plan", true},
+		{"apply", false},
+		{"run --all apply", false},
+		{"validate", false},
+		{"plan-all", false},
+	}
+	for _, tt := range tests {
+		if got := isPlanCommand(tt.command); got != tt.want {
+			t.Errorf("isPlanCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestResolveFolderMapping(t *testing.T) {
+	entries := []string{
+		"arn:aws:iam::111:role/plan",
+		"live/prod/**=arn:aws:iam::222:role/plan",
+		"live/prod/legacy=arn:aws:iam::333:role/plan",
+	}
+	tests := []struct {
+		folder string
+		want   string
+	}{
+		{"live/dev/app", "arn:aws:iam::111:role/plan"},
+		{"live/prod/app", "arn:aws:iam::222:role/plan"},
+		{"live/prod/legacy", "arn:aws:iam::333:role/plan"},
+	}
+	for _, tt := range tests {
+		if got := resolveFolderMapping(entries, tt.folder); got != tt.want {
+			t.Errorf("resolveFolderMapping(%q) = %q, want %q", tt.folder, got, tt.want)
+		}
+	}
+	if got := resolveFolderMapping([]string{"live/prod/**=x"}, "live/dev/app"); got != "" {
+		t.Errorf("resolveFolderMapping() without match = %q, want empty", got)
+	}
+}
+
+func TestReadonlyPlanEnv(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	root := t.TempDir()
+
+	tests := []struct {
+		name    string
+		config  Config
+		folders []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "no credentials configured",
+			config:  Config{Command: "plan"},
+			folders: []string{"live/app"},
+		},
+		{
+			name:    "apply is never rewritten",
+			config:  Config{Command: "apply", PlanAWSRoles: []string{"arn:aws:iam::111:role/plan"}},
+			folders: []string{"live/app"},
+		},
+		{
+			name:    "aws role for absolute folder",
+			config:  Config{Command: "plan", PlanAWSRoles: []string{"live/**=arn:aws:iam::111:role/plan"}},
+			folders: []string{filepath.Join(root, "live/app")},
+			want:    []string{"TG_IAM_ASSUME_ROLE=arn:aws:iam::111:role/plan", "TG_IAM_ASSUME_ROLE_SESSION_NAME=terragrunt-runner-plan-42"},
+		},
+		{
+			name:    "gcp service account",
+			config:  Config{Command: "run --all plan", PlanGCPServiceAccounts: []string{"plan@proj.iam.gserviceaccount.com"}},
+			folders: []string{"live/a", "live/b"},
+			want:    []string{"GOOGLE_IMPERSONATE_SERVICE_ACCOUNT=plan@proj.iam.gserviceaccount.com"},
+		},
+		{
+			name:    "enforced without credentials",
+			config:  Config{Command: "plan", EnforceReadonlyPlan: true, PlanAWSRoles: []string{"live/prod/**=arn"}},
+			folders: []string{"live/dev/app"},
+			wantErr: "no plan role or service account is configured for live/dev/app",
+		},
+		{
+			name:    "enforced with mixed credentials",
+			config:  Config{Command: "run --all plan", EnforceReadonlyPlan: true, PlanAWSRoles: []string{"live/dev/**=arn-dev", "live/prod/**=arn-prod"}},
+			folders: []string{"live/dev/app", "live/prod/app"},
+			wantErr: "needs different plan credentials",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&tt.config)
+			r.workRoot = root
+			got, err := r.readonlyPlanEnv(tt.folders)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readonlyPlanEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readonlyPlanEnv() error = %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("readonlyPlanEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Check whether a comment is replaced by a refresh run: a comment of one of
+// the refreshed folders with the same label. The summary and the comments of
+// the other folders stay as they are.
+func (r *Runner) refreshReplaces(body string) bool {
+	fields, ok := parseCommentMarker(body)
+	return ok && fields["label"] == r.config.RunLabel && slices.Contains(r.config.Folders, fields["folder"])
+}
+
+// Validate a refresh run, which reruns given folders with a plan command
+func (r *Runner) validateRefresh() error {
+	if !r.config.Refresh {
+		return nil
+	}
+	if !isPlanCommand(r.config.Command) || isRunAllCommand(r.config.Command) {
+		return fmt.Errorf("refresh requires a plan command on folders (not run --all)")
+	}
+	if len(r.config.Folders) == 0 {
+		return fmt.Errorf("refresh requires folders")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRefreshReplaces(t *testing.T) {
+	r := newTestRunner(&Config{Folders: []string{"live/dev/app"}, RunLabel: "dev", Refresh: true})
+
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"refreshed folder", commentMarker("live/dev/app", "dev") + "\n## plan", true},
+		{"other folder", commentMarker("live/dev/db", "dev") + "\n## plan", false},
+		{"summary", commentMarker(summaryMarkerFolder, "dev") + "\n## Summary", false},
+		{"other label", commentMarker("live/dev/app", "prod") + "\n## plan", false},
+		{"legacy comment", "## Terragrunt Plan Output", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.refreshReplaces(tt.body); got != tt.want {
+				t.Errorf("refreshReplaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRefresh(t *testing.T) {
+	tests := []struct {
+		command string
+		folders []string
+		wantErr string
+	}{
+		{"plan", []string{"live/dev/app"}, ""},
+		{"apply", []string{"live/dev/app"}, "requires a plan command"},
+		{"run --all plan", []string{"live/dev/app"}, "requires a plan command"},
+		{"plan", nil, "requires folders"},
+	}
+
+	for _, tt := range tests {
+		err := newTestRunner(&Config{Command: tt.command, Folders: tt.folders, Refresh: true}).validateRefresh()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("validateRefresh() %s error = %v", tt.command, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("validateRefresh() %s %v error = %v, want %q", tt.command, tt.folders, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeleteOldCommentsRefresh(t *testing.T) {
+	var deleted []string
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/issues/1/comments":
+			w.Write([]byte(`[
+				{"id": 11, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=1 -->\napp", "user": {"login": "github-actions[bot]"}},
+				{"id": 12, "body": "<!-- terragrunt-runner:folder=live%2Fdb;run=1 -->\ndb", "user": {"login": "github-actions[bot]"}},
+				{"id": 13, "body": "<!-- terragrunt-runner:folder=_summary;run=1 -->\nsummary", "user": {"login": "github-actions[bot]"}}
+			]`))
+		case req.Method == http.MethodDelete:
+			deleted = append(deleted, req.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, Refresh: true})
+	if err := r.deleteOldComments(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deleted, ",") != "/repos/owner/repo/issues/comments/11" {
+		t.Errorf("deleted = %v, want only the live/app comment", deleted)
+	}
+}
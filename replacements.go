@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Resource comment line opening a replaced resource block in a plan
+var reReplacementLine = regexp.MustCompile(`^(\s*)# \S+ (?:must be replaced|is tainted, so must be replaced|will be replaced, as requested)`)
+
+// Split the replaced resource blocks out of a plan, returning the blocks and the
+// rest of the plan. A block runs from its "# ... must be replaced" line to the
+// closing brace of the resource, or up to the next resource when unbalanced.
+func splitReplacements(output string) ([]string, string) {
+	lines := strings.Split(output, "\n")
+	var blocks, rest []string
+	for i := 0; i < len(lines); i++ {
+		m := reReplacementLine.FindStringSubmatch(lines[i])
+		if m == nil {
+			rest = append(rest, lines[i])
+			continue
+		}
+		closing := m[1] + "  }"
+		end := i
+		for j := i + 1; j < len(lines); j++ {
+			if rePlanResourceLine.MatchString(lines[j]) || strings.HasPrefix(strings.TrimSpace(lines[j]), "Plan:") {
+				break
+			}
+			end = j
+			if strings.TrimRight(lines[j], " ") == closing {
+				break
+			}
+		}
+		blocks = append(blocks, strings.TrimRight(strings.Join(lines[i:end+1], "\n"), "\n "))
+		i = end
+		// Drop the blank line separating the block from the next resource
+		if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+			i++
+		}
+	}
+	return blocks, strings.Join(rest, "\n")
+}
+
+// Count the replaced resources of a plan
+func countReplacements(output string) int {
+	n := 0
+	for line := range strings.SplitSeq(stripAnsiCodes(output), "\n") {
+		if reReplacementLine.MatchString(line) {
+			n++
+		}
+	}
+	return n
+}
+
+// Move the change marker of a plan line to the first column so diff syntax
+// highlighting colors it (~ becomes !, highlighted as a modification)
+func diffHighlight(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(line) - len(trimmed)
+	if indent == 0 || len(trimmed) < 2 || trimmed[1] != ' ' {
+		return line
+	}
+	switch trimmed[0] {
+	case '+', '-':
+		return trimmed[:1] + line[1:indent] + " " + trimmed[1:]
+	case '~':
+		return "!" + line[1:indent] + " " + trimmed[1:]
+	}
+	return line
+}
+
+// Format the replaced resources highlighted at the top of a comment
+func (r *Runner) formatReplacements(blocks []string) string {
+	var lines []string
+	for _, block := range blocks {
+		for line := range strings.SplitSeq(block, "\n") {
+			lines = append(lines, diffHighlight(line))
+		}
+		lines = append(lines, "")
+	}
+	intro := r.msg("replacements.intro_other", "count", len(blocks))
+	if len(blocks) == 1 {
+		intro = r.msg("replacements.intro_one")
+	}
+	return fmt.Sprintf("### %s\n\n%s\n\n```diff\n%s\n```\n",
+		r.msg("replacements.title"), intro, strings.TrimRight(strings.Join(lines, "\n"), "\n"))
+}
+
+// Pull the replaced resources of a successful result out of its comment content,
+// returning the highlighted section and the remaining content
+func (r *Runner) highlightReplacements(result ExecutionResult, content string) (string, string) {
+	if !result.Success {
+		return "", content
+	}
+	blocks, rest := splitReplacements(content)
+	if len(blocks) == 0 {
+		return "", content
+	}
+	section := r.formatReplacements(blocks)
+	// Keep huge replacements in place so the plan can still be split across comments
+	if len(section) > maxCommentSize/2 {
+		r.logger.Warn("Replacements too large to highlight, leaving them in the plan", "folder", result.Folder, "size", len(section))
+		return "", content
+	}
+	return section, rest
+}
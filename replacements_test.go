@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const replacementPlan = `Terraform will perform the following actions:
+
+  # aws_instance.web must be replaced
+-/+ resource "aws_instance" "web" {
+      ~ ami  = "ami-1" -> "ami-2" # forces replacement
+      ~ id   = "i-1" -> (known after apply)
+        tags = {}
+    }
+
+  # aws_s3_bucket.logs will be updated in-place
+  ~ resource "aws_s3_bucket" "logs" {
+      + versioning = true
+    }
+
+  # aws_db_instance.main is tainted, so must be replaced
++/- resource "aws_db_instance" "main" {
+        name = "main"
+    }
+
+Plan: 2 to add, 1 to change, 2 to destroy.`
+
+func TestSplitReplacements(t *testing.T) {
+	blocks, rest := splitReplacements(replacementPlan)
+	if len(blocks) != 2 {
+		t.Fatalf("splitReplacements() found %d blocks, want 2: %q", len(blocks), blocks)
+	}
+	if !strings.HasPrefix(blocks[0], "  # aws_instance.web must be replaced") || !strings.HasSuffix(blocks[0], "    }") {
+		t.Errorf("splitReplacements() first block = %q", blocks[0])
+	}
+	if !strings.HasPrefix(blocks[1], "  # aws_db_instance.main is tainted") {
+		t.Errorf("splitReplacements() second block = %q", blocks[1])
+	}
+	for _, moved := range []string{"aws_instance.web", "aws_db_instance.main", "forces replacement"} {
+		if strings.Contains(rest, moved) {
+			t.Errorf("splitReplacements() rest still contains %q:\n%s", moved, rest)
+		}
+	}
+	for _, kept := range []string{"aws_s3_bucket.logs will be updated in-place", "Plan: 2 to add", "following actions"} {
+		if !strings.Contains(rest, kept) {
+			t.Errorf("splitReplacements() rest lost %q:\n%s", kept, rest)
+		}
+	}
+
+	if blocks, rest := splitReplacements("No changes."); blocks != nil || rest != "No changes." {
+		t.Errorf("splitReplacements() without replacements = %q, %q", blocks, rest)
+	}
+}
+
+func TestDiffHighlight(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`      ~ ami = "a" -> "b"`, `!       ami = "a" -> "b"`},
+		{`      + versioning = true`, `+       versioning = true`},
+		{`      - tags = {}`, `-       tags = {}`},
+		{`-/+ resource "aws_instance" "web" {`, `-/+ resource "aws_instance" "web" {`},
+		{`        name = "main"`, `        name = "main"`},
+		{`  # aws_instance.web must be replaced`, `  # aws_instance.web must be replaced`},
+	}
+	for _, tt := range tests {
+		if got := diffHighlight(tt.line); got != tt.want {
+			t.Errorf("diffHighlight(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightReplacements(t *testing.T) {
+	r := newTestRunner(&Config{Command: "plan"})
+
+	section, rest := r.highlightReplacements(ExecutionResult{Folder: "live/app", Success: true}, replacementPlan)
+	if !strings.HasPrefix(section, "### ⚠️ Replacements\n\n2 resources will be replaced:\n\n```diff\n") {
+		t.Errorf("highlightReplacements() section = %q", section)
+	}
+	if strings.Contains(rest, "must be replaced") {
+		t.Errorf("highlightReplacements() left replacements in the content:\n%s", rest)
+	}
+
+	section, rest = r.highlightReplacements(ExecutionResult{Folder: "live/app"}, replacementPlan)
+	if section != "" || rest != replacementPlan {
+		t.Errorf("highlightReplacements() on failure = %q, want content unchanged", section)
+	}
+}
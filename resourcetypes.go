@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const maxTopResourceTypes = 10 // Number of resource types shown in the summary
+
+// Planned resource change line, capturing the resource address and the action.
+// Plan text changes across Terraform and OpenTofu versions, so it is only read
+// when the plan was not saved with -out.
+var rePlannedResourceChange = regexp.MustCompile(`^\s*# (\S+) (will be created|will be updated in-place|will be destroyed|must be replaced|is tainted, so must be replaced)`)
+
+// Count of planned changes for a resource type
+type ResourceTypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// Extract the resource type from a resource address (e.g. module.vpc.aws_subnet.private["a"])
+func resourceTypeFromAddress(address string) string {
+	// Drop instance keys, which may contain dots
+	for strings.Contains(address, "[") {
+		start := strings.Index(address, "[")
+		end := strings.Index(address[start:], "]")
+		if end < 0 {
+			break
+		}
+		address = address[:start] + address[start+end+1:]
+	}
+	parts := strings.Split(address, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// Count planned resource changes by resource type in a plan output
+// (fallback of plans not saved with -out)
+func parseResourceTypeChanges(output string) map[string]int {
+	counts := make(map[string]int)
+	for line := range strings.SplitSeq(stripAnsiCodes(output), "\n") {
+		m := rePlannedResourceChange.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if strings.HasPrefix(m[1], "data.") || strings.Contains(m[1], ".data.") {
+			continue
+		}
+		if t := resourceTypeFromAddress(m[1]); t != "" {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// Count planned managed resource changes by resource type in the JSON of
+// terraform show -json
+func parsePlanJSONResourceTypes(data []byte) (map[string]int, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Mode   string `json:"mode"`
+			Type   string `json:"type"`
+			Change struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("invalid plan JSON: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode == "managed" && slices.ContainsFunc(rc.Change.Actions, func(a string) bool { return a != "no-op" && a != "read" }) {
+			counts[rc.Type]++
+		}
+	}
+	return counts, nil
+}
+
+// Count the planned changes by resource type of a folder from show -json of
+// its saved plan; nil when the plan cannot be read, falling back to the text
+func (r *Runner) savedPlanResourceTypes(folder, absFolder, planFile string) map[string]int {
+	cmd := exec.Command("terragrunt", "show", "-json", planFile)
+	cmd.Dir = absFolder
+	cmd.Env = r.subprocessEnv(r.folderEnv(folder)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := r.runCommand(cmd); err != nil {
+		r.logger.Warn("Failed to read the saved plan, counting resource types from the plan text", "folder", folder, "error", err, "stderr", strings.TrimSpace(stripAnsiCodes(stderr.String())))
+		return nil
+	}
+	// Terragrunt may log before the JSON document
+	data := stdout.Bytes()
+	if i := bytes.IndexByte(data, '{'); i > 0 {
+		data = data[i:]
+	}
+	counts, err := parsePlanJSONResourceTypes(data)
+	if err != nil {
+		r.logger.Warn("Failed to parse the saved plan, counting resource types from the plan text", "folder", folder, "error", err)
+		return nil
+	}
+	return counts
+}
+
+// Get the planned changes by resource type of a result: from its saved plan
+// JSON when available, else from its plan text
+func resultResourceTypes(result ExecutionResult) map[string]int {
+	if result.ResourceTypes != nil {
+		return result.ResourceTypes
+	}
+	output := result.FullOutput
+	if output == "" {
+		output = result.Output
+	}
+	return parseResourceTypeChanges(output)
+}
+
+// Aggregate resource type changes across results, sorted by count (descending)
+func aggregateResourceTypes(results []ExecutionResult) []ResourceTypeCount {
+	totals := make(map[string]int)
+	for _, r := range results {
+		for t, n := range resultResourceTypes(r) {
+			totals[t] += n
+		}
+	}
+
+	counts := make([]ResourceTypeCount, 0, len(totals))
+	for t, n := range totals {
+		counts = append(counts, ResourceTypeCount{Type: t, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Type < counts[j].Type
+	})
+	return counts
+}
+
+// Format the top changed resource types for the summary comment
+func formatTopResourceTypes(counts []ResourceTypeCount) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	var parts []string
+	for i, c := range counts {
+		if i == maxTopResourceTypes {
+			parts = append(parts, fmt.Sprintf("and %d more", len(counts)-maxTopResourceTypes))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("`%s` ×%d", c.Type, c.Count))
+	}
+	return "\n**Top changed resource types:** " + strings.Join(parts, ", ") + "\n"
+}
+
+// Write resource type changes to the resource-type-changes action output
+func writeResourceTypeOutput(counts []ResourceTypeCount) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	return writeActionOutput("resource-type-changes", string(data))
+}
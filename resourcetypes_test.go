@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResourceTypeFromAddress(t *testing.T) {
+	tests := map[string]string{
+		"aws_s3_bucket.logs":                            "aws_s3_bucket",
+		"module.vpc.aws_subnet.private[\"eu-west-1a\"]": "aws_subnet",
+		"module.iam[0].aws_iam_policy.this":             "aws_iam_policy",
+		"aws_route53_record.www[\"a.example.com\"]":     "aws_route53_record",
+		"invalid": "",
+	}
+	for address, expected := range tests {
+		if got := resourceTypeFromAddress(address); got != expected {
+			t.Errorf("resourceTypeFromAddress(%q) = %q, want %q", address, got, expected)
+		}
+	}
+}
+
+func TestAggregateResourceTypes(t *testing.T) {
+	results := []ExecutionResult{
+		{FullOutput: `  # aws_iam_policy.a will be created
+  # aws_iam_policy.b will be updated in-place
+  # data.aws_iam_policy_document.x will be read during apply
+  # aws_s3_bucket.logs must be replaced`},
+		{Output: `  # module.x.aws_iam_policy.c will be destroyed
+  # aws_instance.web has moved to aws_instance.app`},
+	}
+
+	expected := []ResourceTypeCount{{Type: "aws_iam_policy", Count: 3}, {Type: "aws_s3_bucket", Count: 1}}
+	if got := aggregateResourceTypes(results); !reflect.DeepEqual(got, expected) {
+		t.Errorf("aggregateResourceTypes() = %+v, want %+v", got, expected)
+	}
+	if got := formatTopResourceTypes(expected); got != "\n**Top changed resource types:** `aws_iam_policy` ×3, `aws_s3_bucket` ×1\n" {
+		t.Errorf("formatTopResourceTypes() = %q", got)
+	}
+}
+
+const testPlanJSON = `{"format_version": "1.2", "resource_changes": [
+	{"address": "aws_iam_policy.a", "mode": "managed", "type": "aws_iam_policy", "change": {"actions": ["create"]}},
+	{"address": "aws_iam_policy.b", "mode": "managed", "type": "aws_iam_policy", "change": {"actions": ["no-op"]}},
+	{"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "change": {"actions": ["delete", "create"]}},
+	{"address": "data.aws_iam_policy_document.x", "mode": "data", "type": "aws_iam_policy_document", "change": {"actions": ["read"]}}
+]}`
+
+func TestParsePlanJSONResourceTypes(t *testing.T) {
+	got, err := parsePlanJSONResourceTypes([]byte(testPlanJSON))
+	if want := map[string]int{"aws_iam_policy": 1, "aws_s3_bucket": 1}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePlanJSONResourceTypes() = %v, %v, want %v", got, err, want)
+	}
+	if _, err := parsePlanJSONResourceTypes([]byte("Plan: 1 to add")); err == nil {
+		t.Error("parsePlanJSONResourceTypes() of plan text = nil error, want an error")
+	}
+
+	// The saved plan wins over the plan text
+	result := ExecutionResult{ResourceTypes: got, FullOutput: "  # aws_instance.web will be created\n"}
+	if types := resultResourceTypes(result); !reflect.DeepEqual(types, got) {
+		t.Errorf("resultResourceTypes() = %v, want the saved plan counts", types)
+	}
+}
+
+func TestSavedPlanResourceTypes(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$*\" = \"show -json tfplan\" ] || exit 1\necho 'INFO terragrunt log line'\ncat <<'EOF'\n" + testPlanJSON + "\nEOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	r := newTestRunner(&Config{Command: "plan"})
+	if got := r.savedPlanResourceTypes("live/app", dir, "tfplan"); !reflect.DeepEqual(got, map[string]int{"aws_iam_policy": 1, "aws_s3_bucket": 1}) {
+		t.Errorf("savedPlanResourceTypes() = %v", got)
+	}
+	if got := r.savedPlanResourceTypes("live/app", dir, "missing"); got != nil {
+		t.Errorf("savedPlanResourceTypes() of an unreadable plan = %v, want nil to fall back to the text", got)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Hidden block of the summary comment fingerprinting the folder results, so a
+// rerun can tell whether anything but the run metadata changed
+const resultsDigestPrefix = "terragrunt-runner-results:v1 "
+
+var reResultsDigest = regexp.MustCompile(`<!-- terragrunt-runner-results:v1 ([0-9a-f]+) -->`)
+
+// Summary comment of the previous run on the target
+type PriorSummary struct {
+	ID     int64  // Comment ID
+	RunID  string // Run that posted it
+	Digest string // Fingerprint of its folder results (empty if it has none)
+}
+
+// Fingerprint the results of a run. Timing and run identifiers are left out:
+// only what the folder comments show counts.
+func resultsDigest(command string, results []ExecutionResult) string {
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		c := result.ResourceChanges
+		if c == nil {
+			c = &ResourceChanges{}
+		}
+		lines = append(lines, fmt.Sprintf("%s\x00%t\x00%t\x00%s\x00%t\x00%d/%d/%d/%d/%d/%d/%d/%t\x00%s",
+			result.Folder, result.Success, result.Retried, result.ProviderCrash, result.SetupError,
+			c.ToAdd, c.ToChange, c.ToDestroy, c.ToImport, c.ToMove, c.ToForget, c.ToReplace, c.NoChanges,
+			result.Output))
+	}
+	// Parallel runs finish in any order
+	slices.Sort(lines)
+	sum := sha256.Sum256([]byte(command + "\x01" + strings.Join(lines, "\x01")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Format the hidden results block of the summary comment
+func formatResultsDigest(digest string) string {
+	return "<!-- " + resultsDigestPrefix + digest + " -->\n"
+}
+
+// Load the latest summary comment of an earlier run with the same label,
+// before old comments are deleted (nil if there is none)
+func (r *Runner) loadPriorSummary(ctx context.Context, client *github.Client) *PriorSummary {
+	parts := strings.Split(r.config.Repository, "/")
+	comments, err := r.listTargetComments(ctx, client, parts[0], parts[1])
+	if err != nil {
+		r.logger.Warn("Failed to list comments for the previous summary", "error", err)
+		return nil
+	}
+	var prior *PriorSummary
+	// Comments are listed oldest first, so the latest summary wins
+	for _, comment := range comments {
+		fields, ok := parseCommentMarker(comment.Body)
+		if !ok || fields["folder"] != summaryMarkerFolder || fields["label"] != r.config.RunLabel || !strings.Contains(comment.Login, "[bot]") {
+			continue
+		}
+		if fields["run"] == getRunID() {
+			continue
+		}
+		prior = &PriorSummary{ID: comment.ID, RunID: fields["run"]}
+		if m := reResultsDigest.FindStringSubmatch(comment.Body); m != nil {
+			prior.Digest = m[1]
+		}
+	}
+	return prior
+}
+
+// Check whether the previous run posted the same folder results, so that only
+// its summary comment needs updating
+func (r *Runner) reusesComments(results []ExecutionResult) bool {
+	if !r.config.ReuseComments || r.config.Refresh || r.prevSummary == nil || r.prevSummary.Digest == "" {
+		return false
+	}
+	return r.prevSummary.Digest == resultsDigest(r.config.Command, results)
+}
+
+// Replace the previous summary comment with the summary of this run
+func (r *Runner) editSummary(ctx context.Context, client *github.Client, summary string) error {
+	parts := strings.Split(r.config.Repository, "/")
+	body := commentMarker(summaryMarkerFolder, r.config.RunLabel) + "\n" + summary + commentFooter(r.config.RunLabel)
+	if len(body) > maxCommentSize {
+		body = truncateComment(body)
+	}
+	return r.editTargetComment(ctx, client, parts[0], parts[1], r.prevSummary.ID, body)
+}
+
+// Format the summary note on folder comments kept from the previous run
+func (r *Runner) formatReusedComments() string {
+	if r.reusedFrom == "" {
+		return ""
+	}
+	return "- " + r.msg("summary.reused_comments", "run", r.reusedFrom) + "\n"
+}
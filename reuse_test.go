@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultsDigest(t *testing.T) {
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, Output: "Plan: 1 to add", ResourceChanges: &ResourceChanges{ToAdd: 1}, Duration: time.Second},
+		{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}, Duration: time.Minute},
+	}
+	digest := resultsDigest("plan", results)
+
+	rerun := []ExecutionResult{results[1], results[0]}
+	rerun[0].Duration, rerun[1].Duration = 3*time.Second, time.Hour
+	if got := resultsDigest("plan", rerun); got != digest {
+		t.Error("digest changed with the timing or order of the results")
+	}
+
+	changed := []ExecutionResult{results[0], results[1]}
+	changed[0].Output = "Plan: 2 to add"
+	if resultsDigest("plan", changed) == digest {
+		t.Error("digest unchanged with a different output")
+	}
+	if resultsDigest("apply", results) == digest {
+		t.Error("digest unchanged with a different command")
+	}
+}
+
+func TestReuseCommentsEditsSummary(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "300")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, Output: "Plan: 1 to add", ResourceChanges: &ResourceChanges{ToAdd: 1}},
+	}
+	summary := func(run string, results []ExecutionResult) string {
+		body := "<!-- terragrunt-runner:folder=_summary;run=" + run + " -->\n## Terragrunt Summary\n" + formatResultsDigest(resultsDigest("plan", results))
+		data, _ := json.Marshal(body)
+		return string(data)
+	}
+	changed := []ExecutionResult{results[0]}
+	changed[0].Output = "Plan: 2 to add"
+
+	tests := []struct {
+		name     string
+		previous string
+		wantEdit bool
+	}{
+		{"same results", summary("200", results), true},
+		{"changed results", summary("200", changed), false},
+		{"summary without digest", `"<!-- terragrunt-runner:folder=_summary;run=200 -->\n## Terragrunt Summary"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var edited, created []string
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				var comment struct{ Body string }
+				json.NewDecoder(req.Body).Decode(&comment)
+				switch {
+				case req.Method == http.MethodGet:
+					w.Write([]byte(`[{"id": 7, "body": ` + tt.previous + `, "user": {"login": "github-actions[bot]"}}]`))
+				case req.Method == http.MethodPatch && req.URL.Path == "/repos/owner/repo/issues/comments/7":
+					edited = append(edited, comment.Body)
+					w.Write([]byte(`{"id": 7}`))
+				case req.Method == http.MethodPost:
+					created = append(created, comment.Body)
+					w.Write([]byte(`{"id": 8}`))
+				default:
+					http.NotFound(w, req)
+				}
+			}))
+
+			r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, Command: "plan", ReuseComments: true})
+			r.prevSummary = r.loadPriorSummary(t.Context(), client)
+			if r.prevSummary == nil || r.prevSummary.ID != 7 || r.prevSummary.RunID != "200" {
+				t.Fatalf("prior summary = %+v, want comment 7 of run 200", r.prevSummary)
+			}
+			r.results = results
+			if err := r.commentStage(t.Context(), client); err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.wantEdit {
+				if len(edited) != 0 || len(created) != 2 {
+					t.Errorf("edited %d and created %d comments, want the folder and summary comments reposted", len(edited), len(created))
+				}
+				return
+			}
+			if len(created) != 0 || len(edited) != 1 {
+				t.Fatalf("edited %d and created %d comments, want only the summary edited", len(edited), len(created))
+			}
+			for _, want := range []string{"run=300", "Folder results unchanged since run `200`"} {
+				if !strings.Contains(edited[0], want) {
+					t.Errorf("edited summary lacks %q:\n%s", want, edited[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Weights of the planned changes in the risk score of a folder
+const (
+	riskWeightAdd       = 1
+	riskWeightChange    = 2
+	riskWeightReplace   = 8
+	riskWeightDestroy   = 10
+	riskWeightSensitive = 5 // Per change of a sensitive resource type, on top of its action
+	riskProdMultiplier  = 2 // Applied to folders under a production path
+
+	riskMediumScore = 10 // Lowest 🟡 score
+	riskHighScore   = 30 // Lowest 🔴 score
+)
+
+// Resource types whose changes affect access or network exposure (globs)
+var sensitiveResourceTypes = []string{
+	"aws_iam_*", "google_*iam*", "azurerm_role_*", "kubernetes_*role*",
+	"*security_group*", "*firewall*", "*network_acl*",
+	"aws_vpc*", "aws_subnet", "aws_route*", "aws_nat_gateway", "aws_internet_gateway",
+	"google_compute_network", "google_compute_subnetwork", "azurerm_virtual_network", "azurerm_subnet",
+}
+
+// Path segments marking a production folder
+var prodPathSegments = []string{"prod", "production", "prd"}
+
+// Check whether a resource type is sensitive
+func isSensitiveResourceType(resourceType string) bool {
+	for _, pattern := range sensitiveResourceTypes {
+		if ok, _ := path.Match(pattern, resourceType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether a folder is under a production path, e.g. live/prod/app
+func isProdFolder(folder string) bool {
+	for segment := range strings.SplitSeq(strings.ToLower(strings.ReplaceAll(folder, "\\", "/")), "/") {
+		for _, prod := range prodPathSegments {
+			if segment == prod {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compute the risk score of a folder result from its planned changes, the
+// sensitive resource types they touch and whether the folder is production
+func riskScore(result ExecutionResult) int {
+	c := result.ResourceChanges
+	if c == nil || c.NoChanges {
+		return 0
+	}
+	output := result.FullOutput
+	if output == "" {
+		output = result.Output
+	}
+	replace := c.ToReplace
+	if replace == 0 {
+		replace = countReplacements(output)
+	}
+	// The plan summary counts each replacement as an add and a destroy
+	add, destroy := max(c.ToAdd-replace, 0), max(c.ToDestroy-replace, 0)
+	score := add*riskWeightAdd + c.ToChange*riskWeightChange + replace*riskWeightReplace + destroy*riskWeightDestroy
+	for resourceType, n := range resultResourceTypes(result) {
+		if isSensitiveResourceType(resourceType) {
+			score += n * riskWeightSensitive
+		}
+	}
+	if isProdFolder(result.Folder) {
+		score *= riskProdMultiplier
+	}
+	return score
+}
+
+// Sum the risk scores of the folder results of a run
+func totalRiskScore(results []ExecutionResult) int {
+	total := 0
+	for _, result := range results {
+		total += riskScore(result)
+	}
+	return total
+}
+
+// Format a risk score with its level for the summary table, e.g. "🟡 14"
+func formatRiskScore(score int) string {
+	switch {
+	case score >= riskHighScore:
+		return fmt.Sprintf("🔴 %d", score)
+	case score >= riskMediumScore:
+		return fmt.Sprintf("🟡 %d", score)
+	}
+	return fmt.Sprintf("🟢 %d", score)
+}
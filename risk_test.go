@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRiskScore(t *testing.T) {
+	replacePlan := `  # aws_instance.web must be replaced
+-/+ resource "aws_instance" "web" {
+      ~ ami = "ami-123" -> "ami-456" # forces replacement
+    }
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {}
+
+  # aws_s3_bucket.data will be updated in-place
+  ~ resource "aws_s3_bucket" "data" {}
+
+Plan: 2 to add, 1 to change, 1 to destroy.
+`
+	tests := []struct {
+		name   string
+		result ExecutionResult
+		want   int
+	}{
+		{"no changes", ExecutionResult{Folder: "live/prod/app", ResourceChanges: &ResourceChanges{NoChanges: true}}, 0},
+		{"no plan", ExecutionResult{Folder: "live/dev/app"}, 0},
+		{"weighted actions", ExecutionResult{Folder: "live/dev/app", ResourceChanges: &ResourceChanges{ToAdd: 2, ToChange: 1, ToDestroy: 1}}, 14},
+		{"replacement instead of its add and destroy", ExecutionResult{
+			Folder:          "live/dev/app",
+			ResourceChanges: parseResourceChanges(replacePlan),
+			Output:          replacePlan,
+		}, 11},
+		{"sensitive types", ExecutionResult{
+			Folder:          "live/dev/app",
+			ResourceChanges: &ResourceChanges{ToAdd: 1, ToChange: 1},
+			Output:          "  # aws_iam_role.app will be created\n  # aws_security_group.web will be updated in-place\n",
+		}, 13},
+		{"production path", ExecutionResult{Folder: "live/Prod/app", ResourceChanges: &ResourceChanges{ToDestroy: 1}}, 20},
+		{"prod prefix is not production", ExecutionResult{Folder: "live/products/app", ResourceChanges: &ResourceChanges{ToDestroy: 1}}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := riskScore(tt.result); got != tt.want {
+				t.Errorf("riskScore() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRiskScore(t *testing.T) {
+	tests := map[int]string{0: "🟢 0", 9: "🟢 9", 10: "🟡 10", 29: "🟡 29", 30: "🔴 30"}
+	for score, want := range tests {
+		if got := formatRiskScore(score); got != want {
+			t.Errorf("formatRiskScore(%d) = %q, want %q", score, got, want)
+		}
+	}
+}
+
+func TestSummaryRiskColumn(t *testing.T) {
+	results := []ExecutionResult{
+		{Folder: "live/prod/db", Success: true, ResourceChanges: &ResourceChanges{ToDestroy: 2}},
+		{Folder: "live/dev/app", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+	}
+	r := newTestRunner(&Config{Command: "plan", RiskScore: true})
+	summary := r.formatSummary(results)
+	for _, want := range []string{"| Replace | Risk |\n", "| live/prod/db | ✅ | 0 | 0 | -2 | 0 | 🔴 40 |\n", "| live/dev/app | ✅ | 0 | 0 | 0 | 0 | 🟢 0 |\n"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary lacks %q:\n%s", want, summary)
+		}
+	}
+	if got := totalRiskScore(results); got != 40 {
+		t.Errorf("totalRiskScore() = %d, want 40", got)
+	}
+
+	r.config.RiskScore = false
+	if summary := r.formatSummary(results); strings.Contains(summary, "Risk") {
+		t.Errorf("summary has a risk column without risk-score:\n%s", summary)
+	}
+}
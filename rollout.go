@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Single select field of a GitHub Project holding its board columns
+const projectStatusField = "Status"
+
+var reProjectURL = regexp.MustCompile(`^https?://[^/]+/(?:orgs|users)/([^/]+)/projects/(\d+)/?$`)
+
+// Parse a GitHub Project given as owner/number or as its URL
+func parseProjectRef(ref string) (owner string, number int, err error) {
+	if m := reProjectURL.FindStringSubmatch(ref); m != nil {
+		number, _ = strconv.Atoi(m[2])
+		return m[1], number, nil
+	}
+	owner, n, ok := strings.Cut(ref, "/")
+	number, err = strconv.Atoi(n)
+	if !ok || owner == "" || err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("invalid deploy-project: %q (expected owner/number or a project URL)", ref)
+	}
+	return owner, number, nil
+}
+
+// Check whether a successful apply updates the pull request it deployed
+func (r *Runner) tracksRollout() bool {
+	return r.config.DeployMilestone != "" || r.config.DeployProject != "" || r.config.DeployRecord
+}
+
+// Find the pull request deployed by the apply: the configured one, or the
+// merged pull request of the workflow commit after a merge
+func (r *Runner) deployedPullRequest(ctx context.Context, client *github.Client, owner, repo string) (*github.PullRequest, error) {
+	if r.config.PullRequest > 0 {
+		pr, _, err := client.PullRequests.Get(ctx, owner, repo, r.config.PullRequest)
+		return pr, err
+	}
+	sha := os.Getenv("GITHUB_SHA")
+	if sha == "" {
+		return nil, fmt.Errorf("no pull request or commit to find the deployed pull request")
+	}
+	prs, _, err := client.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != nil {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("no merged pull request contains commit %s", sha)
+}
+
+// Update the deployed pull request after a successful apply: set its
+// milestone, move it on the project board and comment a deployment record.
+// Failures are logged only, the apply already happened.
+func (r *Runner) recordRollout(ctx context.Context, client *github.Client, results []ExecutionResult) {
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	pr, err := r.deployedPullRequest(ctx, client, owner, repo)
+	if err != nil {
+		r.logger.Warn("Failed to find the deployed pull request", "error", err)
+		return
+	}
+	if r.config.DeployMilestone != "" {
+		if err := r.setMilestone(ctx, client, owner, repo, pr.GetNumber(), r.config.DeployMilestone); err != nil {
+			r.logger.Warn("Failed to set the milestone of the deployed pull request", "pull_request", pr.GetNumber(), "error", err)
+		}
+	}
+	if r.config.DeployProject != "" {
+		if err := r.addToProject(ctx, client, pr.GetNodeID()); err != nil {
+			r.logger.Warn("Failed to add the deployed pull request to the project", "pull_request", pr.GetNumber(), "project", r.config.DeployProject, "error", err)
+		}
+	}
+	if r.config.DeployRecord {
+		body := r.formatDeploymentRecord(results, deploymentRef(), time.Now())
+		if _, _, err := client.Issues.CreateComment(ctx, owner, repo, pr.GetNumber(), &github.IssueComment{Body: &body}); err != nil {
+			r.logger.Warn("Failed to comment the deployment record", "pull_request", pr.GetNumber(), "error", err)
+		}
+	}
+}
+
+// Set the milestone of a pull request to the open milestone with this title
+func (r *Runner) setMilestone(ctx context.Context, client *github.Client, owner, repo string, number int, title string) error {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return err
+		}
+		for _, milestone := range milestones {
+			if milestone.GetTitle() == title {
+				_, _, err := client.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{Milestone: milestone.Number})
+				return err
+			}
+		}
+		if resp.NextPage == 0 {
+			return fmt.Errorf("no open milestone %q", title)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Add a pull request to the configured GitHub Project and move it to the
+// configured status column. Projects are only reachable through GraphQL.
+func (r *Runner) addToProject(ctx context.Context, client *github.Client, contentID string) error {
+	owner, number, err := parseProjectRef(r.config.DeployProject)
+	if err != nil {
+		return err
+	}
+	var project struct {
+		RepositoryOwner struct {
+			ProjectV2 *struct {
+				ID    string `json:"id"`
+				Field *struct {
+					ID      string `json:"id"`
+					Options []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"options"`
+				} `json:"field"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	err = githubGraphQL(ctx, client, `query($owner: String!, $number: Int!, $field: String!) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectV2(number: $number) {
+        id
+        field(name: $field) { ... on ProjectV2SingleSelectField { id options { id name } } }
+      }
+    }
+  }
+}`, map[string]any{"owner": owner, "number": number, "field": projectStatusField}, &project)
+	if err != nil {
+		return err
+	}
+	board := project.RepositoryOwner.ProjectV2
+	if board == nil {
+		return fmt.Errorf("project %s/%d not found", owner, number)
+	}
+
+	// Adding an item already on the project returns the existing item
+	var added struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	err = githubGraphQL(ctx, client, `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`, map[string]any{"project": board.ID, "content": contentID}, &added)
+	if err != nil || r.config.DeployProjectStatus == "" {
+		return err
+	}
+
+	option := ""
+	if board.Field != nil {
+		for _, o := range board.Field.Options {
+			if strings.EqualFold(o.Name, r.config.DeployProjectStatus) {
+				option = o.ID
+			}
+		}
+	}
+	if option == "" {
+		return fmt.Errorf("project %s/%d has no %s option %q", owner, number, projectStatusField, r.config.DeployProjectStatus)
+	}
+	return githubGraphQL(ctx, client, `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) { projectV2Item { id } }
+}`, map[string]any{"project": board.ID, "item": added.AddProjectV2ItemByID.Item.ID, "field": board.Field.ID, "option": option}, nil)
+}
+
+// Run a GraphQL query against the GraphQL endpoint next to the REST API of
+// the client (/graphql, or /api/graphql on GitHub Enterprise Server)
+func githubGraphQL(ctx context.Context, client *github.Client, query string, variables map[string]any, data any) error {
+	req, err := client.NewRequest("POST", "../graphql", map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   any `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	resp.Data = data
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", resp.Errors[0].Message)
+	}
+	return nil
+}
+
+// Format the deployment record commented on the deployed pull request
+func (r *Runner) formatDeploymentRecord(results []ExecutionResult, sha string, now time.Time) string {
+	folders := r.folderResults(results)
+	var b strings.Builder
+	b.WriteString("### " + r.msg("deploy.record", "count", len(folders)) + "\n\n")
+	b.WriteString("| | |\n|---|---|\n")
+	if r.config.DeploymentEnvironment != "" {
+		fmt.Fprintf(&b, "| Environment | `%s` |\n", r.config.DeploymentEnvironment)
+	}
+	if r.config.RunLabel != "" {
+		fmt.Fprintf(&b, "| Run | %s |\n", r.config.RunLabel)
+	}
+	if sha != "" {
+		fmt.Fprintf(&b, "| Commit | %s |\n", sha)
+	}
+	fmt.Fprintf(&b, "| Applied at | %s |\n", now.UTC().Format(time.RFC3339))
+	if runURL := getRunURL(); runURL != "" {
+		fmt.Fprintf(&b, "| Workflow run | [#%s](%s) |\n", os.Getenv("GITHUB_RUN_ID"), runURL)
+	}
+	b.WriteString("\n")
+	for _, result := range folders {
+		fmt.Fprintf(&b, "- `%s`", result.Folder)
+		if c := result.ResourceChanges; c != nil && !c.NoChanges {
+			fmt.Fprintf(&b, ": %d added, %d changed, %d destroyed", c.ToAdd, c.ToChange, c.ToDestroy)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProjectRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantOwner  string
+		wantNumber int
+		wantErr    bool
+	}{
+		{ref: "acme/5", wantOwner: "acme", wantNumber: 5},
+		{ref: "https://github.com/orgs/acme/projects/12", wantOwner: "acme", wantNumber: 12},
+		{ref: "https://github.example.com/users/octocat/projects/3/", wantOwner: "octocat", wantNumber: 3},
+		{ref: "acme", wantErr: true},
+		{ref: "acme/board", wantErr: true},
+		{ref: "/5", wantErr: true},
+	}
+	for _, tt := range tests {
+		owner, number, err := parseProjectRef(tt.ref)
+		if (err != nil) != tt.wantErr || owner != tt.wantOwner || number != tt.wantNumber {
+			t.Errorf("parseProjectRef(%q) = %q, %d, %v", tt.ref, owner, number, err)
+		}
+	}
+}
+
+func TestRecordRollout(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "abc1234")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+
+	var milestone float64
+	var record string
+	var graphql []string
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/commits/abc1234/pulls":
+			w.Write([]byte(`[{"number": 3, "node_id": "PR_open"}, {"number": 7, "node_id": "PR_merged", "merged_at": "2026-10-01T10:00:00Z"}]`))
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/milestones":
+			w.Write([]byte(`[{"number": 1, "title": "2026.09"}, {"number": 2, "title": "2026.10"}]`))
+		case req.Method == http.MethodPatch && req.URL.Path == "/repos/owner/repo/issues/7":
+			var issue map[string]any
+			json.NewDecoder(req.Body).Decode(&issue)
+			milestone, _ = issue["milestone"].(float64)
+			w.Write([]byte(`{"number": 7}`))
+		case req.Method == http.MethodPost && req.URL.Path == "/graphql":
+			var body struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			switch {
+			case strings.Contains(body.Query, "repositoryOwner"):
+				graphql = append(graphql, "project")
+				w.Write([]byte(`{"data": {"repositoryOwner": {"projectV2": {"id": "P1", "field": {"id": "F1", "options": [{"id": "O1", "name": "In review"}, {"id": "O2", "name": "Deployed"}]}}}}}`))
+			case strings.Contains(body.Query, "addProjectV2ItemById"):
+				graphql = append(graphql, "add "+body.Variables["content"].(string))
+				w.Write([]byte(`{"data": {"addProjectV2ItemById": {"item": {"id": "I1"}}}}`))
+			case strings.Contains(body.Query, "updateProjectV2ItemFieldValue"):
+				graphql = append(graphql, "status "+body.Variables["item"].(string)+" "+body.Variables["option"].(string))
+				w.Write([]byte(`{"data": {}}`))
+			}
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/issues/7/comments":
+			var comment struct {
+				Body string `json:"body"`
+			}
+			json.NewDecoder(req.Body).Decode(&comment)
+			record = comment.Body
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := newTestRunner(&Config{
+		Repository:          "owner/repo",
+		Command:             "apply",
+		DeployMilestone:     "2026.10",
+		DeployProject:       "https://github.com/orgs/owner/projects/4",
+		DeployProjectStatus: "deployed",
+		DeployRecord:        true,
+	})
+	results := []ExecutionResult{{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2}}}
+	r.recordRollout(context.Background(), client, results)
+
+	if milestone != 2 {
+		t.Errorf("milestone = %v, want 2", milestone)
+	}
+	if strings.Join(graphql, ",") != "project,add PR_merged,status I1 O2" {
+		t.Errorf("graphql requests = %v", graphql)
+	}
+	if !strings.Contains(record, "🚀 Deployed 1 folders") || !strings.Contains(record, "| Commit | abc1234 |") || !strings.Contains(record, "- `live/app`: 2 added, 0 changed, 0 destroyed") {
+		t.Errorf("deployment record = %q", record)
+	}
+}
+
+func TestFormatDeploymentRecord(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	r := newTestRunner(&Config{Command: "apply", DeploymentEnvironment: "production"})
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+		{Folder: "live/db", Success: true},
+	}
+	got := r.formatDeploymentRecord(results, "", time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	for _, want := range []string{
+		"### 🚀 Deployed 2 folders",
+		"| Environment | `production` |",
+		"| Applied at | 2026-10-16T09:30:00Z |",
+		"| Workflow run | [#42](https://github.com/owner/repo/actions/runs/42) |",
+		"- `live/app`\n- `live/db`\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("record missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Commit") {
+		t.Errorf("record = %q, want no commit row without a commit", got)
+	}
+}
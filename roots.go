@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Get the run --all roots of root-dir, which lists several roots separated by
+// commas when the repository has no single root, e.g. live/prod,live/staging
+func runAllRoots(rootDir string) []string {
+	var roots []string
+	for root := range strings.SplitSeq(rootDir, ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, filepath.Clean(root))
+		}
+	}
+	slices.Sort(roots)
+	return slices.Compact(roots)
+}
+
+// Group run --all folders by the innermost root containing them. Without
+// folders every root runs all of its units; folders outside every root are
+// left out with a warning.
+func (r *Runner) groupFoldersByRoot(repoRoot string, roots []string) map[string][]string {
+	groups := make(map[string][]string)
+	if len(r.config.Folders) == 0 {
+		for _, root := range roots {
+			groups[root] = nil
+		}
+		return groups
+	}
+	for _, folder := range r.config.Folders {
+		rel := filepath.Clean(folder)
+		if filepath.IsAbs(rel) {
+			if relToRepo, err := filepath.Rel(repoRoot, rel); err == nil {
+				rel = relToRepo
+			}
+		}
+		owner := ""
+		for _, root := range roots {
+			if (rel == root || strings.HasPrefix(rel, root+string(filepath.Separator))) && len(root) > len(owner) {
+				owner = root
+			}
+		}
+		if owner == "" {
+			r.logger.Warn("Folder is outside every root-dir, leaving it out of run --all", "folder", folder, "roots", roots)
+			continue
+		}
+		groups[owner] = append(groups[owner], folder)
+	}
+	return groups
+}
+
+// Qualify a unit reported relative to its shard directory with the directory,
+// so units of different shards (e.g. app in two roots) stay distinct
+func qualifyShardFolder(dir, folder string) string {
+	clean := filepath.Clean(folder)
+	if filepath.IsAbs(clean) || clean == dir || strings.HasPrefix(clean, dir+string(filepath.Separator)) {
+		return folder
+	}
+	return filepath.Join(dir, clean)
+}
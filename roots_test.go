@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunAllRoots(t *testing.T) {
+	tests := []struct {
+		rootDir string
+		want    []string
+	}{
+		{"live", []string{"live"}},
+		{"live/staging, live/prod/", []string{"live/prod", "live/staging"}},
+		{"live/prod,,live/prod", []string{"live/prod"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := runAllRoots(tt.rootDir); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("runAllRoots(%q) = %v, want %v", tt.rootDir, got, tt.want)
+		}
+	}
+}
+
+func TestGroupFoldersByRoot(t *testing.T) {
+	repoRoot := "/repo"
+	roots := []string{"live/prod", "live/prod/eu", "live/staging"}
+	r := newTestRunner(&Config{Folders: []string{"live/prod/us/app", "/repo/live/prod/eu/db", "live/staging/app", "live/dev/app", "live/production/app"}})
+	want := map[string][]string{
+		"live/prod":    {"live/prod/us/app"},
+		"live/prod/eu": {"/repo/live/prod/eu/db"},
+		"live/staging": {"live/staging/app"},
+	}
+	if got := r.groupFoldersByRoot(repoRoot, roots); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupFoldersByRoot() = %v, want %v", got, want)
+	}
+
+	r.config.Folders = nil
+	want = map[string][]string{"live/prod": nil, "live/prod/eu": nil, "live/staging": nil}
+	if got := r.groupFoldersByRoot(repoRoot, roots); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupFoldersByRoot() without folders = %v, want every root", got)
+	}
+}
+
+func TestQualifyShardFolder(t *testing.T) {
+	tests := []struct {
+		dir, folder, want string
+	}{
+		{"live/prod", "app", "live/prod/app"},
+		{"live/prod", "live/prod/app", "live/prod/app"},
+		{"live/prod", "live/prod", "live/prod"},
+		{"live/prod", "/repo/live/prod/app", "/repo/live/prod/app"},
+	}
+	for _, tt := range tests {
+		if got := qualifyShardFolder(tt.dir, tt.folder); got != tt.want {
+			t.Errorf("qualifyShardFolder(%q, %q) = %q, want %q", tt.dir, tt.folder, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Gate of a runbook step checking the approval of the run's deployment to
+// deployment-environment; any other gate is a shell command like apply-gates
+const runbookApprovalGate = "approval"
+
+// GitHub reviews whole jobs, so a job reaching an approval gate after running
+// other steps pauses there; a job declaring the environment resumes the runbook
+var errRunbookPaused = errors.New("waiting for approval")
+
+// Ordered steps executed one after the other (--runbook), e.g. apply A, wait
+// for approval, then apply B and C in parallel
+type Runbook struct {
+	Steps  []RunbookStep `json:"steps"`
+	digest string        // SHA-256 of the runbook file, tying a state file to it
+}
+
+// Step of a runbook: its folders run together with the usual parallelism
+type RunbookStep struct {
+	Name    string   `json:"name"`
+	Folders []string `json:"folders"`
+	Command string   `json:"command,omitempty"` // Command of the step's folders (empty = the run command)
+	Gate    string   `json:"gate,omitempty"`    // "approval" or a command that must succeed before the step
+}
+
+// Progress of a runbook kept between runs (--runbook-state), so a rerun resumes
+// after the last completed step
+type RunbookState struct {
+	Runbook   string   `json:"runbook"`   // Digest of the runbook the steps belong to
+	Completed []string `json:"completed"` // Names of the completed steps
+}
+
+// Load a runbook from a JSON file, checking that its steps are complete and
+// their names unique
+func loadRunbook(path string) (*Runbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runbook %s: %w", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var runbook Runbook
+	if err := decoder.Decode(&runbook); err != nil {
+		return nil, fmt.Errorf("invalid runbook %s: %w", path, err)
+	}
+	if len(runbook.Steps) == 0 {
+		return nil, fmt.Errorf("invalid runbook %s: no steps", path)
+	}
+	var names []string
+	for i, step := range runbook.Steps {
+		switch {
+		case step.Name == "" || strings.HasPrefix(step.Name, "_"):
+			return nil, fmt.Errorf("invalid runbook %s: step %d needs a name not starting with _", path, i+1)
+		case slices.Contains(names, step.Name):
+			return nil, fmt.Errorf("invalid runbook %s: duplicate step %s", path, step.Name)
+		case len(step.Folders) == 0:
+			return nil, fmt.Errorf("invalid runbook %s: step %s has no folders", path, step.Name)
+		case step.Command != "" && isRunAllCommand(step.Command):
+			return nil, fmt.Errorf("invalid runbook %s: command %q of step %s runs all units, steps need a single-unit command", path, step.Command, step.Name)
+		}
+		names = append(names, step.Name)
+		for j, folder := range step.Folders {
+			runbook.Steps[i].Folders[j] = filepath.Clean(folder)
+		}
+	}
+	sum := sha256.Sum256(data)
+	runbook.digest = hex.EncodeToString(sum[:])
+	return &runbook, nil
+}
+
+// Validate the runbook against the rest of the configuration
+func (r *Runner) validateRunbook() error {
+	if r.runbook == nil {
+		if r.config.RunbookState != "" {
+			return fmt.Errorf("runbook-state requires runbook")
+		}
+		return nil
+	}
+	switch {
+	case isRunAllCommand(r.config.Command):
+		return fmt.Errorf("runbook requires per-folder execution, not run --all")
+	case len(r.config.ApplyOrder) > 0:
+		return fmt.Errorf("runbook and apply-order cannot be combined, order the steps in the runbook")
+	case r.config.InputsFrom != "":
+		return fmt.Errorf("runbook and inputs-from cannot be combined")
+	}
+	for _, step := range r.runbook.Steps {
+		// Apply gates (approvals, deployments) are decided by the run command
+		if step.Command != "" && isApplyCommand(step.Command) != isApplyCommand(r.config.Command) {
+			return fmt.Errorf("invalid runbook: command %q of step %s must be of the same kind as %q", step.Command, step.Name, r.config.Command)
+		}
+		if step.Gate == runbookApprovalGate && r.config.DeploymentEnvironment == "" {
+			return fmt.Errorf("invalid runbook: approval gate of step %s requires deployment-environment", step.Name)
+		}
+		if step.Gate == runbookApprovalGate && r.config.RunbookState == "" {
+			return fmt.Errorf("invalid runbook: approval gate of step %s requires runbook-state to resume from", step.Name)
+		}
+	}
+	return nil
+}
+
+// Replace the folders of the run with those of the runbook steps, resolving
+// each folder's command
+func (r *Runner) applyRunbook(runbook *Runbook) {
+	r.runbook = runbook
+	r.config.Folders = nil
+	r.config.AutoDetect = false // The runbook lists the folders
+	r.folderInputs = map[string]FolderInput{}
+	for _, step := range runbook.Steps {
+		for _, folder := range step.Folders {
+			if step.Command != "" {
+				r.folderInputs[folder] = FolderInput{Folder: folder, Command: step.Command, Args: r.config.TerragruntArgs}
+			}
+			r.config.Folders = append(r.config.Folders, folder)
+		}
+	}
+}
+
+// Load the progress of the runbook, starting over when there is none or it
+// belongs to another version of the runbook
+func (r *Runner) loadRunbookState() RunbookState {
+	state := RunbookState{Runbook: r.runbook.digest}
+	if r.config.RunbookState == "" {
+		return state
+	}
+	data, err := os.ReadFile(r.config.RunbookState)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.logger.Warn("Failed to read the runbook state, starting over", "error", err)
+		}
+		return state
+	}
+	var saved RunbookState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		r.logger.Warn("Ignoring invalid runbook state, starting over", "path", r.config.RunbookState, "error", err)
+		return state
+	}
+	if saved.Runbook != r.runbook.digest {
+		fmt.Printf("::warning::The runbook changed since its state was saved, starting over\n")
+		return state
+	}
+	return saved
+}
+
+// Save the progress of the runbook after a completed step
+func (r *Runner) saveRunbookState(state RunbookState) {
+	if r.config.RunbookState == "" {
+		return
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = os.WriteFile(r.config.RunbookState, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		r.logger.Warn("Failed to save the runbook state", "error", err)
+	}
+}
+
+// Record the folders of a step that did not run
+func runbookNotRun(step RunbookStep, reason string) []ExecutionResult {
+	var results []ExecutionResult
+	for _, folder := range step.Folders {
+		results = append(results, ExecutionResult{Folder: folder, Success: false, Error: fmt.Errorf("not run: %s", reason)})
+	}
+	return results
+}
+
+// Pass the gate of a step: check the deployment approval, returning the
+// deployment to report the step on, or run the gate command. The approval was
+// given before the job started, so it only covers the first step the job runs;
+// later approval gates pause the runbook.
+func (r *Runner) passRunbookGate(ctx context.Context, client *github.Client, step RunbookStep, firstStep bool) (int64, error) {
+	switch step.Gate {
+	case "":
+		return 0, nil
+	case runbookApprovalGate:
+		if !firstStep {
+			return 0, errRunbookPaused
+		}
+		return r.awaitDeploymentApproval(ctx, client)
+	default:
+		return 0, r.runApplyGate(step.Gate, ApplyGroup{Name: step.Name, Folders: step.Folders})
+	}
+}
+
+// Execute the runbook step by step. A step starts once the previous one fully
+// succeeded and its gate passed; steps completed by an earlier run are skipped.
+// An approval gate after a step of this run pauses the runbook: the remaining
+// steps are left to a job declaring the environment (runbook-paused output).
+// Folders filtered out of the run (by labels or their configuration) are left
+// out of their step. Each step posts a comment with its outcome.
+func (r *Runner) executeRunbook(ctx context.Context, client *github.Client) []ExecutionResult {
+	allFolders := r.config.Folders
+	defer func() { r.config.Folders = allFolders }()
+
+	state := r.loadRunbookState()
+	var results []ExecutionResult
+	stopped := ""
+	ran := false
+	for i, step := range r.runbook.Steps {
+		if slices.Contains(state.Completed, step.Name) {
+			fmt.Printf("::notice::Runbook step %s was completed by an earlier run, skipping it\n", step.Name)
+			continue
+		}
+		if stopped != "" {
+			results = append(results, runbookNotRun(step, "runbook step "+stopped+" did not complete")...)
+			continue
+		}
+
+		step.Folders = slices.DeleteFunc(slices.Clone(step.Folders), func(folder string) bool { return !slices.Contains(allFolders, folder) })
+		if len(step.Folders) == 0 {
+			r.logger.Info("Skipping runbook step without folders left to run", "step", step.Name)
+			continue
+		}
+		r.config.Folders = step.Folders
+		r.logger.Info("Running runbook step", "step", step.Name, "folders", step.Folders)
+		deployment, err := r.passRunbookGate(ctx, client, step, !ran)
+		if errors.Is(err, errRunbookPaused) {
+			fmt.Printf("::notice::Runbook paused before step %s until a job in environment %s resumes it\n", step.Name, r.config.DeploymentEnvironment)
+			if err := writeActionOutput("runbook-paused", step.Name); err != nil {
+				r.logger.Warn("Failed to write the runbook-paused output", "error", err)
+			}
+			r.postRunbookStep(ctx, client, i, nil, err)
+			break
+		}
+		if err != nil {
+			r.logger.Warn("Runbook gate failed", "step", step.Name, "error", err)
+			results = append(results, runbookNotRun(step, "gate of runbook step "+step.Name+" failed")...)
+			r.postRunbookStep(ctx, client, i, nil, err)
+			stopped = step.Name
+			continue
+		}
+		ran = true
+		stepResults := r.executeTerragrunt()
+		if deployment != 0 {
+			r.finishDeployment(ctx, client, deployment, stepResults)
+		}
+		results = append(results, stepResults...)
+		r.postRunbookStep(ctx, client, i, stepResults, nil)
+
+		if slices.ContainsFunc(stepResults, func(result ExecutionResult) bool { return !result.Success }) {
+			stopped = step.Name
+			continue
+		}
+		state.Completed = append(state.Completed, step.Name)
+		r.saveRunbookState(state)
+	}
+	return results
+}
+
+// Format the comment of a runbook step: its gate and the outcome of its folders
+func (r *Runner) formatRunbookStep(index int, results []ExecutionResult, gateErr error) string {
+	step := r.runbook.Steps[index]
+	command, _ := r.folderCommand(step.Folders[0])
+	status := "✅"
+	paused := errors.Is(gateErr, errRunbookPaused)
+	switch {
+	case paused:
+		status = "⏸️"
+	case gateErr != nil || slices.ContainsFunc(results, func(result ExecutionResult) bool { return !result.Success }):
+		status = "❌"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 📓 %s Runbook Step %d/%d: %s\n\n**Command:** %s\n", status, index+1, len(r.runbook.Steps), step.Name, command)
+	if step.Gate != "" {
+		gate := "passed"
+		switch {
+		case paused:
+			gate = gateErr.Error()
+		case gateErr != nil:
+			gate = "failed: " + gateErr.Error()
+		}
+		fmt.Fprintf(&b, "**Gate:** `%s` %s\n", step.Gate, gate)
+	}
+	if paused {
+		fmt.Fprintf(&b, "\nThe runbook paused. A job declaring `environment: %s` resumes it from this step once approved.\n", r.config.DeploymentEnvironment)
+		return b.String()
+	}
+	if gateErr != nil {
+		b.WriteString("\nThe step did not run and the runbook stopped.\n")
+		return b.String()
+	}
+	b.WriteString("\n| Folder | Status |\n|--------|--------|\n")
+	for _, result := range results {
+		outcome := "✅"
+		if !result.Success {
+			outcome = "❌ " + strings.ReplaceAll(firstErrorLine(result), "|", "\\|")
+		} else if result.ResourceChanges != nil && !result.ResourceChanges.NoChanges {
+			changes := result.ResourceChanges
+			outcome += fmt.Sprintf(" +%d ~%d -%d", changes.ToAdd, changes.ToChange, changes.ToDestroy)
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", strings.ReplaceAll(r.displayName(result.Folder), "|", "\\|"), outcome)
+	}
+	if status == "❌" {
+		b.WriteString("\nThe runbook stopped; rerun the workflow to resume from this step.\n")
+	}
+	return b.String()
+}
+
+// Post the comment of a runbook step. Comments are best effort: failures are
+// logged and the runbook goes on.
+func (r *Runner) postRunbookStep(ctx context.Context, client *github.Client, index int, results []ExecutionResult, gateErr error) {
+	if client == nil {
+		return
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	body := r.formatRunbookStep(index, results, gateErr)
+	if err := r.createComment(ctx, client, parts[0], parts[1], "_runbook-"+r.runbook.Steps[index].Name, body); err != nil {
+		r.logger.Warn("Failed to post the runbook step comment", "step", r.runbook.Steps[index].Name, "error", err)
+	}
+}
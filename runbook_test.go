@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRunbook(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: `{"steps": [{"name": "network", "folders": ["live/vpc/"]}, {"name": "apps", "folders": ["live/a", "live/b"], "gate": "approval"}]}`},
+		{name: "no steps", content: `{"steps": []}`, wantErr: "no steps"},
+		{name: "unnamed step", content: `{"steps": [{"folders": ["live/vpc"]}]}`, wantErr: "needs a name"},
+		{name: "duplicate step", content: `{"steps": [{"name": "a", "folders": ["x"]}, {"name": "a", "folders": ["y"]}]}`, wantErr: "duplicate step a"},
+		{name: "no folders", content: `{"steps": [{"name": "a"}]}`, wantErr: "has no folders"},
+		{name: "run all", content: `{"steps": [{"name": "a", "folders": ["x"], "command": "run --all apply"}]}`, wantErr: "runs all units"},
+		{name: "unknown field", content: `{"steps": [{"name": "a", "folders": ["x"], "parallel": true}]}`, wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			os.WriteFile(path, []byte(tt.content), 0o644)
+			runbook, err := loadRunbook(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadRunbook() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runbook.Steps[0].Folders[0] != "live/vpc" || len(runbook.digest) != 64 {
+				t.Errorf("loadRunbook() = %+v, want clean folders and a digest", runbook)
+			}
+		})
+	}
+}
+
+func TestValidateRunbook(t *testing.T) {
+	runbook := &Runbook{Steps: []RunbookStep{{Name: "network", Folders: []string{"live/vpc"}, Gate: "approval"}}}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "valid", config: Config{Command: "apply", DeploymentEnvironment: "prod", RunbookState: "state.json"}},
+		{name: "run all", config: Config{Command: "run --all apply", DeploymentEnvironment: "prod"}, wantErr: "per-folder execution"},
+		{name: "apply order", config: Config{Command: "apply", DeploymentEnvironment: "prod", ApplyOrder: []string{"a=live/**"}}, wantErr: "apply-order"},
+		{name: "approval without environment", config: Config{Command: "apply"}, wantErr: "requires deployment-environment"},
+		{name: "approval without state", config: Config{Command: "apply", DeploymentEnvironment: "prod"}, wantErr: "requires runbook-state"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&tt.config)
+			r.runbook = runbook
+			err := r.validateRunbook()
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateRunbook() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	r := newTestRunner(&Config{Command: "apply"})
+	r.runbook = &Runbook{Steps: []RunbookStep{{Name: "plan-first", Folders: []string{"live/vpc"}, Command: "plan"}}}
+	if err := r.validateRunbook(); err == nil || !strings.Contains(err.Error(), "same kind") {
+		t.Errorf("validateRunbook() with a plan step in an apply = %v, want an error", err)
+	}
+	if err := newTestRunner(&Config{RunbookState: "state.json"}).validateRunbook(); err == nil {
+		t.Error("validateRunbook() with runbook-state only = nil, want an error")
+	}
+}
+
+func TestExecuteRunbookResumesAndStops(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	r := newTestRunner(&Config{Command: "apply", TerragruntArgs: "-auto-approve", RunbookState: statePath})
+	r.applyRunbook(&Runbook{digest: "abc", Steps: []RunbookStep{
+		{Name: "network", Folders: []string{"live/vpc"}},
+		{Name: "database", Folders: []string{"live/db"}, Gate: "echo change freeze; exit 1"},
+		{Name: "apps", Folders: []string{"live/a", "live/b"}, Command: "destroy"},
+	}})
+	if got := strings.Join(r.config.Folders, ","); got != "live/vpc,live/db,live/a,live/b" {
+		t.Errorf("applyRunbook() folders = %s", got)
+	}
+	if command, args := r.folderCommand("live/a"); command != "destroy" || args != "-auto-approve" {
+		t.Errorf("folderCommand() = %q %q, want the step command", command, args)
+	}
+	r.saveRunbookState(RunbookState{Runbook: "abc", Completed: []string{"network"}})
+
+	results := r.executeRunbook(context.Background(), nil)
+	if len(results) != 3 {
+		t.Fatalf("executeRunbook() = %+v, want the database and apps folders", results)
+	}
+	if results[0].Folder != "live/db" || !strings.Contains(results[0].Error.Error(), "gate of runbook step database failed") {
+		t.Errorf("executeRunbook() database result = %+v", results[0])
+	}
+	if !strings.Contains(results[2].Error.Error(), "runbook step database did not complete") {
+		t.Errorf("executeRunbook() apps result = %+v", results[2])
+	}
+	if got := strings.Join(r.config.Folders, ","); got != "live/vpc,live/db,live/a,live/b" {
+		t.Errorf("executeRunbook() left folders = %s", got)
+	}
+	if state := r.loadRunbookState(); strings.Join(state.Completed, ",") != "network" {
+		t.Errorf("runbook state = %+v, want only network completed", state)
+	}
+
+	// A changed runbook starts over
+	r.runbook.digest = "def"
+	if state := r.loadRunbookState(); len(state.Completed) != 0 {
+		t.Errorf("runbook state of a changed runbook = %+v, want none completed", state)
+	}
+}
+
+func TestExecuteRunbookPausesForApproval(t *testing.T) {
+	h := newHarness(t)
+	h.addUnit("live/vpc", "terraform-1.9/plan-changes.txt", 0)
+	h.addUnit("live/app", "terraform-1.9/plan-changes.txt", 0)
+	h.addUnit("live/legacy", "terraform-1.9/plan-changes.txt", 0)
+	deploymentPollInterval = 0
+	t.Setenv("GITHUB_SHA", "abc123")
+	t.Setenv("GITHUB_RUN_ID", "500")
+	fake := &fakeDeployments{runs: map[int64]string{42: "500"}, states: []string{"in_progress"}}
+	deployments := fake.handler(t)
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.Contains(req.URL.Path, "/issues/") {
+			deployments.ServeHTTP(w, req)
+			return
+		}
+		if req.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	runbook := &Runbook{digest: "abc", Steps: []RunbookStep{
+		{Name: "network", Folders: []string{"live/vpc"}},
+		{Name: "apps", Folders: []string{"live/app"}, Gate: runbookApprovalGate},
+		{Name: "cleanup", Folders: []string{"live/legacy"}, Gate: runbookApprovalGate},
+	}}
+	newRunbookRunner := func() *Runner {
+		r := newTestRunner(&Config{
+			Command:               "apply",
+			TerragruntArgs:        "-auto-approve",
+			Repository:            "owner/repo",
+			PullRequest:           1,
+			DeploymentEnvironment: "production",
+			DeploymentTimeout:     1,
+			RunbookState:          filepath.Join(h.scripts, "state.json"),
+		})
+		r.applyRunbook(runbook)
+		return r
+	}
+
+	// The job approved before it started cannot cover a step after the first
+	results := newRunbookRunner().executeRunbook(context.Background(), client)
+	if len(results) != 1 || results[0].Folder != "live/vpc" || !results[0].Success {
+		t.Fatalf("executeRunbook() = %+v, want only the network step", results)
+	}
+	if got := h.outputs()["runbook-paused"]; got != "apps" {
+		t.Errorf("runbook-paused output = %q, want apps", got)
+	}
+	if len(fake.posted) != 0 {
+		t.Errorf("posted deployment statuses = %v, want none before the approval", fake.posted)
+	}
+
+	// A job in the environment resumes with the approved step and pauses again
+	r := newRunbookRunner()
+	results = r.executeRunbook(context.Background(), client)
+	if len(results) != 1 || results[0].Folder != "live/app" || !results[0].Success {
+		t.Fatalf("resumed executeRunbook() = %+v, want only the apps step", results)
+	}
+	if strings.Join(fake.posted, ",") != "in_progress,success" {
+		t.Errorf("posted deployment statuses = %v, want the apps step reported", fake.posted)
+	}
+	if state := r.loadRunbookState(); strings.Join(state.Completed, ",") != "network,apps" {
+		t.Errorf("runbook state = %+v, want network and apps completed", state)
+	}
+	if got := h.outputs()["runbook-paused"]; got != "cleanup" {
+		t.Errorf("runbook-paused output = %q, want cleanup", got)
+	}
+	for _, call := range h.calls() {
+		if strings.HasPrefix(call, "legacy ") {
+			t.Errorf("terragrunt ran %q before the cleanup approval", call)
+		}
+	}
+}
+
+func TestFormatRunbookStep(t *testing.T) {
+	r := newTestRunner(&Config{Command: "apply"})
+	r.runbook = &Runbook{Steps: []RunbookStep{
+		{Name: "network", Folders: []string{"live/vpc"}},
+		{Name: "apps", Folders: []string{"live/a", "live/b"}, Gate: "approval"},
+	}}
+	body := r.formatRunbookStep(1, []ExecutionResult{
+		{Folder: "live/a", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2}},
+		{Folder: "live/b", Error: errors.New("exit status 1"), Output: "Error: access denied"},
+	}, nil)
+	for _, want := range []string{"## 📓 ❌ Runbook Step 2/2: apps", "**Command:** apply", "**Gate:** `approval` passed", "| live/a | ✅ +2 ~0 -0 |", "| live/b | ❌ Error: access denied |", "resume from this step"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatRunbookStep() = %q, want it to contain %q", body, want)
+		}
+	}
+	r.config.DeploymentEnvironment = "production"
+	if body := r.formatRunbookStep(1, nil, errRunbookPaused); !strings.Contains(body, "⏸️") || !strings.Contains(body, "`environment: production` resumes it") {
+		t.Errorf("formatRunbookStep() of a paused step = %q", body)
+	}
+	if body := r.formatRunbookStep(1, nil, errors.New("not approved")); !strings.Contains(body, "failed: not approved") || !strings.Contains(body, "did not run") {
+		t.Errorf("formatRunbookStep() with a failed gate = %q", body)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Hidden block of the summary comment recording the statistics of the run,
+// read back by the next run on the same pull request
+const runStatsPrefix = "terragrunt-runner-stats:v1 "
+
+var reRunStats = regexp.MustCompile(`<!-- terragrunt-runner-stats:v1 ([^>]*?) -->`)
+
+// Statistics of a run compared between consecutive runs
+type RunStats struct {
+	RunID    string
+	Folders  int
+	Failures int
+	Add      int
+	Change   int
+	Destroy  int
+	Replace  int
+}
+
+// Compute the statistics of the folder results of a run
+func runStats(results []ExecutionResult) RunStats {
+	stats := RunStats{RunID: getRunID(), Folders: len(results)}
+	for _, result := range results {
+		if !result.Success {
+			stats.Failures++
+		}
+		if c := result.ResourceChanges; c != nil {
+			stats.Add += c.ToAdd
+			stats.Change += c.ToChange
+			stats.Destroy += c.ToDestroy
+			stats.Replace += c.ToReplace
+		}
+	}
+	return stats
+}
+
+// Named metric of the run statistics
+type runMetric struct {
+	Name  string
+	Value *int
+}
+
+// Metrics of the statistics in display order
+func (s *RunStats) metrics() []runMetric {
+	return []runMetric{
+		{"folders", &s.Folders}, {"failures", &s.Failures}, {"adds", &s.Add},
+		{"changes", &s.Change}, {"destroys", &s.Destroy}, {"replaces", &s.Replace},
+	}
+}
+
+// Format the hidden statistics block of the summary comment
+func formatRunStats(stats RunStats) string {
+	fields := []string{"run=" + url.QueryEscape(stats.RunID)}
+	for _, metric := range stats.metrics() {
+		fields = append(fields, fmt.Sprintf("%s=%d", metric.Name, *metric.Value))
+	}
+	return "<!-- " + runStatsPrefix + strings.Join(fields, ";") + " -->\n"
+}
+
+// Parse the statistics block of a summary comment
+func parseRunStats(body string) (RunStats, bool) {
+	m := reRunStats.FindStringSubmatch(body)
+	if m == nil {
+		return RunStats{}, false
+	}
+	var stats RunStats
+	metrics := stats.metrics()
+	for pair := range strings.SplitSeq(m[1], ";") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key == "run" {
+			stats.RunID, _ = url.QueryUnescape(value)
+		} else if i := slices.IndexFunc(metrics, func(m runMetric) bool { return m.Name == key }); i >= 0 {
+			*metrics[i].Value, _ = strconv.Atoi(value)
+		}
+	}
+	return stats, true
+}
+
+// Load the statistics of the latest earlier run with the same label from its
+// summary comment, before the comment is deleted (nil if there is none)
+func (r *Runner) loadPreviousStats(ctx context.Context, client *github.Client) *RunStats {
+	parts := strings.Split(r.config.Repository, "/")
+	comments, err := r.listTargetComments(ctx, client, parts[0], parts[1])
+	if err != nil {
+		r.logger.Warn("Failed to list comments for the previous run statistics", "error", err)
+		return nil
+	}
+	login := r.tokenLogin(ctx, client)
+	var previous *RunStats
+	// Comments are listed oldest first, so the latest summary wins. Anyone can
+	// copy the marker, so only the runner's own summaries count.
+	for _, comment := range comments {
+		fields, ok := parseCommentMarker(comment.Body)
+		if !ok || fields["folder"] != summaryMarkerFolder || fields["label"] != r.config.RunLabel || !isRunnerAuthor(comment.Login, login) {
+			continue
+		}
+		if stats, ok := parseRunStats(comment.Body); ok && stats.RunID != getRunID() {
+			previous = &stats
+		}
+	}
+	return previous
+}
+
+// Get the login the token posts comments as, or "" when the token cannot read
+// its user (GitHub App installation tokens, which post as a bot)
+func (r *Runner) tokenLogin(ctx context.Context, client *github.Client) string {
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		r.logger.Debug("Cannot read the token user, matching bot comments", "error", err)
+		return ""
+	}
+	return user.GetLogin()
+}
+
+// Check whether a comment author is the runner: the token user, or any bot
+// when the token user is unknown
+func isRunnerAuthor(author, login string) bool {
+	if login != "" {
+		return strings.EqualFold(author, login)
+	}
+	return strings.Contains(author, "[bot]")
+}
+
+// Format the changes of the statistics since the previous run, e.g.
+// "destroys: 3 → 0, failures: 2 → 0" (empty without a previous run)
+func (r *Runner) formatRunDelta(results []ExecutionResult) string {
+	if r.prevStats == nil {
+		return ""
+	}
+	current := runStats(results)
+	var changes []string
+	previous := r.prevStats.metrics()
+	for i, metric := range current.metrics() {
+		if *metric.Value != *previous[i].Value {
+			changes = append(changes, fmt.Sprintf("%s: %d → %d", metric.Name, *previous[i].Value, *metric.Value))
+		}
+	}
+	delta := strings.Join(changes, ", ")
+	if len(changes) == 0 {
+		delta = "no changes"
+	}
+	return "**" + r.msg("summary.previous_run", "run", r.prevStats.RunID) + ":** " + delta + "\n\n"
+}
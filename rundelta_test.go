@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunStatsRoundTrip(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "77")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "2")
+	stats := runStats([]ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2, ToDestroy: 1}},
+		{Folder: "live/db", Success: false},
+		{Folder: "live/dns", Success: true, ResourceChanges: &ResourceChanges{ToChange: 3, ToReplace: 1}},
+	})
+	want := RunStats{RunID: "77-2", Folders: 3, Failures: 1, Add: 2, Change: 3, Destroy: 1, Replace: 1}
+	if stats != want {
+		t.Fatalf("runStats() = %+v, want %+v", stats, want)
+	}
+	got, ok := parseRunStats("## Summary\n\n" + formatRunStats(stats) + "\n<sub>Run `77-2`</sub>")
+	if !ok || got != want {
+		t.Errorf("parseRunStats() = %+v, %t, want %+v", got, ok, want)
+	}
+	if _, ok := parseRunStats("## Summary"); ok {
+		t.Error("parseRunStats() found statistics in a summary without them")
+	}
+}
+
+func TestPreviousRunDelta(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "300")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "")
+	summary := func(run, label string, destroys, failures int) string {
+		marker := "<!-- terragrunt-runner:folder=_summary;run=" + run
+		if label != "" {
+			marker += ";label=" + label
+		}
+		stats := formatRunStats(RunStats{RunID: run, Folders: 2, Failures: failures, Destroy: destroys})
+		return strings.ReplaceAll(marker+" -->\n## Terragrunt Summary\n"+stats, "\n", `\n`)
+	}
+	tokenUser := ""
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/user" {
+			if tokenUser == "" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+				return
+			}
+			w.Write([]byte(`{"login": "` + tokenUser + `"}`))
+			return
+		}
+		w.Write([]byte(`[
+			{"id": 1, "body": "` + summary("100", "", 5, 2) + `", "user": {"login": "github-actions[bot]"}},
+			{"id": 2, "body": "` + summary("200", "", 3, 2) + `", "user": {"login": "ci-user"}},
+			{"id": 3, "body": "` + summary("250", "nightly", 9, 0) + `", "user": {"login": "github-actions[bot]"}},
+			{"id": 4, "body": "Looks good, failures: 9", "user": {"login": "octocat"}},
+			{"id": 5, "body": "` + summary("260", "", 0, 0) + `", "user": {"login": "octocat"}}
+		]`))
+	}))
+
+	// A forged summary of another participant is ignored
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, Command: "plan"})
+	if stats := r.loadPreviousStats(context.Background(), client); stats == nil || stats.RunID != "100" {
+		t.Errorf("previous stats with an app token = %+v, want the latest bot summary (run 100)", stats)
+	}
+	tokenUser = "ci-user"
+	r.prevStats = r.loadPreviousStats(context.Background(), client)
+	if r.prevStats == nil || r.prevStats.RunID != "200" {
+		t.Fatalf("previous stats = %+v, want the latest unlabeled summary of the token user (run 200)", r.prevStats)
+	}
+
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 1}},
+		{Folder: "live/db", Success: true, ResourceChanges: &ResourceChanges{NoChanges: true}},
+	}
+	want := "**Since the previous run `200`:** failures: 2 → 0, adds: 0 → 1, destroys: 3 → 0\n\n"
+	if got := r.formatRunDelta(results); got != want {
+		t.Errorf("formatRunDelta() = %q, want %q", got, want)
+	}
+	if summary := r.formatSummary(results); !strings.Contains(summary, want) {
+		t.Errorf("summary lacks the delta:\n%s", summary)
+	}
+
+	r.prevStats = &RunStats{RunID: "200", Folders: 2, Add: 1}
+	if got := r.formatRunDelta(results); !strings.HasSuffix(got, ":** no changes\n\n") {
+		t.Errorf("formatRunDelta() = %q, want no changes", got)
+	}
+	r.prevStats = nil
+	if got := r.formatRunDelta(results); got != "" {
+		t.Errorf("formatRunDelta() = %q without a previous run", got)
+	}
+}
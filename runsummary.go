@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const maxUnitErrorLines = 20 // Maximum lines kept from a unit's error block
+
+// Parsed Terragrunt run --all summary block
+type RunSummary struct {
+	Units      int    // Total number of units in the run
+	Duration   string // Total duration as reported by Terragrunt
+	Succeeded  int    // Units that succeeded
+	Failed     int    // Units that failed
+	Excluded   int    // Units excluded from the run
+	EarlyExits int    // Units that exited early because a dependency failed
+}
+
+var (
+	reRunSummaryHeader = regexp.MustCompile(`Run Summary\s+(\d+)\s+units?(?:\s+(\S+))?`)
+	reRunSummaryCount  = regexp.MustCompile(`^\s*(Succeeded|Failed|Excluded|Early exits)\s+(\d+)\s*$`)
+	reFailedToExecute  = regexp.MustCompile(`Failed to execute "[^"]*" in (\S+)`)
+	reUnitErrorLine    = regexp.MustCompile(`^(?:[│|]\s*)?Error:|(?:^|\s)ERROR\s`)
+	reUnitDiffStart    = regexp.MustCompile(`will perform the following actions:|^Changes to Outputs:`)
+	reUnitCompleted    = regexp.MustCompile(`^(?:Plan: \d+ to add|No changes\.|Apply complete!|Destroy complete!|Success! The configuration is valid)`)
+)
+
+// Parse the run summary block printed at the end of a Terragrunt run --all
+func parseRunSummary(output string) *RunSummary {
+	output = stripAnsiCodes(output)
+	m := reRunSummaryHeader.FindStringSubmatch(output)
+	if m == nil {
+		return nil
+	}
+
+	summary := &RunSummary{Duration: m[2]}
+	summary.Units, _ = strconv.Atoi(m[1])
+
+	scanner := bufio.NewScanner(strings.NewReader(output[strings.Index(output, m[0]):]))
+	for scanner.Scan() {
+		cm := reRunSummaryCount.FindStringSubmatch(scanner.Text())
+		if cm == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(cm[2])
+		switch cm[1] {
+		case "Succeeded":
+			summary.Succeeded = n
+		case "Failed":
+			summary.Failed = n
+		case "Excluded":
+			summary.Excluded = n
+		case "Early exits":
+			summary.EarlyExits = n
+		}
+	}
+	return summary
+}
+
+// Extract the first error block of a unit's output (empty if the unit has no error).
+// Lines inside a plan diff are resource or output values, never diagnostics.
+func extractUnitError(output string) string {
+	lines := strings.Split(stripAnsiCodes(output), "\n")
+	inDiff := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if reUnitDiffStart.MatchString(trimmed) {
+			inDiff = true
+			continue
+		}
+		// Diff content is indented; the first unindented line ends it
+		if inDiff && trimmed != "" && trimmed == strings.TrimRight(line, " \t") {
+			inDiff = false
+		}
+		if inDiff || !reUnitErrorLine.MatchString(trimmed) {
+			continue
+		}
+		// Keep the error with its details until the end of the block
+		var block []string
+		for _, l := range lines[i:] {
+			trimmed := strings.TrimSpace(l)
+			if len(block) > 0 && (trimmed == "" || strings.HasPrefix(trimmed, "╵")) {
+				break
+			}
+			block = append(block, strings.TrimRight(l, " "))
+			if len(block) >= maxUnitErrorLines {
+				break
+			}
+		}
+		return strings.TrimSpace(strings.Join(block, "\n"))
+	}
+	return ""
+}
+
+// Check whether a unit's output shows its command ran to completion
+func unitCompleted(output string) bool {
+	for line := range strings.SplitSeq(stripAnsiCodes(output), "\n") {
+		if reUnitCompleted.MatchString(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Determine a unit's error from its own output and the run's failed units report.
+// The outcome is unknown when the unit neither failed nor ran to completion.
+func unitOutcome(unit, output string, failed map[string]string) (unitErr string, known bool) {
+	if msg := lookupFailedUnit(failed, unit); msg != "" {
+		if unitErr := extractUnitError(output); unitErr != "" {
+			return unitErr, true
+		}
+		return msg, true
+	}
+	if unitErr := extractUnitError(output); unitErr != "" {
+		return unitErr, true
+	}
+	return "", unitCompleted(output) || len(failed) > 0
+}
+
+// Parse Terragrunt's error report for "Failed to execute ... in <dir>" lines and
+// map each failed unit path to its error line
+func parseFailedUnits(output string) map[string]string {
+	failed := make(map[string]string)
+	for line := range strings.SplitSeq(stripAnsiCodes(output), "\n") {
+		m := reFailedToExecute.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		unit := m[1]
+		// Commands run inside the unit's cache dir: ./unit/.terragrunt-cache/<hash>/<hash>
+		if idx := strings.Index(unit, ".terragrunt-cache"); idx >= 0 {
+			unit = unit[:idx]
+		}
+		unit = filepath.Clean(strings.TrimPrefix(unit, "./"))
+		failed[unit] = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "*"))
+	}
+	return failed
+}
+
+// Find the error of a unit in the failed units report by matching path suffixes
+func lookupFailedUnit(failed map[string]string, unit string) string {
+	unit = filepath.Clean(unit)
+	for path, msg := range failed {
+		if path == unit || strings.HasSuffix(path, "/"+unit) || strings.HasSuffix(unit, "/"+path) {
+			return msg
+		}
+	}
+	return ""
+}
+
+// Format run summary counts for the summary comment
+func formatRunSummary(summary *RunSummary) string {
+	s := fmt.Sprintf("- Units: %d (succeeded: %d, failed: %d, excluded: %d", summary.Units, summary.Succeeded, summary.Failed, summary.Excluded)
+	if summary.EarlyExits > 0 {
+		s += fmt.Sprintf(", early exits: %d", summary.EarlyExits)
+	}
+	return s + ")\n"
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRunSummary(t *testing.T) {
+	output := `[account1/baseline] Plan: 1 to add, 0 to change, 0 to destroy.
+
+❯❯ Run Summary  3 units  24s
+   ────────────────────────────────
+   Succeeded    1
+   Failed       1
+   Early exits  1
+`
+	got := parseRunSummary(output)
+	expected := &RunSummary{Units: 3, Duration: "24s", Succeeded: 1, Failed: 1, EarlyExits: 1}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseRunSummary() = %+v, want %+v", got, expected)
+	}
+
+	if got := parseRunSummary("no summary here"); got != nil {
+		t.Errorf("parseRunSummary() = %+v, want nil", got)
+	}
+}
+
+func TestExtractUnitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "terraform error block",
+			input: `Initializing...
+╷
+│ Error: Invalid reference
+│
+│   on main.tf line 3:
+╵
+Releasing state lock`,
+			expected: "│ Error: Invalid reference\n│\n│   on main.tf line 3:",
+		},
+		{
+			name:     "no error",
+			input:    "Plan: 1 to add, 0 to change, 0 to destroy.",
+			expected: "",
+		},
+		{
+			name: "error text inside plan diff",
+			input: `OpenTofu will perform the following actions:
+
+  # aws_ssm_parameter.msg will be created
+  + resource "aws_ssm_parameter" "msg" {
+      + value = "Error: not really"
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractUnitError(tt.input); got != tt.expected {
+				t.Errorf("extractUnitError() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFailedUnits(t *testing.T) {
+	output := `ERROR  error occurred:
+
+* Failed to execute "tofu plan -input=false" in ./account2/baseline/.terragrunt-cache/abc/def
+  exit status 1
+`
+	failed := parseFailedUnits(output)
+	if got := lookupFailedUnit(failed, "account2/baseline"); got != `Failed to execute "tofu plan -input=false" in ./account2/baseline/.terragrunt-cache/abc/def` {
+		t.Errorf("lookupFailedUnit() = %q", got)
+	}
+	if got := lookupFailedUnit(failed, "account1/baseline"); got != "" {
+		t.Errorf("lookupFailedUnit() = %q, want empty", got)
+	}
+}
+
+func TestUnitOutcome(t *testing.T) {
+	failed := map[string]string{"account2/baseline": `Failed to execute "tofu plan" in ./account2/baseline`}
+
+	tests := []struct {
+		name      string
+		unit      string
+		output    string
+		failed    map[string]string
+		wantErr   string
+		wantKnown bool
+	}{
+		{"completed", "account1/baseline", "Plan: 1 to add, 0 to change, 0 to destroy.", nil, "", true},
+		{"own error", "account1/baseline", "Error: Invalid reference", nil, "Error: Invalid reference", true},
+		{"reported failure", "account2/baseline", "Initializing...", failed, failed["account2/baseline"], true},
+		{"not in failure report", "account1/baseline", "Initializing...", failed, "", true},
+		{"unknown", "account1/baseline", "Initializing...", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr, gotKnown := unitOutcome(tt.unit, tt.output, tt.failed)
+			if gotErr != tt.wantErr || gotKnown != tt.wantKnown {
+				t.Errorf("unitOutcome() = (%q, %v), want (%q, %v)", gotErr, gotKnown, tt.wantErr, tt.wantKnown)
+			}
+		})
+	}
+}
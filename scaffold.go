@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Top-level blocks and attributes of a unit file, and the terraform block
+var (
+	reTopLevelItem     = regexp.MustCompile(`(?m)^([A-Za-z_][A-Za-z0-9_-]*)\s*(?:"[^"]*"\s*)*[{=]`)
+	reTerraformBlock   = regexp.MustCompile(`(?ms)^terraform\s*\{(.*?)^\}`)
+	reBlockAttribute   = regexp.MustCompile(`(?m)^\s*([A-Za-z_][A-Za-z0-9_-]*)\s*[{=]`)
+	reScaffoldDocument = regexp.MustCompile(`(?i)^readme(\.\w+)?$`)
+)
+
+// Top-level items terragrunt scaffold and catalog generate in a unit file
+var scaffoldItems = []string{"include", "terraform", "locals", "inputs"}
+
+// Check whether a unit file only holds scaffold boilerplate: includes, a
+// terraform block setting just the module source, locals and inputs.
+// Dependencies, generate and remote_state blocks need a full plan.
+func isScaffoldConfig(content string) bool {
+	items := reTopLevelItem.FindAllStringSubmatch(content, -1)
+	if len(items) == 0 {
+		return false
+	}
+	for _, m := range items {
+		if !slices.Contains(scaffoldItems, m[1]) {
+			return false
+		}
+	}
+	for _, block := range reTerraformBlock.FindAllStringSubmatch(content, -1) {
+		for _, m := range reBlockAttribute.FindAllStringSubmatch(block[1], -1) {
+			if m[1] != "source" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check whether a folder is a unit new since the base ref holding nothing but
+// scaffold boilerplate (its unit file and a README), which is rendered for
+// review instead of planned
+func (r *Runner) isScaffoldUnit(absFolder string) bool {
+	if !r.config.ScaffoldReview || r.config.DiffBase == "" || !isPlanCommand(r.config.Command) {
+		return false
+	}
+	entries, err := os.ReadDir(absFolder)
+	if err != nil {
+		return false
+	}
+	unitFile := ""
+	for _, entry := range entries {
+		switch name := entry.Name(); {
+		case strings.HasPrefix(name, "."):
+		case entry.IsDir():
+			return false
+		case slices.Contains(r.config.TerragruntFiles, name) && filepath.Ext(name) == ".hcl" && unitFile == "":
+			unitFile = name
+		case !reScaffoldDocument.MatchString(name):
+			return false
+		}
+	}
+	if unitFile == "" {
+		return false
+	}
+	content, err := os.ReadFile(filepath.Join(absFolder, unitFile))
+	if err != nil || !isScaffoldConfig(string(content)) {
+		return false
+	}
+
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(repoRoot, filepath.Join(absFolder, unitFile))
+	if err != nil {
+		return false
+	}
+	// The unit file is new when the base ref does not have it
+	return r.runCommand(exec.Command("git", "-C", repoRoot, "cat-file", "-e", r.config.DiffBase+":"+filepath.ToSlash(relPath))) != nil
+}
+
+// Validate that a new scaffolded unit renders, and report its module source and
+// rendered inputs for review instead of a plan, which needs the backend to exist
+func (r *Runner) executeScaffoldRender(folder string) ExecutionResult {
+	r.logger.Info("Rendering new scaffolded unit instead of planning", "folder", folder)
+	config, err := r.renderUnitConfig(folder)
+	if err != nil {
+		return ExecutionResult{Folder: folder, Scaffolded: true, Error: fmt.Errorf("unit does not render: %w", err), Output: err.Error()}
+	}
+	return ExecutionResult{Folder: folder, Scaffolded: true, Success: true, Output: formatRenderedUnit(config)}
+}
+
+// Format the module source and inputs of a rendered unit in HCL style
+func formatRenderedUnit(config *UnitConfig) string {
+	var b strings.Builder
+	if config.Source != "" {
+		fmt.Fprintf(&b, "terraform {\n  source = %q\n}\n\n", config.Source)
+	}
+	b.WriteString("inputs = {\n")
+	width := 0
+	for name := range config.Inputs {
+		width = max(width, len(name))
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Inputs)) {
+		fmt.Fprintf(&b, "  %-*s = %s\n", width, name, renderInputValue(config.Inputs[name]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// Format the note of a comment explaining that a new unit was rendered, not planned
+func formatScaffoldNote(result ExecutionResult) string {
+	if !result.Scaffolded {
+		return ""
+	}
+	if !result.Success {
+		return "🏗️ **New scaffolded unit:** `terragrunt render` failed; fix the unit before it can be planned.\n"
+	}
+	return "🏗️ **New scaffolded unit:** rendered with `terragrunt render` instead of planned, as its backend may not exist yet. Review the module source and inputs below.\n"
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsScaffoldConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{
+			name: "scaffold boilerplate",
+			content: `include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+terraform {
+  source = "git::https://github.com/acme/modules.git//vpc?ref=v1.2.0"
+}
+
+locals {
+  env = "prod"
+}
+
+inputs = {
+  name = "vpc"
+}
+`,
+			expected: true,
+		},
+		{name: "dependency", content: "dependency \"vpc\" {\n  config_path = \"../vpc\"\n}\n\ninputs = {}\n"},
+		{name: "generate", content: "generate \"provider\" {\n  path = \"provider.tf\"\n}\n"},
+		{name: "remote state", content: "remote_state {\n  backend = \"s3\"\n}\n"},
+		{name: "terraform hooks", content: "terraform {\n  source = \"../modules/vpc\"\n\n  before_hook \"lint\" {\n    commands = [\"plan\"]\n  }\n}\n"},
+		{name: "empty", content: "# nothing yet\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isScaffoldConfig(tt.content); got != tt.expected {
+				t.Errorf("isScaffoldConfig() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsScaffoldUnitRequiresReview(t *testing.T) {
+	dir := t.TempDir()
+	content := "terraform {\n  source = \"../modules/vpc\"\n}\n\ninputs = {}\n"
+	for name, data := range map[string]string{"terragrunt.hcl": content, "README.md": "# vpc\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "review disabled", config: Config{Command: "plan", DiffBase: "main"}},
+		{name: "no diff base", config: Config{ScaffoldReview: true, Command: "plan"}},
+		{name: "apply", config: Config{ScaffoldReview: true, Command: "apply", DiffBase: "main"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.TerragruntFiles = []string{"terragrunt.hcl"}
+			if newTestRunner(&tt.config).isScaffoldUnit(dir) {
+				t.Error("isScaffoldUnit() = true, want false")
+			}
+		})
+	}
+
+	r := newTestRunner(&Config{ScaffoldReview: true, Command: "plan", DiffBase: "main", TerragruntFiles: []string{"terragrunt.hcl"}})
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r.isScaffoldUnit(dir) {
+		t.Error("isScaffoldUnit() with other files = true, want false")
+	}
+}
+
+func TestFormatRenderedUnit(t *testing.T) {
+	config := &UnitConfig{
+		Source: "git::https://github.com/acme/modules.git//vpc?ref=v1.2.0",
+		Inputs: map[string]any{"name": "vpc", "cidr_block": "10.0.0.0/16", "azs": 3.0},
+	}
+	expected := `terraform {
+  source = "git::https://github.com/acme/modules.git//vpc?ref=v1.2.0"
+}
+
+inputs = {
+  azs        = 3
+  cidr_block = "10.0.0.0/16"
+  name       = "vpc"
+}`
+	if got := formatRenderedUnit(config); got != expected {
+		t.Errorf("formatRenderedUnit() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestFormatScaffoldNote(t *testing.T) {
+	if got := formatScaffoldNote(ExecutionResult{Success: true}); got != "" {
+		t.Errorf("formatScaffoldNote() for a planned unit = %q, want empty", got)
+	}
+	if got := formatScaffoldNote(ExecutionResult{Scaffolded: true, Success: true}); !strings.Contains(got, "instead of planned") {
+		t.Errorf("formatScaffoldNote() = %q, want the render note", got)
+	}
+	failed := ExecutionResult{Scaffolded: true, Error: errors.New("unit does not render")}
+	if got := formatScaffoldNote(failed); !strings.Contains(got, "failed") {
+		t.Errorf("formatScaffoldNote() for a failed render = %q, want the failure note", got)
+	}
+}
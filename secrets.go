@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	secretMask          = "***MASKED***"
+	minEntropyLength    = 32  // Minimum token length considered for entropy detection
+	minSecretEntropy    = 4.5 // Shannon entropy (bits per char) above which a token is considered a secret
+	secretPreviewLength = 4   // Characters of a match kept when reporting a finding
+)
+
+type secretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Known token formats that must never be posted to a PR
+var secretRules = []secretRule{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws_secret_access_key["'\s:=]+[A-Za-z0-9/+=]{40}`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"Password assignment", regexp.MustCompile(`(?i)\b(?:password|passwd|secret|token)\s*[=:]\s*["']?[^\s"'(]{8,}`)},
+}
+
+// Candidate tokens for entropy based detection (base64/URL-safe alphabets)
+var entropyTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+type SecretFinding struct {
+	Folder  string // Folder whose output contained the secret
+	Line    int    // 1-based line number in the comment output
+	Rule    string // Name of the rule that matched
+	Preview string // Truncated match, safe to log
+}
+
+// Scan output for secrets and return the masked output along with the findings
+func scanSecrets(folder, output string) (string, []SecretFinding) {
+	var findings []SecretFinding
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		for _, rule := range secretRules {
+			line = rule.Pattern.ReplaceAllStringFunc(line, func(match string) string {
+				findings = append(findings, SecretFinding{Folder: folder, Line: i + 1, Rule: rule.Name, Preview: previewSecret(match)})
+				return secretMask
+			})
+		}
+		line = entropyTokenPattern.ReplaceAllStringFunc(line, func(match string) string {
+			if !isHighEntropySecret(match) {
+				return match
+			}
+			findings = append(findings, SecretFinding{Folder: folder, Line: i + 1, Rule: "High entropy string", Preview: previewSecret(match)})
+			return secretMask
+		})
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n"), findings
+}
+
+// Append the findings not already reported with the same rule and preview
+func appendNewFindings(findings, more []SecretFinding) []SecretFinding {
+	for _, f := range more {
+		if !slices.ContainsFunc(findings, func(existing SecretFinding) bool {
+			return existing.Rule == f.Rule && existing.Preview == f.Preview
+		}) {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Check whether a token looks like a random secret rather than an identifier
+func isHighEntropySecret(token string) bool {
+	if len(token) < minEntropyLength {
+		return false
+	}
+	// Require mixed character classes to skip hex digests and plain words
+	hasUpper := strings.ContainsAny(token, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	hasLower := strings.ContainsAny(token, "abcdefghijklmnopqrstuvwxyz")
+	hasDigit := strings.ContainsAny(token, "0123456789")
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	return shannonEntropy(token) >= minSecretEntropy
+}
+
+// Calculate the Shannon entropy of a string in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	for _, r := range s {
+		freq[r]++
+	}
+	var entropy float64
+	length := float64(len([]rune(s)))
+	for _, count := range freq {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Keep only the first characters of a secret so findings can be logged safely
+func previewSecret(match string) string {
+	if len(match) <= secretPreviewLength {
+		return strings.Repeat("*", len(match))
+	}
+	return match[:secretPreviewLength] + "..."
+}
+
+// Mask secrets in all results and report where they were found
+func maskSecretsInResults(results []ExecutionResult) []SecretFinding {
+	var all []SecretFinding
+	for i := range results {
+		masked, findings := scanSecrets(results[i].Folder, results[i].Output)
+		results[i].Output = masked
+		if results[i].FullOutput != "" {
+			maskedFull, fullFindings := scanSecrets(results[i].Folder, results[i].FullOutput)
+			results[i].FullOutput = maskedFull
+			// The full output mostly repeats the extracted output; keep only new secrets
+			findings = appendNewFindings(findings, fullFindings)
+		}
+		// Planned outputs are parsed from the raw output and end up in the outputs file
+		for name, o := range results[i].PlannedOutputs {
+			before, beforeFindings := scanSecrets(results[i].Folder, o.Before)
+			after, afterFindings := scanSecrets(results[i].Folder, o.After)
+			o.Before, o.After = before, after
+			results[i].PlannedOutputs[name] = o
+			findings = appendNewFindings(findings, slices.Concat(beforeFindings, afterFindings))
+		}
+		if results[i].Error != nil {
+			// Error messages are posted verbatim for failed folders
+			if maskedErr, errFindings := scanSecrets(results[i].Folder, results[i].Error.Error()); len(errFindings) > 0 {
+				results[i].Error = errors.New(maskedErr)
+				findings = append(findings, errFindings...)
+			}
+		}
+		all = append(all, findings...)
+	}
+
+	for _, f := range all {
+		fmt.Printf("::warning title=Possible secret in output::%s line %d: %s (%s)\n", escapeAnnotation(f.Folder), f.Line, f.Rule, f.Preview)
+	}
+	return all
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/spf13/cobra"
+)
+
+type ServeConfig struct {
+	ListenAddr     string   // Address the webhook server listens on
+	WebhookSecret  string   // Secret used to validate webhook signatures
+	Workers        int      // Number of concurrent runs
+	QueueSize      int      // Maximum number of queued runs
+	WorkDir        string   // Directory where repositories are checked out
+	CommentTrigger string   // PR comment prefix that triggers a run
+	RoleMapFile    string   // JSON role map authorizing comment-triggered runs (empty = permission levels)
+	RunnerArgs     []string // Arguments passed to each runner invocation
+}
+
+// A run enqueued from a webhook event
+type RunJob struct {
+	DeliveryID  string // GitHub webhook delivery ID
+	Repository  string // Repository in "owner/repo" format
+	CloneURL    string // HTTPS clone URL of the repository
+	PullRequest int    // Pull request number
+	HeadSHA     string // Commit to check out (empty to use the PR head ref)
+	BaseSHA     string // PR base commit for changed files detection
+	Commenter   string // Login of the user whose comment triggered the run (empty for PR events)
+	Action      string // Action requested in the comment (plan, apply; empty = runner default)
+	Folders     string // Space separated folders requested in the comment (empty = auto-detect)
+	Args        string // Extra arguments requested in the comment after "--"
+}
+
+// Create the serve subcommand
+func newServeCmd(logger *slog.Logger) *cobra.Command {
+	serveConfig := &ServeConfig{}
+	cmd := &cobra.Command{
+		Use:   "serve [flags] [-- runner flags]",
+		Short: "Run as a webhook service that executes Terragrunt for pull request events",
+		Long: `Start an HTTP server receiving GitHub webhooks (pull_request, issue_comment).
+Validated events are queued and executed by a worker pool; each run checks out the
+pull request and invokes the runner with the flags given after "--".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serveConfig.RunnerArgs = args
+			return serve(serveConfig, logger)
+		},
+	}
+	cmd.Flags().StringVar(&serveConfig.ListenAddr, "listen", ":8080", "Address to listen on")
+	cmd.Flags().StringVar(&serveConfig.WebhookSecret, "webhook-secret", os.Getenv("GITHUB_WEBHOOK_SECRET"), "Secret used to validate webhook signatures")
+	cmd.Flags().IntVar(&serveConfig.Workers, "workers", 2, "Number of concurrent runs")
+	cmd.Flags().IntVar(&serveConfig.QueueSize, "queue-size", 100, "Maximum number of queued runs")
+	cmd.Flags().StringVar(&serveConfig.WorkDir, "work-dir", os.TempDir(), "Directory where repositories are checked out")
+	cmd.Flags().StringVar(&serveConfig.CommentTrigger, "comment-trigger", "terragrunt-runner run", "PR comment prefix that triggers a run")
+	cmd.Flags().StringVar(&serveConfig.RoleMapFile, "role-map", "", "JSON role map deciding who may plan or apply which folders from comments (default: write may plan, maintain may apply)")
+	return cmd
+}
+
+// Start the webhook server and worker pool until interrupted
+func serve(serveConfig *ServeConfig, logger *slog.Logger) error {
+	if serveConfig.WebhookSecret == "" {
+		return fmt.Errorf("webhook secret is required (--webhook-secret or GITHUB_WEBHOOK_SECRET)")
+	}
+	if serveConfig.Workers < 1 || serveConfig.QueueSize < 1 {
+		return fmt.Errorf("workers and queue-size must be positive")
+	}
+
+	roleMap, err := loadRoleMap(serveConfig.RoleMapFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	jobs := make(chan RunJob, serveConfig.QueueSize)
+	var wg sync.WaitGroup
+	for i := 0; i < serveConfig.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for job := range jobs {
+				logger.Info("Starting run", "worker", worker, "delivery", job.DeliveryID, "repository", job.Repository, "pr", job.PullRequest)
+				if err := runJob(ctx, serveConfig, roleMap, job); err != nil {
+					logger.Error("Run failed", "delivery", job.DeliveryID, "repository", job.Repository, "pr", job.PullRequest, "error", err)
+				} else {
+					logger.Info("Run finished", "delivery", job.DeliveryID, "repository", job.Repository, "pr", job.PullRequest)
+				}
+			}
+		}(i)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("/webhook", newWebhookHandler(serveConfig, jobs, logger))
+
+	server := &http.Server{Addr: serveConfig.ListenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Listening for webhooks", "addr", serveConfig.ListenAddr, "workers", serveConfig.Workers)
+	err = server.ListenAndServe()
+
+	// Stop accepting jobs and let running ones finish
+	close(jobs)
+	wg.Wait()
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Create the HTTP handler validating webhooks and enqueuing runs
+func newWebhookHandler(serveConfig *ServeConfig, jobs chan<- RunJob, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload, err := github.ValidatePayload(r, []byte(serveConfig.WebhookSecret))
+		if err != nil {
+			logger.Warn("Rejected webhook", "error", err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		event, err := github.ParseWebHook(github.WebHookType(r), payload)
+		if err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		job, ok := jobFromEvent(event, serveConfig.CommentTrigger)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		job.DeliveryID = github.DeliveryID(r)
+
+		select {
+		case jobs <- job:
+			logger.Info("Queued run", "delivery", job.DeliveryID, "repository", job.Repository, "pr", job.PullRequest)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.Error(w, "run queue is full", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// Build a run job from a webhook event (false if the event does not trigger a run)
+func jobFromEvent(event any, commentTrigger string) (RunJob, bool) {
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		switch e.GetAction() {
+		case "opened", "synchronize", "reopened":
+		default:
+			return RunJob{}, false
+		}
+		return RunJob{
+			Repository:  e.GetRepo().GetFullName(),
+			CloneURL:    e.GetRepo().GetCloneURL(),
+			PullRequest: e.GetNumber(),
+			HeadSHA:     e.GetPullRequest().GetHead().GetSHA(),
+			BaseSHA:     e.GetPullRequest().GetBase().GetSHA(),
+		}, true
+	case *github.IssueCommentEvent:
+		if e.GetAction() != "created" || !e.GetIssue().IsPullRequest() {
+			return RunJob{}, false
+		}
+		if commentTrigger == "" || !strings.HasPrefix(strings.TrimSpace(e.GetComment().GetBody()), commentTrigger) {
+			return RunJob{}, false
+		}
+		action, folders, args := parseChatOpsCommand(e.GetComment().GetBody(), commentTrigger)
+		// A refresh without exactly one folder must not fall back to a full run
+		if action == ChatOpsRefresh && len(folders) != 1 {
+			return RunJob{}, false
+		}
+		return RunJob{
+			Repository:  e.GetRepo().GetFullName(),
+			CloneURL:    e.GetRepo().GetCloneURL(),
+			PullRequest: e.GetIssue().GetNumber(),
+			Commenter:   e.GetComment().GetUser().GetLogin(),
+			Action:      action,
+			Folders:     strings.Join(folders, " "),
+			Args:        strings.Join(args, " "),
+		}, true
+	}
+	return RunJob{}, false
+}
+
+// Authorize comment-triggered jobs, then check out the pull request and invoke the runner
+func runJob(ctx context.Context, serveConfig *ServeConfig, roleMap *RoleMap, job RunJob) error {
+	action := job.Action
+	if action == "" {
+		action = ChatOpsPlan
+		if isApplyCommand(runnerCommand(serveConfig.RunnerArgs)) {
+			action = ChatOpsApply
+		}
+	}
+	if job.Commenter != "" {
+		// A refresh is a plan of the folder for the role map
+		authzAction := action
+		if action == ChatOpsRefresh {
+			authzAction = ChatOpsPlan
+		}
+		client := github.NewClient(nil).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
+		decision, err := authorizeJob(ctx, client, roleMap, runnerArgPolicy(serveConfig.RunnerArgs), job, authzAction)
+		if err != nil && !decision.Allowed {
+			return err
+		}
+		if !decision.Allowed {
+			return fmt.Errorf("%s is not authorized: %s", job.Commenter, decision.Reason)
+		}
+	}
+
+	dir, err := os.MkdirTemp(serveConfig.WorkDir, "terragrunt-runner-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// The checkout and the runner invocation go to the audit log of the runner arguments
+	r := NewRunner(&Config{AuditLog: runnerFlag(serveConfig.RunnerArgs, "--audit-log", ""), Repository: job.Repository, PullRequest: job.PullRequest}, slog.Default())
+
+	ref := fmt.Sprintf("pull/%d/head", job.PullRequest)
+	gitCmds := [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", job.CloneURL},
+		{"fetch", "-q", "origin", ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	}
+	if job.BaseSHA != "" {
+		gitCmds = append(gitCmds, []string{"fetch", "-q", "origin", job.BaseSHA})
+	}
+	for _, args := range gitCmds {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), gitAuthEnv(os.Getenv("GITHUB_TOKEN"))...)
+		if out, err := r.combinedOutput(cmd); err != nil {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := append([]string{
+		"--repository", job.Repository,
+		"--pull-request", strconv.Itoa(job.PullRequest),
+	}, serveConfig.RunnerArgs...)
+	if job.BaseSHA != "" {
+		args = append(args, "--diff-base", job.BaseSHA)
+	}
+	if job.Action == ChatOpsRefresh {
+		args = append(args, "--command", ChatOpsPlan, "--refresh")
+	} else if job.Action != "" {
+		args = append(args, "--command", job.Action)
+	}
+	if job.Folders != "" {
+		args = append(args, "--folders", job.Folders)
+	}
+	if job.Args != "" {
+		// Comment arguments extend the configured ones; the runner checks them against its policy again
+		args = append(args, "--args", strings.TrimSpace(runnerFlag(serveConfig.RunnerArgs, "--args", "")+" "+job.Args))
+	}
+
+	cmd := exec.CommandContext(ctx, self, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GITHUB_REPOSITORY="+job.Repository, "GITHUB_REPOSITORY_OWNER="+strings.Split(job.Repository, "/")[0])
+	logFile, err := os.Create(filepath.Join(serveConfig.WorkDir, fmt.Sprintf("run-%s-%d.log", strings.ReplaceAll(job.Repository, "/", "_"), job.PullRequest)))
+	if err == nil {
+		defer logFile.Close()
+		cmd.Stdout, cmd.Stderr = logFile, logFile
+	}
+	return r.runCommand(cmd)
+}
+
+// Build git environment passing the token as an HTTP header, keeping it out of
+// the process arguments and the remote URL
+func gitAuthEnv(token string) []string {
+	if token == "" {
+		return nil
+	}
+	basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraheader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + basic,
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func signedWebhookRequest(t *testing.T, secret, event, body string) *http.Request {
+	t.Helper()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestWebhookHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	serveConfig := &ServeConfig{WebhookSecret: "s3cret", CommentTrigger: "terragrunt-runner run"}
+	prEvent := `{"action": "synchronize", "number": 7, "repository": {"full_name": "owner/repo", "clone_url": "https://github.com/owner/repo.git"},
+		"pull_request": {"head": {"sha": "def456"}, "base": {"sha": "abc123"}}}`
+	commentEvent := `{"action": "created", "issue": {"number": 8, "pull_request": {"url": "x"}}, "comment": {"body": "terragrunt-runner run please"},
+		"repository": {"full_name": "owner/repo", "clone_url": "https://github.com/owner/repo.git"}}`
+	applyComment := `{"action": "created", "issue": {"number": 9, "pull_request": {"url": "x"}}, "comment": {"body": "terragrunt-runner run apply live/dev/app", "user": {"login": "alice"}},
+		"repository": {"full_name": "owner/repo", "clone_url": "https://github.com/owner/repo.git"}}`
+	refreshComment := `{"action": "created", "issue": {"number": 9, "pull_request": {"url": "x"}}, "comment": {"body": "terragrunt-runner run refresh live/dev/app", "user": {"login": "alice"}},
+		"repository": {"full_name": "owner/repo", "clone_url": "https://github.com/owner/repo.git"}}`
+	refreshAllComment := `{"action": "created", "issue": {"number": 9, "pull_request": {"url": "x"}}, "comment": {"body": "terragrunt-runner run refresh", "user": {"login": "alice"}},
+		"repository": {"full_name": "owner/repo", "clone_url": "https://github.com/owner/repo.git"}}`
+	otherComment := `{"action": "created", "issue": {"number": 8, "pull_request": {"url": "x"}}, "comment": {"body": "LGTM"},
+		"repository": {"full_name": "owner/repo"}}`
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantJob    *RunJob
+	}{
+		{
+			name:       "pull request synchronize",
+			req:        signedWebhookRequest(t, "s3cret", "pull_request", prEvent),
+			wantStatus: http.StatusAccepted,
+			wantJob:    &RunJob{DeliveryID: "delivery-1", Repository: "owner/repo", CloneURL: "https://github.com/owner/repo.git", PullRequest: 7, HeadSHA: "def456", BaseSHA: "abc123"},
+		},
+		{
+			name:       "trigger comment",
+			req:        signedWebhookRequest(t, "s3cret", "issue_comment", commentEvent),
+			wantStatus: http.StatusAccepted,
+			wantJob:    &RunJob{DeliveryID: "delivery-1", Repository: "owner/repo", CloneURL: "https://github.com/owner/repo.git", PullRequest: 8},
+		},
+		{
+			name:       "apply comment",
+			req:        signedWebhookRequest(t, "s3cret", "issue_comment", applyComment),
+			wantStatus: http.StatusAccepted,
+			wantJob:    &RunJob{DeliveryID: "delivery-1", Repository: "owner/repo", CloneURL: "https://github.com/owner/repo.git", PullRequest: 9, Commenter: "alice", Action: "apply", Folders: "live/dev/app"},
+		},
+		{
+			name:       "refresh comment",
+			req:        signedWebhookRequest(t, "s3cret", "issue_comment", refreshComment),
+			wantStatus: http.StatusAccepted,
+			wantJob:    &RunJob{DeliveryID: "delivery-1", Repository: "owner/repo", CloneURL: "https://github.com/owner/repo.git", PullRequest: 9, Commenter: "alice", Action: "refresh", Folders: "live/dev/app"},
+		},
+		{
+			name:       "refresh comment without folder",
+			req:        signedWebhookRequest(t, "s3cret", "issue_comment", refreshAllComment),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "unrelated comment",
+			req:        signedWebhookRequest(t, "s3cret", "issue_comment", otherComment),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "invalid signature",
+			req:        signedWebhookRequest(t, "wrong", "pull_request", prEvent),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := make(chan RunJob, 1)
+			rec := httptest.NewRecorder()
+			newWebhookHandler(serveConfig, jobs, logger).ServeHTTP(rec, tt.req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantJob == nil {
+				if len(jobs) != 0 {
+					t.Errorf("unexpected job queued: %+v", <-jobs)
+				}
+				return
+			}
+			if got := <-jobs; got != *tt.wantJob {
+				t.Errorf("queued job = %+v, want %+v", got, *tt.wantJob)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const setupErrorMarkerFolder = "_setup-error" // Marker folder key of the setup error comment
+
+// Errors of Terragrunt failing to launch Terraform/OpenTofu
+var reSetupFailure = regexp.MustCompile(`exec: "[^"]+": executable file not found in \$PATH` +
+	`|fork/exec \S+: (?:no such file or directory|permission denied)`)
+
+// Check whether an execution failed because of its environment (missing
+// binary, PATH or working directory) rather than a Terragrunt failure
+func isSetupError(err error, output string) bool {
+	if err == nil {
+		return false
+	}
+	var execErr *exec.Error
+	var pathErr *fs.PathError
+	if errors.As(err, &execErr) || errors.As(err, &pathErr) {
+		return true
+	}
+	return reSetupFailure.MatchString(stripAnsiCodes(output))
+}
+
+// Get the line explaining a setup failure
+func setupErrorLine(result ExecutionResult) string {
+	if line := reSetupFailure.FindString(stripAnsiCodes(result.FullOutput + "\n" + result.Output)); line != "" {
+		return line
+	}
+	if result.Error != nil {
+		return result.Error.Error()
+	}
+	return firstErrorLine(result)
+}
+
+// Split results into those that failed on setup and the others
+func splitSetupErrors(results []ExecutionResult) (setup, rest []ExecutionResult) {
+	for _, result := range results {
+		if result.SetupError {
+			setup = append(setup, result)
+		} else {
+			rest = append(rest, result)
+		}
+	}
+	return setup, rest
+}
+
+// Format the setup error comment: the failures and the installed tools
+func formatSetupError(failures []ExecutionResult, tools []DoctorCheck) string {
+	var b strings.Builder
+	b.WriteString("## 🧰 Terragrunt Setup Error\n\n")
+	b.WriteString(fmt.Sprintf("Terragrunt could not run in %d folders because of the runner environment, not the Terragrunt configuration:\n\n", len(failures)))
+	for _, result := range failures {
+		b.WriteString(fmt.Sprintf("- `%s`: %s\n", result.Folder, setupErrorLine(result)))
+	}
+
+	icons := map[string]string{DoctorPass: "✅", DoctorWarn: "⚠️", DoctorFail: "❌"}
+	b.WriteString("\n### Installed tools\n\n| Tool | Status |\n|------|--------|\n")
+	for _, tool := range tools {
+		b.WriteString(fmt.Sprintf("| %s | %s %s |\n", tool.Name, icons[tool.Status], tool.Detail))
+	}
+	b.WriteString(fmt.Sprintf("\n**PATH:** `%s`\n", os.Getenv("PATH")))
+	b.WriteString("\nInstall the tools before the runner, e.g. with the `terragrunt-version` and `opentofu-version` or `terraform-version` inputs, or run `terragrunt-runner doctor` to check the environment.\n")
+	return b.String()
+}
+
+// Post the setup error comment with a report of the installed tools
+func (r *Runner) postSetupError(ctx context.Context, client *github.Client, failures []ExecutionResult) error {
+	parts := strings.Split(r.config.Repository, "/")
+	return r.createComment(ctx, client, parts[0], parts[1], setupErrorMarkerFolder, formatSetupError(failures, checkTools()))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestIsSetupError(t *testing.T) {
+	missing := exec.Command("terragrunt-runner-no-such-binary").Run()
+	badDir := exec.Command("true")
+	badDir.Dir = "/nonexistent/terragrunt-runner"
+
+	tests := []struct {
+		name   string
+		err    error
+		output string
+		want   bool
+	}{
+		{"success", nil, "", false},
+		{"missing binary", missing, "", true},
+		{"missing working directory", badDir.Run(), "", true},
+		{"terraform not in PATH", errors.New("exit status 1"), "ERROR exec: \"tofu\": executable file not found in $PATH", true},
+		{"terraform not executable", errors.New("exit status 1"), "fork/exec /usr/local/bin/terraform: permission denied", true},
+		{"plan failure", errors.New("exit status 1"), "Error: Invalid reference", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSetupError(tt.err, tt.output); got != tt.want {
+				t.Errorf("isSetupError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSetupErrors(t *testing.T) {
+	results := []ExecutionResult{{Folder: "a", SetupError: true}, {Folder: "b", Success: true}, {Folder: "c", SetupError: true}}
+	setup, rest := splitSetupErrors(results)
+	if len(setup) != 2 || len(rest) != 1 || rest[0].Folder != "b" {
+		t.Errorf("splitSetupErrors() = %v, %v", setup, rest)
+	}
+}
+
+func TestFormatSetupError(t *testing.T) {
+	failures := []ExecutionResult{
+		{Folder: "live/app", Error: errors.New("exit status 1"), FullOutput: "time=... ERROR exec: \"tofu\": executable file not found in $PATH\n", SetupError: true},
+		{Folder: "live/db", Error: &exec.Error{Name: "terragrunt", Err: exec.ErrNotFound}, SetupError: true},
+	}
+	tools := []DoctorCheck{
+		{Name: "terragrunt", Status: DoctorPass, Detail: "terragrunt version v0.88.1 (/usr/local/bin/terragrunt)"},
+		{Name: "tofu", Status: DoctorFail, Detail: "not found in PATH"},
+	}
+	body := formatSetupError(failures, tools)
+	for _, want := range []string{
+		"## 🧰 Terragrunt Setup Error",
+		"could not run in 2 folders",
+		"- `live/app`: exec: \"tofu\": executable file not found in $PATH",
+		"- `live/db`: exec: \"terragrunt\": executable file not found in $PATH",
+		"| tofu | ❌ not found in PATH |",
+		"**PATH:**",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatSetupError() missing %q in:\n%s", want, body)
+		}
+	}
+}
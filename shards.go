@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Group run --all folders by account directory. The account of a folder is the
+// nearest ancestor (below the root dir) containing the account marker file, or the
+// first AccountDepth directories below the root dir when a depth is configured.
+// Folders outside any account are grouped under the root dir itself.
+func (r *Runner) groupFoldersByAccount(repoRoot string) map[string][]string {
+	root := filepath.Clean(r.config.RunAllRootDir)
+	groups := make(map[string][]string)
+	for _, folder := range r.config.Folders {
+		account := r.accountDir(repoRoot, root, folder)
+		groups[account] = append(groups[account], folder)
+	}
+	return groups
+}
+
+// Find the account directory of a folder (relative to the repo root, root if none)
+func (r *Runner) accountDir(repoRoot, root, folder string) string {
+	rel := filepath.Clean(folder)
+	if filepath.IsAbs(rel) {
+		var err error
+		if rel, err = filepath.Rel(repoRoot, rel); err != nil {
+			return root
+		}
+	}
+	below, err := filepath.Rel(root, rel)
+	if err != nil || below == "." || strings.HasPrefix(below, "..") {
+		return root
+	}
+	parts := strings.Split(below, string(filepath.Separator))
+
+	if r.config.AccountDepth > 0 {
+		if len(parts) <= r.config.AccountDepth {
+			return root
+		}
+		return filepath.Join(append([]string{root}, parts[:r.config.AccountDepth]...)...)
+	}
+	if r.config.AccountMarker == "" {
+		return root
+	}
+	for i := len(parts); i > 0; i-- {
+		dir := filepath.Join(append([]string{root}, parts[:i]...)...)
+		if _, err := os.Stat(filepath.Join(repoRoot, dir, r.config.AccountMarker)); err == nil {
+			return dir
+		}
+	}
+	return root
+}
+
+// Run one run --all per shard (account directory or root) in parallel, each from
+// its directory, and merge the results as if they came from a single run
+func (r *Runner) executeTerragruntAllSharded(shards map[string][]string) []ExecutionResult {
+	accounts := make([]string, 0, len(shards))
+	for account := range shards {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	r.logger.Info("Splitting run --all into shards", "shards", accounts)
+
+	shardResults := make([][]ExecutionResult, len(accounts))
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		shardConfig := *r.config
+		shardConfig.RunAllRootDir = account
+		shardConfig.Folders = shards[account]
+		shard := NewRunner(&shardConfig, r.logger.With("shard", account))
+		shard.progress = r.progress
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shardResults[i] = shard.executeTrackedRunAll()
+		}()
+	}
+	wg.Wait()
+
+	overall := ExecutionResult{
+		Folder:          r.config.RunAllRootDir,
+		ResourceChanges: &ResourceChanges{},
+		Success:         true,
+	}
+	var outputs []string
+	var errs []error
+	var folderResults []ExecutionResult
+	for i, results := range shardResults {
+		if len(results) == 0 {
+			continue
+		}
+		shard := results[0]
+		outputs = append(outputs, fmt.Sprintf("### %s\n\n%s", accounts[i], shard.Output))
+		if shard.Error != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", accounts[i], shard.Error))
+		}
+		overall.Success = overall.Success && shard.Success
+		overall.Duration = max(overall.Duration, shard.Duration)
+		addResourceChanges(overall.ResourceChanges, shard.ResourceChanges)
+		overall.RunSummary = mergeRunSummaries(overall.RunSummary, shard.RunSummary)
+		// Shards without folder results only carry their overall result
+		if len(results) > 1 && shard.Folder == accounts[i] {
+			for _, result := range results[1:] {
+				result.Folder = qualifyShardFolder(accounts[i], result.Folder)
+				folderResults = append(folderResults, result)
+			}
+		}
+	}
+	overall.Output = strings.Join(outputs, "\n\n")
+	overall.Engine = resultsEngine(folderResults)
+	overall.Error = errors.Join(errs...)
+	return append([]ExecutionResult{overall}, folderResults...)
+}
+
+// Add the resource changes of a shard to the totals
+func addResourceChanges(total, changes *ResourceChanges) {
+	if changes == nil {
+		return
+	}
+	total.ToAdd += changes.ToAdd
+	total.ToChange += changes.ToChange
+	total.ToDestroy += changes.ToDestroy
+	total.ToReplace += changes.ToReplace
+}
+
+// Merge the run summaries of two shards (nil if neither has one)
+func mergeRunSummaries(a, b *RunSummary) *RunSummary {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	duration := a.Duration
+	if da, errA := time.ParseDuration(a.Duration); errA == nil {
+		if db, errB := time.ParseDuration(b.Duration); errB == nil && db > da {
+			duration = b.Duration
+		}
+	}
+	return &RunSummary{
+		Units:      a.Units + b.Units,
+		Duration:   duration,
+		Succeeded:  a.Succeeded + b.Succeeded,
+		Failed:     a.Failed + b.Failed,
+		Excluded:   a.Excluded + b.Excluded,
+		EarlyExits: a.EarlyExits + b.EarlyExits,
+	}
+}
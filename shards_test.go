@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGroupFoldersByAccount(t *testing.T) {
+	repoRoot := t.TempDir()
+	for _, account := range []string{"live/prod", "live/dev"} {
+		if err := os.MkdirAll(filepath.Join(repoRoot, account), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoRoot, account, "account.hcl"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	folders := []string{"live/prod/us-east-1/vpc", "live/prod/us-east-1/app", "live/dev/us-east-1/vpc", "live/shared/dns"}
+	r := newTestRunner(&Config{RunAllRootDir: "live", Folders: folders, AccountMarker: "account.hcl"})
+	expected := map[string][]string{
+		"live/prod": {"live/prod/us-east-1/vpc", "live/prod/us-east-1/app"},
+		"live/dev":  {"live/dev/us-east-1/vpc"},
+		"live":      {"live/shared/dns"},
+	}
+	if got := r.groupFoldersByAccount(repoRoot); !reflect.DeepEqual(got, expected) {
+		t.Errorf("groupFoldersByAccount() = %v, want %v", got, expected)
+	}
+
+	r.config.AccountDepth = 2
+	expected = map[string][]string{
+		"live/prod/us-east-1": {"live/prod/us-east-1/vpc", "live/prod/us-east-1/app"},
+		"live/dev/us-east-1":  {"live/dev/us-east-1/vpc"},
+		"live":                {"live/shared/dns"},
+	}
+	if got := r.groupFoldersByAccount(repoRoot); !reflect.DeepEqual(got, expected) {
+		t.Errorf("groupFoldersByAccount() with depth = %v, want %v", got, expected)
+	}
+}
+
+func TestMergeRunSummaries(t *testing.T) {
+	a := &RunSummary{Units: 2, Duration: "24s", Succeeded: 2}
+	b := &RunSummary{Units: 3, Duration: "1m2s", Succeeded: 1, Failed: 1, EarlyExits: 1}
+	expected := &RunSummary{Units: 5, Duration: "1m2s", Succeeded: 3, Failed: 1, EarlyExits: 1}
+	if got := mergeRunSummaries(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("mergeRunSummaries() = %+v, want %+v", got, expected)
+	}
+	if got := mergeRunSummaries(nil, b); got != b {
+		t.Errorf("mergeRunSummaries(nil, b) = %+v, want b", got)
+	}
+}
+
+func TestAddResourceChanges(t *testing.T) {
+	total := &ResourceChanges{ToAdd: 1}
+	addResourceChanges(total, &ResourceChanges{ToAdd: 2, ToDestroy: 1})
+	addResourceChanges(total, nil)
+	if total.ToAdd != 3 || total.ToDestroy != 1 {
+		t.Errorf("addResourceChanges() = %+v", total)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Summary signing methods
+const (
+	SignSigstore = "sigstore" // Keyless signature with cosign and the workflow OIDC identity
+	SignKey      = "key"      // Signature with a provided PKCS#8 private key
+)
+
+const githubOIDCIssuer = "https://token.actions.githubusercontent.com"
+
+var reSummarySignature = regexp.MustCompile(`\n\n<sub>🔏[^\n]*</sub><!-- terragrunt-runner-signature:([A-Za-z0-9+/=]+) -->`)
+
+// Canonical results JSON covered by the summary signature
+type SignedResults struct {
+	Version       int            `json:"version"`
+	Repository    string         `json:"repository"`
+	Target        string         `json:"target"` // pr/<number>, commit/<sha> or issue/<number>
+	RunID         string         `json:"run_id"`
+	Command       string         `json:"command"`
+	Results       []SignedResult `json:"results"`
+	SummarySHA256 string         `json:"summary_sha256"` // Digest of the visible summary text
+}
+
+// Result of a folder in the signed results
+type SignedResult struct {
+	Folder    string `json:"folder"`
+	Success   bool   `json:"success"`
+	NoChanges bool   `json:"no_changes"`
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Destroy   int    `json:"destroy"`
+	Replace   int    `json:"replace"`
+}
+
+// Detached signature embedded in the summary comment
+type SummarySignature struct {
+	Method    string `json:"method"`              // sigstore or key
+	Payload   []byte `json:"payload"`             // Canonical results JSON
+	Signature []byte `json:"signature,omitempty"` // Key signature of the payload
+	Bundle    []byte `json:"bundle,omitempty"`    // Sigstore bundle of the payload
+}
+
+// Digest of a summary text, independent of line endings
+func summaryDigest(summary string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(summary, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get the results target as recorded in the signed results
+func (r *Runner) signedTarget() string {
+	switch r.config.Target {
+	case TargetCommit, TargetIssue:
+		return r.config.Target + "/" + r.config.TargetID
+	}
+	return fmt.Sprintf("pr/%d", r.config.PullRequest)
+}
+
+// Build the canonical results JSON of a summary
+func (r *Runner) canonicalResults(results []ExecutionResult, summary string) ([]byte, error) {
+	signed := SignedResults{
+		Version:       1,
+		Repository:    r.config.Repository,
+		Target:        r.signedTarget(),
+		RunID:         getRunID(),
+		Command:       r.config.Command,
+		Results:       []SignedResult{},
+		SummarySHA256: summaryDigest(summary),
+	}
+	for _, result := range results {
+		s := SignedResult{Folder: result.Folder, Success: result.Success}
+		if c := result.ResourceChanges; c != nil {
+			s.NoChanges, s.Add, s.Change, s.Destroy, s.Replace = c.NoChanges, c.ToAdd, c.ToChange, c.ToDestroy, c.ToReplace
+		}
+		signed.Results = append(signed.Results, s)
+	}
+	return json.Marshal(signed)
+}
+
+// Sign the results of a summary, returning the signature block appended to it
+func (r *Runner) signSummary(results []ExecutionResult, summary string) (string, error) {
+	payload, err := r.canonicalResults(results, summary)
+	if err != nil {
+		return "", err
+	}
+	sig := SummarySignature{Method: r.config.SignSummary, Payload: payload}
+	switch r.config.SignSummary {
+	case SignKey:
+		sig.Signature, err = signWithKey(r.config.SigningKey, payload)
+	case SignSigstore:
+		sig.Bundle, err = r.signWithSigstore(payload)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign summary: %w", err)
+	}
+
+	encoded, err := json.Marshal(sig)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("\n\n<sub>🔏 Results signed (%s), check with `terragrunt-runner verify`</sub><!-- terragrunt-runner-signature:%s -->",
+		sig.Method, base64.StdEncoding.EncodeToString(encoded)), nil
+}
+
+// Hash a payload for the key type: Ed25519 signs the message itself
+func signingDigest(key any, payload []byte) ([]byte, crypto.Hash) {
+	if _, ok := key.(ed25519.PublicKey); ok {
+		return payload, crypto.Hash(0)
+	}
+	sum := sha256.Sum256(payload)
+	return sum[:], crypto.SHA256
+}
+
+// Sign a payload with a PEM PKCS#8 private key (Ed25519, ECDSA or RSA)
+func signWithKey(path string, payload []byte) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM key in %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s (expected unencrypted PKCS#8): %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	digest, hash := signingDigest(signer.Public(), payload)
+	return signer.Sign(rand.Reader, digest, hash)
+}
+
+// Verify a key signature of a payload with a PEM PKIX public key
+func verifyWithKey(path string, payload, signature []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM key in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+	digest, _ := signingDigest(key, payload)
+	valid := false
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, digest, signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	if !valid {
+		return fmt.Errorf("signature does not match the results")
+	}
+	return nil
+}
+
+// Sign a payload keyless with cosign, returning the Sigstore bundle
+func (r *Runner) signWithSigstore(payload []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "terragrunt-runner-sign-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	payloadPath, bundlePath := filepath.Join(dir, "results.json"), filepath.Join(dir, "results.sigstore.json")
+	if err := os.WriteFile(payloadPath, payload, 0o600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("cosign", "sign-blob", "--yes", "--bundle", bundlePath, payloadPath)
+	cmd.Env = os.Environ() // cosign needs the workflow OIDC token request variables
+	if out, err := r.combinedOutput(cmd); err != nil {
+		return nil, fmt.Errorf("cosign sign-blob: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(bundlePath)
+}
+
+// Verify a Sigstore bundle of a payload with cosign
+func verifyWithSigstore(payload, bundle []byte, identityRegexp, issuer string) error {
+	dir, err := os.MkdirTemp("", "terragrunt-runner-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	payloadPath, bundlePath := filepath.Join(dir, "results.json"), filepath.Join(dir, "results.sigstore.json")
+	if err := os.WriteFile(payloadPath, payload, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(bundlePath, bundle, 0o600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("cosign", "verify-blob", "--bundle", bundlePath,
+		"--certificate-identity-regexp", identityRegexp, "--certificate-oidc-issuer", issuer, payloadPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cosign verify-blob: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Split a signed summary comment into its visible summary and signature
+func parseSignedSummary(comment string) (string, *SummarySignature, error) {
+	comment = strings.ReplaceAll(comment, "\r\n", "\n")
+	loc := reSummarySignature.FindStringSubmatchIndex(comment)
+	if loc == nil {
+		return "", nil, fmt.Errorf("no summary signature found")
+	}
+	encoded, err := base64.StdEncoding.DecodeString(comment[loc[2]:loc[3]])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid summary signature: %w", err)
+	}
+	var sig SummarySignature
+	if err := json.Unmarshal(encoded, &sig); err != nil {
+		return "", nil, fmt.Errorf("invalid summary signature: %w", err)
+	}
+
+	summary := comment[:loc[0]]
+	if strings.HasPrefix(summary, "<!-- "+commentMarkerPrefix) {
+		_, summary, _ = strings.Cut(summary, "\n") // Hidden marker added when posting
+	}
+	return summary, &sig, nil
+}
+
+// Options of the verify subcommand
+type verifyOptions struct {
+	PublicKey      string // PEM public key of key signatures
+	Repository     string // Repository the results must be signed for ("owner/repo")
+	IdentityRegexp string // Signer identity of Sigstore signatures (default: the workflows of Repository)
+	OIDCIssuer     string // OIDC issuer of Sigstore signatures
+}
+
+// Verify a signed summary comment, returning its signed results
+func verifySummaryComment(comment string, opts verifyOptions) (*SignedResults, error) {
+	summary, sig, err := parseSignedSummary(comment)
+	if err != nil {
+		return nil, err
+	}
+	var results SignedResults
+	if err := json.Unmarshal(sig.Payload, &results); err != nil {
+		return nil, fmt.Errorf("invalid signed results: %w", err)
+	}
+
+	switch sig.Method {
+	case SignKey:
+		if opts.PublicKey == "" {
+			return nil, fmt.Errorf("summary is signed with a key: --public-key is required")
+		}
+		err = verifyWithKey(opts.PublicKey, sig.Payload, sig.Signature)
+	case SignSigstore:
+		// The trust anchor comes from the verifier, never from the signed payload
+		identity := opts.IdentityRegexp
+		if identity == "" && opts.Repository == "" {
+			return nil, fmt.Errorf("summary is signed with sigstore: --repository or --certificate-identity-regexp is required")
+		}
+		if identity == "" {
+			identity = "^https://github.com/" + regexp.QuoteMeta(opts.Repository) + "/"
+		}
+		err = verifyWithSigstore(sig.Payload, sig.Bundle, identity, opts.OIDCIssuer)
+	default:
+		return nil, fmt.Errorf("unknown signature method: %s", sig.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Repository != "" && !strings.EqualFold(results.Repository, opts.Repository) {
+		return nil, fmt.Errorf("summary was signed for %s, not %s", results.Repository, opts.Repository)
+	}
+
+	// A valid signature only covers the payload: the visible summary must be the one signed
+	if summaryDigest(summary) != results.SummarySHA256 {
+		return nil, fmt.Errorf("summary text was altered after signing")
+	}
+	return &results, nil
+}
+
+// Create the verify subcommand
+func newVerifyCmd() *cobra.Command {
+	var opts verifyOptions
+	cmd := &cobra.Command{
+		Use:   "verify <comment-file|->",
+		Short: "Verify the signature of a summary comment",
+		Long: `Verify a summary comment signed with sign-summary: the signature of its results
+JSON, and that its visible text is the one that was signed. Read the comment body
+from a file or stdin, e.g.:
+
+  gh api repos/OWNER/REPO/issues/comments/ID --jq .body | terragrunt-runner verify --public-key results.pub -
+
+Sigstore signatures are checked with cosign, which must be on PATH, against the
+workflows of --repository or --certificate-identity-regexp.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var comment []byte
+			var err error
+			if args[0] == "-" {
+				comment, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				comment, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			results, err := verifySummaryComment(string(comment), opts)
+			if err != nil {
+				return fmt.Errorf("verification failed: %w", err)
+			}
+
+			var b bytes.Buffer
+			fmt.Fprintf(&b, "Verified summary of run %s on %s (%s): %s\n", results.RunID, results.Repository, results.Target, results.Command)
+			for _, result := range results.Results {
+				status := "success"
+				if !result.Success {
+					status = "failed"
+				}
+				fmt.Fprintf(&b, "  %s: %s +%d ~%d -%d /%d\n", result.Folder, status, result.Add, result.Change, result.Destroy, result.Replace)
+			}
+			_, err = b.WriteTo(cmd.OutOrStdout())
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&opts.PublicKey, "public-key", "", "PEM public key of the signing key (key signatures)")
+	cmd.Flags().StringVar(&opts.Repository, "repository", "", "Repository (owner/repo) the summary must be signed for; Sigstore signatures must come from its workflows")
+	cmd.Flags().StringVar(&opts.IdentityRegexp, "certificate-identity-regexp", "", "Signer identity of Sigstore signatures (default: workflows of --repository)")
+	cmd.Flags().StringVar(&opts.OIDCIssuer, "certificate-oidc-issuer", githubOIDCIssuer, "OIDC issuer of Sigstore signatures")
+	return cmd
+}
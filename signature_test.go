@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Write a PKCS#8 private key and its PKIX public key as PEM files
+func writeTestKeyPair(t *testing.T, key crypto.Signer) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath, publicPath := filepath.Join(dir, "results.key"), filepath.Join(dir, "results.pub")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600)
+	os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o644)
+	return privatePath, publicPath
+}
+
+// Sign a summary as posted: marker, summary, signature block and footer
+func signedTestComment(t *testing.T, r *Runner, results []ExecutionResult) string {
+	t.Helper()
+	summary := r.formatSummary(results)
+	signature, err := r.signSummary(results, summary)
+	if err != nil {
+		t.Fatalf("signSummary() error = %v", err)
+	}
+	return commentMarker(summaryMarkerFolder, "") + "\n" + summary + signature + commentFooter("")
+}
+
+func TestSignedSummaryWithKey(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherPublic := writeTestKeyPair(t, otherKey)
+
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2, ToDestroy: 1}},
+		{Folder: "live/db", Success: false},
+	}
+	for name, key := range map[string]crypto.Signer{"ed25519": edKey, "ecdsa": ecKey} {
+		t.Run(name, func(t *testing.T) {
+			private, public := writeTestKeyPair(t, key)
+			r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 7, Command: "plan", SignSummary: SignKey, SigningKey: private})
+			comment := signedTestComment(t, r, results)
+
+			signed, err := verifySummaryComment(comment, verifyOptions{PublicKey: public})
+			if err != nil {
+				t.Fatalf("verifySummaryComment() error = %v", err)
+			}
+			if signed.Target != "pr/7" || len(signed.Results) != 2 || signed.Results[0].Add != 2 || signed.Results[1].Success {
+				t.Errorf("signed results = %+v", signed)
+			}
+
+			// Line endings normalized by the API do not break the signature
+			if _, err := verifySummaryComment(strings.ReplaceAll(comment, "\n", "\r\n"), verifyOptions{PublicKey: public}); err != nil {
+				t.Errorf("verifySummaryComment() with CRLF error = %v", err)
+			}
+			tampered := strings.Replace(comment, "| live/db | ❌", "| live/db | ✅", 1)
+			if _, err := verifySummaryComment(tampered, verifyOptions{PublicKey: public}); err == nil || !strings.Contains(err.Error(), "altered") {
+				t.Errorf("verifySummaryComment() of an edited summary error = %v, want altered", err)
+			}
+			if _, err := verifySummaryComment(comment, verifyOptions{PublicKey: public, Repository: "other/repo"}); err == nil || !strings.Contains(err.Error(), "signed for") {
+				t.Errorf("verifySummaryComment() for another repository error = %v, want a mismatch", err)
+			}
+			if _, err := verifySummaryComment(comment, verifyOptions{PublicKey: otherPublic}); err == nil || !strings.Contains(err.Error(), "does not match") {
+				t.Errorf("verifySummaryComment() with another key error = %v, want mismatch", err)
+			}
+			if _, err := verifySummaryComment(comment, verifyOptions{}); err == nil || !strings.Contains(err.Error(), "--public-key") {
+				t.Errorf("verifySummaryComment() without key error = %v", err)
+			}
+		})
+	}
+
+	if _, err := verifySummaryComment("## Terragrunt Summary\n", verifyOptions{}); err == nil || !strings.Contains(err.Error(), "no summary signature") {
+		t.Errorf("verifySummaryComment() of an unsigned summary error = %v", err)
+	}
+}
+
+// Fake cosign: sign-blob writes a bundle recording the payload, verify-blob
+// checks the payload against it and logs the identity flags
+const fakeCosignScript = `#!/bin/sh
+echo "$*" >> "$FAKE_COSIGN_LOG"
+case "$1" in
+sign-blob) printf '{"payload":"%s"}' "$(cat "$5")" | base64 | tr -d '\n' > "$4" ;;
+verify-blob) [ "$(cat "$3")" = "$(printf '{"payload":"%s"}' "$(cat "$8")" | base64 | tr -d '\n')" ] ;;
+esac
+`
+
+func TestSignedSummaryWithSigstore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cosign"), []byte(fakeCosignScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_COSIGN_LOG", filepath.Join(dir, "cosign.log"))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", Target: TargetIssue, TargetID: "42", Command: "plan", SignSummary: SignSigstore})
+	comment := signedTestComment(t, r, []ExecutionResult{{Folder: "live/app", Success: true}})
+	if _, err := verifySummaryComment(comment, verifyOptions{OIDCIssuer: githubOIDCIssuer}); err == nil || !strings.Contains(err.Error(), "--repository") {
+		t.Errorf("verifySummaryComment() without a trusted identity error = %v, want --repository required", err)
+	}
+	if _, err := verifySummaryComment(comment, verifyOptions{Repository: "other/repo", OIDCIssuer: githubOIDCIssuer}); err == nil {
+		t.Error("verifySummaryComment() for another repository = nil, want an error")
+	}
+	signed, err := verifySummaryComment(comment, verifyOptions{Repository: "owner/repo", OIDCIssuer: githubOIDCIssuer})
+	if err != nil {
+		t.Fatalf("verifySummaryComment() error = %v", err)
+	}
+	if signed.Target != "issue/42" {
+		t.Errorf("target = %s, want issue/42", signed.Target)
+	}
+
+	log, _ := os.ReadFile(filepath.Join(dir, "cosign.log"))
+	if !bytes.Contains(log, []byte("--certificate-identity-regexp ^https://github.com/owner/repo/ --certificate-oidc-issuer "+githubOIDCIssuer)) {
+		t.Errorf("cosign calls = %s, want the repository workflow identity", log)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Unit left out of the run by its own configuration
+type SkippedUnit struct {
+	Folder string // Unit folder
+	Reason string // Configuration that excludes it
+}
+
+var (
+	reSkipAttr       = regexp.MustCompile(`(?m)^\s*skip\s*=\s*true\s*(?:(?:#|//).*)?$`)
+	reExcludeBlock   = regexp.MustCompile(`(?m)^\s*exclude\s*\{`)
+	reExcludeIfTrue  = regexp.MustCompile(`(?m)^\s*if\s*=\s*true\s*(?:(?:#|//).*)?$`)
+	reExcludeActions = regexp.MustCompile(`actions\s*=\s*\[([^\]]*)\]`)
+)
+
+// Get the Terraform command run in each unit ("plan" for "run --all plan")
+func commandAction(command string) string {
+	for _, field := range strings.Fields(command) {
+		if strings.HasPrefix(field, "-") || field == "run" || field == "run-all" {
+			continue
+		}
+		return field
+	}
+	return ""
+}
+
+// Extract the body of the block whose opening brace ends at start
+func blockBody(content string, start int) string {
+	depth := 1
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return content[start:i]
+			}
+		}
+	}
+	return content[start:]
+}
+
+// Check whether a unit configuration excludes it from the action, returning the
+// reason. Only literal conditions are understood: exclude blocks whose "if" is
+// an expression are left for Terragrunt to evaluate.
+func unitSkipReason(content, action string) string {
+	if reSkipAttr.MatchString(content) {
+		return "skip = true"
+	}
+	for _, loc := range reExcludeBlock.FindAllStringIndex(content, -1) {
+		body := blockBody(content, loc[1])
+		if !reExcludeIfTrue.MatchString(body) {
+			continue
+		}
+		m := reExcludeActions.FindStringSubmatch(body)
+		if m == nil {
+			continue
+		}
+		var actions []string
+		for a := range strings.SplitSeq(m[1], ",") {
+			if a = strings.Trim(strings.TrimSpace(a), `"`); a != "" {
+				actions = append(actions, a)
+			}
+		}
+		if slices.Contains(actions, "all") || (slices.Contains(actions, "all_except_output") && action != "output") || slices.Contains(actions, action) {
+			return fmt.Sprintf("exclude block (actions: %s)", strings.Join(actions, ", "))
+		}
+	}
+	return ""
+}
+
+// Remove the folders whose unit configuration excludes them from the command,
+// recording them so the summary can explain why they were not planned
+func (r *Runner) filterSkippedUnits(folders []string) []string {
+	action := commandAction(r.config.Command)
+	var kept []string
+	for _, folder := range folders {
+		name := r.unitFile(folder)
+		if name == "" {
+			kept = append(kept, folder)
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(folder, name))
+		if err != nil {
+			kept = append(kept, folder)
+			continue
+		}
+		if reason := unitSkipReason(string(content), action); reason != "" {
+			r.logger.Info("Skipping unit excluded by its configuration", "folder", folder, "reason", reason)
+			r.skippedUnits = append(r.skippedUnits, SkippedUnit{Folder: folder, Reason: reason})
+			continue
+		}
+		kept = append(kept, folder)
+	}
+	return kept
+}
+
+// Format the units skipped by configuration for the summary comment
+func (r *Runner) formatSkippedUnits() string {
+	if len(r.skippedUnits) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n**" + r.msg("summary.skipped", "count", len(r.skippedUnits)) + "**\n\n")
+	for _, unit := range r.skippedUnits {
+		b.WriteString(fmt.Sprintf("- `%s`: %s\n", unit.Folder, unit.Reason))
+	}
+	return b.String()
+}
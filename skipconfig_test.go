@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandAction(t *testing.T) {
+	tests := map[string]string{
+		"plan":               "plan",
+		"run --all plan":     "plan",
+		"run-all apply":      "apply",
+		"run --all -- apply": "apply",
+		"":                   "",
+	}
+	for command, want := range tests {
+		if got := commandAction(command); got != want {
+			t.Errorf("commandAction(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestUnitSkipReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		action  string
+		want    string
+	}{
+		{"no skip", "inputs = {}\n", "plan", ""},
+		{"skip attribute", "skip = true # retired\n", "plan", "skip = true"},
+		{"skip false", "skip = false\n", "plan", ""},
+		{"exclude all", "exclude {\n  if      = true\n  actions = [\"all\"]\n}\n", "plan", "exclude block (actions: all)"},
+		{"exclude listed action", "exclude {\n  if      = true\n  actions = [\"plan\", \"apply\"]\n}\n", "plan", "exclude block (actions: plan, apply)"},
+		{"exclude other action", "exclude {\n  if      = true\n  actions = [\"apply\"]\n}\n", "plan", ""},
+		{"exclude all except output", "exclude {\n  if      = true\n  actions = [\"all_except_output\"]\n}\n", "output", ""},
+		{"exclude dynamic condition", "exclude {\n  if      = local.env == \"prod\"\n  actions = [\"all\"]\n}\n", "plan", ""},
+		{"nested if outside exclude", "locals {\n  x = { if = true }\n}\nexclude {\n  if      = false\n  actions = [\"all\"]\n}\n", "plan", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitSkipReason(tt.content, tt.action); got != tt.want {
+				t.Errorf("unitSkipReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSkippedUnits(t *testing.T) {
+	root := t.TempDir()
+	units := map[string]string{
+		"app":     "inputs = {}\n",
+		"retired": "skip = true\n",
+		"frozen":  "exclude {\n  if      = true\n  actions = [\"plan\"]\n}\n",
+	}
+	for name, content := range units {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name, "terragrunt.hcl"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRunner(&Config{Command: "plan", TerragruntFiles: []string{"terragrunt.hcl"}})
+	folders := []string{filepath.Join(root, "app"), filepath.Join(root, "retired"), filepath.Join(root, "frozen")}
+	if got := r.filterSkippedUnits(folders); !reflect.DeepEqual(got, folders[:1]) {
+		t.Errorf("filterSkippedUnits() = %v, want %v", got, folders[:1])
+	}
+	if len(r.skippedUnits) != 2 || r.skippedUnits[0].Reason != "skip = true" {
+		t.Errorf("filterSkippedUnits() skipped = %+v", r.skippedUnits)
+	}
+
+	summary := r.formatSkippedUnits()
+	if !strings.Contains(summary, "**Skipped by config: 2 folders**") || !strings.Contains(summary, "exclude block (actions: plan)") {
+		t.Errorf("formatSkippedUnits() = %q", summary)
+	}
+}
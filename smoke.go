@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// Sandbox stack of the smoke test: a unit planning one local-only resource and
+// a unit without changes. terraform_data needs no provider download.
+var smokeStack = map[string]string{
+	"root.hcl": "# Root configuration of the smoke test stack\n",
+	"live/app/terragrunt.hcl": `include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+`,
+	"live/app/main.tf": `resource "terraform_data" "smoke" {
+  input = "terragrunt-runner smoke test"
+}
+`,
+	"live/noop/terragrunt.hcl": `include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+`,
+	"live/noop/main.tf": "# Nothing to plan\n",
+}
+
+// Mock GitHub API of the smoke test, recording the comments posted
+type smokeGitHub struct {
+	mu       sync.Mutex
+	comments []string
+}
+
+func (g *smokeGitHub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/repos/smoke/sandbox":
+		w.Write([]byte(`{"full_name": "smoke/sandbox", "permissions": {"push": true, "pull": true}}`))
+	case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/comments"):
+		w.Write([]byte(`[]`))
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/comments"):
+		var comment struct {
+			Body string `json:"body"`
+		}
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &comment)
+		g.mu.Lock()
+		g.comments = append(g.comments, comment.Body)
+		id := len(g.comments)
+		g.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id": %d}`, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+// Create the smoke subcommand
+func newSmokeCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var keep bool
+	var format string
+	cmd := &cobra.Command{
+		Use:   "smoke",
+		Short: "Plan a sandbox stack against a mock GitHub API to validate the tool versions",
+		Long: `Create a local-only Terragrunt stack in a temporary directory, run the full plan
+pipeline on it against an in-process mock GitHub API, and check that the plans are
+parsed and the comments and outputs are formatted as expected. Run it after
+installing a new terragrunt, terraform or tofu version, before rolling it out.
+Exits non-zero when a check fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := runSmoke(config, logger, keep)
+			if err := printDoctorReport(cmd.OutOrStdout(), checks, format); err != nil {
+				return err
+			}
+			for _, check := range checks {
+				if check.Status == DoctorFail {
+					return fmt.Errorf("smoke test failed")
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&keep, "keep", false, "Keep the sandbox directory for inspection")
+	cmd.Flags().StringVarP(&format, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+// Run the pipeline on the sandbox stack and check its results, comments and
+// action outputs. The process environment and working directory are restored
+// afterwards.
+func runSmoke(config *Config, logger *slog.Logger, keep bool) []DoctorCheck {
+	checks := checkTools()
+	sandbox, err := os.MkdirTemp("", "terragrunt-runner-smoke-*")
+	if err != nil {
+		return append(checks, DoctorCheck{Name: "sandbox", Status: DoctorFail, Detail: err.Error()})
+	}
+	if keep {
+		checks = append(checks, DoctorCheck{Name: "sandbox", Status: DoctorPass, Detail: sandbox})
+	} else {
+		defer os.RemoveAll(sandbox)
+	}
+	if err := writeSmokeStack(sandbox); err != nil {
+		return append(checks, DoctorCheck{Name: "sandbox", Status: DoctorFail, Detail: err.Error()})
+	}
+
+	github := &smokeGitHub{}
+	server := httptest.NewServer(github)
+	defer server.Close()
+	restore := setSmokeEnv(map[string]string{
+		"GITHUB_API_URL":      server.URL,
+		"GITHUB_OUTPUT":       filepath.Join(sandbox, ".github-output"),
+		"GITHUB_STEP_SUMMARY": filepath.Join(sandbox, ".step-summary.md"),
+		"GITHUB_EVENT_PATH":   filepath.Join(sandbox, ".missing-event.json"),
+		"GITHUB_EVENT_NAME":   "",
+	})
+	defer restore()
+	wd, err := os.Getwd()
+	if err != nil {
+		return append(checks, DoctorCheck{Name: "sandbox", Status: DoctorFail, Detail: err.Error()})
+	}
+	if err := os.Chdir(sandbox); err != nil {
+		return append(checks, DoctorCheck{Name: "sandbox", Status: DoctorFail, Detail: err.Error()})
+	}
+	defer os.Chdir(wd)
+
+	smoke := *config
+	smoke.GithubToken = "smoke-token"
+	smoke.Repository = "smoke/sandbox"
+	smoke.Owner = ""
+	smoke.PullRequest = 1
+	smoke.Target = ""
+	smoke.Command = "plan"
+	smoke.Folders = []string{"live/app", "live/noop"}
+	smoke.AutoDetect = false
+	smoke.InputsFrom = ""
+	smoke.PublishResults = ""
+	smoke.WebhookURL = ""
+	smoke.SummaryFile = ""
+	smoke.SignSummary = ""
+	smoke.MessagesFile = ""
+	smoke.TFCRuns = false
+	smoke.LabelFilters = false
+	smoke.PlanEncryptKey = ""
+	r := NewRunner(&smoke, logger)
+	runErr := r.run()
+	return append(checks, smokeChecks(runErr, r.results, github.comments, readActionOutputs(filepath.Join(sandbox, ".github-output")))...)
+}
+
+// Write the sandbox stack, as a git repository when git is available so the
+// runner finds its root
+func writeSmokeStack(dir string) error {
+	for name, content := range smokeStack {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	if _, err := exec.LookPath("git"); err == nil {
+		if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+			return fmt.Errorf("git init: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// Set environment variables, returning the function restoring them
+func setSmokeEnv(vars map[string]string) func() {
+	previous := map[string]*string{}
+	for name, value := range vars {
+		if old, ok := os.LookupEnv(name); ok {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		os.Setenv(name, value)
+	}
+	return func() {
+		for name, old := range previous {
+			if old == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *old)
+			}
+		}
+	}
+}
+
+// Read the name=value action outputs written to a GITHUB_OUTPUT file
+func readActionOutputs(path string) map[string]string {
+	content, _ := os.ReadFile(path)
+	outputs := map[string]string{}
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			outputs[name] = value
+		}
+	}
+	return outputs
+}
+
+// Check the results, comments and outputs of the smoke run
+func smokeChecks(runErr error, results []ExecutionResult, comments []string, outputs map[string]string) []DoctorCheck {
+	check := func(name string, ok bool, pass, fail string) DoctorCheck {
+		if ok {
+			return DoctorCheck{Name: name, Status: DoctorPass, Detail: pass}
+		}
+		return DoctorCheck{Name: name, Status: DoctorFail, Detail: fail}
+	}
+
+	byFolder := map[string]ExecutionResult{}
+	for _, result := range results {
+		byFolder[result.Folder] = result
+	}
+	app, noop := byFolder["live/app"], byFolder["live/noop"]
+	runDetail := fmt.Sprintf("%d folders planned", len(results))
+	if runErr != nil {
+		runDetail = runErr.Error()
+	} else if !app.Success || !noop.Success {
+		runDetail = fmt.Sprintf("plan failed: %s", firstLine(smokeError(app, noop)))
+	}
+
+	var changes, noChanges string
+	if c := app.ResourceChanges; c != nil {
+		changes = fmt.Sprintf("+%d ~%d -%d", c.ToAdd, c.ToChange, c.ToDestroy)
+	}
+	appComment, summary := false, false
+	for _, comment := range comments {
+		appComment = appComment || strings.Contains(comment, commentMarker("live/app", ""))
+		summary = summary || strings.Contains(comment, defaultMessages["summary.title"])
+	}
+	if noop.ResourceChanges != nil && noop.ResourceChanges.NoChanges {
+		noChanges = "no changes"
+	}
+
+	return []DoctorCheck{
+		check("smoke plan", runErr == nil && app.Success && noop.Success, runDetail, runDetail),
+		check("plan parsing", app.ResourceChanges != nil && app.ResourceChanges.ToAdd == 1, changes+" in live/app", "expected +1 in live/app, got "+valueOr(changes, "no plan summary")),
+		check("no-change parsing", noChanges != "", "no changes in live/noop", "live/noop was not parsed as no changes"),
+		check("comments", appComment && summary, fmt.Sprintf("%d comments posted", len(comments)), fmt.Sprintf("%d comments posted, folder comment %t, summary %t", len(comments), appComment, summary)),
+		check("action outputs", outputs["total-resources-to-add"] == "1" && outputs["changed-folders"] == `["live/app"]`,
+			"total-resources-to-add=1, changed-folders=[\"live/app\"]",
+			fmt.Sprintf("total-resources-to-add=%s, changed-folders=%s", outputs["total-resources-to-add"], outputs["changed-folders"])),
+	}
+}
+
+// Get the error of the first failed smoke unit
+func smokeError(results ...ExecutionResult) string {
+	for _, result := range results {
+		if result.Error != nil {
+			return result.Error.Error()
+		}
+		if !result.Success {
+			return result.Output
+		}
+	}
+	return ""
+}
+
+// Get the first non-empty line of a text
+func firstLine(text string) string {
+	for line := range strings.SplitSeq(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Get the value, or the fallback when empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Fake terragrunt planning like Terraform would on the smoke stack
+const fakeSmokeTerragruntScript = `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "terragrunt version v0.88.1"; exit 0; fi
+if [ "$(basename "$PWD")" = app ]; then
+  echo "  # terraform_data.smoke will be created"
+  echo "Plan: 1 to add, 0 to change, 0 to destroy."
+else
+  echo "No changes. Your infrastructure matches the configuration."
+fi
+`
+
+func TestRunSmoke(t *testing.T) {
+	scripts := t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(fakeSmokeTerragruntScript), 0o755)
+	os.WriteFile(filepath.Join(scripts, "terraform"), []byte("#!/bin/sh\necho Terraform v1.9.0\n"), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("GITHUB_OUTPUT", "")
+	wd, _ := os.Getwd()
+
+	config := &Config{
+		TerragruntArgs:  "--non-interactive",
+		MaxParallel:     5,
+		FilePatterns:    []string{"*.hcl"},
+		TerragruntFiles: []string{"terragrunt.hcl"},
+		RunAllRootDir:   "live",
+		DetailLevel:     DetailStandard,
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, check := range runSmoke(config, logger, false) {
+		if check.Status == DoctorFail {
+			t.Errorf("check %s failed: %s", check.Name, check.Detail)
+		}
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("working directory = %s, want restored to %s", now, wd)
+	}
+	if got := os.Getenv("GITHUB_OUTPUT"); got != "" {
+		t.Errorf("GITHUB_OUTPUT = %q, want restored", got)
+	}
+}
+
+func TestSmokeChecksFailures(t *testing.T) {
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true, ResourceChanges: &ResourceChanges{ToAdd: 2}},
+		{Folder: "live/noop", Success: false, Error: errors.New("exit status 1\nError: no valid credential sources")},
+	}
+	failed := map[string]string{}
+	for _, check := range smokeChecks(nil, results, nil, map[string]string{}) {
+		if check.Status == DoctorFail {
+			failed[check.Name] = check.Detail
+		}
+	}
+	want := map[string]string{
+		"smoke plan":        "plan failed: exit status 1",
+		"plan parsing":      "expected +1 in live/app, got +2 ~0 -0",
+		"no-change parsing": "live/noop was not parsed as no changes",
+		"comments":          "0 comments posted, folder comment false, summary false",
+		"action outputs":    "total-resources-to-add=, changed-folders=",
+	}
+	for name, detail := range want {
+		if failed[name] != detail {
+			t.Errorf("check %s = %q, want %q", name, failed[name], detail)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Name of the check run reporting a soft-failed run, suffixed with the run label
+const softFailCheckName = "terragrunt-runner"
+
+// Get the folders that failed when soft-fail keeps them from failing the run
+// (nil when the run did not soft-fail). Setup errors still fail the run.
+func (r *Runner) softFailedFolders(results []ExecutionResult) []string {
+	if !r.config.SoftFail {
+		return nil
+	}
+	var failed []string
+	for _, result := range r.folderResults(results) {
+		if !result.Success && !result.SetupError {
+			failed = append(failed, result.Folder)
+		}
+	}
+	return failed
+}
+
+// Format the banner of a soft-failed run at the top of the summary comment
+func (r *Runner) formatSoftFailBanner(results []ExecutionResult) string {
+	failed := r.softFailedFolders(results)
+	if len(failed) == 0 {
+		return ""
+	}
+	return "> [!WARNING]\n> " + r.msg("summary.soft_fail", "count", len(failed)) + "\n\n"
+}
+
+// Report a soft-failed run as a check run with a neutral conclusion on the
+// commit, so it informs reviewers without blocking the merge
+func (r *Runner) postSoftFailCheck(ctx context.Context, client *github.Client, failed []string) error {
+	sha := deploymentRef()
+	if sha == "" {
+		return fmt.Errorf("no commit to report the soft fail on")
+	}
+	name := softFailCheckName
+	if r.config.RunLabel != "" {
+		name += " (" + r.config.RunLabel + ")"
+	}
+	var summary strings.Builder
+	summary.WriteString("Soft fail is enabled, so these failures do not fail the run:\n\n")
+	for _, folder := range failed {
+		summary.WriteString("- `" + folder + "`\n")
+	}
+	opts := github.CreateCheckRunOptions{
+		Name:       name,
+		HeadSHA:    sha,
+		Status:     github.Ptr("completed"),
+		Conclusion: github.Ptr("neutral"),
+		Output: &github.CheckRunOutput{
+			Title:   github.Ptr(fmt.Sprintf("%d folders failed (soft fail)", len(failed))),
+			Summary: github.Ptr(summary.String()),
+		},
+	}
+	if url := getRunURL(); url != "" {
+		opts.DetailsURL = &url
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	_, _, err := client.Checks.CreateCheckRun(ctx, parts[0], parts[1], opts)
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSoftFailedFolders(t *testing.T) {
+	results := []ExecutionResult{
+		{Folder: "live/app", Success: true},
+		{Folder: "live/db", Success: false, Error: errors.New("exit status 1")},
+		{Folder: "live/dns", Success: false, SetupError: true},
+	}
+	r := newTestRunner(&Config{Command: "plan"})
+	if got := r.softFailedFolders(results); got != nil {
+		t.Errorf("softFailedFolders() without soft-fail = %v, want none", got)
+	}
+	r.config.SoftFail = true
+	if got := r.softFailedFolders(results); !reflect.DeepEqual(got, []string{"live/db"}) {
+		t.Errorf("softFailedFolders() = %v, want live/db", got)
+	}
+	if banner := r.formatSoftFailBanner(results); !strings.HasPrefix(banner, "> [!WARNING]\n> **Soft fail:** 1 folders failed") {
+		t.Errorf("formatSoftFailBanner() = %q", banner)
+	}
+}
+
+func TestPostSoftFailCheck(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_SHA", "abc123")
+	var check map[string]any
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/repos/owner/repo/check-runs" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		json.NewDecoder(req.Body).Decode(&check)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", RunLabel: "prod", SoftFail: true})
+	if err := r.postSoftFailCheck(context.Background(), client, []string{"live/db"}); err != nil {
+		t.Fatalf("postSoftFailCheck() error = %v", err)
+	}
+	output, _ := check["output"].(map[string]any)
+	if check["name"] != "terragrunt-runner (prod)" || check["head_sha"] != "abc123" || check["conclusion"] != "neutral" ||
+		!strings.Contains(output["summary"].(string), "`live/db`") {
+		t.Errorf("check run = %v", check)
+	}
+}
+
+func TestEndToEndSoftFail(t *testing.T) {
+	h := newHarness(t)
+	h.addUnit("live/dev/app", "terraform-1.9/plan-changes.txt", 0)
+	h.addUnit("live/dev/rds", "opentofu-1.8/plan-error.txt", 1)
+	h.commit("add units")
+
+	if err := h.run(Config{Folders: []string{"live/dev/app", "live/dev/rds"}, SoftFail: true}); err != nil {
+		t.Fatalf("run() error = %v, want the failure soft-failed", err)
+	}
+	if outputs := h.outputs(); outputs["success"] != "false" || outputs["soft-failed"] != "true" {
+		t.Errorf("outputs = %v, want success false and soft-failed true", outputs)
+	}
+	h.mu.Lock()
+	summary := h.comments[len(h.comments)-1]
+	h.mu.Unlock()
+	if !strings.Contains(summary, "**Soft fail:** 1 folders failed") {
+		t.Errorf("summary = %q, want the soft fail banner", summary)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Execution and reporting granularities, selectable with --granularity
+const (
+	GranularityUnit  = "unit"  // One execution, comment and summary row per folder
+	GranularityStack = "stack" // One run --all, comment and summary row per stack
+)
+
+var granularities = []string{GranularityUnit, GranularityStack}
+
+// Group folders by stack. The stack of a folder is the nearest directory, the
+// folder itself or an ancestor up to the repo root, containing a stack marker
+// file. Folders outside any stack form a stack of their own.
+func (r *Runner) groupFoldersByStack(repoRoot string) map[string][]string {
+	stacks := make(map[string][]string)
+	for _, folder := range r.config.Folders {
+		stack := r.stackDir(repoRoot, folder)
+		stacks[stack] = append(stacks[stack], folder)
+	}
+	return stacks
+}
+
+// Find the stack directory of a folder (relative to the repo root)
+func (r *Runner) stackDir(repoRoot, folder string) string {
+	rel := filepath.Clean(folder)
+	if filepath.IsAbs(rel) {
+		var err error
+		if rel, err = filepath.Rel(repoRoot, rel); err != nil || strings.HasPrefix(rel, "..") {
+			return filepath.Clean(folder)
+		}
+	}
+	for dir := rel; ; dir = filepath.Dir(dir) {
+		for _, marker := range r.config.StackMarkers {
+			if _, err := os.Stat(filepath.Join(repoRoot, dir, marker)); err == nil {
+				return dir
+			}
+		}
+		if dir == "." || dir == string(filepath.Separator) {
+			return rel
+		}
+	}
+}
+
+// Get the run --all form of a unit command
+func runAllCommand(command string) string {
+	if isRunAllCommand(command) {
+		return command
+	}
+	return "run --all " + command
+}
+
+// Run one run --all per stack from the stack directory, limited to the
+// stack's folders, and report each stack as a single result
+func (r *Runner) executeStacks(stacks map[string][]string) []ExecutionResult {
+	names := make([]string, 0, len(stacks))
+	for stack := range stacks {
+		names = append(names, stack)
+	}
+	slices.Sort(names)
+	r.logger.Info("Running folders grouped by stack", "stacks", names)
+
+	results := make([]ExecutionResult, len(names))
+	maxParallel := len(names)
+	if !r.config.ParallelExec {
+		maxParallel = 1
+	} else if r.config.MaxParallel > 0 {
+		maxParallel = min(r.config.MaxParallel, len(names))
+	}
+	semaphore := make(chan struct{}, maxParallel)
+	var wg sync.WaitGroup
+	for i, stack := range names {
+		stackConfig := *r.config
+		stackConfig.Command = runAllCommand(r.config.Command)
+		stackConfig.RunAllRootDir = stack
+		stackConfig.Folders = stacks[stack]
+		runner := NewRunner(&stackConfig, r.logger.With("stack", stack))
+		runner.progress = r.progress
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			result := ExecutionResult{Folder: stack}
+			if out := runner.executeTrackedRunAll(); len(out) > 0 {
+				result = out[0]
+			}
+			result.Folder = stack
+			result.StackUnits = stacks[stack]
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Format the units of a stack for its comment header (empty for unit results)
+func (r *Runner) formatStackUnits(result ExecutionResult) string {
+	if len(result.StackUnits) == 0 {
+		return ""
+	}
+	return "**" + r.msg("comment.stack_units") + ":** `" + strings.Join(result.StackUnits, "`, `") + "`\n"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGroupFoldersByStack(t *testing.T) {
+	repoRoot := t.TempDir()
+	markers := map[string]string{
+		"live/prod":          "env.hcl",
+		"live/dev":           "env.hcl",
+		"live/dev/platform":  "terragrunt.stack.hcl",
+		"live/shared/global": "",
+	}
+	for dir, marker := range markers {
+		if err := os.MkdirAll(filepath.Join(repoRoot, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if marker == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(repoRoot, dir, marker), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	folders := []string{"live/prod/vpc", "live/prod/app", "live/dev/platform", "live/dev/platform/eks", "live/dev/vpc", "live/shared/global"}
+	r := newTestRunner(&Config{Folders: folders, StackMarkers: []string{"terragrunt.stack.hcl", "env.hcl"}})
+	expected := map[string][]string{
+		"live/prod":          {"live/prod/vpc", "live/prod/app"},
+		"live/dev/platform":  {"live/dev/platform", "live/dev/platform/eks"},
+		"live/dev":           {"live/dev/vpc"},
+		"live/shared/global": {"live/shared/global"},
+	}
+	if got := r.groupFoldersByStack(repoRoot); !reflect.DeepEqual(got, expected) {
+		t.Errorf("groupFoldersByStack() = %v, want %v", got, expected)
+	}
+
+	r.config.Folders = []string{filepath.Join(repoRoot, "live/prod/vpc")}
+	if got := r.stackDir(repoRoot, r.config.Folders[0]); got != "live/prod" {
+		t.Errorf("stackDir() of an absolute folder = %q, want %q", got, "live/prod")
+	}
+}
+
+func TestRunAllCommand(t *testing.T) {
+	tests := map[string]string{
+		"plan":           "run --all plan",
+		"apply":          "run --all apply",
+		"run --all plan": "run --all plan",
+		"run-all plan":   "run-all plan",
+	}
+	for command, expected := range tests {
+		if got := runAllCommand(command); got != expected {
+			t.Errorf("runAllCommand(%q) = %q, want %q", command, got, expected)
+		}
+	}
+}
+
+func TestFormatStackUnits(t *testing.T) {
+	r := newTestRunner(&Config{})
+	if got := r.formatStackUnits(ExecutionResult{Folder: "live/app"}); got != "" {
+		t.Errorf("formatStackUnits() of a unit = %q, want empty", got)
+	}
+	expected := "**Stack units:** `live/prod/vpc`, `live/prod/app`\n"
+	if got := r.formatStackUnits(ExecutionResult{Folder: "live/prod", StackUnits: []string{"live/prod/vpc", "live/prod/app"}}); got != expected {
+		t.Errorf("formatStackUnits() = %q, want %q", got, expected)
+	}
+}
+
+func TestValidateConfigGranularity(t *testing.T) {
+	tests := []struct {
+		granularity string
+		command     string
+		wantErr     bool
+	}{
+		{"", "plan", false},
+		{GranularityUnit, "run --all plan", false},
+		{GranularityStack, "plan", false},
+		{GranularityStack, "run --all plan", true},
+		{"module", "plan", true},
+	}
+	for _, tt := range tests {
+		r := newTestRunner(&Config{GithubToken: "t", Repository: "owner/repo", PullRequest: 1, Folders: []string{"live/app"}, Command: tt.command, Granularity: tt.granularity})
+		if err := r.validateConfig(); (err != nil) != tt.wantErr {
+			t.Errorf("validateConfig() with granularity %q and command %q error = %v, wantErr %v", tt.granularity, tt.command, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+const maxFmtSuggestions = 20 // Suggestions posted per run, the rest is left to the pre-checks comment
+
+const fmtSuggestionTitle = "🧹 Formatting (`terragrunt hcl fmt`)"
+
+var (
+	reDiffHunk = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+	// validate-inputs lists the missing required inputs below this header, one "- name" per line
+	reMissingInputsHeader = regexp.MustCompile(`(?i)required inputs are missing|are required,? but not passed`)
+	reListedInput         = regexp.MustCompile(`-\s+([A-Za-z_][\w-]*)\s*$`)
+	reInputsBlockStart    = regexp.MustCompile(`^(\s*)inputs\s*=\s*\{\s*$`)
+)
+
+// Fix of consecutive lines, posted as a suggestion block
+type FmtSuggestion struct {
+	Path      string // File relative to the repo root
+	StartLine int    // First replaced line
+	Line      int    // Last replaced line
+	Body      string // Replacement lines
+	Title     string // Heading of the suggestion comment (default: formatting)
+}
+
+// Parse the suggestions of a zero-context diff (git diff -U0) of formatted
+// files. Pure insertions are anchored on the line before them, which they
+// repeat; original returns the lines of a file before formatting.
+func parseFmtSuggestions(diff string, original func(path string) []string) []FmtSuggestion {
+	var suggestions []FmtSuggestion
+	var current *FmtSuggestion
+	var path string
+	flush := func() {
+		if current != nil {
+			suggestions = append(suggestions, *current)
+			current = nil
+		}
+	}
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			flush()
+			path = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			m := reDiffHunk.FindStringSubmatch(line)
+			if m == nil || path == "" {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			current = &FmtSuggestion{Path: path, StartLine: start, Line: start + count - 1}
+			if count == 0 {
+				// Insertion after line start: replace that line with itself and the new lines
+				lines := original(path)
+				if start < 1 || start > len(lines) {
+					current = nil
+					continue
+				}
+				current.Line = start
+				current.Body = lines[start-1] + "\n"
+			}
+		case current != nil && strings.HasPrefix(line, "+"):
+			current.Body += strings.TrimPrefix(line, "+") + "\n"
+		}
+	}
+	flush()
+	return suggestions
+}
+
+// Compute the formatting fixes of the folders as suggestions. The files are
+// formatted in place and restored from git, so folders with local changes are
+// skipped.
+func (r *Runner) fmtSuggestions(repoRoot string, folders []string) []FmtSuggestion {
+	var suggestions []FmtSuggestion
+	for _, folder := range folders {
+		absFolder := folder
+		if !filepath.IsAbs(folder) {
+			absFolder = filepath.Join(repoRoot, folder)
+		}
+		status, err := r.commandOutput(exec.Command("git", "-C", repoRoot, "status", "--porcelain", "--", absFolder))
+		if err != nil || len(strings.TrimSpace(string(status))) > 0 {
+			r.logger.Warn("Not suggesting formatting fixes for a folder with local changes", "folder", folder, "error", err)
+			continue
+		}
+
+		cmd := exec.Command("terragrunt", "hcl", "fmt")
+		cmd.Dir = absFolder
+		cmd.Env = r.subprocessEnv()
+		if err := r.runCommand(cmd); err != nil {
+			r.logger.Warn("Formatter failed, no suggestions for the folder", "folder", folder, "error", err)
+		}
+		diff, diffErr := r.commandOutput(exec.Command("git", "-C", repoRoot, "diff", "-U0", "--no-color", "--", absFolder))
+		if err := r.runCommand(exec.Command("git", "-C", repoRoot, "checkout", "--", absFolder)); err != nil {
+			r.logger.Warn("Failed to restore the formatted files", "folder", folder, "error", err)
+		}
+		if diffErr != nil {
+			r.logger.Warn("git diff failed, no suggestions for the folder", "folder", folder, "error", diffErr)
+			continue
+		}
+		suggestions = append(suggestions, parseFmtSuggestions(string(diff), func(path string) []string {
+			data, _ := os.ReadFile(filepath.Join(repoRoot, path))
+			return strings.Split(string(data), "\n")
+		})...)
+	}
+	return suggestions
+}
+
+// Get the missing required inputs reported by the validate-inputs pre-check
+func parseMissingInputs(output string) []string {
+	var names []string
+	listing := false
+	for line := range strings.SplitSeq(output, "\n") {
+		switch m := reListedInput.FindStringSubmatch(line); {
+		case reMissingInputsHeader.MatchString(line):
+			listing = true
+		case listing && m != nil:
+			names = append(names, m[1])
+		case strings.TrimSpace(line) != "":
+			listing = false
+		}
+	}
+	return names
+}
+
+// Get the obvious default of a required variable: an empty collection for
+// list, set, tuple, map and object types ("" if there is none)
+func obviousVariableDefault(varType string) string {
+	switch varType {
+	case "list", "set", "tuple":
+		return "[]"
+	case "map", "object":
+		return "{}"
+	}
+	return ""
+}
+
+// Find the type constructor of a variable declared by the module of a unit,
+// downloaded to its Terragrunt cache by validate-inputs ("" if not found)
+func findVariableType(absFolder, name string) string {
+	reVariable := regexp.MustCompile(`(?s)variable\s+"` + regexp.QuoteMeta(name) + `"\s*\{[^}]*?\btype\s*=\s*(\w+)`)
+	varType := ""
+	filepath.WalkDir(filepath.Join(absFolder, ".terragrunt-cache"), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".tf" || varType != "" {
+			return nil
+		}
+		data, _ := os.ReadFile(p)
+		if m := reVariable.FindSubmatch(data); m != nil {
+			varType = string(m[1])
+		}
+		return nil
+	})
+	return varType
+}
+
+// Suggest adding the missing required inputs of a unit whose default is
+// obvious (an empty collection) to its inputs block. Other missing inputs are
+// left to the pre-check report, as their values cannot be guessed.
+func (r *Runner) missingInputSuggestions(repoRoot, folder, output string) []FmtSuggestion {
+	absFolder := folder
+	if !filepath.IsAbs(folder) {
+		absFolder = filepath.Join(repoRoot, folder)
+	}
+	unitFile := r.statUnitFile(absFolder)
+	if unitFile == "" {
+		return nil
+	}
+	var added []string
+	for _, name := range parseMissingInputs(output) {
+		if value := obviousVariableDefault(findVariableType(absFolder, name)); value != "" {
+			added = append(added, name+" = "+value)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(absFolder, unitFile))
+	if err != nil {
+		return nil
+	}
+	relPath, err := filepath.Rel(repoRoot, filepath.Join(absFolder, unitFile))
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		m := reInputsBlockStart.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		body := line + "\n"
+		for _, input := range added {
+			body += m[1] + "  " + input + "\n"
+		}
+		return []FmtSuggestion{{
+			Path:      filepath.ToSlash(relPath),
+			StartLine: i + 1,
+			Line:      i + 1,
+			Body:      body,
+			Title:     "🧩 Missing required inputs with an empty default (`validate-inputs`)",
+		}}
+	}
+	r.logger.Debug("No inputs block to suggest the missing inputs in", "folder", folder, "inputs", added)
+	return nil
+}
+
+// Get the head commit of the pull request, from the event or the API
+func (r *Runner) prHeadSHA(ctx context.Context, client *github.Client, owner, repo string) string {
+	if sha := getPRHeadSHA(); sha != "" {
+		return sha
+	}
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, r.config.PullRequest)
+	if err != nil {
+		r.logger.Warn("Failed to get the pull request head", "error", err)
+		return ""
+	}
+	return pr.GetHead().GetSHA()
+}
+
+// Post the suggestions as review comments on the lines they fix, returning how
+// many were posted. Lines outside the pull request diff cannot be commented on
+// and are skipped.
+func (r *Runner) postFmtSuggestions(ctx context.Context, client *github.Client, suggestions []FmtSuggestion) int {
+	if len(suggestions) == 0 || !r.caps.commentsAllowed() || (r.config.Target != "" && r.config.Target != TargetPR) {
+		return 0
+	}
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+	head := r.prHeadSHA(ctx, client, owner, repo)
+	if head == "" {
+		return 0
+	}
+	if len(suggestions) > maxFmtSuggestions {
+		r.logger.Warn("More formatting fixes than suggestions posted per run", "fixes", len(suggestions), "max", maxFmtSuggestions)
+		suggestions = suggestions[:maxFmtSuggestions]
+	}
+	posted := 0
+	for _, s := range suggestions {
+		title := s.Title
+		if title == "" {
+			title = fmtSuggestionTitle
+		}
+		body := fmt.Sprintf("%s:\n\n```suggestion\n%s```", title, s.Body)
+		comment := &github.PullRequestComment{Body: &body, CommitID: &head, Path: &s.Path, Line: &s.Line, Side: github.Ptr("RIGHT")}
+		if s.StartLine < s.Line {
+			comment.StartLine, comment.StartSide = &s.StartLine, github.Ptr("RIGHT")
+		}
+		if _, _, err := client.PullRequests.CreateComment(ctx, owner, repo, r.config.PullRequest, comment); err != nil {
+			r.logger.Debug("Could not post a formatting suggestion, the lines may be outside the diff", "path", s.Path, "line", s.Line, "error", err)
+			continue
+		}
+		posted++
+	}
+	return posted
+}
+
+// Suggest the fixes of the folders failing the hclfmt pre-check, and the
+// missing inputs with an obvious default of those failing validate-inputs
+func (r *Runner) suggestFmtFixes(ctx context.Context, client *github.Client, preChecks []PreCheckResult) {
+	var folders []string
+	var inputChecks []PreCheckResult
+	for _, result := range preChecks {
+		switch {
+		case result.Passed:
+		case result.Check == "hclfmt":
+			folders = append(folders, result.Folder)
+		case result.Check == "validate-inputs":
+			inputChecks = append(inputChecks, result)
+		}
+	}
+	if len(folders) == 0 && len(inputChecks) == 0 {
+		return
+	}
+	repoRoot, err := r.getRepoRoot()
+	if err != nil {
+		r.logger.Warn("Failed to determine repo root for fix suggestions", "error", err)
+		return
+	}
+	suggestions := r.fmtSuggestions(repoRoot, folders)
+	for _, result := range inputChecks {
+		suggestions = append(suggestions, r.missingInputSuggestions(repoRoot, result.Folder, result.Output)...)
+	}
+	posted := r.postFmtSuggestions(ctx, client, suggestions)
+	r.logger.Info("Posted fix suggestions", "posted", posted, "fixes", len(suggestions))
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFmtSuggestions(t *testing.T) {
+	diff := `diff --git a/live/app/terragrunt.hcl b/live/app/terragrunt.hcl
+--- a/live/app/terragrunt.hcl
++++ b/live/app/terragrunt.hcl
+@@ -2 +2 @@ inputs = {
+-  name     = "app"
++  name = "app"
+@@ -5,3 +5,2 @@ inputs = {
+-  a=1
+-  b=2
+-
++  a = 1
++  b = 2
+@@ -9,0 +9 @@ locals {
++}
+@@ -12,2 +11,0 @@
+-
+-
+`
+	original := func(path string) []string {
+		if path != "live/app/terragrunt.hcl" {
+			t.Errorf("original(%q) for an unexpected file", path)
+		}
+		return []string{"1", "2", "3", "4", "5", "6", "7", "8", "  x = 1", "10", "11", "12", "13"}
+	}
+	want := []FmtSuggestion{
+		{Path: "live/app/terragrunt.hcl", StartLine: 2, Line: 2, Body: "  name = \"app\"\n"},
+		{Path: "live/app/terragrunt.hcl", StartLine: 5, Line: 7, Body: "  a = 1\n  b = 2\n"},
+		{Path: "live/app/terragrunt.hcl", StartLine: 9, Line: 9, Body: "  x = 1\n}\n"},
+		{Path: "live/app/terragrunt.hcl", StartLine: 12, Line: 13, Body: ""},
+	}
+	if got := parseFmtSuggestions(diff, original); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFmtSuggestions() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// Fake terragrunt hcl fmt collapsing the alignment of one attribute
+const fakeFmtTerragruntScript = `#!/bin/sh
+sed -i 's/name     = /name = /' terragrunt.hcl
+`
+
+func TestFmtSuggestions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	scripts := t.TempDir()
+	os.WriteFile(filepath.Join(scripts, "terragrunt"), []byte(fakeFmtTerragruntScript), 0o755)
+	t.Setenv("PATH", scripts+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	original := "inputs = {\n  name     = \"app\"\n}\n"
+	for _, folder := range []string{"live/app", "live/dirty"} {
+		os.MkdirAll(filepath.Join(repo, folder), 0o755)
+		os.WriteFile(filepath.Join(repo, folder, "terragrunt.hcl"), []byte(original), 0o644)
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	git("add", ".")
+	git("commit", "-qm", "init")
+	os.WriteFile(filepath.Join(repo, "live/dirty/terragrunt.hcl"), []byte(original+"# wip\n"), 0o644)
+
+	r := newTestRunner(&Config{})
+	got := r.fmtSuggestions(repo, []string{"live/app", "live/dirty"})
+	want := []FmtSuggestion{{Path: "live/app/terragrunt.hcl", StartLine: 2, Line: 2, Body: "  name = \"app\"\n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fmtSuggestions() = %+v, want %+v", got, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "live/app/terragrunt.hcl")); string(data) != original {
+		t.Errorf("formatted file not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "live/dirty/terragrunt.hcl")); string(data) != original+"# wip\n" {
+		t.Errorf("local changes lost: %q", data)
+	}
+}
+
+func TestParseMissingInputs(t *testing.T) {
+	output := `ERROR  The following required inputs are missing:
+ERROR  	- subnet_ids
+ERROR  	- region
+
+ERROR  The following inputs passed in by terragrunt are unused:
+ERROR  	- legacy
+`
+	want := []string{"subnet_ids", "region"}
+	if got := parseMissingInputs(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMissingInputs() = %v, want %v", got, want)
+	}
+	if got := parseMissingInputs("All required inputs are passed in.\n- not_an_input\n"); got != nil {
+		t.Errorf("parseMissingInputs() without a missing header = %v, want none", got)
+	}
+}
+
+func TestMissingInputSuggestions(t *testing.T) {
+	repo := t.TempDir()
+	unit := filepath.Join(repo, "live/app")
+	module := filepath.Join(unit, ".terragrunt-cache/abc/def")
+	os.MkdirAll(module, 0o755)
+	os.WriteFile(filepath.Join(unit, "terragrunt.hcl"), []byte("terraform {\n  source = \"../mod\"\n}\n\n  inputs = {\n    name = \"app\"\n  }\n"), 0o644)
+	os.WriteFile(filepath.Join(module, "variables.tf"), []byte(`variable "subnet_ids" {
+  type = list(string)
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+}
+
+variable "region" {
+  type = string
+}
+`), 0o644)
+	output := "The following required inputs are missing:\n  - subnet_ids\n  - region\n  - tags\n  - undeclared\n"
+
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}})
+	got := r.missingInputSuggestions(repo, "live/app", output)
+	want := []FmtSuggestion{{
+		Path:      "live/app/terragrunt.hcl",
+		StartLine: 5,
+		Line:      5,
+		Body:      "  inputs = {\n    subnet_ids = []\n    tags = {}\n",
+		Title:     "🧩 Missing required inputs with an empty default (`validate-inputs`)",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("missingInputSuggestions() = %+v, want %+v", got, want)
+	}
+
+	if got := r.missingInputSuggestions(repo, "live/app", "The following required inputs are missing:\n  - region\n"); got != nil {
+		t.Errorf("missingInputSuggestions() without an obvious default = %+v, want none", got)
+	}
+	os.WriteFile(filepath.Join(unit, "terragrunt.hcl"), []byte("terraform {}\n"), 0o644)
+	if got := r.missingInputSuggestions(repo, "live/app", output); got != nil {
+		t.Errorf("missingInputSuggestions() without an inputs block = %+v, want none", got)
+	}
+}
+
+func TestPostFmtSuggestions(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	var comments []reviewCommentRequest
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/pulls/5":
+			w.Write([]byte(`{"head": {"sha": "abc123"}}`))
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/pulls/5/comments":
+			var c reviewCommentRequest
+			json.NewDecoder(req.Body).Decode(&c)
+			if c.Line == 40 {
+				// Outside the diff
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message": "Validation Failed"}`))
+				return
+			}
+			comments = append(comments, c)
+			w.Write([]byte(`{"id": 1}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 5})
+	posted := r.postFmtSuggestions(t.Context(), client, []FmtSuggestion{
+		{Path: "live/app/terragrunt.hcl", StartLine: 2, Line: 2, Body: "  name = \"app\"\n"},
+		{Path: "live/app/terragrunt.hcl", StartLine: 5, Line: 7, Body: "  a = 1\n"},
+		{Path: "live/db/terragrunt.hcl", StartLine: 40, Line: 40, Body: "}\n"},
+	})
+	if posted != 2 || len(comments) != 2 {
+		t.Fatalf("posted %d suggestions (%d received), want 2", posted, len(comments))
+	}
+	want := reviewCommentRequest{
+		Body:     "🧹 Formatting (`terragrunt hcl fmt`):\n\n```suggestion\n  name = \"app\"\n```",
+		CommitID: "abc123", Path: "live/app/terragrunt.hcl", Line: 2, Side: "RIGHT",
+	}
+	if comments[0] != want {
+		t.Errorf("single line suggestion = %+v, want %+v", comments[0], want)
+	}
+	if comments[1].StartLine != 5 || comments[1].StartSide != "RIGHT" || comments[1].Line != 7 {
+		t.Errorf("multi-line suggestion = %+v, want lines 5-7", comments[1])
+	}
+}
+
+// Fields of a pull request review comment as sent to the API
+type reviewCommentRequest struct {
+	Body      string `json:"body"`
+	CommitID  string `json:"commit_id"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Side      string `json:"side"`
+	StartLine int    `json:"start_line"`
+	StartSide string `json:"start_side"`
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// Where results are posted
+const (
+	TargetPR     = "pr"     // Pull request comments
+	TargetCommit = "commit" // Commit comments, e.g. for push-triggered plans on main
+	TargetIssue  = "issue"  // Issue comments, e.g. on a drift tracking issue
+)
+
+var reCommitSHA = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// Comment of the results target, whatever its kind
+type targetComment struct {
+	ID      int64
+	Body    string
+	Login   string
+	URL     string    // Web URL of the comment
+	Created time.Time // When the comment was posted
+}
+
+// Validate the results target, defaulting a commit target to the workflow commit
+func (r *Runner) validateTarget() error {
+	switch r.config.Target {
+	case "", TargetPR:
+		if r.config.TargetID != "" {
+			return fmt.Errorf("target-id is only used with target commit or issue (use pull-request for pull requests)")
+		}
+	case TargetCommit:
+		if r.config.TargetID == "" {
+			r.config.TargetID = os.Getenv("GITHUB_SHA")
+		}
+		if !reCommitSHA.MatchString(r.config.TargetID) {
+			return fmt.Errorf("invalid target-id for target commit: %q (expected a commit SHA)", r.config.TargetID)
+		}
+	case TargetIssue:
+		if n, err := strconv.Atoi(r.config.TargetID); err != nil || n <= 0 {
+			return fmt.Errorf("invalid target-id for target issue: %q (expected an issue number)", r.config.TargetID)
+		}
+	default:
+		return fmt.Errorf("invalid target: %s (expected pr, commit or issue)", r.config.Target)
+	}
+	return nil
+}
+
+// Get the issue or pull request number receiving the comments (0 for commits)
+func (r *Runner) targetNumber() int {
+	if r.config.Target == TargetIssue {
+		n, _ := strconv.Atoi(r.config.TargetID)
+		return n
+	}
+	if r.config.Target == TargetCommit {
+		return 0
+	}
+	return r.config.PullRequest
+}
+
+// Post a comment to the target, returning its ID
+func (r *Runner) postTargetComment(ctx context.Context, client *github.Client, owner, repo, body string) (int64, error) {
+	if r.config.Target == TargetCommit {
+		comment, _, err := client.Repositories.CreateComment(ctx, owner, repo, r.config.TargetID, &github.RepositoryComment{Body: &body})
+		return comment.GetID(), err
+	}
+	comment, _, err := client.Issues.CreateComment(ctx, owner, repo, r.targetNumber(), &github.IssueComment{Body: &body})
+	return comment.GetID(), err
+}
+
+// Replace the body of a comment of the target
+func (r *Runner) editTargetComment(ctx context.Context, client *github.Client, owner, repo string, id int64, body string) error {
+	if r.config.Target == TargetCommit {
+		_, _, err := client.Repositories.UpdateComment(ctx, owner, repo, id, &github.RepositoryComment{Body: &body})
+		return err
+	}
+	_, _, err := client.Issues.EditComment(ctx, owner, repo, id, &github.IssueComment{Body: &body})
+	return err
+}
+
+// Delete a comment of the target
+func (r *Runner) deleteTargetComment(ctx context.Context, client *github.Client, owner, repo string, id int64) error {
+	if r.config.Target == TargetCommit {
+		_, err := client.Repositories.DeleteComment(ctx, owner, repo, id)
+		return err
+	}
+	_, err := client.Issues.DeleteComment(ctx, owner, repo, id)
+	return err
+}
+
+// List every comment of the target
+func (r *Runner) listTargetComments(ctx context.Context, client *github.Client, owner, repo string) ([]targetComment, error) {
+	var all []targetComment
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		var resp *github.Response
+		if r.config.Target == TargetCommit {
+			comments, res, err := client.Repositories.ListCommitComments(ctx, owner, repo, r.config.TargetID, &opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range comments {
+				all = append(all, targetComment{ID: c.GetID(), Body: c.GetBody(), Login: c.GetUser().GetLogin(), URL: c.GetHTMLURL(), Created: c.GetCreatedAt().Time})
+			}
+			resp = res
+		} else {
+			comments, res, err := client.Issues.ListComments(ctx, owner, repo, r.targetNumber(), &github.IssueListCommentsOptions{ListOptions: opts})
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range comments {
+				all = append(all, targetComment{ID: c.GetID(), Body: c.GetBody(), Login: c.GetUser().GetLogin(), URL: c.GetHTMLURL(), Created: c.GetCreatedAt().Time})
+			}
+			resp = res
+		}
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTarget(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "0123456789abcdef0123456789abcdef01234567")
+
+	tests := []struct {
+		name    string
+		target  string
+		id      string
+		wantID  string
+		wantErr string
+	}{
+		{name: "default pr", target: ""},
+		{name: "pr", target: TargetPR},
+		{name: "pr with id", target: TargetPR, id: "5", wantErr: "target-id is only used"},
+		{name: "commit defaults to GITHUB_SHA", target: TargetCommit, wantID: "0123456789abcdef0123456789abcdef01234567"},
+		{name: "commit", target: TargetCommit, id: "abc1234", wantID: "abc1234"},
+		{name: "commit with ref", target: TargetCommit, id: "main", wantErr: "expected a commit SHA"},
+		{name: "issue", target: TargetIssue, id: "42", wantID: "42"},
+		{name: "issue without number", target: TargetIssue, wantErr: "expected an issue number"},
+		{name: "unknown", target: "discussion", wantErr: "invalid target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{Target: tt.target, TargetID: tt.id})
+			err := r.validateTarget()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("validateTarget() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateTarget() error = %v", err)
+			}
+			if r.config.TargetID != tt.wantID {
+				t.Errorf("TargetID = %q, want %q", r.config.TargetID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestTargetComments(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		createPath string
+		listPath   string
+		deletePath string
+	}{
+		{
+			name:       "pull request",
+			config:     Config{PullRequest: 7},
+			createPath: "/repos/owner/repo/issues/7/comments",
+			listPath:   "/repos/owner/repo/issues/7/comments",
+			deletePath: "/repos/owner/repo/issues/comments/11",
+		},
+		{
+			name:       "issue",
+			config:     Config{Target: TargetIssue, TargetID: "42"},
+			createPath: "/repos/owner/repo/issues/42/comments",
+			listPath:   "/repos/owner/repo/issues/42/comments",
+			deletePath: "/repos/owner/repo/issues/comments/11",
+		},
+		{
+			name:       "commit",
+			config:     Config{Target: TargetCommit, TargetID: "abc1234"},
+			createPath: "/repos/owner/repo/commits/abc1234/comments",
+			listPath:   "/repos/owner/repo/commits/abc1234/comments",
+			deletePath: "/repos/owner/repo/comments/11",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created, deleted []string
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.Method == http.MethodPost && req.URL.Path == tt.createPath:
+					created = append(created, req.URL.Path)
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id": 1}`))
+				case req.Method == http.MethodGet && req.URL.Path == tt.listPath:
+					w.Write([]byte(`[
+						{"id": 11, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=1 -->\nold", "user": {"login": "github-actions[bot]"}},
+						{"id": 12, "body": "looks good", "user": {"login": "octocat"}}
+					]`))
+				case req.Method == http.MethodDelete && req.URL.Path == tt.deletePath:
+					deleted = append(deleted, req.URL.Path)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			config := tt.config
+			config.Repository = "owner/repo"
+			r := newTestRunner(&config)
+			ctx := context.Background()
+			if err := r.deleteOldComments(ctx, client); err != nil {
+				t.Fatalf("deleteOldComments() error = %v", err)
+			}
+			if err := r.createComment(ctx, client, "owner", "repo", "live/app", "## Terragrunt Summary"); err != nil {
+				t.Fatalf("createComment() error = %v", err)
+			}
+			if len(deleted) != 1 || len(created) != 1 {
+				t.Errorf("deleted %v and created %v, want one runner comment replaced", deleted, created)
+			}
+		})
+	}
+}
+
+func TestDeleteOldCommentsLimits(t *testing.T) {
+	now := time.Now().UTC()
+	comments := []map[string]any{
+		{"id": 1, "body": "<!-- terragrunt-runner:folder=a;run=1 -->\nold", "user": map[string]string{"login": "github-actions[bot]"}, "created_at": now.Add(-72 * time.Hour)},
+		{"id": 2, "body": "<!-- terragrunt-runner:folder=b;run=1 -->\nolder", "user": map[string]string{"login": "github-actions[bot]"}, "created_at": now.Add(-96 * time.Hour)},
+		{"id": 3, "body": "<!-- terragrunt-runner:folder=c;run=2 -->\nrecent", "user": map[string]string{"login": "github-actions[bot]"}, "created_at": now.Add(-time.Hour)},
+		{"id": 4, "body": "## Terragrunt Summary\n\nother bot", "user": map[string]string{"login": "other-ci[bot]"}, "created_at": now.Add(-96 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected []string
+	}{
+		{name: "all marked comments", config: Config{}, expected: []string{"2", "1", "3"}},
+		{name: "older than", config: Config{DeleteCommentsOlderThan: 24}, expected: []string{"2", "1"}},
+		{name: "oldest first up to the cap", config: Config{DeleteMax: 2}, expected: []string{"2", "1"}},
+		{name: "dry run", config: Config{DeleteDryRun: true}, expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodDelete {
+					deleted = append(deleted, req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				json.NewEncoder(w).Encode(comments)
+			}))
+
+			config := tt.config
+			config.Repository, config.PullRequest = "owner/repo", 7
+			if err := newTestRunner(&config).deleteOldComments(context.Background(), client); err != nil {
+				t.Fatalf("deleteOldComments() error = %v", err)
+			}
+			if !reflect.DeepEqual(deleted, tt.expected) {
+				t.Errorf("deleted comments %v, want %v", deleted, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HCP Terraform API polling, variables so tests can shorten them
+var (
+	tfcScheme       = "https"
+	tfcPollInterval = 5 * time.Second
+	tfcRunTimeout   = 60 * time.Minute
+)
+
+var (
+	reCloudBlock     = regexp.MustCompile(`(?m)^\s*cloud\s*\{`)
+	reRemoteBackend  = regexp.MustCompile(`(?m)^\s*backend\s+"remote"\s*\{`)
+	reTFCOrg         = regexp.MustCompile(`organization\s*=\s*"([^"]+)"`)
+	reTFCHostname    = regexp.MustCompile(`hostname\s*=\s*"([^"]+)"`)
+	reTFCWorkspaces  = regexp.MustCompile(`workspaces\s*\{`)
+	reTFCWorkspace   = regexp.MustCompile(`\bname\s*=\s*"([^"]+)"`)
+	tfcUploadExclude = []string{".git", ".terraform", ".terragrunt-cache"}
+)
+
+// Final statuses of an HCP Terraform run, and those of a successful plan
+var (
+	tfcFinalStatuses   = []string{"planned_and_finished", "planned_and_saved", "errored", "canceled", "force_canceled", "discarded", "policy_soft_failed"}
+	tfcSuccessStatuses = []string{"planned_and_finished", "planned_and_saved"}
+)
+
+// HCP Terraform (or Terraform Enterprise) workspace of a folder
+type TFCWorkspace struct {
+	Hostname     string
+	Organization string
+	Name         string
+}
+
+// Detect the HCP Terraform workspace of a folder from a cloud block or a remote
+// backend in its unit file or Terraform files (nil if the folder plans locally).
+// Workspaces selected by tags or prefix cannot be resolved and are an error.
+func (r *Runner) detectTFCWorkspace(absFolder string) (*TFCWorkspace, error) {
+	files, _ := filepath.Glob(filepath.Join(absFolder, "*.tf"))
+	if name := r.unitFile(absFolder); name != "" {
+		files = append([]string{filepath.Join(absFolder, name)}, files...)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		loc := reCloudBlock.FindStringIndex(string(content))
+		if loc == nil {
+			loc = reRemoteBackend.FindStringIndex(string(content))
+		}
+		if loc == nil {
+			continue
+		}
+		return parseTFCBlock(blockBody(string(content), loc[1]), filepath.Base(file))
+	}
+	return nil, nil
+}
+
+// Parse the organization, hostname and workspace name of a cloud or remote backend block
+func parseTFCBlock(body, file string) (*TFCWorkspace, error) {
+	ws := &TFCWorkspace{Hostname: "app.terraform.io"}
+	if m := reTFCHostname.FindStringSubmatch(body); m != nil {
+		ws.Hostname = m[1]
+	}
+	if m := reTFCOrg.FindStringSubmatch(body); m != nil {
+		ws.Organization = m[1]
+	}
+	if loc := reTFCWorkspaces.FindStringIndex(body); loc != nil {
+		if m := reTFCWorkspace.FindStringSubmatch(blockBody(body, loc[1])); m != nil {
+			ws.Name = m[1]
+		}
+	}
+	if ws.Organization == "" || ws.Name == "" {
+		return nil, fmt.Errorf("HCP Terraform block in %s needs a literal organization and workspace name", file)
+	}
+	return ws, nil
+}
+
+// Minimal HCP Terraform API client
+type tfcClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// JSON:API document of the HCP Terraform API
+type tfcDocument struct {
+	Data struct {
+		ID            string         `json:"id"`
+		Attributes    map[string]any `json:"attributes"`
+		Relationships map[string]struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+// Send an API request, decoding the response document
+func (c *tfcClient) do(method, path string, body any) (*tfcDocument, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	var doc tfcDocument
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, path, err)
+		}
+	}
+	return &doc, nil
+}
+
+// Package a folder as a configuration version upload (tar.gz), leaving out
+// caches and git metadata
+func tfcConfigArchive(folder string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(folder, path)
+		if rel == "." {
+			return nil
+		}
+		for _, excluded := range tfcUploadExclude {
+			if d.Name() == excluded {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !d.Type().IsRegular() && !d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Poll an API resource until its status is one of the wanted ones
+func (c *tfcClient) waitForStatus(path string, statuses []string) (*tfcDocument, error) {
+	deadline := time.Now().Add(tfcRunTimeout)
+	for {
+		doc, err := c.do(http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		status, _ := doc.Data.Attributes["status"].(string)
+		if slices.Contains(statuses, status) {
+			return doc, nil
+		}
+		if status == "errored" {
+			return nil, fmt.Errorf("%s errored", path)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for %s (status %s)", tfcRunTimeout, path, status)
+		}
+		time.Sleep(tfcPollInterval)
+	}
+}
+
+// Outcome of an HCP Terraform run
+type tfcRun struct {
+	Status string         // Final run status
+	Plan   map[string]any // Attributes of the run plan (resource counts)
+	URL    string         // Run page
+	Log    string         // Plan log
+}
+
+// Run a speculative plan of the folder in its HCP Terraform workspace instead
+// of locally. The run URL is set as soon as the run exists, even on errors.
+func (r *Runner) runTFCPlan(absFolder string, ws *TFCWorkspace) (*tfcRun, error) {
+	result := &tfcRun{}
+	c := &tfcClient{base: tfcScheme + "://" + ws.Hostname + "/api/v2", token: r.config.TFCToken, http: &http.Client{Timeout: time.Minute}}
+
+	workspace, err := c.do(http.MethodGet, fmt.Sprintf("/organizations/%s/workspaces/%s", ws.Organization, ws.Name), nil)
+	if err != nil {
+		return result, err
+	}
+	cv, err := c.do(http.MethodPost, "/workspaces/"+workspace.Data.ID+"/configuration-versions", map[string]any{
+		"data": map[string]any{"type": "configuration-versions", "attributes": map[string]any{"auto-queue-runs": false, "speculative": true}},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	archive, err := tfcConfigArchive(absFolder)
+	if err != nil {
+		return result, fmt.Errorf("failed to package %s: %w", absFolder, err)
+	}
+	uploadURL, _ := cv.Data.Attributes["upload-url"].(string)
+	req, err := http.NewRequest(http.MethodPut, uploadURL, bytes.NewReader(archive))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("failed to upload configuration: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return result, fmt.Errorf("failed to upload configuration: %s", resp.Status)
+	}
+	if _, err := c.waitForStatus("/configuration-versions/"+cv.Data.ID, []string{"uploaded"}); err != nil {
+		return result, err
+	}
+
+	run, err := c.do(http.MethodPost, "/runs", map[string]any{
+		"data": map[string]any{
+			"type":       "runs",
+			"attributes": map[string]any{"plan-only": true, "message": fmt.Sprintf("terragrunt-runner %s (run %s)", r.config.Command, getRunID())},
+			"relationships": map[string]any{
+				"workspace":             map[string]any{"data": map[string]any{"type": "workspaces", "id": workspace.Data.ID}},
+				"configuration-version": map[string]any{"data": map[string]any{"type": "configuration-versions", "id": cv.Data.ID}},
+			},
+		},
+	})
+	if err != nil {
+		return result, err
+	}
+	result.URL = fmt.Sprintf("%s://%s/app/%s/workspaces/%s/runs/%s", tfcScheme, ws.Hostname, ws.Organization, ws.Name, run.Data.ID)
+	r.logger.Info("Started HCP Terraform run", "workspace", ws.Organization+"/"+ws.Name, "url", result.URL)
+
+	run, err = c.waitForStatus("/runs/"+run.Data.ID, tfcFinalStatuses)
+	if err != nil {
+		return result, err
+	}
+	result.Status, _ = run.Data.Attributes["status"].(string)
+	plan, err := c.do(http.MethodGet, "/plans/"+run.Data.Relationships["plan"].Data.ID, nil)
+	if err != nil {
+		return result, err
+	}
+	result.Plan = plan.Data.Attributes
+
+	// The plan log is best effort: counts come from the plan itself
+	if logURL, _ := plan.Data.Attributes["log-read-url"].(string); logURL != "" {
+		if resp, err := c.http.Get(logURL); err == nil {
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			result.Log = string(data)
+		}
+	}
+	return result, nil
+}
+
+// Execute the command of a folder as an HCP Terraform run
+func (r *Runner) executeTFCRun(folder, absFolder string, ws *TFCWorkspace) ExecutionResult {
+	if !isPlanCommand(r.config.Command) {
+		return ExecutionResult{Folder: folder, Success: false,
+			Error: fmt.Errorf("%s uses HCP Terraform workspace %s/%s: only plans run there from the runner, apply through HCP Terraform", folder, ws.Organization, ws.Name)}
+	}
+
+	start := time.Now()
+	run, err := r.runTFCPlan(absFolder, ws)
+	result := ExecutionResult{
+		Folder:         folder,
+		Output:         extractTerraformOutput(run.Log),
+		FullOutput:     stripAnsiCodes(run.Log),
+		PlannedOutputs: parsePlannedOutputs(run.Log),
+		Duration:       time.Since(start),
+		Engine:         "Terraform",
+		RemoteRunURL:   run.URL,
+	}
+	fmt.Printf("::group::HCP Terraform run in %s\n%s\n%s\n::endgroup::\n", folder, run.URL, run.Log)
+	if err != nil {
+		result.Error = fmt.Errorf("HCP Terraform run: %w", err)
+		return result
+	}
+
+	result.Success = slices.Contains(tfcSuccessStatuses, run.Status)
+	if !result.Success {
+		result.Error = fmt.Errorf("HCP Terraform run ended with status %s", run.Status)
+		return result
+	}
+	count := func(key string) int {
+		n, _ := run.Plan[key].(float64)
+		return int(n)
+	}
+	hasChanges, _ := run.Plan["has-changes"].(bool)
+	result.ResourceChanges = &ResourceChanges{
+		ToAdd:     count("resource-additions"),
+		ToChange:  count("resource-changes"),
+		ToDestroy: count("resource-destructions"),
+		ToImport:  count("resource-imports"),
+		NoChanges: !hasChanges,
+	}
+	// The plan attributes have no replacement count, the log shows them
+	result.ResourceChanges.ToReplace = countReplacements(run.Log)
+	return result
+}
+
+// Format the link to the HCP Terraform run of a folder for its comment header
+func formatTFCRunNote(result ExecutionResult) string {
+	if result.RemoteRunURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("☁️ **HCP Terraform run:** %s\n", result.RemoteRunURL)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectTFCWorkspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *TFCWorkspace
+		wantErr string
+	}{
+		{name: "local backend", files: map[string]string{"terragrunt.hcl": "remote_state {\n  backend = \"s3\"\n}\n"}},
+		{
+			name:  "cloud block",
+			files: map[string]string{"main.tf": "terraform {\n  cloud {\n    organization = \"acme\"\n    workspaces {\n      name = \"app-prod\"\n    }\n  }\n}\n"},
+			want:  &TFCWorkspace{Hostname: "app.terraform.io", Organization: "acme", Name: "app-prod"},
+		},
+		{
+			name: "remote backend generated by terragrunt",
+			files: map[string]string{"terragrunt.hcl": `generate "backend" {
+  path      = "backend.tf"
+  if_exists = "overwrite"
+  contents  = <<EOF
+terraform {
+  backend "remote" {
+    hostname     = "tfe.example.com"
+    organization = "acme"
+    workspaces {
+      name = "dns"
+    }
+  }
+}
+EOF
+}
+`},
+			want: &TFCWorkspace{Hostname: "tfe.example.com", Organization: "acme", Name: "dns"},
+		},
+		{
+			name:    "tagged workspaces",
+			files:   map[string]string{"main.tf": "terraform {\n  cloud {\n    organization = \"acme\"\n    workspaces {\n      tags = [\"app\"]\n    }\n  }\n}\n"},
+			wantErr: "literal organization and workspace name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+			}
+			r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}})
+			got, err := r.detectTFCWorkspace(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("detectTFCWorkspace() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("detectTFCWorkspace() error = %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("detectTFCWorkspace() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTFCConfigArchive(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.tf"), []byte("terraform {}\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, ".terraform", "providers"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".terraform", "providers", "big"), []byte("binary"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "modules", "app"), 0o755)
+	os.WriteFile(filepath.Join(dir, "modules", "app", "main.tf"), []byte("# module\n"), 0o644)
+
+	archive, err := tfcConfigArchive(dir)
+	if err != nil {
+		t.Fatalf("tfcConfigArchive() error = %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	if got := strings.Join(names, ","); got != "main.tf,modules/,modules/app/,modules/app/main.tf" {
+		t.Errorf("archive entries = %s", got)
+	}
+}
+
+func TestExecuteTFCRun(t *testing.T) {
+	oldScheme, oldInterval := tfcScheme, tfcPollInterval
+	t.Cleanup(func() { tfcScheme, tfcPollInterval = oldScheme, oldInterval })
+	tfcScheme, tfcPollInterval = "http", time.Millisecond
+
+	var server *httptest.Server
+	runPolls, uploaded := 0, false
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/upload" && req.URL.Path != "/log" && req.Header.Get("Authorization") != "Bearer tfc-token" {
+			t.Errorf("%s %s without the API token", req.Method, req.URL.Path)
+		}
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/api/v2/organizations/acme/workspaces/app-prod":
+			w.Write([]byte(`{"data": {"id": "ws-1"}}`))
+		case req.Method == http.MethodPost && req.URL.Path == "/api/v2/workspaces/ws-1/configuration-versions":
+			body, _ := io.ReadAll(req.Body)
+			if !strings.Contains(string(body), `"speculative":true`) {
+				t.Errorf("configuration version = %s, want speculative", body)
+			}
+			fmt.Fprintf(w, `{"data": {"id": "cv-1", "attributes": {"upload-url": "%s/upload"}}}`, server.URL)
+		case req.Method == http.MethodPut && req.URL.Path == "/upload":
+			uploaded = true
+		case req.Method == http.MethodGet && req.URL.Path == "/api/v2/configuration-versions/cv-1":
+			w.Write([]byte(`{"data": {"id": "cv-1", "attributes": {"status": "uploaded"}}}`))
+		case req.Method == http.MethodPost && req.URL.Path == "/api/v2/runs":
+			body, _ := io.ReadAll(req.Body)
+			if !strings.Contains(string(body), `"plan-only":true`) || !strings.Contains(string(body), `"id":"cv-1"`) {
+				t.Errorf("run = %s, want a plan-only run of the uploaded configuration", body)
+			}
+			w.Write([]byte(`{"data": {"id": "run-1", "attributes": {"status": "pending"}}}`))
+		case req.Method == http.MethodGet && req.URL.Path == "/api/v2/runs/run-1":
+			runPolls++
+			status := "planning"
+			if runPolls > 1 {
+				status = "planned_and_finished"
+			}
+			fmt.Fprintf(w, `{"data": {"id": "run-1", "attributes": {"status": %q}, "relationships": {"plan": {"data": {"id": "plan-1"}}}}}`, status)
+		case req.Method == http.MethodGet && req.URL.Path == "/api/v2/plans/plan-1":
+			fmt.Fprintf(w, `{"data": {"id": "plan-1", "attributes": {"has-changes": true, "resource-additions": 2, "resource-changes": 1, "resource-destructions": 1, "log-read-url": "%s/log"}}}`, server.URL)
+		case req.Method == http.MethodGet && req.URL.Path == "/log":
+			w.Write([]byte("Terraform will perform the following actions:\n\n  # aws_instance.web must be replaced\n-/+ resource \"aws_instance\" \"web\" {}\n\nPlan: 2 to add, 1 to change, 1 to destroy.\n"))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.tf"), []byte("terraform {}\n"), 0o644)
+	ws := &TFCWorkspace{Hostname: host, Organization: "acme", Name: "app-prod"}
+
+	r := newTestRunner(&Config{Command: "plan", TFCToken: "tfc-token"})
+	result := r.executeTFCRun("live/app", dir, ws)
+	if !result.Success || result.Error != nil {
+		t.Fatalf("executeTFCRun() = %+v", result)
+	}
+	if !uploaded || runPolls != 2 {
+		t.Errorf("uploaded = %v, run polled %d times", uploaded, runPolls)
+	}
+	if c := result.ResourceChanges; c.ToAdd != 2 || c.ToChange != 1 || c.ToDestroy != 1 || c.ToReplace != 1 || c.NoChanges {
+		t.Errorf("resource changes = %+v", c)
+	}
+	wantURL := "http://" + host + "/app/acme/workspaces/app-prod/runs/run-1"
+	if result.RemoteRunURL != wantURL || !strings.Contains(formatTFCRunNote(result), wantURL) {
+		t.Errorf("run URL = %s, want %s", result.RemoteRunURL, wantURL)
+	}
+
+	r = newTestRunner(&Config{Command: "apply", TFCToken: "tfc-token"})
+	if result := r.executeTFCRun("live/app", dir, ws); result.Success || !strings.Contains(result.Error.Error(), "only plans") {
+		t.Errorf("executeTFCRun() apply = %+v, want refused", result)
+	}
+}
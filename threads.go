@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+)
+
+// How the results of a folder relate to its comments of earlier runs,
+// selectable with --comment-threading
+const (
+	ThreadingOff       = "off"       // New top-level comments, earlier ones deleted
+	ThreadingReference = "reference" // Keep earlier comments and link the latest one
+	ThreadingReview    = "review"    // Reply in a review thread on the unit file
+)
+
+var threadingModes = []string{ThreadingOff, ThreadingReference, ThreadingReview}
+
+// Comment threads of the folders, loaded before the results are posted
+type commentThreads struct {
+	mode     string
+	head     string            // Head commit of the pull request, anchoring new review threads
+	previous map[string]string // Latest earlier comment per folder (reference)
+	roots    map[string]int64  // Review comment starting the thread per folder (review)
+}
+
+// Check whether a marker folder key is a folder rather than a run-level
+// comment such as the summary
+func isFolderMarker(folder string) bool {
+	return folder != "" && !strings.HasPrefix(folder, "_")
+}
+
+// Check whether a comment is a folder comment kept as thread history
+func (r *Runner) keepsThreadComment(body string) bool {
+	if r.config.CommentThreading != ThreadingReference {
+		return false
+	}
+	fields, ok := parseCommentMarker(body)
+	return ok && isFolderMarker(fields["folder"])
+}
+
+// Load the comment threads of the folders from the earlier runner comments.
+// Threads that cannot be loaded leave the results as new comments.
+func (r *Runner) loadCommentThreads(ctx context.Context, client *github.Client) *commentThreads {
+	threads := &commentThreads{mode: r.config.CommentThreading, previous: map[string]string{}, roots: map[string]int64{}}
+	parts := strings.Split(r.config.Repository, "/")
+	owner, repo := parts[0], parts[1]
+
+	if threads.mode == ThreadingReference {
+		comments, err := r.listTargetComments(ctx, client, owner, repo)
+		if err != nil {
+			r.logger.Warn("Failed to list comments for threading", "error", err)
+			return threads
+		}
+		// Comments are listed oldest first, so the latest of a folder wins
+		for _, comment := range comments {
+			if fields, ok := parseCommentMarker(comment.Body); ok && strings.Contains(comment.Login, "[bot]") && isFolderMarker(fields["folder"]) && comment.URL != "" {
+				threads.previous[fields["folder"]] = comment.URL
+			}
+		}
+		return threads
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, r.config.PullRequest)
+	if err != nil {
+		r.logger.Warn("Failed to read the pull request head for review threads", "error", err)
+		return threads
+	}
+	threads.head = pr.GetHead().GetSHA()
+	opts := &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.PullRequests.ListComments(ctx, owner, repo, r.config.PullRequest, opts)
+		if err != nil {
+			r.logger.Warn("Failed to list review comments for threading", "error", err)
+			return threads
+		}
+		for _, comment := range comments {
+			fields, ok := parseCommentMarker(comment.GetBody())
+			if !ok || comment.InReplyTo != nil || !strings.Contains(comment.GetUser().GetLogin(), "[bot]") || !isFolderMarker(fields["folder"]) {
+				continue
+			}
+			if _, seen := threads.roots[fields["folder"]]; !seen {
+				threads.roots[fields["folder"]] = comment.GetID()
+			}
+		}
+		if resp.NextPage == 0 {
+			return threads
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Format the reference to the latest earlier comment of a folder (empty if none)
+func (r *Runner) threadReference(folder string) string {
+	if r.threads == nil || r.threads.mode != ThreadingReference || r.threads.previous[folder] == "" {
+		return ""
+	}
+	return "> " + r.msg("comment.follow_up", "url", r.threads.previous[folder], "folder", folder) + "\n\n"
+}
+
+// Post the comment of a folder in its review thread, starting the thread on
+// the unit file when there is none yet. False when the folder has no review
+// thread to post in.
+func (r *Runner) postInReviewThread(ctx context.Context, client *github.Client, owner, repo, folder, body string) (bool, error) {
+	if r.threads == nil || r.threads.mode != ThreadingReview {
+		return false, nil
+	}
+	if id, ok := r.threads.roots[folder]; ok {
+		_, _, err := client.PullRequests.CreateCommentInReplyTo(ctx, owner, repo, r.config.PullRequest, body, id)
+		return err == nil, err
+	}
+	name := r.unitFile(folder)
+	if name == "" || r.threads.head == "" {
+		return false, nil
+	}
+	comment, _, err := client.PullRequests.CreateComment(ctx, owner, repo, r.config.PullRequest, &github.PullRequestComment{
+		Body:        &body,
+		CommitID:    &r.threads.head,
+		Path:        github.Ptr(path.Join(filepath.ToSlash(folder), name)),
+		SubjectType: github.Ptr("file"),
+	})
+	if err != nil {
+		return false, err
+	}
+	// Later parts of a split comment reply in the new thread
+	r.threads.roots[folder] = comment.GetID()
+	return true, nil
+}
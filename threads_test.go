@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReferenceThreads(t *testing.T) {
+	var deleted []string
+	var posted []string
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/issues/1/comments":
+			w.Write([]byte(`[
+				{"id": 11, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=1 -->\nold", "html_url": "https://github.com/owner/repo/pull/1#issuecomment-11", "user": {"login": "github-actions[bot]"}},
+				{"id": 12, "body": "<!-- terragrunt-runner:folder=_summary;run=1 -->\nsummary", "html_url": "https://github.com/owner/repo/pull/1#issuecomment-12", "user": {"login": "github-actions[bot]"}},
+				{"id": 13, "body": "why does this replace the instance?", "html_url": "https://github.com/owner/repo/pull/1#issuecomment-13", "user": {"login": "octocat"}},
+				{"id": 14, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=2 -->\nnewer", "html_url": "https://github.com/owner/repo/pull/1#issuecomment-14", "user": {"login": "github-actions[bot]"}}
+			]`))
+		case req.Method == http.MethodDelete:
+			deleted = append(deleted, req.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/issues/1/comments":
+			var comment struct {
+				Body string `json:"body"`
+			}
+			json.NewDecoder(req.Body).Decode(&comment)
+			posted = append(posted, comment.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 20}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	ctx := context.Background()
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, CommentThreading: ThreadingReference})
+	r.threads = r.loadCommentThreads(ctx, client)
+	if err := r.deleteOldComments(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deleted, ",") != "/repos/owner/repo/issues/comments/12" {
+		t.Errorf("deleted = %v, want only the summary", deleted)
+	}
+
+	for _, folder := range []string{"live/app", "live/db"} {
+		if err := r.createComment(ctx, client, "owner", "repo", folder, "## Plan"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(posted) != 2 {
+		t.Fatalf("posted %d comments, want 2", len(posted))
+	}
+	reference := "[previous results](https://github.com/owner/repo/pull/1#issuecomment-14)"
+	if !strings.Contains(posted[0], reference) || !strings.HasPrefix(posted[0], "<!-- terragrunt-runner:folder=live%2Fapp") {
+		t.Errorf("live/app comment = %q, want the marker and a reference to the latest comment", posted[0])
+	}
+	if strings.Contains(posted[1], "previous results") {
+		t.Errorf("live/db comment = %q, want no reference", posted[1])
+	}
+}
+
+func TestReviewThreads(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.MkdirAll("live/db", 0o755)
+	os.WriteFile("live/db/terragrunt.hcl", nil, 0o644)
+
+	var requests []map[string]any
+	client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/pulls/1":
+			w.Write([]byte(`{"number": 1, "head": {"sha": "abc123"}}`))
+		case req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/pulls/1/comments":
+			w.Write([]byte(`[
+				{"id": 5, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=1 -->\nplan", "user": {"login": "github-actions[bot]"}},
+				{"id": 6, "body": "<!-- terragrunt-runner:folder=live%2Fapp;run=2 -->\nplan", "in_reply_to_id": 5, "user": {"login": "github-actions[bot]"}}
+			]`))
+		case req.Method == http.MethodPost && req.URL.Path == "/repos/owner/repo/pulls/1/comments":
+			body, _ := io.ReadAll(req.Body)
+			var fields map[string]any
+			json.Unmarshal(body, &fields)
+			requests = append(requests, fields)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 30}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	ctx := context.Background()
+	r := newTestRunner(&Config{Repository: "owner/repo", PullRequest: 1, CommentThreading: ThreadingReview, TerragruntFiles: []string{"terragrunt.hcl"}})
+	r.threads = r.loadCommentThreads(ctx, client)
+	for _, folder := range []string{"live/app", "live/db", "live/db"} {
+		if err := r.createComment(ctx, client, "owner", "repo", folder, "## Plan"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(requests) != 3 {
+		t.Fatalf("review comment requests = %v, want 3", requests)
+	}
+	if requests[0]["in_reply_to"] != float64(5) {
+		t.Errorf("live/app = %v, want a reply to the thread root", requests[0])
+	}
+	if requests[1]["path"] != "live/db/terragrunt.hcl" || requests[1]["subject_type"] != "file" || requests[1]["commit_id"] != "abc123" {
+		t.Errorf("live/db = %v, want a new thread on the unit file", requests[1])
+	}
+	if requests[2]["in_reply_to"] != float64(30) {
+		t.Errorf("live/db second part = %v, want a reply to the new thread", requests[2])
+	}
+}
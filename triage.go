@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Timeout of the debug rerun of a failed folder
+const triageRerunTimeout = 10 * time.Minute
+
+// Environment variable names whose values are never written to a triage bundle
+var reSensitiveEnvName = regexp.MustCompile(`(?i)token|secret|passw|key|credential|auth|session|cookie|private|signature`)
+
+// Environment variables whose values a triage bundle records; any other
+// variable (TF_VAR_*, proxies with credentials, ...) is recorded by name only
+var triageEnvValues = []string{
+	"PATH", "HOME", "USER", "SHELL", "TMPDIR", "TMP", "TEMP", "TERM", "TZ", "LANG", "LC_*",
+	"CI", "RUNNER_TEMP", "SSL_CERT_FILE", "SSL_CERT_DIR", "NO_COLOR",
+	"TF_IN_AUTOMATION", "TF_LOG", "TF_LOG_*", "TF_PLUGIN_CACHE_DIR", "TG_NON_INTERACTIVE",
+	"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE",
+}
+
+// Collect a triage bundle for every failed folder: the raw output, a rerun
+// with --log-level debug, the environment Terragrunt ran with and the tool
+// versions, written below the triage directory for upload as an artifact
+func (r *Runner) collectTriageBundles(results []ExecutionResult) []ExecutionResult {
+	if r.config.TriageDir == "" {
+		return results
+	}
+	versions := triageVersions()
+	for i, result := range results {
+		if result.Success || result.SetupError {
+			continue
+		}
+		dir, err := r.writeTriageBundle(result, versions)
+		if err != nil {
+			r.logger.Warn("Failed to write triage bundle", "folder", result.Folder, "error", err)
+			continue
+		}
+		r.logger.Info("Wrote triage bundle", "folder", result.Folder, "dir", dir)
+		results[i].TriageBundle = dir
+	}
+	return results
+}
+
+// Write the triage bundle of a failed folder, returning its directory
+func (r *Runner) writeTriageBundle(result ExecutionResult, versions string) (string, error) {
+	name := strings.Trim(reCrashDumpKey.ReplaceAllString(filepath.ToSlash(result.Folder), "_"), "_")
+	if name == "" {
+		name = "root"
+	}
+	dir := filepath.Join(r.config.TriageDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	output := result.FullOutput
+	if output == "" {
+		output = result.Output
+	}
+	if result.Error != nil {
+		output += "\n\nError: " + result.Error.Error() + "\n"
+	}
+	files := map[string]string{
+		"output.log":      output,
+		"debug.log":       r.triageDebugRerun(result),
+		"environment.txt": formatTriageEnv(r.subprocessEnv(r.folderEnv(result.Folder)...)),
+		"versions.txt":    versions,
+	}
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		masked, _ := scanSecrets(result.Folder, files[file])
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(masked), 0o600); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// Rerun a failed folder with --log-level debug, returning the output, or why
+// it was not rerun. Applies and run --all results are never rerun.
+func (r *Runner) triageDebugRerun(result ExecutionResult) string {
+	command, args := r.folderCommand(result.Folder)
+	switch {
+	case !r.config.TriageRerun:
+		return "Not rerun: triage-rerun is disabled.\n"
+	case isApplyCommand(command):
+		return "Not rerun: apply and destroy commands are never rerun.\n"
+	case isRunAllCommand(command) || result.RemoteRunURL != "" || len(result.StackUnits) > 0:
+		return "Not rerun: only single folder runs are rerun.\n"
+	}
+
+	absFolder := result.Folder
+	if !filepath.IsAbs(absFolder) {
+		repoRoot, err := r.getRepoRoot()
+		if err != nil {
+			return fmt.Sprintf("Not rerun: failed to determine repo root: %v\n", err)
+		}
+		absFolder = filepath.Join(repoRoot, absFolder)
+	}
+	cmdParts := append(strings.Fields(command), "--log-level", "debug")
+	if args != "" {
+		sArgs, err := sanitizeArgs(args, r.argPolicy())
+		if err != nil {
+			return fmt.Sprintf("Not rerun: %v\n", err)
+		}
+		cmdParts = append(cmdParts, sArgs...)
+	}
+	if !hasArgFlag(args, "-no-color") {
+		cmdParts = append(cmdParts, "-no-color")
+	}
+	planEnv, err := r.readonlyPlanEnv([]string{absFolder})
+	if err != nil {
+		return fmt.Sprintf("Not rerun: %v\n", err)
+	}
+	secretEnv, secretValues, err := r.fetchFolderSecrets(result.Folder)
+	if err != nil {
+		return fmt.Sprintf("Not rerun: %v\n", err)
+	}
+
+	r.logger.Info("Rerunning failed folder with debug logs for triage", "folder", result.Folder)
+	ctx, cancel := context.WithTimeout(context.Background(), triageRerunTimeout)
+	defer cancel()
+	var output strings.Builder
+	cmd := exec.CommandContext(ctx, "terragrunt", cmdParts...)
+	cmd.Dir = filepath.Clean(absFolder)
+	cmd.Env = r.subprocessEnv(slices.Concat(r.folderEnv(result.Folder), planEnv, secretEnv)...)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	err = r.runCommand(cmd)
+
+	header := "$ terragrunt " + strings.Join(cmdParts, " ") + "\n\n"
+	debugOutput := stripAnsiCodes(redactSecrets(output.String(), secretValues))
+	if err != nil {
+		return header + debugOutput + "\nExit: " + err.Error() + "\n"
+	}
+	return header + debugOutput
+}
+
+// Format an environment for a triage bundle, sorted by name, with the values
+// of the known tool settings only
+func formatTriageEnv(env []string) string {
+	var b strings.Builder
+	for _, entry := range slices.Sorted(slices.Values(env)) {
+		name, value, _ := strings.Cut(entry, "=")
+		if value != "" && (!envNameMatches(name, triageEnvValues) || reSensitiveEnvName.MatchString(name)) {
+			value = secretMask
+		}
+		b.WriteString(name + "=" + value + "\n")
+	}
+	return b.String()
+}
+
+// Describe the versions of the runner and the tools it runs
+func triageVersions() string {
+	info := getBuildInfo()
+	var b strings.Builder
+	fmt.Fprintf(&b, "terragrunt-runner: %s (%s, %s, %s)\n", info.Version, info.Commit, info.GoVersion, info.Platform)
+	for _, check := range []DoctorCheck{checkBinary("terragrunt", "--version", true), checkBinary("tofu", "-version", false), checkBinary("terraform", "-version", false)} {
+		fmt.Fprintf(&b, "%s: %s\n", check.Name, check.Detail)
+	}
+	return b.String()
+}
+
+// Format the note of a failed comment linking the triage bundle
+func formatTriageNote(result ExecutionResult) string {
+	if result.TriageBundle == "" {
+		return ""
+	}
+	where := "the `terragrunt-triage` artifact"
+	if runURL := getRunURL(); runURL != "" {
+		where = fmt.Sprintf("the [`terragrunt-triage` artifact](%s#artifacts)", runURL)
+	}
+	return fmt.Sprintf("🧰 **Triage bundle:** the raw output, a debug rerun, the environment and tool versions are in %s.\n", where)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Resource comment line opening an updated or replaced resource block in a plan
+var reUpdatedResourceLine = regexp.MustCompile(`^(\s*)# \S+ (?:will be updated in-place|must be replaced|is tainted, so must be replaced|will be replaced, as requested)`)
+
+// Check whether a line inside a resource block must be kept: changed lines,
+// comments, and the lines opening or closing blocks, maps and lists
+func keepPlanLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return false
+	}
+	for _, prefix := range []string{"+ ", "- ", "~ ", "-/+ ", "+/- ", "<= ", "#"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	switch trimmed[len(trimmed)-1] {
+	case '{', '[', '(':
+		return true
+	}
+	return strings.TrimRight(trimmed, ",") == "}" || strings.TrimRight(trimmed, ",") == "]" || strings.TrimRight(trimmed, ",") == ")"
+}
+
+// Remove the unchanged attribute lines inside updated and replaced resources,
+// keeping the changed lines and their enclosing headers. Each run of removed
+// lines is replaced by a note, as Terraform does for hidden attributes.
+func hideUnchangedAttributes(output string) string {
+	lines := strings.Split(output, "\n")
+	var kept []string
+	closing := "" // Closing line of the resource block being compacted
+	hidden, hiddenIndent := 0, ""
+	flush := func() {
+		if hidden > 0 {
+			kept = append(kept, fmt.Sprintf("%s# (%d unchanged lines hidden)", hiddenIndent, hidden))
+			hidden = 0
+		}
+	}
+
+	for _, line := range lines {
+		if closing == "" {
+			if m := reUpdatedResourceLine.FindStringSubmatch(line); m != nil {
+				closing = m[1] + "  }"
+			}
+			kept = append(kept, line)
+			continue
+		}
+		if strings.TrimRight(line, " ") == closing || rePlanResourceLine.MatchString(line) {
+			flush()
+			kept = append(kept, line)
+			closing = ""
+			if m := reUpdatedResourceLine.FindStringSubmatch(line); m != nil {
+				closing = m[1] + "  }"
+			}
+			continue
+		}
+		if keepPlanLine(line) {
+			flush()
+			kept = append(kept, line)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if hidden == 0 {
+			hiddenIndent = line[:len(line)-len(strings.TrimLeft(line, " "))]
+		}
+		hidden++
+	}
+	flush()
+	return strings.Join(kept, "\n")
+}
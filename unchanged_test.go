@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHideUnchangedAttributes(t *testing.T) {
+	plan := `Terraform will perform the following actions:
+
+  # aws_iam_policy.app will be updated in-place
+  ~ resource "aws_iam_policy" "app" {
+        id     = "arn:aws:iam::111:policy/app"
+        name   = "app"
+      ~ policy = jsonencode(
+          ~ {
+              ~ Statement = [
+                  ~ {
+                      ~ Action   = "s3:GetObject" -> "s3:*"
+                        Effect   = "Allow"
+                        Resource = "*"
+                        Sid      = "Read"
+                    },
+                ]
+                Version   = "2012-10-17"
+            }
+        )
+      ~ tags   = {
+          + "Owner" = "platform"
+            "Team"  = "infra"
+        }
+        # (2 unchanged attributes hidden)
+    }
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + bucket = "logs"
+    }
+
+Plan: 1 to add, 1 to change, 0 to destroy.`
+
+	want := `Terraform will perform the following actions:
+
+  # aws_iam_policy.app will be updated in-place
+  ~ resource "aws_iam_policy" "app" {
+        # (2 unchanged lines hidden)
+      ~ policy = jsonencode(
+          ~ {
+              ~ Statement = [
+                  ~ {
+                      ~ Action   = "s3:GetObject" -> "s3:*"
+                        # (3 unchanged lines hidden)
+                    },
+                ]
+                # (1 unchanged lines hidden)
+            }
+        )
+      ~ tags   = {
+          + "Owner" = "platform"
+            # (1 unchanged lines hidden)
+        }
+        # (2 unchanged attributes hidden)
+    }
+
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+      + bucket = "logs"
+    }
+
+Plan: 1 to add, 1 to change, 0 to destroy.`
+
+	if got := hideUnchangedAttributes(plan); got != want {
+		t.Errorf("hideUnchangedAttributes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestKeepPlanLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`      ~ ami = "a" -> "b"`, true},
+		{`      + tags = {`, true},
+		{`      - name = "x" -> null`, true},
+		{`        name = "x"`, false},
+		{`        ingress {`, true},
+		{`        }`, true},
+		{`                },`, true},
+		{`        # (3 unchanged attributes hidden)`, true},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := keepPlanLine(tt.line); got != tt.want {
+			t.Errorf("keepPlanLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
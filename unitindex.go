@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version of the unit index format; an index of another version is rebuilt
+const unitIndexVersion = 1
+
+// Index of the Terragrunt units of the working directory and their
+// dependencies, persisted between runs so large repositories are not walked
+// and parsed again
+type UnitIndex struct {
+	Version int                    `json:"version"`
+	Commit  string                 `json:"commit"` // Commit the index is up to date with
+	Files   []string               `json:"files"`  // Unit file names the index was built for
+	Units   map[string]IndexedUnit `json:"units"`  // Units by directory, relative to the working directory
+}
+
+// Unit of the index
+type IndexedUnit struct {
+	File         string   `json:"file"`                   // Name of the unit file
+	Dependencies []string `json:"dependencies,omitempty"` // Units it depends on, relative to the working directory
+}
+
+// Index a unit directory, relative to the working directory, or drop it from
+// the index when it no longer holds a unit file
+func (r *Runner) indexUnit(index *UnitIndex, dir string) {
+	name := r.statUnitFile(dir)
+	if name == "" {
+		delete(index.Units, dir)
+		return
+	}
+	index.Units[dir] = IndexedUnit{File: name, Dependencies: r.parseUnitDependencies(dir)}
+}
+
+// Build the index by walking the working directory, skipping hidden
+// directories (.git, .terragrunt-cache) and node_modules
+func (r *Runner) buildUnitIndex(commit string) (*UnitIndex, error) {
+	index := &UnitIndex{Version: unitIndexVersion, Commit: commit, Files: r.config.TerragruntFiles, Units: map[string]IndexedUnit{}}
+	err := filepath.WalkDir(".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && slices.Contains(r.config.TerragruntFiles, d.Name()) {
+			if _, ok := index.Units[filepath.Dir(p)]; !ok {
+				r.indexUnit(index, filepath.Dir(p))
+			}
+		}
+		return nil
+	})
+	return index, err
+}
+
+// Update the index with the unit files changed since its commit, including
+// uncommitted changes, re-parsing only those units
+func (r *Runner) updateUnitIndex(index *UnitIndex, commit string) error {
+	out, err := r.commandOutput(exec.Command("git", "diff", "--name-only", "--relative", index.Commit))
+	if err != nil {
+		return fmt.Errorf("failed to diff the index commit %s: %w", index.Commit, err)
+	}
+	for file := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if file != "" && slices.Contains(r.config.TerragruntFiles, filepath.Base(file)) {
+			r.indexUnit(index, filepath.Dir(filepath.FromSlash(file)))
+		}
+	}
+	index.Commit = commit
+	return nil
+}
+
+// Load the unit index from path, updating it from the diff since its commit,
+// or build it when it is missing, stale or unusable, and save it back
+func (r *Runner) loadUnitIndex(path string) (*UnitIndex, error) {
+	start := time.Now()
+	out, err := r.commandOutput(exec.Command("git", "rev-parse", "HEAD"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the commit of the unit index: %w", err)
+	}
+	commit := strings.TrimSpace(string(out))
+
+	var index *UnitIndex
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			r.logger.Warn("Ignoring invalid unit index", "path", path, "error", err)
+			index = nil
+		}
+	}
+	if index != nil && (index.Version != unitIndexVersion || !slices.Equal(index.Files, r.config.TerragruntFiles) || index.Units == nil) {
+		index = nil
+	}
+	// Uncommitted changes are picked up too, even when the commit is the same
+	if index != nil {
+		if err := r.updateUnitIndex(index, commit); err != nil {
+			r.logger.Warn("Rebuilding the unit index", "error", err)
+			index = nil
+		}
+	}
+	mode := "updated"
+	if index == nil {
+		mode = "built"
+		if index, err = r.buildUnitIndex(commit); err != nil {
+			return nil, fmt.Errorf("failed to build the unit index: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save the unit index: %w", err)
+	}
+	r.logger.Info("Loaded unit index", "mode", mode, "units", len(index.Units), "duration", time.Since(start).Round(time.Millisecond))
+	return index, nil
+}
+
+// Look up a directory in the unit index, which only covers the working
+// directory's tree outside hidden directories and node_modules; ok is false
+// when the index cannot answer for the directory
+func (r *Runner) indexedUnit(dir string) (unit IndexedUnit, indexed, ok bool) {
+	if r.unitIndex == nil {
+		return IndexedUnit{}, false, false
+	}
+	if filepath.IsAbs(dir) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return IndexedUnit{}, false, false
+		}
+		if dir, err = filepath.Rel(wd, dir); err != nil {
+			return IndexedUnit{}, false, false
+		}
+	}
+	dir = filepath.Clean(dir)
+	for _, part := range strings.Split(dir, string(filepath.Separator)) {
+		if part != "." && (strings.HasPrefix(part, ".") || part == "node_modules") {
+			return IndexedUnit{}, false, false
+		}
+	}
+	unit, indexed = r.unitIndex.Units[dir]
+	return unit, indexed, true
+}
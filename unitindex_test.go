@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUnitIndex(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(file, content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(file), 0o755)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	write("live/vpc/terragrunt.hcl", "")
+	write("live/app/terragrunt.hcl", "dependency \"vpc\" {\n  config_path = \"../vpc\"\n}\n")
+	write("live/.terragrunt-cache/x/terragrunt.hcl", "")
+	write("live/db/main.tf", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "units")
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}})
+	index, err := r.loadUnitIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]IndexedUnit{
+		"live/vpc": {File: "terragrunt.hcl"},
+		"live/app": {File: "terragrunt.hcl", Dependencies: []string{"live/vpc"}},
+	}
+	if !reflect.DeepEqual(index.Units, expected) {
+		t.Errorf("built index = %v, want %v", index.Units, expected)
+	}
+
+	// Later runs only re-index the unit files changed since the saved commit
+	write("live/db/terragrunt.hcl", "dependencies {\n  paths = [\"../vpc\"]\n}\n")
+	os.Remove("live/vpc/terragrunt.hcl")
+	git("add", "-A")
+	git("commit", "-q", "-m", "db")
+	write("live/app/terragrunt.hcl", "")
+	index, err = r.loadUnitIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]IndexedUnit{
+		"live/db":  {File: "terragrunt.hcl", Dependencies: []string{"live/vpc"}},
+		"live/app": {File: "terragrunt.hcl"},
+	}
+	if !reflect.DeepEqual(index.Units, expected) {
+		t.Errorf("updated index = %v, want %v", index.Units, expected)
+	}
+	var saved UnitIndex
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &saved); err != nil || !reflect.DeepEqual(saved.Units, expected) {
+		t.Errorf("saved index = %v (%v), want %v", saved.Units, err, expected)
+	}
+
+	// Lookups answer from the index inside the tree and from disk elsewhere
+	r.unitIndex = index
+	if got := r.unitFile("live/db"); got != "terragrunt.hcl" {
+		t.Errorf("unitFile(live/db) = %q, want terragrunt.hcl", got)
+	}
+	if got := r.unitFile("live/vpc"); got != "" {
+		t.Errorf("unitFile(live/vpc) = %q, want empty", got)
+	}
+	if got := r.unitFile("live/.terragrunt-cache/x"); got != "terragrunt.hcl" {
+		t.Errorf("unitFile() in a hidden directory = %q, want the file on disk", got)
+	}
+	if got := r.unitDependencies("live/db"); !reflect.DeepEqual(got, []string{"live/vpc"}) {
+		t.Errorf("unitDependencies(live/db) = %v, want [live/vpc]", got)
+	}
+}
+
+func TestLoadUnitIndexRebuildsUnknownCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+	t.Chdir(t.TempDir())
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.email", "test@example.com"}, {"config", "user.name", "test"}, {"commit", "-q", "--allow-empty", "-m", "empty"}} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.WriteFile("terragrunt.hcl", nil, 0o644)
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	stale := UnitIndex{Version: unitIndexVersion, Commit: "0123456789abcdef0123456789abcdef01234567", Files: []string{"terragrunt.hcl"}, Units: map[string]IndexedUnit{"gone": {File: "terragrunt.hcl"}}}
+	data, _ := json.Marshal(stale)
+	os.WriteFile(path, data, 0o644)
+
+	index, err := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}}).loadUnitIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]IndexedUnit{".": {File: "terragrunt.hcl"}}; !reflect.DeepEqual(index.Units, expected) {
+		t.Errorf("index = %v, want the rebuilt %v", index.Units, expected)
+	}
+}
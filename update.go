@@ -0,0 +1,222 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/spf13/cobra"
+)
+
+// Repository publishing the runner releases
+const (
+	releaseOwner = "boogy"
+	releaseRepo  = "terragrunt-runner"
+)
+
+var reReleaseVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+
+// Compare two release versions (vMAJOR.MINOR.PATCH); ok is false when either
+// is not a release version, e.g. a dev build
+func compareVersions(a, b string) (int, bool) {
+	ma, mb := reReleaseVersion.FindStringSubmatch(a), reReleaseVersion.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		return 0, false
+	}
+	for i := 1; i <= 3; i++ {
+		x, _ := strconv.Atoi(ma[i])
+		y, _ := strconv.Atoi(mb[i])
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// Get the release of a version, or the latest release if version is empty
+func getRelease(ctx context.Context, client *github.Client, version string) (*github.RepositoryRelease, error) {
+	var release *github.RepositoryRelease
+	var err error
+	if version == "" {
+		release, _, err = client.Repositories.GetLatestRelease(ctx, releaseOwner, releaseRepo)
+	} else {
+		release, _, err = client.Repositories.GetReleaseByTag(ctx, releaseOwner, releaseRepo, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", cmp.Or(version, "latest"), err)
+	}
+	return release, nil
+}
+
+// Compare the running version with the latest release, reporting it in the
+// debug logs and the runner-update-available output. The check is best effort:
+// failures only leave the output false.
+func (r *Runner) checkForUpdate(ctx context.Context, client *github.Client) {
+	available := false
+	defer func() {
+		if err := writeActionOutput("runner-update-available", strconv.FormatBool(available)); err != nil {
+			r.logger.Warn("Failed to write update available output", "error", err)
+		}
+	}()
+	release, err := getRelease(ctx, client, "")
+	if err != nil {
+		r.logger.Debug("Could not check for a newer runner release", "error", err)
+		return
+	}
+	latest := release.GetTagName()
+	order, ok := compareVersions(Version, latest)
+	if !ok {
+		r.logger.Debug("Not checking for updates of a development build", "version", Version, "latest", latest)
+		return
+	}
+	available = order < 0
+	if available {
+		r.logger.Debug("Newer runner release available", "version", Version, "latest", latest, "url", release.GetHTMLURL())
+	} else {
+		r.logger.Debug("Runner is up to date", "version", Version, "latest", latest)
+	}
+}
+
+// Name of the release asset of the running platform
+func releaseAssetName() string {
+	return fmt.Sprintf("terragrunt-runner-%s-%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// Download a release asset into w, checking it against the SHA-256 digest
+// GitHub computed on upload
+func downloadReleaseAsset(ctx context.Context, client *github.Client, asset *github.ReleaseAsset, w io.Writer) error {
+	algorithm, want, ok := strings.Cut(asset.GetDigest(), ":")
+	if !ok || algorithm != "sha256" {
+		return fmt.Errorf("release asset %s has no sha256 digest to verify", asset.GetName())
+	}
+	rc, _, err := client.Repositories.DownloadReleaseAsset(ctx, releaseOwner, releaseRepo, asset.GetID(), http.DefaultClient)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.GetName(), err)
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), rc); err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.GetName(), err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("digest mismatch for %s: got sha256:%s, want sha256:%s", asset.GetName(), got, want)
+	}
+	return nil
+}
+
+// Check that a downloaded binary runs and reports the expected version
+func verifyBinaryVersion(path, version string) error {
+	out, err := exec.Command(path, "version", "--output", "json").Output()
+	if err != nil {
+		return fmt.Errorf("downloaded binary does not run: %w", err)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		return fmt.Errorf("downloaded binary reports no version: %w", err)
+	}
+	if info.Version != version {
+		return fmt.Errorf("downloaded binary reports version %s, want %s", info.Version, version)
+	}
+	return nil
+}
+
+// Replace the binary at target with a release, downloaded next to it so the
+// final rename is atomic
+func updateBinary(ctx context.Context, client *github.Client, release *github.RepositoryRelease, target string) error {
+	name := releaseAssetName()
+	var asset *github.ReleaseAsset
+	for _, a := range release.Assets {
+		if a.GetName() == name {
+			asset = a
+			break
+		}
+	}
+	if asset == nil {
+		return fmt.Errorf("release %s has no %s binary", release.GetTagName(), name)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".terragrunt-runner-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = downloadReleaseAsset(ctx, client, asset, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if err := verifyBinaryVersion(tmp.Name(), release.GetTagName()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Create the update subcommand
+func newUpdateCmd(config *Config, logger *slog.Logger) *cobra.Command {
+	var version string
+	var check bool
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update the runner binary to the latest (or a given) release",
+		Long: `Download the runner release for this platform, verify it against the SHA-256
+digest GitHub published for the asset and check that it runs and reports the
+release version, then replace the running binary. For installations outside the
+GitHub Action; the action installs the release of its own ref.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := NewRunner(config, logger)
+			ctx := cmd.Context()
+			client := r.createGitHubClient()
+			release, err := getRelease(ctx, client, version)
+			if err != nil {
+				return err
+			}
+			tag := release.GetTagName()
+			if order, ok := compareVersions(Version, tag); ok && order == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Already at %s\n", tag)
+				return nil
+			}
+			if check {
+				fmt.Fprintf(cmd.OutOrStdout(), "Release %s is available (running %s)\n", tag, Version)
+				return nil
+			}
+			target, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			if target, err = filepath.EvalSymlinks(target); err != nil {
+				return err
+			}
+			if err := updateBinary(ctx, client, release, target); err != nil {
+				return fmt.Errorf("update failed: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated %s from %s to %s\n", target, Version, tag)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&version, "version", "", "Release tag to install, e.g. v1.4.0 (empty = the latest release)")
+	cmd.Flags().BoolVar(&check, "check", false, "Only report whether another release is available")
+	return cmd
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.2.3", "v1.3.0", -1, true},
+		{"v1.10.0", "v1.9.9", 1, true},
+		{"1.2.3", "v2.0.0", -1, true},
+		{"dev", "v1.0.0", 0, false},
+		{"v1.2.3-rc1", "v1.2.3", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareVersions(tt.a, tt.b)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("compareVersions(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{"older", "v1.0.0", "runner-update-available=true"},
+		{"current", "v1.2.0", "runner-update-available=false"},
+		{"dev build", "dev", "runner-update-available=false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := Version
+			Version = tt.version
+			t.Cleanup(func() { Version = orig })
+			outputFile := filepath.Join(t.TempDir(), "output")
+			t.Setenv("GITHUB_OUTPUT", outputFile)
+
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/repos/boogy/terragrunt-runner/releases/latest" {
+					http.NotFound(w, req)
+					return
+				}
+				w.Write([]byte(`{"tag_name": "v1.2.0"}`))
+			}))
+			newTestRunner(&Config{}).checkForUpdate(t.Context(), client)
+
+			out, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(out)); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadReleaseAsset(t *testing.T) {
+	content := []byte("binary")
+	sum := sha256.Sum256(content)
+	tests := []struct {
+		name    string
+		digest  string
+		wantErr string
+	}{
+		{"matching digest", "sha256:" + hex.EncodeToString(sum[:]), ""},
+		{"mismatch", "sha256:" + strings.Repeat("0", 64), "digest mismatch"},
+		{"missing digest", "", "no sha256 digest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/repos/boogy/terragrunt-runner/releases/assets/7" {
+					http.NotFound(w, req)
+					return
+				}
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write(content)
+			}))
+			asset := &github.ReleaseAsset{ID: github.Ptr(int64(7)), Name: github.Ptr(releaseAssetName())}
+			if tt.digest != "" {
+				asset.Digest = github.Ptr(tt.digest)
+			}
+			var buf bytes.Buffer
+			err := downloadReleaseAsset(t.Context(), client, asset, &buf)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !bytes.Equal(buf.Bytes(), content) {
+					t.Errorf("downloaded %q, want %q", buf.Bytes(), content)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, overridden at build time via -ldflags "-X main.Version=..."
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+	Commit    = "unknown"
+)
+
+type BuildInfo struct {
+	Version   string `json:"version"`   // Release version (git tag)
+	Commit    string `json:"commit"`    // Short git commit SHA
+	BuildTime string `json:"buildTime"` // UTC build timestamp
+	GoVersion string `json:"goVersion"` // Go toolchain used for the build
+	Platform  string `json:"platform"`  // Running platform as GOOS/GOARCH
+}
+
+// Collect build metadata, falling back to Go's embedded VCS info for local builds
+func getBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "unknown" && len(setting.Value) >= 7 {
+					info.Commit = setting.Value[:7]
+				}
+			case "vcs.time":
+				if info.BuildTime == "unknown" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// Print build metadata as text or JSON
+func printVersion(w io.Writer, format string) error {
+	info := getBuildInfo()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	case "text", "":
+		fmt.Fprintf(w, "Version:    %s\n", info.Version)
+		fmt.Fprintf(w, "Commit:     %s\n", info.Commit)
+		fmt.Fprintf(w, "Build Time: %s\n", info.BuildTime)
+		fmt.Fprintf(w, "Go Version: %s\n", info.GoVersion)
+		fmt.Fprintf(w, "Platform:   %s\n", info.Platform)
+		return nil
+	default:
+		return fmt.Errorf("invalid output format: %s (expected text or json)", format)
+	}
+}
+
+// Create the version subcommand
+func newVersionCmd() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printVersion(cmd.OutOrStdout(), format)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "output", "o", "text", "Output format (text or json)")
+	return cmd
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPrintVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printVersion(&buf, "json"); err != nil {
+		t.Fatalf("printVersion(json) error = %v", err)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("printVersion(json) produced invalid JSON: %v", err)
+	}
+	if info.Version != Version || info.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("printVersion(json) = %+v", info)
+	}
+
+	buf.Reset()
+	if err := printVersion(&buf, "text"); err != nil {
+		t.Fatalf("printVersion(text) error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Version:    "+Version) {
+		t.Errorf("printVersion(text) = %q", buf.String())
+	}
+
+	if err := printVersion(&buf, "yaml"); err == nil {
+		t.Error("printVersion() expected error for invalid format")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Marker file ending the walk up from a changed file, for repositories nesting
+// independent Terragrunt trees
+const walkUpRootMarker = ".terragrunt-root"
+
+// Get the boundary the walk up from a changed file stops at in the directory:
+// the repository root, a .terragrunt-root marker or root-dir (empty if none)
+func (r *Runner) walkUpBoundary(dir string) string {
+	clean := filepath.Clean(dir)
+	if clean == "." {
+		return "repository root"
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return "repository root"
+	}
+	if _, err := os.Stat(filepath.Join(dir, walkUpRootMarker)); err == nil {
+		return walkUpRootMarker + " marker"
+	}
+	if slices.Contains(runAllRoots(r.config.RunAllRootDir), clean) {
+		return "root-dir " + clean
+	}
+	return ""
+}
+
+// Get the changed files matching the file patterns whose unit could not be
+// determined, with the reason
+func (r *Runner) undeterminedFiles() []DetectionExplanation {
+	var files []DetectionExplanation
+	for _, e := range r.explanations {
+		if e.Pattern != "" && e.Folder == "" {
+			files = append(files, e)
+		}
+	}
+	return files
+}
+
+// Warn about the changed files whose unit could not be determined, so they
+// are not dropped silently
+func (r *Runner) reportUndeterminedFiles() {
+	for _, e := range r.undeterminedFiles() {
+		fmt.Printf("::warning file=%s::No Terragrunt unit found for changed file: %s\n", e.File, e.Reason)
+	}
+}
+
+// Format the changed files without a unit for the summary comment
+func (r *Runner) formatUndeterminedFiles() string {
+	files := r.undeterminedFiles()
+	if len(files) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n**" + r.msg("summary.undetermined", "count", len(files)) + "**\n\n")
+	for _, e := range files {
+		b.WriteString(fmt.Sprintf("- `%s`: %s\n", e.File, e.Reason))
+	}
+	return b.String()
+}
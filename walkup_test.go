@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTraceTerragruntDirectoryBoundaries(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.MkdirAll("live/app/policies/iam/deep/deeper", 0o755)
+	os.WriteFile("live/app/terragrunt.hcl", nil, 0o644)
+	os.MkdirAll("platform/nested/modules/vpc", 0o755)
+	os.WriteFile("platform/terragrunt.hcl", nil, 0o644)
+	os.WriteFile("platform/nested/"+walkUpRootMarker, nil, 0o644)
+	os.MkdirAll("docs", 0o755)
+
+	tests := []struct {
+		name       string
+		file       string
+		wantDir    string
+		wantReason string
+	}{
+		{name: "beyond the old fixed limit", file: "live/app/policies/iam/deep/deeper/policy.json", wantDir: "live/app"},
+		{name: "root marker", file: "platform/nested/modules/vpc/main.tf", wantReason: "reached the .terragrunt-root marker"},
+		{name: "root-dir", file: "live/common.hcl", wantReason: "reached the root-dir live"},
+		{name: "repository root", file: "docs/notes.hcl", wantReason: "reached the repository root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&Config{TerragruntFiles: []string{"terragrunt.hcl"}, RunAllRootDir: "live"})
+			dir, _, reason := r.traceTerragruntDirectory(tt.file)
+			if dir != tt.wantDir || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("traceTerragruntDirectory() = %q, %q, want %q, %q", dir, reason, tt.wantDir, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestWalkUpBoundaryGitRoot(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, ".git"), 0o755)
+	r := newTestRunner(&Config{})
+	if got := r.walkUpBoundary(root); got != "repository root" {
+		t.Errorf("walkUpBoundary() = %q, want repository root", got)
+	}
+	if got := r.walkUpBoundary(filepath.Join(root, "live")); got != "" {
+		t.Errorf("walkUpBoundary() = %q, want none", got)
+	}
+}
+
+func TestFormatUndeterminedFiles(t *testing.T) {
+	r := newTestRunner(&Config{})
+	r.explanations = []DetectionExplanation{
+		{File: "live/app/main.tf", Pattern: "*.tf", Folder: "live/app"},
+		{File: "README.md", Reason: "no file pattern matched"},
+		{File: "live/common.hcl", Pattern: "*.hcl", Reason: "reached the root-dir live without finding terragrunt.hcl"},
+	}
+	got := r.formatUndeterminedFiles()
+	want := "\n**Changed files without a unit: 1**\n\n- `live/common.hcl`: reached the root-dir live without finding terragrunt.hcl\n"
+	if got != want {
+		t.Errorf("formatUndeterminedFiles() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookMaxAttempts     = 3                // Deliveries attempted before giving up
+	webhookTimeout         = 30 * time.Second // Timeout of a single delivery
+	webhookSignatureHeader = "X-Terragrunt-Runner-Signature-256"
+)
+
+// Delay before the first retry, doubled for every further attempt
+var webhookRetryDelay = 2 * time.Second
+
+// Sign a payload like GitHub webhooks do ("sha256=" followed by the hex HMAC)
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// POST the signed run results to the webhook URL, retrying failed deliveries
+func (r *Runner) sendResultsWebhook(results []ExecutionResult) error {
+	run := r.buildPublishedRun(results, time.Now())
+	run.Text = r.formatDigest(results)
+	payload, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delay := webhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		status, err := r.deliverWebhook(client, payload)
+		if err == nil {
+			r.logger.Info("Delivered results webhook", "url", r.config.WebhookURL, "status", status, "attempt", attempt)
+			return nil
+		}
+		// Client errors other than rate limiting won't succeed on retry
+		retryable := status == 0 || status == http.StatusTooManyRequests || status >= 500
+		if !retryable || attempt == webhookMaxAttempts {
+			return fmt.Errorf("results webhook delivery failed after %d attempts: %w", attempt, err)
+		}
+		r.logger.Warn("Results webhook delivery failed, retrying", "url", r.config.WebhookURL, "attempt", attempt, "error", err, "retry_in", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Deliver the payload once, returning the HTTP status (0 if no response was received)
+func (r *Runner) deliverWebhook(client *http.Client, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, r.config.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "terragrunt-runner/"+getBuildInfo().Version)
+	req.Header.Set("X-Terragrunt-Runner-Event", "run")
+	req.Header.Set("X-Terragrunt-Runner-Delivery", fmt.Sprintf("%s-%d", getRunID(), time.Now().UnixNano()))
+	if r.config.WebhookSecret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(r.config.WebhookSecret, payload))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
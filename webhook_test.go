@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Example from GitHub's webhook signature documentation
+	got := signWebhookPayload("It's a Secret to Everybody", []byte("Hello, World!"))
+	expected := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if got != expected {
+		t.Errorf("signWebhookPayload() = %q, want %q", got, expected)
+	}
+}
+
+func TestSendResultsWebhook(t *testing.T) {
+	webhookRetryDelay = 0
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get(webhookSignatureHeader) != signWebhookPayload("s3cret", body) {
+			t.Errorf("invalid signature header %q", req.Header.Get(webhookSignatureHeader))
+		}
+		var run PublishedRun
+		if err := json.Unmarshal(body, &run); err != nil || len(run.Results) != 1 {
+			t.Errorf("invalid payload %s", body)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r := newTestRunner(&Config{Repository: "org/infra", Command: "plan", WebhookURL: server.URL, WebhookSecret: "s3cret"})
+	if err := r.sendResultsWebhook([]ExecutionResult{{Folder: "live/app", Success: true}}); err != nil {
+		t.Fatalf("sendResultsWebhook() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("sendResultsWebhook() attempts = %d, want 2", attempts)
+	}
+}
+
+func TestSendResultsWebhookClientError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	r := newTestRunner(&Config{Repository: "org/infra", Command: "plan", WebhookURL: server.URL})
+	if err := r.sendResultsWebhook(nil); err == nil {
+		t.Error("sendResultsWebhook() expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("sendResultsWebhook() attempts = %d, want 1 (no retry on 404)", attempts)
+	}
+}